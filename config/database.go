@@ -33,19 +33,59 @@ func InitDB() (*gorm.DB, error) {
 	// jika struktur tabel sudah fix
 	if shouldAutoMigrate() {
 		err = db.AutoMigrate(
+			&models.RW{},
+			&models.RT{},
 			&models.Level{},
 			&models.User{},
 			&models.Keluarga{},
 			&models.Agama{},
 			&models.Pekerjaan{},
 			&models.Warga{},
+			&models.WargaMergeLog{},
 			&models.Rumah{},
+			&models.HunianRumah{},
+			&models.LaporanTamu{},
 			&models.KategoriKegiatan{},
 			&models.Kegiatan{},
+			&models.KehadiranKegiatan{},
+			&models.FotoKegiatan{},
+			&models.NotulenKegiatan{},
+			&models.TindakLanjutKegiatan{},
+			&models.Fasilitas{},
+			&models.PeminjamanFasilitas{},
+			&models.JadwalRonda{},
+			&models.AnggotaRonda{},
+			&models.PetugasRonda{},
+			&models.TukarRonda{},
 			&models.Broadcast{},
+			&models.BroadcastTarget{},
+			&models.BroadcastPenerimaan{},
+			&models.BroadcastLampiran{},
+			&models.BroadcastKomentar{},
+			&models.BroadcastKomentarMention{},
+			&models.GrupPenerima{},
+			&models.GrupPenerimaAnggota{},
+			&models.NotifikasiOutbox{},
+			&models.PreferensiNotifikasi{},
+			&models.LanggananPush{},
+			&models.Voting{},
+			&models.VotingPilihan{},
+			&models.PemilihVoting{},
+			&models.SuaraVoting{},
+			&models.KategoriPengaduan{},
+			&models.Pengaduan{},
+			&models.FotoPengaduan{},
+			&models.KomentarPengaduan{},
+			&models.RiwayatStatusPengaduan{},
+			&models.PeringatanDarurat{},
+			&models.TanggapanDarurat{},
+			&models.LogDarurat{},
 			&models.MutasiKeluarga{},
 			&models.KategoriPengeluaran{},
 			&models.Pengeluaran{},
+			&models.Inventaris{},
+			&models.RiwayatKondisiInventaris{},
+			&models.FotoInventaris{},
 			&models.KategoriPemasukan{},
 			&models.Pemasukan{},
 			&models.TagihanIuran{},
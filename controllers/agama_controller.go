@@ -0,0 +1,358 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"rt-management/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AgamaController struct {
+	db *gorm.DB
+}
+
+func NewAgamaController(db *gorm.DB) *AgamaController {
+	return &AgamaController{db: db}
+}
+
+// Request structs
+type AgamaRequest struct {
+	AgamaNama string `form:"agama_nama" binding:"required"`
+}
+
+type MergeAgamaRequest struct {
+	SumberID uint `form:"sumber_id" binding:"required"`
+}
+
+// Agama beserta jumlah warga yang menggunakannya
+type AgamaWithUsage struct {
+	models.Agama
+	JumlahWarga int64 `json:"jumlah_warga"`
+}
+
+// ✅ CREATE - Membuat agama baru
+func (ac *AgamaController) CreateAgama(c *gin.Context) {
+	var req AgamaRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Sanitize input
+	req.AgamaNama = strings.TrimSpace(req.AgamaNama)
+
+	// Validasi panjang nama
+	if len(req.AgamaNama) < 2 || len(req.AgamaNama) > 50 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Nama agama harus 2-50 karakter",
+		})
+		return
+	}
+
+	// Check if agama dengan nama yang sama sudah ada
+	var existing models.Agama
+	if err := ac.db.Where("agama_nama = ?", req.AgamaNama).First(&existing).Error; err == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Agama dengan nama tersebut sudah ada",
+		})
+		return
+	}
+
+	agama := models.Agama{
+		AgamaNama: req.AgamaNama,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if err := ac.db.Create(&agama).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal membuat agama",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Agama berhasil dibuat",
+		"data":    agama,
+	})
+}
+
+// ✅ READ - Mendapatkan semua agama beserta jumlah pemakaian
+func (ac *AgamaController) GetAllAgama(c *gin.Context) {
+	var agama []AgamaWithUsage
+
+	query := ac.db.Model(&models.Agama{}).
+		Select("agamas.*, COUNT(wargas.warga_id) AS jumlah_warga").
+		Joins("LEFT JOIN wargas ON wargas.agama_id = agamas.agama_id AND wargas.deleted_at IS NULL").
+		Group("agamas.agama_id")
+
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		query = query.Where("agamas.agama_nama LIKE ?", "%"+search+"%")
+	}
+
+	if err := query.Order("agamas.agama_nama ASC").Scan(&agama).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data agama",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": agama,
+	})
+}
+
+// ✅ READ - Mendapatkan agama by ID
+func (ac *AgamaController) GetAgamaByID(c *gin.Context) {
+	agamaID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID agama tidak valid",
+		})
+		return
+	}
+
+	var agama models.Agama
+	if err := ac.db.First(&agama, agamaID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Agama tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal mengambil data agama",
+			})
+		}
+		return
+	}
+
+	var jumlahWarga int64
+	ac.db.Model(&models.Warga{}).Where("agama_id = ?", agama.AgamaID).Count(&jumlahWarga)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": AgamaWithUsage{Agama: agama, JumlahWarga: jumlahWarga},
+	})
+}
+
+// ✅ UPDATE - Mengupdate agama
+func (ac *AgamaController) UpdateAgama(c *gin.Context) {
+	agamaID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID agama tidak valid",
+		})
+		return
+	}
+
+	var agama models.Agama
+	if err := ac.db.First(&agama, agamaID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Agama tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan agama",
+			})
+		}
+		return
+	}
+
+	var req AgamaRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	req.AgamaNama = strings.TrimSpace(req.AgamaNama)
+	if len(req.AgamaNama) < 2 || len(req.AgamaNama) > 50 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Nama agama harus 2-50 karakter",
+		})
+		return
+	}
+
+	// Check if agama dengan nama yang sama sudah ada (exclude current)
+	var existing models.Agama
+	if err := ac.db.Where("agama_nama = ? AND agama_id != ?", req.AgamaNama, agamaID).First(&existing).Error; err == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Agama dengan nama tersebut sudah ada",
+		})
+		return
+	}
+
+	agama.AgamaNama = req.AgamaNama
+	agama.UpdatedAt = time.Now()
+
+	if err := ac.db.Save(&agama).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mengupdate agama",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Agama berhasil diupdate",
+		"data":    agama,
+	})
+}
+
+// ✅ DELETE - Menghapus agama yang tidak dipakai warga (admin global)
+func (ac *AgamaController) DeleteAgama(c *gin.Context) {
+	if !requireGlobalMasterData(c) {
+		return
+	}
+
+	agamaID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID agama tidak valid",
+		})
+		return
+	}
+
+	var agama models.Agama
+	if err := ac.db.First(&agama, agamaID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Agama tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan agama",
+			})
+		}
+		return
+	}
+
+	// Check jika agama masih dipakai warga (termasuk warga di trash)
+	var jumlahWarga int64
+	ac.db.Unscoped().Model(&models.Warga{}).Where("agama_id = ?", agama.AgamaID).Count(&jumlahWarga)
+	if jumlahWarga > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tidak dapat menghapus agama yang masih dipakai warga",
+			"details": gin.H{
+				"total_warga": jumlahWarga,
+			},
+		})
+		return
+	}
+
+	if err := ac.db.Delete(&agama).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menghapus agama",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Agama berhasil dihapus",
+	})
+}
+
+// ✅ POST - Menggabungkan agama sumber ke agama :id lalu menghapus agama sumber (admin global)
+func (ac *AgamaController) MergeAgama(c *gin.Context) {
+	if !requireGlobalMasterData(c) {
+		return
+	}
+
+	agamaID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID agama tidak valid",
+		})
+		return
+	}
+
+	var req MergeAgamaRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if uint(agamaID) == req.SumberID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Agama tujuan dan sumber tidak boleh sama",
+		})
+		return
+	}
+
+	var tujuan, sumber models.Agama
+	if err := ac.db.First(&tujuan, agamaID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Agama tujuan tidak ditemukan",
+		})
+		return
+	}
+	if err := ac.db.First(&sumber, req.SumberID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Agama sumber tidak ditemukan",
+		})
+		return
+	}
+
+	var jumlahWarga int64
+	err = ac.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(&models.Warga{}).
+			Where("agama_id = ?", sumber.AgamaID).
+			Update("agama_id", tujuan.AgamaID)
+		if result.Error != nil {
+			return result.Error
+		}
+		jumlahWarga = result.RowsAffected
+		return tx.Delete(&sumber).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menggabungkan agama",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":             "Agama berhasil digabungkan",
+		"data":                tujuan,
+		"jumlah_warga_pindah": jumlahWarga,
+	})
+}
+
+// ✅ GET - Dropdown agama
+func (ac *AgamaController) GetAgamaDropdown(c *gin.Context) {
+	var agama []struct {
+		AgamaID   uint   `json:"agama_id"`
+		AgamaNama string `json:"agama_nama"`
+	}
+
+	if err := ac.db.
+		Model(&models.Agama{}).
+		Select("agama_id, agama_nama").
+		Order("agama_nama ASC").
+		Find(&agama).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data dropdown agama",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": agama,
+	})
+}
@@ -108,7 +108,7 @@ func (ac *AuthController) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := ac.jwtUtils.GenerateToken(user.UserID, user.Username, user.LevelID)
+	token, err := ac.jwtUtils.GenerateToken(user.UserID, user.Username, user.LevelID, userTenantScope(user))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
@@ -170,7 +170,7 @@ func (ac *AuthController) UniversalLogin(c *gin.Context) {
 		return
 	}
 
-	token, err := ac.jwtUtils.GenerateToken(user.UserID, user.Username, user.LevelID)
+	token, err := ac.jwtUtils.GenerateToken(user.UserID, user.Username, user.LevelID, userTenantScope(user))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
@@ -232,12 +232,15 @@ func (ac *AuthController) RefreshToken(c *gin.Context) {
 
 	username, _ := c.Get("username")
 	levelID, _ := c.Get("levelID")
+	rtID, _ := c.Get("rtID")
+	rwID, _ := c.Get("rwID")
 
 	// Generate new token
 	token, err := ac.jwtUtils.GenerateToken(
 		userID.(uint),
 		username.(string),
 		levelID.(uint),
+		utils.TenantScope{RTID: rtID.(uint), RWID: rwID.(uint)},
 	)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
@@ -11,28 +11,60 @@ import (
 
 	"rt-management/helper" // Import package helper
 	"rt-management/models"
+	"rt-management/notification"
+	"rt-management/realtime"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type BroadcastController struct {
-	db *gorm.DB
+	db         *gorm.DB
+	notifikasi *notification.Service
+	hub        *realtime.Hub
 }
 
-func NewBroadcastController(db *gorm.DB) *BroadcastController {
-	return &BroadcastController{db: db}
+func NewBroadcastController(db *gorm.DB, notifikasi *notification.Service, hub *realtime.Hub) *BroadcastController {
+	return &BroadcastController{db: db, notifikasi: notifikasi, hub: hub}
 }
 
 // Request structs untuk form-data
 type CreateBroadcastRequest struct {
-	BroadcastNama      string `form:"broadcast_nama" binding:"required"`
-	BroadcastDeskripsi string `form:"broadcast_deskripsi"`
+	BroadcastNama            string `form:"broadcast_nama" binding:"required"`
+	BroadcastDeskripsi       string `form:"broadcast_deskripsi"`
+	BroadcastWajibKonfirmasi bool   `form:"broadcast_wajib_konfirmasi"`
+	BroadcastStatus          string `form:"broadcast_status"`           // draft / terbit (default)
+	BroadcastTerbitPada      string `form:"broadcast_terbit_pada"`      // YYYY-MM-DD HH:MM, kosong = terbit sekarang
+	BroadcastKedaluwarsaPada string `form:"broadcast_kedaluwarsa_pada"` // YYYY-MM-DD HH:MM, kosong = tidak kedaluwarsa
+	BroadcastDisematkan      bool   `form:"broadcast_disematkan"`
+	BroadcastPrioritas       string `form:"broadcast_prioritas"` // normal / penting / darurat
+	BroadcastAudiensRequest
 }
 
 type UpdateBroadcastRequest struct {
-	BroadcastNama      string `form:"broadcast_nama"`
-	BroadcastDeskripsi string `form:"broadcast_deskripsi"`
+	BroadcastNama            string `form:"broadcast_nama"`
+	BroadcastDeskripsi       string `form:"broadcast_deskripsi"`
+	BroadcastWajibKonfirmasi *bool   `form:"broadcast_wajib_konfirmasi"`
+	BroadcastStatus          *string `form:"broadcast_status"`           // draft / terbit, hanya untuk broadcast yang belum terbit
+	BroadcastTerbitPada      *string `form:"broadcast_terbit_pada"`      // kosong = hapus jadwal
+	BroadcastKedaluwarsaPada *string `form:"broadcast_kedaluwarsa_pada"` // kosong = tidak kedaluwarsa
+	BroadcastDisematkan      *bool   `form:"broadcast_disematkan"`
+	BroadcastPrioritas       *string `form:"broadcast_prioritas"`
+	BroadcastAudiensRequest
+}
+
+// Audiens broadcast, field target boleh dikirim berulang. Tanpa target = semua warga.
+type BroadcastAudiensRequest struct {
+	Audiens          string   `form:"audiens"` // semua / terbatas
+	TargetKeluargaID []uint   `form:"target_keluarga_id"`
+	TargetBlok       []string `form:"target_blok"`
+	TargetLevelID    []uint   `form:"target_level_id"`
+	TargetGrupID     []uint   `form:"target_grup_id"`
+}
+
+func (r BroadcastAudiensRequest) isSet() bool {
+	return r.Audiens != "" || len(r.TargetKeluargaID) > 0 || len(r.TargetBlok) > 0 ||
+		len(r.TargetLevelID) > 0 || len(r.TargetGrupID) > 0
 }
 
 // ✅ CREATE - Membuat broadcast baru dengan form-data
@@ -70,13 +102,49 @@ func (bc *BroadcastController) CreateBroadcast(c *gin.Context) {
 
 	// Check if broadcast dengan nama yang sama sudah ada
 	var existingBroadcast models.Broadcast
-	if err := bc.db.Where("broadcast_nama = ?", req.BroadcastNama).First(&existingBroadcast).Error; err == nil {
+	if err := scopedDB(c, bc.db).Where("broadcast_nama = ?", req.BroadcastNama).First(&existingBroadcast).Error; err == nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Broadcast dengan nama tersebut sudah ada",
 		})
 		return
 	}
 
+	// Validasi audiens sebelum file diupload
+	audiens, targets, audiensErr := bc.buildBroadcastTarget(c, req.BroadcastAudiensRequest)
+	if audiensErr != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": audiensErr,
+		})
+		return
+	}
+
+	// Validasi jadwal terbit/kedaluwarsa dan prioritas
+	terbitPada, errTerbit := parseWaktuBroadcast(req.BroadcastTerbitPada)
+	kedaluwarsaPada, errKedaluwarsa := parseWaktuBroadcast(req.BroadcastKedaluwarsaPada)
+	if errTerbit != nil || errKedaluwarsa != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Format waktu tidak valid. Gunakan format YYYY-MM-DD HH:MM",
+		})
+		return
+	}
+	jadwal, jadwalErr := tentukanJadwalBroadcast(strings.ToLower(strings.TrimSpace(req.BroadcastStatus)), terbitPada, kedaluwarsaPada, time.Now())
+	if jadwalErr != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": jadwalErr,
+		})
+		return
+	}
+	prioritas := strings.ToLower(strings.TrimSpace(req.BroadcastPrioritas))
+	if prioritas == "" {
+		prioritas = "normal"
+	}
+	if !isValidPrioritasBroadcast(prioritas) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Prioritas harus normal, penting, atau darurat",
+		})
+		return
+	}
+
 	// Handle file upload untuk foto
 	broadcastFoto, err := helper.HandleFileImageUpload(c, "broadcast_foto", "")
 	if err != nil {
@@ -104,16 +172,33 @@ func (bc *BroadcastController) CreateBroadcast(c *gin.Context) {
 	}
 
 	// Buat broadcast baru
+	userID, _ := c.Get("userID")
+	dibuatOlehID := userID.(uint)
 	broadcast := models.Broadcast{
-		BroadcastNama:      req.BroadcastNama,
-		BroadcastDeskripsi: req.BroadcastDeskripsi,
-		BroadcastFoto:      broadcastFoto,
-		BroadcastDokumen:   broadcastDokumen,
-		CreatedAt:          time.Now(),
-		UpdatedAt:          time.Now(),
+		BroadcastNama:            req.BroadcastNama,
+		BroadcastDeskripsi:       req.BroadcastDeskripsi,
+		BroadcastFoto:            broadcastFoto,
+		BroadcastDokumen:         broadcastDokumen,
+		BroadcastAudiens:         audiens,
+		BroadcastWajibKonfirmasi: req.BroadcastWajibKonfirmasi,
+		BroadcastStatus:          jadwal.Status,
+		BroadcastTerbitPada:      jadwal.TerbitPada,
+		BroadcastKedaluwarsaPada: jadwal.KedaluwarsaPada,
+		BroadcastDiterbitkanPada: jadwal.DiterbitkanPada,
+		BroadcastDisematkan:      req.BroadcastDisematkan,
+		BroadcastPrioritas:       prioritas,
+		BroadcastDibuatOlehID:    &dibuatOlehID,
+		CreatedAt:                time.Now(),
+		UpdatedAt:                time.Now(),
 	}
 
-	if err := bc.db.Create(&broadcast).Error; err != nil {
+	err = scopedDB(c, bc.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&broadcast).Error; err != nil {
+			return err
+		}
+		return simpanBroadcastTarget(tx, &broadcast, targets)
+	})
+	if err != nil {
 		// Rollback file upload jika gagal menyimpan ke database
 		if broadcastFoto != "" {
 			helper.DeleteOldPhoto(broadcastFoto, "broadcast_foto")
@@ -129,6 +214,9 @@ func (bc *BroadcastController) CreateBroadcast(c *gin.Context) {
 		return
 	}
 
+	// Broadcast yang langsung terbit diumumkan tanpa menunggu penjadwal
+	go bc.umumkanBroadcastTerbit(broadcast.BroadcastID, time.Now())
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Broadcast berhasil dibuat",
 		"data":    broadcast,
@@ -149,7 +237,7 @@ func (bc *BroadcastController) UpdateBroadcast(c *gin.Context) {
 	}
 
 	var broadcast models.Broadcast
-	if err := bc.db.First(&broadcast, broadcastID).Error; err != nil {
+	if err := scopedDB(c, bc.db).First(&broadcast, broadcastID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Broadcast tidak ditemukan",
@@ -192,7 +280,7 @@ func (bc *BroadcastController) UpdateBroadcast(c *gin.Context) {
 
 		// Check duplicate name (exclude current)
 		var existingBroadcast models.Broadcast
-		if err := bc.db.Where("broadcast_nama = ? AND broadcast_id != ?", req.BroadcastNama, broadcastID).
+		if err := scopedDB(c, bc.db).Where("broadcast_nama = ? AND broadcast_id != ?", req.BroadcastNama, broadcastID).
 			First(&existingBroadcast).Error; err == nil {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": "Broadcast dengan nama tersebut sudah ada",
@@ -201,6 +289,29 @@ func (bc *BroadcastController) UpdateBroadcast(c *gin.Context) {
 		}
 	}
 
+	// Audiens hanya diganti jika field audiens/target dikirim
+	var audiens string
+	var targets []models.BroadcastTarget
+	if req.BroadcastAudiensRequest.isSet() {
+		var audiensErr string
+		audiens, targets, audiensErr = bc.buildBroadcastTarget(c, req.BroadcastAudiensRequest)
+		if audiensErr != "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": audiensErr,
+			})
+			return
+		}
+	}
+
+	// Jadwal hanya dihitung ulang jika field jadwal dikirim
+	jadwalUpdates, jadwalErr := jadwalUpdateBroadcast(broadcast, req, time.Now())
+	if jadwalErr != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": jadwalErr,
+		})
+		return
+	}
+
 	// Handle file upload untuk foto (jika ada file baru)
 	var newFoto string
 	if c.Request.MultipartForm != nil && c.Request.MultipartForm.File["broadcast_foto"] != nil {
@@ -243,10 +354,33 @@ func (bc *BroadcastController) UpdateBroadcast(c *gin.Context) {
 		updates["broadcast_dokumen"] = newDokumen
 	}
 	
+	if audiens != "" {
+		updates["broadcast_audiens"] = audiens
+	}
+	if req.BroadcastWajibKonfirmasi != nil {
+		updates["broadcast_wajib_konfirmasi"] = *req.BroadcastWajibKonfirmasi
+	}
+	for kolom, nilai := range jadwalUpdates {
+		updates[kolom] = nilai
+	}
+	
 	updates["updated_at"] = time.Now()
 
 	if len(updates) > 0 {
-		if err := bc.db.Model(&broadcast).Updates(updates).Error; err != nil {
+		err := scopedDB(c, bc.db).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&broadcast).Updates(updates).Error; err != nil {
+				return err
+			}
+			if audiens == "" {
+				return nil
+			}
+			// Target lama diganti seluruhnya dengan target baru
+			if err := tx.Where("broadcast_id = ?", broadcast.BroadcastID).Delete(&models.BroadcastTarget{}).Error; err != nil {
+				return err
+			}
+			return simpanBroadcastTarget(tx, &broadcast, targets)
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error":   "Gagal mengupdate broadcast",
 				"details": err.Error(),
@@ -256,20 +390,22 @@ func (bc *BroadcastController) UpdateBroadcast(c *gin.Context) {
 	}
 
 	// Reload dengan data terbaru
-	if err := bc.db.First(&broadcast, broadcastID).Error; err != nil {
+	if err := scopedDB(c, bc.db).Preload("Target").First(&broadcast, broadcastID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Gagal memuat data broadcast yang diupdate",
 		})
 		return
 	}
 
+	go bc.umumkanBroadcastTerbit(broadcast.BroadcastID, time.Now())
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Broadcast berhasil diupdate",
 		"data":    broadcast,
 	})
 }
 
-// ✅ DELETE - Menghapus broadcast
+// ✅ DELETE - Memindahkan broadcast ke trash (soft delete)
 func (bc *BroadcastController) DeleteBroadcast(c *gin.Context) {
 	id := c.Param("id")
 
@@ -283,7 +419,7 @@ func (bc *BroadcastController) DeleteBroadcast(c *gin.Context) {
 	}
 
 	var broadcast models.Broadcast
-	if err := bc.db.First(&broadcast, broadcastID).Error; err != nil {
+	if err := scopedDB(c, bc.db).First(&broadcast, broadcastID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Broadcast tidak ditemukan",
@@ -296,12 +432,9 @@ func (bc *BroadcastController) DeleteBroadcast(c *gin.Context) {
 		return
 	}
 
-	// Simpan nama file untuk dihapus nanti
-	fotoToDelete := broadcast.BroadcastFoto
-	dokumenToDelete := broadcast.BroadcastDokumen
-
-	// Delete dari database
-	if err := bc.db.Delete(&broadcast).Error; err != nil {
+	// Soft delete - file foto & dokumen tetap disimpan agar bisa dipulihkan,
+	// file baru dihapus permanen saat purge trash
+	if err := scopedDB(c, bc.db).Delete(&broadcast).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Gagal menghapus broadcast",
 			"details": err.Error(),
@@ -309,14 +442,6 @@ func (bc *BroadcastController) DeleteBroadcast(c *gin.Context) {
 		return
 	}
 
-	// Hapus file dari storage setelah berhasil delete dari database
-	if fotoToDelete != "" {
-		helper.DeleteOldPhoto(fotoToDelete, "broadcast_foto")
-	}
-	if dokumenToDelete != "" {
-		helper.DeleteOldDocument(dokumenToDelete, "broadcast_dokumen")
-	}
-
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Broadcast berhasil dihapus",
 	})
@@ -335,7 +460,13 @@ func (bc *BroadcastController) GetAllBroadcast(c *gin.Context) {
 	search := strings.TrimSpace(c.Query("search"))
 
 	// Build query dengan GORM (AMAN - parameterized queries)
-	query := bc.db.Model(&models.Broadcast{})
+	query, err := bc.scopeAudiens(c, scopedDB(c, bc.db).Model(&models.Broadcast{}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal menentukan audiens broadcast",
+		})
+		return
+	}
 
 	// Apply search filter jika ada
 	if search != "" {
@@ -343,14 +474,25 @@ func (bc *BroadcastController) GetAllBroadcast(c *gin.Context) {
 			"%"+search+"%", "%"+search+"%")
 	}
 
-	// Get total count for pagination
+	// Pengelola melihat detail audiens setiap broadcast dan dapat memfilter status
+	if isPengelolaBroadcast(c) {
+		if status := c.Query("status"); status != "" {
+			query = query.Where("broadcast_status = ?", status)
+		}
+	}
+
+	// Get total count for pagination (setelah semua filter)
 	var total int64
 	query.Count(&total)
 
+	if isPengelolaBroadcast(c) {
+		query = query.Preload("Target")
+	}
+
 	// Execute query dengan pagination dan sorting
 	if err := query.Offset(offset).
 		// Limit(limit).
-		Order("created_at DESC").
+		Order("COALESCE(broadcast_diterbitkan_pada, broadcast_terbit_pada, created_at) DESC").
 		Find(&broadcast).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Gagal mengambil data broadcast",
@@ -358,8 +500,11 @@ func (bc *BroadcastController) GetAllBroadcast(c *gin.Context) {
 		return
 	}
 
+	// Broadcast yang tampil di daftar tercatat sudah diterima user
+	bc.tandaiDiterima(c, broadcast)
+
 	c.JSON(http.StatusOK, gin.H{
-		"data": broadcast,
+		"data": bc.lengkapiJumlahKomentar(c, broadcast),
 		"pagination": gin.H{
 			"page":  page,
 			"limit": limit,
@@ -381,8 +526,23 @@ func (bc *BroadcastController) GetBroadcastByID(c *gin.Context) {
 		return
 	}
 
+	query, err := bc.scopeAudiens(c, scopedDB(c, bc.db))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal menentukan audiens broadcast",
+		})
+		return
+	}
+	if isPengelolaBroadcast(c) {
+		query = query.Preload("Target")
+	}
+	query = query.Preload("Lampiran", func(db *gorm.DB) *gorm.DB {
+		return db.Order("lampiran_urutan ASC, broadcast_lampiran_id ASC")
+	})
+
+	// Broadcast di luar audiens user diperlakukan sama dengan tidak ditemukan
 	var broadcast models.Broadcast
-	if err := bc.db.First(&broadcast, broadcastID).Error; err != nil {
+	if err := query.First(&broadcast, broadcastID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Broadcast tidak ditemukan",
@@ -395,8 +555,12 @@ func (bc *BroadcastController) GetBroadcastByID(c *gin.Context) {
 		return
 	}
 
+	// Membuka detail broadcast tercatat sebagai sudah dibaca
+	penerimaan := bc.tandaiDibaca(c, broadcast)
+
 	c.JSON(http.StatusOK, gin.H{
-		"data": broadcast,
+		"data":       broadcast,
+		"penerimaan": penerimaan,
 	})
 }
 
@@ -407,9 +571,24 @@ func (bc *BroadcastController) GetBroadcastTerbaru(c *gin.Context) {
 
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
 
-	// Query broadcast terbaru (AMAN)
-	if err := bc.db.
-		Order("created_at DESC").
+	query, err := bc.scopeAudiens(c, scopedDB(c, bc.db))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal menentukan audiens broadcast",
+		})
+		return
+	}
+
+	// Pengelola juga hanya melihat broadcast yang sedang tayang di daftar terbaru
+	if isPengelolaBroadcast(c) {
+		query = filterBroadcastTampil(query, time.Now())
+	}
+
+	// Query broadcast terbaru (AMAN), broadcast disematkan dan prioritas tinggi di atas
+	if err := query.
+		Order("broadcast_disematkan DESC").
+		Order("CASE broadcast_prioritas WHEN 'darurat' THEN 0 WHEN 'penting' THEN 1 ELSE 2 END").
+		Order("COALESCE(broadcast_diterbitkan_pada, broadcast_terbit_pada, created_at) DESC").
 		Limit(limit).
 		Find(&broadcast).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -418,6 +597,8 @@ func (bc *BroadcastController) GetBroadcastTerbaru(c *gin.Context) {
 		return
 	}
 
+	bc.tandaiDiterima(c, broadcast)
+
 	c.JSON(http.StatusOK, gin.H{
 		"data":  broadcast,
 		"total": len(broadcast),
@@ -437,8 +618,16 @@ func (bc *BroadcastController) SearchBroadcast(c *gin.Context) {
 
 	var broadcast []models.Broadcast
 
+	query, err := bc.scopeAudiens(c, scopedDB(c, bc.db))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal menentukan audiens broadcast",
+		})
+		return
+	}
+
 	// Execute search query dengan GORM (AMAN - parameterized)
-	if err := bc.db.
+	if err := query.
 		Where("broadcast_nama LIKE ? OR broadcast_deskripsi LIKE ?", 
 			"%"+search+"%", "%"+search+"%").
 		Order("created_at DESC").
@@ -468,17 +657,17 @@ func (bc *BroadcastController) GetStatistikBroadcast(c *gin.Context) {
 	var statistik StatistikResult
 
 	// Hitung total broadcast (AMAN)
-	bc.db.Model(&models.Broadcast{}).Count(&statistik.TotalBroadcast)
+	scopedDB(c, bc.db).Model(&models.Broadcast{}).Count(&statistik.TotalBroadcast)
 
 	// Hitung broadcast bulan ini (AMAN)
 	awalBulan := time.Now().AddDate(0, 0, -time.Now().Day()+1)
-	bc.db.Model(&models.Broadcast{}).
+	scopedDB(c, bc.db).Model(&models.Broadcast{}).
 		Where("created_at >= ?", awalBulan).
 		Count(&statistik.BulanIni)
 
 	// Hitung broadcast minggu ini (AMAN)
 	awalMinggu := time.Now().AddDate(0, 0, -int(time.Now().Weekday())+1)
-	bc.db.Model(&models.Broadcast{}).
+	scopedDB(c, bc.db).Model(&models.Broadcast{}).
 		Where("created_at >= ?", awalMinggu).
 		Count(&statistik.MingguIni)
 
@@ -488,6 +677,76 @@ func (bc *BroadcastController) GetStatistikBroadcast(c *gin.Context) {
 }
 
 
+// ✅ GET - Mendapatkan broadcast yang ada di trash
+func (bc *BroadcastController) GetTrashBroadcast(c *gin.Context) {
+	var broadcast []models.Broadcast
+
+	if err := scopedDB(c, bc.db).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Find(&broadcast).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data trash broadcast",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  broadcast,
+		"total": len(broadcast),
+	})
+}
+
+// ✅ PUT - Mengembalikan broadcast dari trash
+func (bc *BroadcastController) RestoreBroadcast(c *gin.Context) {
+	id := c.Param("id")
+
+	// Validasi ID
+	broadcastID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID broadcast tidak valid",
+		})
+		return
+	}
+
+	var broadcast models.Broadcast
+	if err := scopedDB(c, bc.db).Unscoped().Where("deleted_at IS NOT NULL").First(&broadcast, broadcastID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Broadcast tidak ditemukan di trash",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan broadcast",
+			})
+		}
+		return
+	}
+
+	// Nama broadcast harus tetap unik di antara broadcast aktif
+	var existingBroadcast models.Broadcast
+	if err := scopedDB(c, bc.db).Where("broadcast_nama = ? AND broadcast_id != ?", broadcast.BroadcastNama, broadcastID).
+		First(&existingBroadcast).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Broadcast dengan nama tersebut sudah ada, ubah nama broadcast aktif terlebih dahulu",
+		})
+		return
+	}
+
+	if err := scopedDB(c, bc.db).Unscoped().Model(&broadcast).Update("deleted_at", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal memulihkan broadcast",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Broadcast berhasil dipulihkan",
+		"data":    broadcast,
+	})
+}
 
 // ✅ GET - Serve file dokumen broadcast
 func (bc *BroadcastController) GetBroadcastDokumen(c *gin.Context) {
@@ -500,6 +759,14 @@ func (bc *BroadcastController) GetBroadcastDokumen(c *gin.Context) {
 		return
 	}
 
+	// File hanya dapat dibuka oleh audiens broadcast pemiliknya
+	if !bc.bolehAksesFileBroadcast(c, "broadcast_dokumen", filename) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "File dokumen tidak ditemukan",
+		})
+		return
+	}
+
 	file, err := helper.GetFileByFileName("broadcast_dokumen", filename)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -543,6 +810,14 @@ func (bc *BroadcastController) GetBroadcastFoto(c *gin.Context) {
 		return
 	}
 
+	// File hanya dapat dibuka oleh audiens broadcast pemiliknya
+	if !bc.bolehAksesFileBroadcast(c, "broadcast_foto", filename) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "File foto tidak ditemukan",
+		})
+		return
+	}
+
 	file, err := helper.GetFileByFileName("broadcast_foto", filename)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -573,4 +848,881 @@ func (bc *BroadcastController) GetBroadcastFoto(c *gin.Context) {
 	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", filename))
 
 	http.ServeContent(c.Writer, c.Request, filename, fileInfo.ModTime(), file)
-}
\ No newline at end of file
+}
+// ✅ PUT - Menerbitkan broadcast sekarang (draft, terjadwal, atau menayangkan ulang arsip)
+func (bc *BroadcastController) TerbitkanBroadcast(c *gin.Context) {
+	broadcast, ok := bc.findBroadcast(c)
+	if !ok {
+		return
+	}
+	if broadcast.BroadcastStatus == "terbit" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Broadcast sudah terbit",
+		})
+		return
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"broadcast_status": "terbit",
+	}
+	if broadcast.BroadcastTerbitPada == nil || broadcast.BroadcastTerbitPada.After(now) {
+		updates["broadcast_terbit_pada"] = now
+	}
+	if broadcast.BroadcastDiterbitkanPada == nil {
+		updates["broadcast_diterbitkan_pada"] = now
+	}
+	// Arsip yang ditayangkan ulang tidak langsung kedaluwarsa lagi
+	if broadcast.BroadcastKedaluwarsaPada != nil && !broadcast.BroadcastKedaluwarsaPada.After(now) {
+		updates["broadcast_kedaluwarsa_pada"] = nil
+	}
+
+	if err := scopedDB(c, bc.db).Model(&broadcast).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menerbitkan broadcast",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	go bc.umumkanBroadcastTerbit(broadcast.BroadcastID, now)
+
+	// Reload agar response memuat status dan waktu terbit terbaru
+	if err := scopedDB(c, bc.db).Preload("Target").First(&broadcast, broadcast.BroadcastID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal memuat data broadcast yang diupdate",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Broadcast berhasil diterbitkan",
+		"data":    broadcast,
+	})
+}
+
+// ✅ PUT - Mengarsipkan broadcast sehingga tidak lagi tampil ke warga
+func (bc *BroadcastController) ArsipkanBroadcast(c *gin.Context) {
+	broadcast, ok := bc.findBroadcast(c)
+	if !ok {
+		return
+	}
+	if broadcast.BroadcastStatus == "arsip" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Broadcast sudah diarsipkan",
+		})
+		return
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"broadcast_status": "arsip",
+	}
+	if broadcast.BroadcastKedaluwarsaPada == nil || broadcast.BroadcastKedaluwarsaPada.After(now) {
+		updates["broadcast_kedaluwarsa_pada"] = now
+	}
+
+	if err := scopedDB(c, bc.db).Model(&broadcast).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mengarsipkan broadcast",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Reload agar response memuat status dan waktu kedaluwarsa terbaru
+	if err := scopedDB(c, bc.db).Preload("Target").First(&broadcast, broadcast.BroadcastID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal memuat data broadcast yang diupdate",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Broadcast berhasil diarsipkan",
+		"data":    broadcast,
+	})
+}
+
+// ✅ GET - Daftar user penerima broadcast sesuai audiensnya
+func (bc *BroadcastController) GetPenerimaBroadcast(c *gin.Context) {
+	broadcastID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID broadcast tidak valid",
+		})
+		return
+	}
+
+	var broadcast models.Broadcast
+	if err := scopedDB(c, bc.db).Preload("Target").First(&broadcast, broadcastID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Broadcast tidak ditemukan",
+		})
+		return
+	}
+
+	penerima, err := penerimaBroadcast(bc.db, broadcast)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menentukan penerima broadcast",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	for i := range penerima {
+		penerima[i].Password = ""
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    penerima,
+		"total":   len(penerima),
+		"audiens": broadcast.BroadcastAudiens,
+		"target":  broadcast.Target,
+	})
+}
+
+// ✅ POST - Konfirmasi (acknowledge) broadcast yang wajib dikonfirmasi oleh penerima
+func (bc *BroadcastController) KonfirmasiBroadcast(c *gin.Context) {
+	broadcastID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID broadcast tidak valid",
+		})
+		return
+	}
+
+	query, err := bc.scopeAudiens(c, scopedDB(c, bc.db))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal menentukan audiens broadcast",
+		})
+		return
+	}
+
+	var broadcast models.Broadcast
+	if err := query.First(&broadcast, broadcastID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Broadcast tidak ditemukan",
+		})
+		return
+	}
+	if !broadcast.BroadcastWajibKonfirmasi {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Broadcast ini tidak memerlukan konfirmasi",
+		})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	penerimaan, err := bc.findOrNewPenerimaan(broadcast, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal memuat status penerimaan broadcast",
+		})
+		return
+	}
+	if penerimaan.DikonfirmasiPada != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Broadcast sudah dikonfirmasi sebelumnya",
+			"data":    penerimaan,
+		})
+		return
+	}
+
+	now := time.Now()
+	if penerimaan.DiterimaPada == nil {
+		penerimaan.DiterimaPada = &now
+	}
+	if penerimaan.DibacaPada == nil {
+		penerimaan.DibacaPada = &now
+	}
+	penerimaan.DikonfirmasiPada = &now
+
+	if err := bc.db.Save(&penerimaan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menyimpan konfirmasi broadcast",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Broadcast berhasil dikonfirmasi",
+		"data":    penerimaan,
+	})
+}
+
+// Satu baris laporan penerimaan broadcast per user penerima
+type LaporanPenerimaanBroadcast struct {
+	UserID           uint       `json:"user_id"`
+	Username         string     `json:"username"`
+	LevelID          uint       `json:"level_id"`
+	LevelNama        string     `json:"level_nama"`
+	WargaID          *uint      `json:"warga_id"`
+	DiterimaPada     *time.Time `json:"diterima_pada"`
+	DibacaPada       *time.Time `json:"dibaca_pada"`
+	DikonfirmasiPada *time.Time `json:"dikonfirmasi_pada"`
+	Status           string     `json:"status"` // belum_diterima, diterima, dibaca, dikonfirmasi
+}
+
+// ✅ GET - Laporan siapa saja yang sudah/belum menerima, membaca dan mengonfirmasi broadcast
+// Query: status=belum_diterima|diterima|dibaca|dikonfirmasi|belum_dibaca|belum_konfirmasi
+func (bc *BroadcastController) GetLaporanPenerimaanBroadcast(c *gin.Context) {
+	broadcastID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID broadcast tidak valid",
+		})
+		return
+	}
+
+	var broadcast models.Broadcast
+	if err := scopedDB(c, bc.db).First(&broadcast, broadcastID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Broadcast tidak ditemukan",
+		})
+		return
+	}
+
+	penerima, err := penerimaBroadcast(bc.db, broadcast)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menentukan penerima broadcast",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var daftarPenerimaan []models.BroadcastPenerimaan
+	if err := bc.db.Where("broadcast_id = ?", broadcast.BroadcastID).Find(&daftarPenerimaan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data penerimaan broadcast",
+		})
+		return
+	}
+	penerimaanUser := make(map[uint]models.BroadcastPenerimaan, len(daftarPenerimaan))
+	for _, p := range daftarPenerimaan {
+		penerimaanUser[p.UserID] = p
+	}
+
+	filterStatus := c.Query("status")
+	var diterima, dibaca, dikonfirmasi int64
+	laporan := make([]LaporanPenerimaanBroadcast, 0, len(penerima))
+	for _, user := range penerima {
+		p := penerimaanUser[user.UserID]
+		baris := LaporanPenerimaanBroadcast{
+			UserID:           user.UserID,
+			Username:         user.Username,
+			LevelID:          user.LevelID,
+			LevelNama:        user.Level.LevelNama,
+			WargaID:          user.WargaID,
+			DiterimaPada:     p.DiterimaPada,
+			DibacaPada:       p.DibacaPada,
+			DikonfirmasiPada: p.DikonfirmasiPada,
+			Status:           "belum_diterima",
+		}
+		if p.DiterimaPada != nil {
+			baris.Status = "diterima"
+			diterima++
+		}
+		if p.DibacaPada != nil {
+			baris.Status = "dibaca"
+			dibaca++
+		}
+		if p.DikonfirmasiPada != nil {
+			baris.Status = "dikonfirmasi"
+			dikonfirmasi++
+		}
+
+		switch filterStatus {
+		case "":
+		case "belum_dibaca":
+			if p.DibacaPada != nil {
+				continue
+			}
+		case "belum_konfirmasi":
+			if p.DikonfirmasiPada != nil {
+				continue
+			}
+		default:
+			if baris.Status != filterStatus {
+				continue
+			}
+		}
+		laporan = append(laporan, baris)
+	}
+
+	total := int64(len(penerima))
+	ringkasan := gin.H{
+		"total_penerima":      total,
+		"diterima":            diterima,
+		"dibaca":              dibaca,
+		"belum_dibaca":        total - dibaca,
+		"persentase_diterima": persentase(diterima, total),
+		"persentase_dibaca":   persentase(dibaca, total),
+	}
+	if broadcast.BroadcastWajibKonfirmasi {
+		ringkasan["dikonfirmasi"] = dikonfirmasi
+		ringkasan["belum_konfirmasi"] = total - dikonfirmasi
+		ringkasan["persentase_konfirmasi"] = persentase(dikonfirmasi, total)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":             laporan,
+		"ringkasan":        ringkasan,
+		"broadcast_id":     broadcast.BroadcastID,
+		"wajib_konfirmasi": broadcast.BroadcastWajibKonfirmasi,
+	})
+}
+
+// Admin dan sekretaris mengelola broadcast sehingga melihat semua broadcast beserta audiensnya
+func isPengelolaBroadcast(c *gin.Context) bool {
+	levelID, _ := c.Get("levelID")
+	return levelID == uint(1) || levelID == uint(2)
+}
+
+// ✅ Helper memvalidasi audiens dari request dan menyusun target broadcast
+func (bc *BroadcastController) buildBroadcastTarget(c *gin.Context, req BroadcastAudiensRequest) (string, []models.BroadcastTarget, string) {
+	audiens := strings.ToLower(strings.TrimSpace(req.Audiens))
+	hasTarget := len(req.TargetKeluargaID) > 0 || len(req.TargetBlok) > 0 ||
+		len(req.TargetLevelID) > 0 || len(req.TargetGrupID) > 0
+
+	switch audiens {
+	case "":
+		audiens = "semua"
+		if hasTarget {
+			audiens = "terbatas"
+		}
+	case "semua":
+		if hasTarget {
+			return "", nil, "Target audiens hanya dapat diisi untuk audiens terbatas"
+		}
+	case "terbatas":
+		if !hasTarget {
+			return "", nil, "Audiens terbatas membutuhkan minimal satu target"
+		}
+	default:
+		return "", nil, "Audiens harus semua atau terbatas"
+	}
+
+	var targets []models.BroadcastTarget
+	seen := make(map[string]bool)
+	addTarget := func(jenis string, id uint, blok string) {
+		key := fmt.Sprintf("%s:%d:%s", jenis, id, blok)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		target := models.BroadcastTarget{TargetJenis: jenis, TargetBlok: blok}
+		if id != 0 {
+			target.TargetID = &id
+		}
+		targets = append(targets, target)
+	}
+
+	db := scopedDB(c, bc.db)
+	for _, id := range req.TargetKeluargaID {
+		var keluarga models.Keluarga
+		if err := db.First(&keluarga, id).Error; err != nil {
+			return "", nil, fmt.Sprintf("Keluarga dengan ID %d tidak ditemukan", id)
+		}
+		addTarget("keluarga", id, "")
+	}
+	for _, blok := range req.TargetBlok {
+		blok = strings.ToUpper(strings.TrimSpace(blok))
+		var count int64
+		db.Model(&models.Rumah{}).Where("rumah_blok = ?", blok).Count(&count)
+		if blok == "" || count == 0 {
+			return "", nil, fmt.Sprintf("Blok %s tidak ditemukan", blok)
+		}
+		addTarget("blok", 0, blok)
+	}
+	for _, id := range req.TargetLevelID {
+		var level models.Level
+		if err := db.First(&level, id).Error; err != nil {
+			return "", nil, fmt.Sprintf("Level dengan ID %d tidak ditemukan", id)
+		}
+		addTarget("level", id, "")
+	}
+	for _, id := range req.TargetGrupID {
+		var grup models.GrupPenerima
+		if err := db.First(&grup, id).Error; err != nil {
+			return "", nil, fmt.Sprintf("Grup penerima dengan ID %d tidak ditemukan", id)
+		}
+		addTarget("grup", id, "")
+	}
+
+	return audiens, targets, ""
+}
+
+func simpanBroadcastTarget(tx *gorm.DB, broadcast *models.Broadcast, targets []models.BroadcastTarget) error {
+	for i := range targets {
+		targets[i].BroadcastID = broadcast.BroadcastID
+		targets[i].RTID = broadcast.RTID
+	}
+	if len(targets) > 0 {
+		if err := tx.Create(&targets).Error; err != nil {
+			return err
+		}
+	}
+	broadcast.Target = targets
+	return nil
+}
+
+// Data yang menentukan broadcast mana saja yang ditujukan ke seorang user
+type audiensBroadcastUser struct {
+	LevelID    uint
+	KeluargaID uint
+	Blok       []string
+	GrupID     []uint
+}
+
+// audiensUser mengumpulkan level, keluarga, blok hunian aktif dan grup penerima milik user
+func audiensUser(db *gorm.DB, userID uint) (audiensBroadcastUser, error) {
+	var audiens audiensBroadcastUser
+
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		return audiens, err
+	}
+	audiens.LevelID = user.LevelID
+
+	if user.WargaID != nil {
+		var warga models.Warga
+		if err := db.First(&warga, *user.WargaID).Error; err == nil {
+			audiens.KeluargaID = warga.KeluargaID
+
+			var rumahIDs []uint
+			if err := db.Model(&models.HunianRumah{}).
+				Where("keluarga_id = ?", warga.KeluargaID).
+				Scopes(hunianAktif(time.Now())).
+				Pluck("rumah_id", &rumahIDs).Error; err != nil {
+				return audiens, err
+			}
+			if len(rumahIDs) > 0 {
+				if err := db.Model(&models.Rumah{}).
+					Where("rumah_id IN ?", rumahIDs).
+					Distinct().
+					Pluck("rumah_blok", &audiens.Blok).Error; err != nil {
+					return audiens, err
+				}
+			}
+		}
+	}
+
+	if err := db.Model(&models.GrupPenerimaAnggota{}).
+		Where("user_id = ?", userID).
+		Pluck("grup_penerima_id", &audiens.GrupID).Error; err != nil {
+		return audiens, err
+	}
+
+	return audiens, nil
+}
+
+// broadcastUntukUser menambahkan filter agar query broadcast hanya berisi broadcast untuk audiens tersebut
+func broadcastUntukUser(db *gorm.DB, query *gorm.DB, audiens audiensBroadcastUser) *gorm.DB {
+	conds := []string{"(target_jenis = 'level' AND target_id = ?)"}
+	args := []interface{}{audiens.LevelID}
+	if audiens.KeluargaID != 0 {
+		conds = append(conds, "(target_jenis = 'keluarga' AND target_id = ?)")
+		args = append(args, audiens.KeluargaID)
+	}
+	if len(audiens.Blok) > 0 {
+		conds = append(conds, "(target_jenis = 'blok' AND target_blok IN ?)")
+		args = append(args, audiens.Blok)
+	}
+	if len(audiens.GrupID) > 0 {
+		conds = append(conds, "(target_jenis = 'grup' AND target_id IN ?)")
+		args = append(args, audiens.GrupID)
+	}
+
+	targetCocok := db.Model(&models.BroadcastTarget{}).
+		Select("broadcast_id").
+		Where(strings.Join(conds, " OR "), args...)
+
+	return query.Where("broadcast_audiens = ? OR broadcast_id IN (?)", "semua", targetCocok)
+}
+
+// ✅ Helper membatasi query broadcast sesuai audiens user yang login (pengelola melihat semua,
+// termasuk draft, terjadwal dan arsip)
+func (bc *BroadcastController) scopeAudiens(c *gin.Context, query *gorm.DB) (*gorm.DB, error) {
+	if isPengelolaBroadcast(c) {
+		return query, nil
+	}
+
+	userID, _ := c.Get("userID")
+	audiens, err := audiensUser(bc.db, userID.(uint))
+	if err != nil {
+		return nil, err
+	}
+	return broadcastUntukUser(bc.db, filterBroadcastTampil(query, time.Now()), audiens), nil
+}
+
+// ✅ Helper memastikan file foto/dokumen milik broadcast yang ditujukan ke user
+func (bc *BroadcastController) bolehAksesFileBroadcast(c *gin.Context, fieldName, filename string) bool {
+	query, err := bc.scopeAudiens(c, scopedDB(c, bc.db).Model(&models.Broadcast{}))
+	if err != nil {
+		return false
+	}
+
+	var count int64
+	query.Where(fieldName+" = ?", filename).Count(&count)
+	return count > 0
+}
+
+// penerimaBroadcast menyelesaikan audiens broadcast menjadi daftar user di RT broadcast tersebut
+func penerimaBroadcast(db *gorm.DB, broadcast models.Broadcast) ([]models.User, error) {
+	penerima := []models.User{}
+
+	query := db.Preload("Level").Order("username ASC")
+	if broadcast.RTID != nil {
+		query = query.Where("rt_id = ?", *broadcast.RTID)
+	}
+	if broadcast.BroadcastAudiens != "terbatas" {
+		err := query.Find(&penerima).Error
+		return penerima, err
+	}
+
+	var targets []models.BroadcastTarget
+	if err := db.Where("broadcast_id = ?", broadcast.BroadcastID).Find(&targets).Error; err != nil {
+		return nil, err
+	}
+
+	var levelIDs, keluargaIDs, grupIDs []uint
+	var bloks []string
+	for _, target := range targets {
+		switch {
+		case target.TargetJenis == "blok":
+			bloks = append(bloks, target.TargetBlok)
+		case target.TargetID == nil:
+			continue
+		case target.TargetJenis == "level":
+			levelIDs = append(levelIDs, *target.TargetID)
+		case target.TargetJenis == "keluarga":
+			keluargaIDs = append(keluargaIDs, *target.TargetID)
+		case target.TargetJenis == "grup":
+			grupIDs = append(grupIDs, *target.TargetID)
+		}
+	}
+
+	// Blok diterjemahkan menjadi keluarga yang sedang menghuni rumah di blok tersebut
+	if len(bloks) > 0 {
+		rumahQuery := db.Model(&models.Rumah{}).Where("rumah_blok IN ?", bloks)
+		if broadcast.RTID != nil {
+			rumahQuery = rumahQuery.Where("rt_id = ?", *broadcast.RTID)
+		}
+		var rumahIDs []uint
+		if err := rumahQuery.Pluck("rumah_id", &rumahIDs).Error; err != nil {
+			return nil, err
+		}
+		if len(rumahIDs) > 0 {
+			var hunianKeluarga []uint
+			if err := db.Model(&models.HunianRumah{}).
+				Where("rumah_id IN ?", rumahIDs).
+				Scopes(hunianAktif(time.Now())).
+				Pluck("keluarga_id", &hunianKeluarga).Error; err != nil {
+				return nil, err
+			}
+			keluargaIDs = append(keluargaIDs, hunianKeluarga...)
+		}
+	}
+
+	var conds []string
+	var args []interface{}
+	if len(levelIDs) > 0 {
+		conds = append(conds, "level_id IN ?")
+		args = append(args, levelIDs)
+	}
+	if len(keluargaIDs) > 0 {
+		var wargaIDs []uint
+		if err := db.Model(&models.Warga{}).Where("keluarga_id IN ?", keluargaIDs).Pluck("warga_id", &wargaIDs).Error; err != nil {
+			return nil, err
+		}
+		if len(wargaIDs) > 0 {
+			conds = append(conds, "warga_id IN ?")
+			args = append(args, wargaIDs)
+		}
+	}
+	if len(grupIDs) > 0 {
+		var userIDs []uint
+		if err := db.Model(&models.GrupPenerimaAnggota{}).Where("grup_penerima_id IN ?", grupIDs).Pluck("user_id", &userIDs).Error; err != nil {
+			return nil, err
+		}
+		if len(userIDs) > 0 {
+			conds = append(conds, "user_id IN ?")
+			args = append(args, userIDs)
+		}
+	}
+	if len(conds) == 0 {
+		return penerima, nil
+	}
+
+	err := query.Where(strings.Join(conds, " OR "), args...).Find(&penerima).Error
+	return penerima, err
+}
+
+// ✅ Helper status penerimaan broadcast milik user, atau data baru jika belum ada
+func (bc *BroadcastController) findOrNewPenerimaan(broadcast models.Broadcast, userID uint) (models.BroadcastPenerimaan, error) {
+	var penerimaan models.BroadcastPenerimaan
+	err := bc.db.Where("broadcast_id = ? AND user_id = ?", broadcast.BroadcastID, userID).First(&penerimaan).Error
+	if err == gorm.ErrRecordNotFound {
+		return models.BroadcastPenerimaan{
+			BroadcastID: broadcast.BroadcastID,
+			UserID:      userID,
+			RTID:        broadcast.RTID,
+		}, nil
+	}
+	return penerimaan, err
+}
+
+// tandaiDiterima mencatat waktu diterima untuk broadcast yang baru pertama kali tampil ke user.
+// Pencatatan bersifat best-effort, kegagalan tidak menggagalkan request daftar broadcast.
+func (bc *BroadcastController) tandaiDiterima(c *gin.Context, broadcasts []models.Broadcast) {
+	if len(broadcasts) == 0 {
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	ids := make([]uint, 0, len(broadcasts))
+	for _, b := range broadcasts {
+		ids = append(ids, b.BroadcastID)
+	}
+
+	var sudah []uint
+	if err := bc.db.Model(&models.BroadcastPenerimaan{}).
+		Where("user_id = ? AND broadcast_id IN ?", userID, ids).
+		Pluck("broadcast_id", &sudah).Error; err != nil {
+		return
+	}
+	sudahDiterima := make(map[uint]bool, len(sudah))
+	for _, id := range sudah {
+		sudahDiterima[id] = true
+	}
+
+	now := time.Now()
+	var baru []models.BroadcastPenerimaan
+	for _, b := range broadcasts {
+		if sudahDiterima[b.BroadcastID] || !broadcastTampil(b, now) {
+			continue
+		}
+		baru = append(baru, models.BroadcastPenerimaan{
+			BroadcastID:  b.BroadcastID,
+			UserID:       userID.(uint),
+			DiterimaPada: &now,
+			RTID:         b.RTID,
+		})
+	}
+	if len(baru) > 0 {
+		bc.db.Create(&baru)
+	}
+}
+
+// tandaiDibaca mencatat waktu dibaca (dan diterima jika belum) saat user membuka detail broadcast
+func (bc *BroadcastController) tandaiDibaca(c *gin.Context, broadcast models.Broadcast) *models.BroadcastPenerimaan {
+	// Pengelola yang membuka draft/arsip tidak dihitung sebagai pembaca
+	if !broadcastTampil(broadcast, time.Now()) {
+		return nil
+	}
+
+	userID, _ := c.Get("userID")
+	penerimaan, err := bc.findOrNewPenerimaan(broadcast, userID.(uint))
+	if err != nil {
+		return nil
+	}
+	if penerimaan.DibacaPada != nil {
+		return &penerimaan
+	}
+
+	now := time.Now()
+	if penerimaan.DiterimaPada == nil {
+		penerimaan.DiterimaPada = &now
+	}
+	penerimaan.DibacaPada = &now
+	if err := bc.db.Save(&penerimaan).Error; err != nil {
+		return nil
+	}
+	return &penerimaan
+}
+
+// ✅ Helper mengambil broadcast dari parameter :id
+func (bc *BroadcastController) findBroadcast(c *gin.Context) (models.Broadcast, bool) {
+	var broadcast models.Broadcast
+
+	broadcastID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID broadcast tidak valid",
+		})
+		return broadcast, false
+	}
+
+	if err := scopedDB(c, bc.db).First(&broadcast, broadcastID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Broadcast tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan broadcast",
+			})
+		}
+		return broadcast, false
+	}
+
+	return broadcast, true
+}
+
+// Hasil perhitungan status dan waktu tayang broadcast
+type jadwalBroadcast struct {
+	Status          string
+	TerbitPada      *time.Time
+	KedaluwarsaPada *time.Time
+	DiterbitkanPada *time.Time
+}
+
+// tentukanJadwalBroadcast menentukan status dari permintaan draft/terbit: waktu terbit di masa depan
+// menjadi terjadwal, selain itu langsung terbit. Kedaluwarsa harus setelah waktu terbit.
+func tentukanJadwalBroadcast(status string, terbitPada, kedaluwarsaPada *time.Time, now time.Time) (jadwalBroadcast, string) {
+	jadwal := jadwalBroadcast{TerbitPada: terbitPada, KedaluwarsaPada: kedaluwarsaPada}
+
+	switch status {
+	case "", "terbit", "terjadwal":
+		if terbitPada != nil && terbitPada.After(now) {
+			jadwal.Status = "terjadwal"
+		} else {
+			jadwal.Status = "terbit"
+			jadwal.TerbitPada = &now
+			jadwal.DiterbitkanPada = &now
+		}
+	case "draft":
+		jadwal.Status = "draft"
+	default:
+		return jadwal, "Status broadcast harus draft atau terbit"
+	}
+
+	if kedaluwarsaPada != nil {
+		batas := now
+		if terbitPada != nil && terbitPada.After(now) {
+			batas = *terbitPada
+		}
+		if !kedaluwarsaPada.After(batas) {
+			return jadwal, "Waktu kedaluwarsa harus setelah waktu terbit"
+		}
+	}
+
+	return jadwal, ""
+}
+
+// jadwalUpdateBroadcast menyusun kolom jadwal yang berubah pada update broadcast
+func jadwalUpdateBroadcast(broadcast models.Broadcast, req UpdateBroadcastRequest, now time.Time) (map[string]interface{}, string) {
+	updates := make(map[string]interface{})
+
+	if req.BroadcastDisematkan != nil {
+		updates["broadcast_disematkan"] = *req.BroadcastDisematkan
+	}
+	if req.BroadcastPrioritas != nil {
+		prioritas := strings.ToLower(strings.TrimSpace(*req.BroadcastPrioritas))
+		if !isValidPrioritasBroadcast(prioritas) {
+			return nil, "Prioritas harus normal, penting, atau darurat"
+		}
+		updates["broadcast_prioritas"] = prioritas
+	}
+
+	if req.BroadcastStatus == nil && req.BroadcastTerbitPada == nil && req.BroadcastKedaluwarsaPada == nil {
+		return updates, ""
+	}
+
+	terbitPada := broadcast.BroadcastTerbitPada
+	if req.BroadcastTerbitPada != nil {
+		t, err := parseWaktuBroadcast(*req.BroadcastTerbitPada)
+		if err != nil {
+			return nil, "Format waktu terbit tidak valid. Gunakan format YYYY-MM-DD HH:MM"
+		}
+		terbitPada = t
+	}
+	kedaluwarsaPada := broadcast.BroadcastKedaluwarsaPada
+	if req.BroadcastKedaluwarsaPada != nil {
+		t, err := parseWaktuBroadcast(*req.BroadcastKedaluwarsaPada)
+		if err != nil {
+			return nil, "Format waktu kedaluwarsa tidak valid. Gunakan format YYYY-MM-DD HH:MM"
+		}
+		kedaluwarsaPada = t
+	}
+
+	switch broadcast.BroadcastStatus {
+	case "draft", "terjadwal":
+		status := broadcast.BroadcastStatus
+		if req.BroadcastStatus != nil {
+			status = strings.ToLower(strings.TrimSpace(*req.BroadcastStatus))
+		}
+		jadwal, msg := tentukanJadwalBroadcast(status, terbitPada, kedaluwarsaPada, now)
+		if msg != "" {
+			return nil, msg
+		}
+		updates["broadcast_status"] = jadwal.Status
+		updates["broadcast_terbit_pada"] = jadwal.TerbitPada
+		updates["broadcast_kedaluwarsa_pada"] = jadwal.KedaluwarsaPada
+		updates["broadcast_diterbitkan_pada"] = jadwal.DiterbitkanPada
+	default:
+		// Broadcast yang sudah terbit/arsip hanya boleh diubah waktu kedaluwarsanya
+		if req.BroadcastStatus != nil && *req.BroadcastStatus != broadcast.BroadcastStatus {
+			return nil, "Gunakan endpoint terbitkan/arsipkan untuk mengubah status broadcast yang sudah terbit"
+		}
+		if req.BroadcastTerbitPada != nil {
+			return nil, "Broadcast sudah terbit, waktu terbit tidak dapat diubah"
+		}
+		if kedaluwarsaPada != nil && !kedaluwarsaPada.After(now) {
+			return nil, "Waktu kedaluwarsa harus di masa depan"
+		}
+		updates["broadcast_kedaluwarsa_pada"] = kedaluwarsaPada
+	}
+
+	return updates, ""
+}
+
+// ✅ Helper parse waktu broadcast, string kosong berarti tidak diisi
+func parseWaktuBroadcast(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02 15:04", value, time.Local)
+	if err != nil {
+		if t, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, err
+		}
+	}
+	return &t, nil
+}
+
+func isValidPrioritasBroadcast(prioritas string) bool {
+	switch prioritas {
+	case "normal", "penting", "darurat":
+		return true
+	}
+	return false
+}
+
+// filterBroadcastTampil membatasi query pada broadcast yang sedang tayang: sudah terbit
+// (termasuk terjadwal yang waktunya lewat tetapi belum diproses penjadwal) dan belum kedaluwarsa
+func filterBroadcastTampil(query *gorm.DB, now time.Time) *gorm.DB {
+	return query.
+		Where("broadcast_status = ? OR (broadcast_status = ? AND broadcast_terbit_pada <= ?)", "terbit", "terjadwal", now).
+		Where("broadcast_kedaluwarsa_pada IS NULL OR broadcast_kedaluwarsa_pada > ?", now)
+}
+
+// broadcastTampil adalah padanan filterBroadcastTampil untuk satu broadcast yang sudah dimuat
+func broadcastTampil(broadcast models.Broadcast, now time.Time) bool {
+	switch broadcast.BroadcastStatus {
+	case "terbit":
+	case "terjadwal":
+		if broadcast.BroadcastTerbitPada == nil || broadcast.BroadcastTerbitPada.After(now) {
+			return false
+		}
+	default:
+		return false
+	}
+	return broadcast.BroadcastKedaluwarsaPada == nil || broadcast.BroadcastKedaluwarsaPada.After(now)
+}
@@ -0,0 +1,812 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"rt-management/models"
+	"rt-management/notification"
+	"rt-management/realtime"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Panjang maksimal isi satu komentar broadcast
+const maksKomentarBroadcast = 2000
+
+// Mention mengikuti aturan username: 3-20 karakter huruf, angka, underscore
+var polaMentionKomentar = regexp.MustCompile(`@([a-zA-Z0-9_]{3,20})`)
+
+type KomentarBroadcastRequest struct {
+	KomentarIsi string `form:"komentar_isi" binding:"required"`
+	IndukID     *uint  `form:"induk_id"` // diisi untuk membalas komentar
+}
+
+type UpdateKomentarBroadcastRequest struct {
+	KomentarIsi string `form:"komentar_isi" binding:"required"`
+}
+
+type SembunyikanKomentarRequest struct {
+	Disembunyikan *bool  `form:"disembunyikan"` // default true
+	Alasan        string `form:"alasan"`
+}
+
+type KunciKomentarRequest struct {
+	Dikunci *bool `form:"dikunci"` // default true
+}
+
+// Komentar beserta penulis dan balasannya untuk ditampilkan
+type KomentarBroadcastItem struct {
+	models.BroadcastKomentar
+	PenulisUsername string                  `json:"penulis_username"`
+	PenulisFoto     string                  `json:"penulis_foto"`
+	Dihapus         bool                    `json:"dihapus"`
+	Balasan         []KomentarBroadcastItem `json:"balasan,omitempty"`
+}
+
+// Broadcast pada daftar beserta jumlah komentar; komentar_baru hanya untuk pembuat broadcast
+type BroadcastDaftarItem struct {
+	models.Broadcast
+	JumlahKomentar int64 `json:"jumlah_komentar"`
+	KomentarBaru   int64 `json:"komentar_baru,omitempty"`
+}
+
+// ✅ GET - Komentar broadcast beserta balasannya. Pagination berlaku untuk komentar utama.
+func (bc *BroadcastController) GetKomentarBroadcast(c *gin.Context) {
+	broadcast, ok := bc.findBroadcastUntukUser(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	// Komentar utama yang dihapus tetap muncul sebagai penanda jika masih memiliki balasan
+	adaBalasan := bc.db.Model(&models.BroadcastKomentar{}).
+		Select("induk_id").
+		Where("broadcast_id = ? AND induk_id IS NOT NULL AND deleted_at IS NULL", broadcast.BroadcastID)
+	query := bc.db.Unscoped().Model(&models.BroadcastKomentar{}).
+		Where("broadcast_id = ? AND induk_id IS NULL", broadcast.BroadcastID).
+		Where("deleted_at IS NULL OR broadcast_komentar_id IN (?)", adaBalasan)
+
+	var total int64
+	query.Count(&total)
+
+	var utama []models.BroadcastKomentar
+	if err := query.Preload("Mention").
+		Order("created_at ASC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&utama).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil komentar broadcast",
+		})
+		return
+	}
+
+	var balasan []models.BroadcastKomentar
+	if len(utama) > 0 {
+		indukIDs := make([]uint, 0, len(utama))
+		for _, k := range utama {
+			indukIDs = append(indukIDs, k.BroadcastKomentarID)
+		}
+		if err := bc.db.Preload("Mention").
+			Where("induk_id IN ?", indukIDs).
+			Order("created_at ASC").
+			Find(&balasan).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal mengambil balasan komentar",
+			})
+			return
+		}
+	}
+
+	penulis := bc.penulisKomentar(append(append([]models.BroadcastKomentar{}, utama...), balasan...))
+	balasanPerInduk := make(map[uint][]KomentarBroadcastItem)
+	for _, k := range balasan {
+		balasanPerInduk[*k.IndukID] = append(balasanPerInduk[*k.IndukID], tampilkanKomentar(c, k, penulis))
+	}
+
+	data := make([]KomentarBroadcastItem, 0, len(utama))
+	for _, k := range utama {
+		item := tampilkanKomentar(c, k, penulis)
+		item.Balasan = balasanPerInduk[k.BroadcastKomentarID]
+		data = append(data, item)
+	}
+
+	// Pembuat broadcast yang membuka komentar dianggap sudah melihat semua komentar baru
+	userID, _ := c.Get("userID")
+	if broadcast.BroadcastDibuatOlehID != nil && *broadcast.BroadcastDibuatOlehID == userID.(uint) {
+		bc.db.Model(&broadcast).UpdateColumn("broadcast_komentar_dilihat_pada", time.Now())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    data,
+		"dikunci": broadcast.BroadcastKomentarDikunci,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// ✅ POST - Menambah komentar atau membalas komentar pada broadcast
+func (bc *BroadcastController) CreateKomentarBroadcast(c *gin.Context) {
+	broadcast, ok := bc.findBroadcastUntukUser(c)
+	if !ok {
+		return
+	}
+
+	var req KomentarBroadcastRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	isi, isiErr := validasiIsiKomentar(req.KomentarIsi)
+	if isiErr != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": isiErr,
+		})
+		return
+	}
+
+	if !broadcastTampil(broadcast, time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Komentar hanya dapat ditambahkan pada broadcast yang sedang tayang",
+		})
+		return
+	}
+	if broadcast.BroadcastKomentarDikunci {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Komentar pada broadcast ini sudah dikunci",
+		})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	komentar := models.BroadcastKomentar{
+		BroadcastID: broadcast.BroadcastID,
+		UserID:      userID.(uint),
+		KomentarIsi: isi,
+		RTID:        broadcast.RTID,
+	}
+
+	// Balasan hanya satu tingkat: membalas sebuah balasan dicatat sebagai balasan komentar utamanya
+	var dibalas []uint
+	if req.IndukID != nil && *req.IndukID != 0 {
+		var induk models.BroadcastKomentar
+		if err := bc.db.Where("broadcast_id = ?", broadcast.BroadcastID).First(&induk, *req.IndukID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Komentar yang dibalas tidak ditemukan",
+			})
+			return
+		}
+		if induk.UserID != komentar.UserID {
+			dibalas = append(dibalas, induk.UserID)
+		}
+		if induk.IndukID != nil {
+			var utama models.BroadcastKomentar
+			if err := bc.db.First(&utama, *induk.IndukID).Error; err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "Komentar yang dibalas tidak ditemukan",
+				})
+				return
+			}
+			induk = utama
+		}
+		if induk.KomentarDikunci {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Utas komentar ini sudah dikunci",
+			})
+			return
+		}
+		komentar.IndukID = &induk.BroadcastKomentarID
+	}
+
+	peserta, err := pesertaDiskusiBroadcast(bc.db, broadcast)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal menentukan peserta diskusi broadcast",
+		})
+		return
+	}
+	komentar.Mention = mentionKomentar(isi, peserta, komentar)
+
+	if err := bc.db.Create(&komentar).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menambah komentar",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// User yang dibalas dan di-mention mendapat notifikasi, kecuali penulis sendiri
+	bc.beritahuKomentar(broadcast, komentar, dibalas, komentar.Mention, peserta)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Komentar berhasil ditambahkan",
+		"data":    komentar,
+	})
+}
+
+// ✅ PUT - Mengubah komentar milik sendiri
+func (bc *BroadcastController) UpdateKomentarBroadcast(c *gin.Context) {
+	komentar, broadcast, ok := bc.findKomentarBroadcast(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	if komentar.UserID != userID.(uint) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Hanya penulis yang dapat mengubah komentar",
+		})
+		return
+	}
+	if broadcast.BroadcastKomentarDikunci {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Komentar pada broadcast ini sudah dikunci",
+		})
+		return
+	}
+
+	// Kunci utas berlaku untuk komentar utama dan seluruh balasannya
+	utasDikunci := komentar.KomentarDikunci
+	if komentar.IndukID != nil {
+		var induk models.BroadcastKomentar
+		if err := bc.db.Select("broadcast_komentar_id", "komentar_dikunci").First(&induk, *komentar.IndukID).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal memeriksa utas komentar",
+			})
+			return
+		}
+		utasDikunci = induk.KomentarDikunci
+	}
+	if utasDikunci {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Utas komentar ini sudah dikunci",
+		})
+		return
+	}
+
+	var req UpdateKomentarBroadcastRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	isi, isiErr := validasiIsiKomentar(req.KomentarIsi)
+	if isiErr != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": isiErr,
+		})
+		return
+	}
+
+	peserta, err := pesertaDiskusiBroadcast(bc.db, broadcast)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal menentukan peserta diskusi broadcast",
+		})
+		return
+	}
+
+	// Hanya mention yang baru ditambahkan saat edit yang dikirimi notifikasi
+	sudahDisebut := make(map[uint]bool, len(komentar.Mention))
+	for _, m := range komentar.Mention {
+		sudahDisebut[m.UserID] = true
+	}
+	mention := mentionKomentar(isi, peserta, komentar)
+	var mentionBaru []models.BroadcastKomentarMention
+	for _, m := range mention {
+		if !sudahDisebut[m.UserID] {
+			mentionBaru = append(mentionBaru, m)
+		}
+	}
+
+	now := time.Now()
+	err = bc.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&komentar).Updates(map[string]interface{}{
+			"komentar_isi":         isi,
+			"komentar_diubah_pada": now,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("broadcast_komentar_id = ?", komentar.BroadcastKomentarID).
+			Delete(&models.BroadcastKomentarMention{}).Error; err != nil {
+			return err
+		}
+		if len(mention) > 0 {
+			return tx.Create(&mention).Error
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mengubah komentar",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	komentar.KomentarIsi = isi
+	komentar.KomentarDiubahPada = &now
+	komentar.Mention = mention
+	bc.beritahuKomentar(broadcast, komentar, nil, mentionBaru, peserta)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Komentar berhasil diubah",
+		"data":    komentar,
+	})
+}
+
+// ✅ DELETE - Menghapus komentar milik sendiri. Komentar utama yang sudah dibalas tampil sebagai "dihapus".
+func (bc *BroadcastController) DeleteKomentarBroadcast(c *gin.Context) {
+	komentar, _, ok := bc.findKomentarBroadcast(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	if komentar.UserID != userID.(uint) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Hanya penulis yang dapat menghapus komentar, pengurus dapat menyembunyikannya",
+		})
+		return
+	}
+
+	if err := bc.db.Delete(&komentar).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menghapus komentar",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Komentar berhasil dihapus",
+	})
+}
+
+// ✅ PUT - Moderasi: menyembunyikan atau menampilkan kembali komentar
+func (bc *BroadcastController) SembunyikanKomentarBroadcast(c *gin.Context) {
+	komentar, _, ok := bc.findKomentarBroadcast(c)
+	if !ok {
+		return
+	}
+
+	var req SembunyikanKomentarRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	disembunyikan := req.Disembunyikan == nil || *req.Disembunyikan
+	updates := map[string]interface{}{
+		"komentar_disembunyikan":   disembunyikan,
+		"komentar_alasan_sembunyi": "",
+		"disembunyikan_oleh_id":    nil,
+	}
+	komentar.KomentarDisembunyikan = disembunyikan
+	komentar.KomentarAlasanSembunyi = ""
+	komentar.DisembunyikanOlehID = nil
+	message := "Komentar berhasil ditampilkan kembali"
+	if disembunyikan {
+		userID, _ := c.Get("userID")
+		moderatorID := userID.(uint)
+		komentar.KomentarAlasanSembunyi = ringkasTeks(req.Alasan, 255)
+		komentar.DisembunyikanOlehID = &moderatorID
+		updates["komentar_alasan_sembunyi"] = komentar.KomentarAlasanSembunyi
+		updates["disembunyikan_oleh_id"] = moderatorID
+		message = "Komentar berhasil disembunyikan"
+	}
+
+	if err := bc.db.Model(&komentar).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal memoderasi komentar",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    komentar,
+	})
+}
+
+// ✅ PUT - Moderasi: mengunci utas komentar sehingga tidak dapat dibalas lagi
+func (bc *BroadcastController) KunciUtasKomentarBroadcast(c *gin.Context) {
+	komentar, _, ok := bc.findKomentarBroadcast(c)
+	if !ok {
+		return
+	}
+
+	if komentar.IndukID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Hanya komentar utama yang dapat dikunci",
+		})
+		return
+	}
+
+	var req KunciKomentarRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	dikunci := req.Dikunci == nil || *req.Dikunci
+	if err := bc.db.Model(&komentar).Update("komentar_dikunci", dikunci).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mengunci utas komentar",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	komentar.KomentarDikunci = dikunci
+
+	message := "Utas komentar berhasil dibuka"
+	if dikunci {
+		message = "Utas komentar berhasil dikunci"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    komentar,
+	})
+}
+
+// ✅ PUT - Moderasi: mengunci seluruh komentar broadcast
+func (bc *BroadcastController) KunciKomentarBroadcast(c *gin.Context) {
+	broadcast, ok := bc.findBroadcast(c)
+	if !ok {
+		return
+	}
+
+	var req KunciKomentarRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	dikunci := req.Dikunci == nil || *req.Dikunci
+	if err := scopedDB(c, bc.db).Model(&broadcast).Update("broadcast_komentar_dikunci", dikunci).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mengunci komentar broadcast",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	broadcast.BroadcastKomentarDikunci = dikunci
+
+	message := "Komentar broadcast berhasil dibuka"
+	if dikunci {
+		message = "Komentar broadcast berhasil dikunci"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    broadcast,
+	})
+}
+
+// ✅ Helper mengambil broadcast dari parameter :id yang ditujukan ke user yang login
+func (bc *BroadcastController) findBroadcastUntukUser(c *gin.Context) (models.Broadcast, bool) {
+	var broadcast models.Broadcast
+
+	broadcastID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID broadcast tidak valid",
+		})
+		return broadcast, false
+	}
+
+	query, err := bc.scopeAudiens(c, scopedDB(c, bc.db))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal menentukan audiens broadcast",
+		})
+		return broadcast, false
+	}
+
+	// Broadcast di luar audiens user diperlakukan sama dengan tidak ditemukan
+	if err := query.First(&broadcast, broadcastID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Broadcast tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal mengambil data broadcast",
+			})
+		}
+		return broadcast, false
+	}
+
+	return broadcast, true
+}
+
+// ✅ Helper mengambil komentar dari parameter :komentar_id beserta broadcastnya yang dapat diakses user
+func (bc *BroadcastController) findKomentarBroadcast(c *gin.Context) (models.BroadcastKomentar, models.Broadcast, bool) {
+	var komentar models.BroadcastKomentar
+	var broadcast models.Broadcast
+
+	komentarID, err := strconv.ParseUint(c.Param("komentar_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID komentar tidak valid",
+		})
+		return komentar, broadcast, false
+	}
+
+	if err := scopedDB(c, bc.db).Preload("Mention").First(&komentar, komentarID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Komentar tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan komentar",
+			})
+		}
+		return komentar, broadcast, false
+	}
+
+	query, err := bc.scopeAudiens(c, scopedDB(c, bc.db))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal menentukan audiens broadcast",
+		})
+		return komentar, broadcast, false
+	}
+	if err := query.First(&broadcast, komentar.BroadcastID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Komentar tidak ditemukan",
+		})
+		return komentar, broadcast, false
+	}
+
+	return komentar, broadcast, true
+}
+
+// lengkapiJumlahKomentar menambahkan jumlah komentar setiap broadcast pada daftar, dan jumlah komentar
+// dari user lain sejak pembuat broadcast terakhir membuka komentarnya
+func (bc *BroadcastController) lengkapiJumlahKomentar(c *gin.Context, broadcasts []models.Broadcast) []BroadcastDaftarItem {
+	data := make([]BroadcastDaftarItem, 0, len(broadcasts))
+	if len(broadcasts) == 0 {
+		return data
+	}
+
+	ids := make([]uint, 0, len(broadcasts))
+	for _, b := range broadcasts {
+		ids = append(ids, b.BroadcastID)
+	}
+
+	// Komentar baru (dari user lain sejak pembuat broadcast terakhir membuka komentar) dihitung
+	// dalam query yang sama, hanya untuk broadcast yang dibuat oleh user ini
+	userID, _ := c.Get("userID")
+	query := bc.db.Model(&models.BroadcastKomentar{}).
+		Select(`broadcast_komentars.broadcast_id, COUNT(*) AS jumlah,
+			SUM(CASE WHEN broadcasts.broadcast_dibuat_oleh_id = ? AND broadcast_komentars.user_id <> ?
+				AND (broadcasts.broadcast_komentar_dilihat_pada IS NULL
+					OR broadcast_komentars.created_at > broadcasts.broadcast_komentar_dilihat_pada)
+				THEN 1 ELSE 0 END) AS komentar_baru`, userID, userID).
+		Joins("JOIN broadcasts ON broadcasts.broadcast_id = broadcast_komentars.broadcast_id").
+		Where("broadcast_komentars.broadcast_id IN ?", ids)
+	if !isPengelolaBroadcast(c) {
+		query = query.Where("broadcast_komentars.komentar_disembunyikan = ?", false)
+	}
+	var jumlah []struct {
+		BroadcastID  uint
+		Jumlah       int64
+		KomentarBaru int64
+	}
+	query.Group("broadcast_komentars.broadcast_id").Scan(&jumlah)
+	jumlahKomentar := make(map[uint]int64, len(jumlah))
+	komentarBaru := make(map[uint]int64, len(jumlah))
+	for _, j := range jumlah {
+		jumlahKomentar[j.BroadcastID] = j.Jumlah
+		komentarBaru[j.BroadcastID] = j.KomentarBaru
+	}
+
+	for _, b := range broadcasts {
+		data = append(data, BroadcastDaftarItem{
+			Broadcast:      b,
+			JumlahKomentar: jumlahKomentar[b.BroadcastID],
+			KomentarBaru:   komentarBaru[b.BroadcastID],
+		})
+	}
+	return data
+}
+
+// penulisKomentar memuat username dan foto penulis komentar tanpa data sensitif akun
+func (bc *BroadcastController) penulisKomentar(komentar []models.BroadcastKomentar) map[uint]models.User {
+	penulis := make(map[uint]models.User)
+	if len(komentar) == 0 {
+		return penulis
+	}
+
+	ids := make([]uint, 0, len(komentar))
+	for _, k := range komentar {
+		ids = append(ids, k.UserID)
+	}
+	var users []models.User
+	bc.db.Select("user_id", "username", "foto_profile").Where("user_id IN ?", ids).Find(&users)
+	for _, u := range users {
+		penulis[u.UserID] = u
+	}
+	return penulis
+}
+
+// beritahuKomentar mengirim notifikasi ke user yang dibalas/di-mention dan mendorong event
+// broadcast.komentar ke peserta diskusi yang sedang membuka aplikasi
+func (bc *BroadcastController) beritahuKomentar(broadcast models.Broadcast, komentar models.BroadcastKomentar, dibalas []uint, mention []models.BroadcastKomentarMention, peserta []models.User) {
+	penulis := fmt.Sprintf("User #%d", komentar.UserID)
+	for _, p := range peserta {
+		if p.UserID == komentar.UserID {
+			penulis = p.Username
+			break
+		}
+	}
+
+	broadcastID := broadcast.BroadcastID
+	url := fmt.Sprintf("/broadcast/%d#komentar-%d", broadcast.BroadcastID, komentar.BroadcastKomentarID)
+	if len(dibalas) > 0 {
+		bc.notifikasi.KirimKeUser(dibalas, notification.Notifikasi{
+			Jenis:    notification.JenisBroadcast,
+			Judul:    fmt.Sprintf("%s membalas komentar Anda di \"%s\"", penulis, broadcast.BroadcastNama),
+			Pesan:    ringkasTeks(komentar.KomentarIsi, 300),
+			URL:      url,
+			Sumber:   "broadcast_komentar",
+			SumberID: &broadcastID,
+			Kunci:    fmt.Sprintf("broadcast_komentar:%d:balasan", komentar.BroadcastKomentarID),
+			RTID:     broadcast.RTID,
+		})
+	}
+
+	var disebut []uint
+	for _, m := range mention {
+		if m.UserID != komentar.UserID {
+			disebut = append(disebut, m.UserID)
+		}
+	}
+	if len(disebut) > 0 {
+		bc.notifikasi.KirimKeUser(disebut, notification.Notifikasi{
+			Jenis:    notification.JenisBroadcast,
+			Judul:    fmt.Sprintf("%s menyebut Anda di \"%s\"", penulis, broadcast.BroadcastNama),
+			Pesan:    ringkasTeks(komentar.KomentarIsi, 300),
+			URL:      url,
+			Sumber:   "broadcast_komentar",
+			SumberID: &broadcastID,
+			Kunci:    fmt.Sprintf("broadcast_komentar:%d:mention", komentar.BroadcastKomentarID),
+			RTID:     broadcast.RTID,
+		})
+	}
+
+	userIDs := make([]uint, 0, len(peserta))
+	for _, p := range peserta {
+		userIDs = append(userIDs, p.UserID)
+	}
+	if len(userIDs) > 0 {
+		bc.hub.Publikasikan(realtime.Event{
+			Topik: realtime.TopikBroadcast,
+			Jenis: "broadcast.komentar",
+			Data: gin.H{
+				"broadcast_id":          broadcast.BroadcastID,
+				"broadcast_komentar_id": komentar.BroadcastKomentarID,
+				"induk_id":              komentar.IndukID,
+				"user_id":               komentar.UserID,
+				"penulis_username":      penulis,
+			},
+			UserIDs: userIDs,
+		})
+	}
+}
+
+// pesertaDiskusiBroadcast adalah penerima broadcast ditambah pengurus (admin/sekretaris) di wilayahnya,
+// yaitu user yang dapat membaca komentar dan boleh di-mention
+func pesertaDiskusiBroadcast(db *gorm.DB, broadcast models.Broadcast) ([]models.User, error) {
+	penerima, err := penerimaBroadcast(db, broadcast)
+	if err != nil {
+		return nil, err
+	}
+
+	query := db.Where("level_id IN ?", []uint{1, 2})
+	if broadcast.RTID != nil {
+		query = query.Where("rt_id = ? OR rt_id IS NULL", *broadcast.RTID)
+	}
+	var pengurus []models.User
+	if err := query.Find(&pengurus).Error; err != nil {
+		return nil, err
+	}
+
+	ada := make(map[uint]bool, len(penerima))
+	for _, u := range penerima {
+		ada[u.UserID] = true
+	}
+	for _, u := range pengurus {
+		if !ada[u.UserID] {
+			penerima = append(penerima, u)
+		}
+	}
+	return penerima, nil
+}
+
+// mentionKomentar mencari @username pada isi komentar yang cocok dengan peserta diskusi
+func mentionKomentar(isi string, peserta []models.User, komentar models.BroadcastKomentar) []models.BroadcastKomentarMention {
+	var mention []models.BroadcastKomentarMention
+	sudah := make(map[uint]bool)
+	for _, cocok := range polaMentionKomentar.FindAllStringSubmatch(isi, -1) {
+		for _, u := range peserta {
+			if !strings.EqualFold(u.Username, cocok[1]) || sudah[u.UserID] {
+				continue
+			}
+			sudah[u.UserID] = true
+			mention = append(mention, models.BroadcastKomentarMention{
+				BroadcastKomentarID: komentar.BroadcastKomentarID,
+				UserID:              u.UserID,
+				Username:            u.Username,
+				RTID:                komentar.RTID,
+			})
+		}
+	}
+	return mention
+}
+
+// tampilkanKomentar menyusun komentar untuk user yang login: isi komentar yang disembunyikan hanya
+// terlihat oleh pengurus dan penulisnya, isi komentar yang dihapus dikosongkan
+func tampilkanKomentar(c *gin.Context, komentar models.BroadcastKomentar, penulis map[uint]models.User) KomentarBroadcastItem {
+	item := KomentarBroadcastItem{
+		BroadcastKomentar: komentar,
+		PenulisUsername:   penulis[komentar.UserID].Username,
+		PenulisFoto:       penulis[komentar.UserID].FotoProfile,
+		Dihapus:           komentar.DeletedAt.Valid,
+	}
+
+	userID, _ := c.Get("userID")
+	if item.Dihapus || (komentar.KomentarDisembunyikan && !isPengelolaBroadcast(c) && komentar.UserID != userID.(uint)) {
+		item.KomentarIsi = ""
+		item.Mention = nil
+	}
+	return item
+}
+
+func validasiIsiKomentar(isi string) (string, string) {
+	isi = strings.TrimSpace(isi)
+	if isi == "" {
+		return "", "Isi komentar harus diisi"
+	}
+	if len([]rune(isi)) > maksKomentarBroadcast {
+		return "", fmt.Sprintf("Isi komentar maksimal %d karakter", maksKomentarBroadcast)
+	}
+	return isi, ""
+}
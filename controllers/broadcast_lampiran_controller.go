@@ -0,0 +1,425 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"rt-management/helper"
+	"rt-management/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	maxLampiranBroadcast          = 20
+	maxLampiranBroadcastPerUpload = 10
+)
+
+type UpdateLampiranBroadcastRequest struct {
+	LampiranKeterangan *string `form:"lampiran_keterangan"`
+	LampiranUrutan     *int    `form:"lampiran_urutan"`
+}
+
+type UrutanLampiranBroadcastRequest struct {
+	LampiranID []uint `form:"lampiran_id" binding:"required"` // seluruh lampiran dalam urutan baru
+}
+
+// ✅ GET - Daftar lampiran broadcast sesuai urutan
+func (bc *BroadcastController) GetLampiranBroadcast(c *gin.Context) {
+	broadcast, ok := bc.findBroadcastUntukUser(c)
+	if !ok {
+		return
+	}
+
+	var lampiran []models.BroadcastLampiran
+	if err := bc.db.Where("broadcast_id = ?", broadcast.BroadcastID).
+		Order("lampiran_urutan ASC, broadcast_lampiran_id ASC").
+		Find(&lampiran).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil lampiran broadcast",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  lampiran,
+		"total": len(lampiran),
+		// File tunggal broadcast lama tetap dilayani lewat /broadcast/image dan /broadcast/dokumen
+		"broadcast_foto":    broadcast.BroadcastFoto,
+		"broadcast_dokumen": broadcast.BroadcastDokumen,
+	})
+}
+
+// ✅ POST - Upload satu atau beberapa lampiran (form-data field broadcast_lampiran, boleh berulang).
+// Field lampiran_keterangan boleh dikirim berulang mengikuti urutan file.
+func (bc *BroadcastController) UploadLampiranBroadcast(c *gin.Context) {
+	broadcast, ok := bc.findBroadcast(c)
+	if !ok {
+		return
+	}
+
+	var jumlah int64
+	bc.db.Model(&models.BroadcastLampiran{}).Where("broadcast_id = ?", broadcast.BroadcastID).Count(&jumlah)
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["broadcast_lampiran"]) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Minimal satu file harus diupload pada field broadcast_lampiran",
+		})
+		return
+	}
+	if int(jumlah)+len(form.File["broadcast_lampiran"]) > maxLampiranBroadcast {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Satu broadcast maksimal memiliki %d lampiran", maxLampiranBroadcast),
+		})
+		return
+	}
+
+	uploads, err := helper.HandleMultipleLampiranUpload(c, "broadcast_lampiran", maxLampiranBroadcastPerUpload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Gagal mengupload lampiran broadcast",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var urutanTerakhir int
+	bc.db.Model(&models.BroadcastLampiran{}).
+		Where("broadcast_id = ?", broadcast.BroadcastID).
+		Select("COALESCE(MAX(lampiran_urutan), 0)").
+		Scan(&urutanTerakhir)
+
+	userID, _ := c.Get("userID")
+	diunggahOleh := userID.(uint)
+	keterangan := c.PostFormArray("lampiran_keterangan")
+
+	lampiran := make([]models.BroadcastLampiran, 0, len(uploads))
+	for i, upload := range uploads {
+		item := models.BroadcastLampiran{
+			BroadcastID:      broadcast.BroadcastID,
+			LampiranJenis:    upload.Jenis,
+			LampiranFile:     upload.Filename,
+			LampiranNamaAsli: upload.NamaAsli,
+			LampiranTipe:     upload.ContentType,
+			LampiranUkuran:   upload.Ukuran,
+			LampiranUrutan:   urutanTerakhir + i + 1,
+			DiunggahOlehID:   &diunggahOleh,
+			RTID:             broadcast.RTID,
+		}
+		if i < len(keterangan) {
+			item.LampiranKeterangan = ringkasTeks(keterangan[i], 255)
+		}
+
+		// Pratinjau bersifat tambahan, lampiran tetap tersimpan walaupun pratinjau gagal dibuat
+		pratinjau, err := helper.BuatPratinjauLampiran("broadcast_lampiran", upload)
+		if err == nil {
+			item.LampiranPratinjau = pratinjau
+		} else if err != helper.ErrPratinjauTidakDidukung {
+			log.Printf("⚠️ Gagal membuat pratinjau lampiran %s: %v", upload.Filename, err)
+		}
+		lampiran = append(lampiran, item)
+	}
+
+	if err := scopedDB(c, bc.db).Create(&lampiran).Error; err != nil {
+		// Rollback file upload jika gagal menyimpan ke database
+		for _, item := range lampiran {
+			hapusFileLampiranBroadcast(item)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menyimpan lampiran broadcast",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": fmt.Sprintf("%d lampiran broadcast berhasil diupload", len(lampiran)),
+		"data":    lampiran,
+	})
+}
+
+// ✅ PUT - Mengubah keterangan atau urutan satu lampiran
+func (bc *BroadcastController) UpdateLampiranBroadcast(c *gin.Context) {
+	lampiran, ok := bc.findLampiranBroadcast(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateLampiranBroadcastRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.LampiranKeterangan != nil {
+		lampiran.LampiranKeterangan = ringkasTeks(*req.LampiranKeterangan, 255)
+	}
+	if req.LampiranUrutan != nil {
+		if *req.LampiranUrutan < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Urutan lampiran minimal 1",
+			})
+			return
+		}
+		lampiran.LampiranUrutan = *req.LampiranUrutan
+	}
+
+	if err := scopedDB(c, bc.db).Model(&lampiran).Updates(map[string]interface{}{
+		"lampiran_keterangan": lampiran.LampiranKeterangan,
+		"lampiran_urutan":     lampiran.LampiranUrutan,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengupdate lampiran broadcast",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Lampiran broadcast berhasil diupdate",
+		"data":    lampiran,
+	})
+}
+
+// ✅ PUT - Menyusun ulang urutan seluruh lampiran broadcast
+func (bc *BroadcastController) UrutkanLampiranBroadcast(c *gin.Context) {
+	broadcast, ok := bc.findBroadcast(c)
+	if !ok {
+		return
+	}
+
+	var req UrutanLampiranBroadcastRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var ids []uint
+	bc.db.Model(&models.BroadcastLampiran{}).
+		Where("broadcast_id = ?", broadcast.BroadcastID).
+		Pluck("broadcast_lampiran_id", &ids)
+
+	milikBroadcast := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		milikBroadcast[id] = true
+	}
+	seen := make(map[uint]bool, len(req.LampiranID))
+	for _, id := range req.LampiranID {
+		if !milikBroadcast[id] || seen[id] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Lampiran dengan ID %d tidak valid atau ganda", id),
+			})
+			return
+		}
+		seen[id] = true
+	}
+	if len(seen) != len(ids) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Urutan harus menyertakan seluruh lampiran broadcast",
+		})
+		return
+	}
+
+	err := scopedDB(c, bc.db).Transaction(func(tx *gorm.DB) error {
+		for i, id := range req.LampiranID {
+			if err := tx.Model(&models.BroadcastLampiran{}).
+				Where("broadcast_lampiran_id = ?", id).
+				Update("lampiran_urutan", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mengurutkan lampiran broadcast",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var lampiran []models.BroadcastLampiran
+	bc.db.Where("broadcast_id = ?", broadcast.BroadcastID).Order("lampiran_urutan ASC").Find(&lampiran)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Urutan lampiran broadcast berhasil disimpan",
+		"data":    lampiran,
+	})
+}
+
+// ✅ DELETE - Menghapus lampiran beserta file dan pratinjaunya
+func (bc *BroadcastController) DeleteLampiranBroadcast(c *gin.Context) {
+	lampiran, ok := bc.findLampiranBroadcast(c)
+	if !ok {
+		return
+	}
+
+	if err := scopedDB(c, bc.db).Delete(&lampiran).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal menghapus lampiran broadcast",
+		})
+		return
+	}
+	hapusFileLampiranBroadcast(lampiran)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Lampiran broadcast berhasil dihapus",
+	})
+}
+
+// ✅ GET - Mengunduh lampiran broadcast dan mencatat jumlah unduhan
+func (bc *BroadcastController) UnduhLampiranBroadcast(c *gin.Context) {
+	lampiran, ok := bc.findLampiranUntukUser(c)
+	if !ok {
+		return
+	}
+
+	file, err := helper.GetFileByFileName("broadcast_lampiran", lampiran.LampiranFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "File lampiran tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Gagal membuka file",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mendapatkan info file",
+		})
+		return
+	}
+
+	// Permintaan lanjutan (Range di tengah file) dari unduhan yang sama tidak dihitung ulang
+	if rng := c.GetHeader("Range"); rng == "" || strings.HasPrefix(rng, "bytes=0-") {
+		bc.db.Model(&lampiran).UpdateColumn("lampiran_jumlah_unduh", gorm.Expr("lampiran_jumlah_unduh + ?", 1))
+	}
+
+	// ?inline=true untuk menampilkan di browser (foto/PDF), default sebagai unduhan
+	disposition := "attachment"
+	if c.Query("inline") == "true" {
+		disposition = "inline"
+	}
+	nama := lampiran.LampiranNamaAsli
+	if nama == "" {
+		nama = lampiran.LampiranFile
+	}
+	c.Header("Content-Type", lampiran.LampiranTipe)
+	c.Header("Content-Disposition", fmt.Sprintf("%s; filename=%q", disposition, nama))
+
+	http.ServeContent(c.Writer, c.Request, lampiran.LampiranFile, fileInfo.ModTime(), file)
+}
+
+// ✅ GET - Gambar pratinjau lampiran (thumbnail foto atau halaman pertama PDF)
+func (bc *BroadcastController) GetPratinjauLampiranBroadcast(c *gin.Context) {
+	lampiran, ok := bc.findLampiranUntukUser(c)
+	if !ok {
+		return
+	}
+
+	if lampiran.LampiranPratinjau == "" {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Pratinjau lampiran tidak tersedia",
+		})
+		return
+	}
+
+	serveStorageFileName(c, "broadcast_lampiran_pratinjau", lampiran.LampiranPratinjau, "Pratinjau lampiran tidak ditemukan")
+}
+
+// ✅ Helper mengambil lampiran dari parameter :lampiran_id milik broadcast :id
+func (bc *BroadcastController) findLampiranBroadcast(c *gin.Context) (models.BroadcastLampiran, bool) {
+	var lampiran models.BroadcastLampiran
+
+	broadcast, ok := bc.findBroadcast(c)
+	if !ok {
+		return lampiran, false
+	}
+
+	lampiranID, err := strconv.ParseUint(c.Param("lampiran_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID lampiran tidak valid",
+		})
+		return lampiran, false
+	}
+
+	if err := bc.db.Where("broadcast_id = ?", broadcast.BroadcastID).First(&lampiran, lampiranID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Lampiran tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan lampiran",
+			})
+		}
+		return lampiran, false
+	}
+
+	return lampiran, true
+}
+
+// ✅ Helper mengambil lampiran dari parameter :lampiran_id yang broadcastnya ditujukan ke user
+func (bc *BroadcastController) findLampiranUntukUser(c *gin.Context) (models.BroadcastLampiran, bool) {
+	var lampiran models.BroadcastLampiran
+
+	lampiranID, err := strconv.ParseUint(c.Param("lampiran_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID lampiran tidak valid",
+		})
+		return lampiran, false
+	}
+
+	if err := scopedDB(c, bc.db).First(&lampiran, lampiranID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Lampiran tidak ditemukan",
+		})
+		return lampiran, false
+	}
+
+	// Lampiran hanya dapat dibuka oleh audiens broadcast pemiliknya
+	query, err := bc.scopeAudiens(c, scopedDB(c, bc.db).Model(&models.Broadcast{}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal menentukan audiens broadcast",
+		})
+		return lampiran, false
+	}
+	var count int64
+	query.Where("broadcast_id = ?", lampiran.BroadcastID).Count(&count)
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Lampiran tidak ditemukan",
+		})
+		return lampiran, false
+	}
+
+	return lampiran, true
+}
+
+func hapusFileLampiranBroadcast(lampiran models.BroadcastLampiran) {
+	helper.DeleteOldDocument(lampiran.LampiranFile, "broadcast_lampiran")
+	if lampiran.LampiranPratinjau != "" {
+		helper.DeleteOldDocument(lampiran.LampiranPratinjau, "broadcast_lampiran_pratinjau")
+	}
+}
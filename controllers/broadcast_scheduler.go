@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"rt-management/models"
+	"rt-management/notification"
+	"rt-management/realtime"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// JalankanPenjadwalBroadcast memproses jadwal broadcast secara berkala: menerbitkan broadcast
+// terjadwal, mengarsipkan broadcast kedaluwarsa, dan memicu notifikasi saat broadcast terbit.
+// Dijalankan sebagai goroutine dari main.
+func (bc *BroadcastController) JalankanPenjadwalBroadcast(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		bc.prosesJadwalBroadcast(time.Now())
+		<-ticker.C
+	}
+}
+
+func (bc *BroadcastController) prosesJadwalBroadcast(now time.Time) {
+	// Broadcast terjadwal yang waktunya tiba diterbitkan pada waktu terbit yang direncanakan
+	result := bc.db.Model(&models.Broadcast{}).
+		Where("broadcast_status = ? AND broadcast_terbit_pada <= ?", "terjadwal", now).
+		Updates(map[string]interface{}{
+			"broadcast_status":           "terbit",
+			"broadcast_diterbitkan_pada": gorm.Expr("broadcast_terbit_pada"),
+		})
+	if result.Error != nil {
+		log.Printf("⚠️ Penjadwal broadcast: gagal menerbitkan broadcast: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("📢 Penjadwal broadcast: %d broadcast diterbitkan", result.RowsAffected)
+	}
+
+	result = bc.db.Model(&models.Broadcast{}).
+		Where("broadcast_status IN ? AND broadcast_kedaluwarsa_pada <= ?", []string{"terbit", "terjadwal"}, now).
+		Update("broadcast_status", "arsip")
+	if result.Error != nil {
+		log.Printf("⚠️ Penjadwal broadcast: gagal mengarsipkan broadcast: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("🗄️ Penjadwal broadcast: %d broadcast diarsipkan", result.RowsAffected)
+	}
+
+	// Notifikasi hanya sekali per broadcast. Broadcast lama (sebelum ada penjadwal) tidak
+	// memiliki waktu diterbitkan sehingga tidak ikut dinotifikasi.
+	var terbitBaru []models.Broadcast
+	if err := bc.db.
+		Where("broadcast_status = ? AND broadcast_diterbitkan_pada IS NOT NULL AND broadcast_notifikasi_pada IS NULL", "terbit").
+		Find(&terbitBaru).Error; err != nil {
+		log.Printf("⚠️ Penjadwal broadcast: gagal memuat broadcast baru terbit: %v", err)
+		return
+	}
+	for _, broadcast := range terbitBaru {
+		if err := bc.umumkanBroadcastTerbit(broadcast.BroadcastID, now); err != nil {
+			log.Printf("⚠️ Penjadwal broadcast: notifikasi broadcast %d gagal: %v", broadcast.BroadcastID, err)
+		}
+	}
+}
+
+// umumkanBroadcastTerbit mengantrikan notifikasi ke outbox dan mempublikasikan event realtime
+// untuk broadcast yang baru terbit. Broadcast diklaim lebih dulu lewat broadcast_notifikasi_pada
+// sehingga aman dipanggil bersamaan dari handler dan penjadwal; broadcast yang sudah diumumkan dilewati.
+func (bc *BroadcastController) umumkanBroadcastTerbit(broadcastID uint, now time.Time) error {
+	klaim := bc.db.Model(&models.Broadcast{}).
+		Where("broadcast_id = ? AND broadcast_status = ?", broadcastID, "terbit").
+		Where("broadcast_diterbitkan_pada IS NOT NULL AND broadcast_notifikasi_pada IS NULL").
+		Update("broadcast_notifikasi_pada", now)
+	if klaim.Error != nil || klaim.RowsAffected == 0 {
+		return klaim.Error
+	}
+
+	var broadcast models.Broadcast
+	err := bc.db.First(&broadcast, broadcastID).Error
+	if err == nil {
+		err = bc.kirimNotifikasiTerbit(broadcast)
+	}
+	if err != nil {
+		// Klaim dilepas agar dicoba lagi pada putaran penjadwal berikutnya
+		bc.db.Model(&models.Broadcast{}).Where("broadcast_id = ?", broadcastID).
+			Update("broadcast_notifikasi_pada", nil)
+		return err
+	}
+	return nil
+}
+
+// kirimNotifikasiTerbit mengantrikan notifikasi broadcast baru terbit ke outbox untuk semua penerima
+// dan mendorong event broadcast.terbit ke penerima yang sedang membuka aplikasi
+func (bc *BroadcastController) kirimNotifikasiTerbit(broadcast models.Broadcast) error {
+	penerima, err := penerimaBroadcast(bc.db, broadcast)
+	if err != nil {
+		return err
+	}
+
+	userIDs := make([]uint, 0, len(penerima))
+	for _, user := range penerima {
+		userIDs = append(userIDs, user.UserID)
+	}
+
+	judul := broadcast.BroadcastNama
+	switch broadcast.BroadcastPrioritas {
+	case "darurat":
+		judul = "🚨 DARURAT: " + judul
+	case "penting":
+		judul = "⚠️ Penting: " + judul
+	}
+
+	broadcastID := broadcast.BroadcastID
+	jumlah, err := bc.notifikasi.KirimKeUser(userIDs, notification.Notifikasi{
+		Jenis:    notification.JenisBroadcast,
+		Judul:    judul,
+		Pesan:    ringkasTeks(broadcast.BroadcastDeskripsi, 500),
+		URL:      fmt.Sprintf("/broadcast/%d", broadcast.BroadcastID),
+		Sumber:   "broadcast",
+		SumberID: &broadcastID,
+		Kunci:    fmt.Sprintf("broadcast:%d", broadcast.BroadcastID),
+		RTID:     broadcast.RTID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(userIDs) > 0 {
+		bc.hub.Publikasikan(realtime.Event{
+			Topik: realtime.TopikBroadcast,
+			Jenis: "broadcast.terbit",
+			Data: gin.H{
+				"broadcast_id":               broadcast.BroadcastID,
+				"broadcast_nama":             broadcast.BroadcastNama,
+				"broadcast_prioritas":        broadcast.BroadcastPrioritas,
+				"broadcast_disematkan":       broadcast.BroadcastDisematkan,
+				"broadcast_diterbitkan_pada": broadcast.BroadcastDiterbitkanPada,
+			},
+			UserIDs: userIDs,
+		})
+	}
+
+	log.Printf("📢 Broadcast %d \"%s\" terbit: %d notifikasi diantrikan untuk %d penerima",
+		broadcast.BroadcastID, broadcast.BroadcastNama, jumlah, len(penerima))
+	return nil
+}
+
+// ringkasTeks memotong teks panjang untuk isi notifikasi tanpa memotong karakter multibyte
+func ringkasTeks(teks string, maks int) string {
+	r := []rune(strings.TrimSpace(teks))
+	if len(r) <= maks {
+		return string(r)
+	}
+	return strings.TrimSpace(string(r[:maks])) + "…"
+}
@@ -0,0 +1,750 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"rt-management/models"
+	"rt-management/notification"
+	"rt-management/realtime"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type DaruratController struct {
+	db         *gorm.DB
+	notifikasi *notification.Service
+	hub        *realtime.Hub
+}
+
+func NewDaruratController(db *gorm.DB, notifikasi *notification.Service, hub *realtime.Hub) *DaruratController {
+	return &DaruratController{db: db, notifikasi: notifikasi, hub: hub}
+}
+
+// Level yang selalu menerima dan dapat menutup peringatan darurat: admin, sekretaris, dan ketua RT
+var levelPengurusDarurat = []uint{1, 2, 4}
+
+var labelJenisDarurat = map[string]string{
+	"kebakaran": "Kebakaran",
+	"pencurian": "Pencurian",
+	"medis":     "Darurat medis",
+	"lainnya":   "Keadaan darurat",
+}
+
+// Request structs
+type KirimDaruratRequest struct {
+	DaruratJenis      string `form:"darurat_jenis" binding:"required"` // kebakaran, pencurian, medis, lainnya
+	DaruratKeterangan string `form:"darurat_keterangan"`
+	RumahID           *uint  `form:"rumah_id"`       // kosong berarti rumah yang dihuni pelapor
+	DaruratLokasi     string `form:"darurat_lokasi"` // wajib jika pelapor tidak terdaftar menghuni rumah
+}
+
+type TanggapiDaruratRequest struct {
+	TanggapanStatus string `form:"tanggapan_status"` // menuju (default), di_lokasi, tidak_bisa
+	TanggapanPesan  string `form:"tanggapan_pesan"`
+}
+
+type UbahStatusDaruratRequest struct {
+	Status  string `form:"status" binding:"required"` // selesai atau dibatalkan
+	Catatan string `form:"catatan"`
+}
+
+// Peringatan darurat beserta nama pelapor dan ringkasan tanggapan
+type DaruratDetail struct {
+	models.PeringatanDarurat
+	PelaporUsername   string `json:"pelapor_username"`
+	JumlahTanggapan   int64  `json:"jumlah_tanggapan"`
+	WaktuTanggapDetik *int64 `json:"waktu_tanggap_detik"` // jeda dari peringatan ke tanggapan pertama
+}
+
+type TanggapanDaruratDetail struct {
+	models.TanggapanDarurat
+	Username string `json:"username"`
+}
+
+type LogDaruratDetail struct {
+	models.LogDarurat
+	Username string `json:"username"`
+}
+
+// ✅ POST - Mengirim peringatan darurat ke pengurus dan tetangga satu blok secara langsung
+func (dc *DaruratController) KirimDarurat(c *gin.Context) {
+	var req KirimDaruratRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	req.DaruratKeterangan = strings.TrimSpace(req.DaruratKeterangan)
+	req.DaruratLokasi = strings.TrimSpace(req.DaruratLokasi)
+	if _, ok := labelJenisDarurat[req.DaruratJenis]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Jenis darurat harus kebakaran, pencurian, medis, atau lainnya",
+		})
+		return
+	}
+	if len(req.DaruratKeterangan) > 500 || len(req.DaruratLokasi) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Keterangan maksimal 500 karakter dan lokasi maksimal 255 karakter",
+		})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	pelapor := userID.(uint)
+
+	// Tombol darurat sering ditekan berulang kali; peringatan yang masih berlangsung dikembalikan
+	var berlangsung models.PeringatanDarurat
+	if err := scopedDB(c, dc.db).
+		Where("dilaporkan_oleh_id = ? AND darurat_status IN ?", pelapor, []string{"aktif", "ditangani"}).
+		Order("created_at DESC").
+		First(&berlangsung).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Anda masih memiliki peringatan darurat yang sedang berlangsung",
+			"data":  berlangsung,
+		})
+		return
+	}
+
+	var rumah *models.Rumah
+	if req.RumahID != nil && *req.RumahID != 0 {
+		var r models.Rumah
+		if err := scopedDB(c, dc.db).First(&r, *req.RumahID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Rumah tidak ditemukan",
+			})
+			return
+		}
+		rumah = &r
+	} else {
+		rumah = rumahUser(dc.db, pelapor)
+	}
+	if rumah == nil && req.DaruratLokasi == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Anda belum terdaftar menghuni rumah. Pilih rumah_id atau isi darurat_lokasi",
+		})
+		return
+	}
+
+	darurat := models.PeringatanDarurat{
+		DaruratJenis:      req.DaruratJenis,
+		DaruratKeterangan: req.DaruratKeterangan,
+		DaruratLokasi:     req.DaruratLokasi,
+		DaruratStatus:     "aktif",
+		DilaporkanOlehID:  pelapor,
+	}
+	if rumah != nil {
+		darurat.RumahID = &rumah.RumahID
+		darurat.RumahBlok = rumah.RumahBlok
+		if darurat.DaruratLokasi == "" {
+			darurat.DaruratLokasi = rumah.RumahAlamat
+		}
+		// Peringatan mengikuti RT rumah, penting untuk user global yang tidak memiliki scope RT
+		darurat.RTID = rumah.RTID
+	}
+
+	err := scopedDB(c, dc.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&darurat).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.LogDarurat{
+			PeringatanDaruratID: darurat.PeringatanDaruratID,
+			LogAksi:             "dibuat",
+			LogKeterangan:       labelJenisDarurat[darurat.DaruratJenis] + " di " + darurat.DaruratLokasi,
+			UserID:              pelapor,
+			RTID:                darurat.RTID,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mengirim peringatan darurat",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	penerima, err := penerimaDarurat(dc.db, darurat)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Peringatan tersimpan tetapi gagal menentukan penerima",
+			"details": err.Error(),
+		})
+		return
+	}
+	darurat.JumlahPenerima = len(penerima)
+	dc.db.Model(&darurat).Update("jumlah_penerima", darurat.JumlahPenerima)
+
+	nama := petaUsername(dc.db, []uint{pelapor})
+	judul := fmt.Sprintf("🚨 %s: %s", labelJenisDarurat[darurat.DaruratJenis], darurat.DaruratLokasi)
+	pesan := "Dilaporkan oleh " + nama[pelapor]
+	if darurat.DaruratKeterangan != "" {
+		pesan += ". " + darurat.DaruratKeterangan
+	}
+	dc.beritahu(darurat, penerima, penerima, "darurat.baru", judul, pesan, fmt.Sprintf("darurat:%d", darurat.PeringatanDaruratID))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": fmt.Sprintf("Peringatan darurat dikirim ke %d orang", darurat.JumlahPenerima),
+		"data":    dc.lengkapiDarurat([]models.PeringatanDarurat{darurat})[0],
+	})
+}
+
+// ✅ GET - Daftar peringatan darurat. Warga melihat peringatan di bloknya, miliknya, atau yang ia tanggapi.
+func (dc *DaruratController) GetAllDarurat(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	query, ok := dc.queryTerlihat(c)
+	if !ok {
+		return
+	}
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("darurat_status = ?", status)
+	}
+	if c.Query("berlangsung") == "true" {
+		query = query.Where("darurat_status IN ?", []string{"aktif", "ditangani"})
+	}
+	if jenis := c.Query("jenis"); jenis != "" {
+		query = query.Where("darurat_jenis = ?", jenis)
+	}
+	if blok := strings.TrimSpace(c.Query("blok")); blok != "" {
+		query = query.Where("rumah_blok = ?", strings.ToUpper(blok))
+	}
+	if dari := c.Query("dari"); dari != "" {
+		t, err := time.ParseInLocation("2006-01-02", dari, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Format dari harus YYYY-MM-DD",
+			})
+			return
+		}
+		query = query.Where("created_at >= ?", t)
+	}
+	if sampai := c.Query("sampai"); sampai != "" {
+		t, err := time.ParseInLocation("2006-01-02", sampai, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Format sampai harus YYYY-MM-DD",
+			})
+			return
+		}
+		query = query.Where("created_at < ?", t.AddDate(0, 0, 1))
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var darurat []models.PeringatanDarurat
+	if err := query.Preload("Rumah").
+		Order("created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&darurat).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data peringatan darurat",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": dc.lengkapiDarurat(darurat),
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// ✅ GET - Detail peringatan darurat beserta tanggapan responden dan log kejadian
+func (dc *DaruratController) GetDaruratByID(c *gin.Context) {
+	darurat, ok := dc.findDarurat(c)
+	if !ok {
+		return
+	}
+
+	var tanggapan []models.TanggapanDarurat
+	scopedDB(c, dc.db).Where("peringatan_darurat_id = ?", darurat.PeringatanDaruratID).
+		Order("created_at ASC").
+		Find(&tanggapan)
+
+	var logDarurat []models.LogDarurat
+	scopedDB(c, dc.db).Where("peringatan_darurat_id = ?", darurat.PeringatanDaruratID).
+		Order("created_at ASC, log_darurat_id ASC").
+		Find(&logDarurat)
+
+	userIDs := make([]uint, 0, len(tanggapan)+len(logDarurat))
+	for _, t := range tanggapan {
+		userIDs = append(userIDs, t.UserID)
+	}
+	for _, l := range logDarurat {
+		userIDs = append(userIDs, l.UserID)
+	}
+	nama := petaUsername(dc.db, userIDs)
+
+	tanggapanDetail := make([]TanggapanDaruratDetail, 0, len(tanggapan))
+	for _, t := range tanggapan {
+		tanggapanDetail = append(tanggapanDetail, TanggapanDaruratDetail{TanggapanDarurat: t, Username: nama[t.UserID]})
+	}
+	logDetail := make([]LogDaruratDetail, 0, len(logDarurat))
+	for _, l := range logDarurat {
+		logDetail = append(logDetail, LogDaruratDetail{LogDarurat: l, Username: nama[l.UserID]})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      dc.lengkapiDarurat([]models.PeringatanDarurat{darurat})[0],
+		"tanggapan": tanggapanDetail,
+		"log":       logDetail,
+	})
+}
+
+// ✅ POST - Responden mengonfirmasi peringatan (menuju lokasi, sudah di lokasi, atau tidak bisa datang).
+// Tanggapan dapat diperbarui; tanggapan pertama mengubah status menjadi ditangani.
+func (dc *DaruratController) TanggapiDarurat(c *gin.Context) {
+	darurat, ok := dc.findDarurat(c)
+	if !ok {
+		return
+	}
+
+	var req TanggapiDaruratRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+	req.TanggapanPesan = strings.TrimSpace(req.TanggapanPesan)
+	if req.TanggapanStatus == "" {
+		req.TanggapanStatus = "menuju"
+	}
+	if req.TanggapanStatus != "menuju" && req.TanggapanStatus != "di_lokasi" && req.TanggapanStatus != "tidak_bisa" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Status tanggapan harus menuju, di_lokasi, atau tidak_bisa",
+		})
+		return
+	}
+	if len(req.TanggapanPesan) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Pesan tanggapan maksimal 255 karakter",
+		})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	uid := userID.(uint)
+	if uid == darurat.DilaporkanOlehID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Pelapor tidak dapat menanggapi peringatannya sendiri",
+		})
+		return
+	}
+	if darurat.DaruratStatus != "aktif" && darurat.DaruratStatus != "ditangani" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Peringatan darurat sudah " + darurat.DaruratStatus,
+		})
+		return
+	}
+
+	now := time.Now()
+	var tanggapan models.TanggapanDarurat
+	err := scopedDB(c, dc.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("peringatan_darurat_id = ? AND user_id = ?", darurat.PeringatanDaruratID, uid).First(&tanggapan).Error
+		switch {
+		case err == gorm.ErrRecordNotFound:
+			tanggapan = models.TanggapanDarurat{
+				PeringatanDaruratID: darurat.PeringatanDaruratID,
+				UserID:              uid,
+				TanggapanStatus:     req.TanggapanStatus,
+				TanggapanPesan:      req.TanggapanPesan,
+				RTID:                darurat.RTID,
+			}
+			if err := tx.Create(&tanggapan).Error; err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			tanggapan.TanggapanStatus = req.TanggapanStatus
+			tanggapan.TanggapanPesan = req.TanggapanPesan
+			if err := tx.Model(&tanggapan).Updates(map[string]interface{}{
+				"tanggapan_status": tanggapan.TanggapanStatus,
+				"tanggapan_pesan":  tanggapan.TanggapanPesan,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		if darurat.DaruratStatus == "aktif" && req.TanggapanStatus != "tidak_bisa" {
+			darurat.DaruratStatus = "ditangani"
+			darurat.DitanganiPada = &now
+			if err := tx.Model(&darurat).Updates(map[string]interface{}{
+				"darurat_status": darurat.DaruratStatus,
+				"ditangani_pada": now,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		keterangan := labelTanggapanDarurat(req.TanggapanStatus)
+		if req.TanggapanPesan != "" {
+			keterangan += ": " + req.TanggapanPesan
+		}
+		return tx.Create(&models.LogDarurat{
+			PeringatanDaruratID: darurat.PeringatanDaruratID,
+			LogAksi:             "ditanggapi",
+			LogKeterangan:       keterangan,
+			UserID:              uid,
+			RTID:                darurat.RTID,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menyimpan tanggapan",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Event didorong ke seluruh penerima agar responden lain tahu siapa yang sudah bergerak,
+	// notifikasi cukup ke pelapor
+	penerima, _ := penerimaDarurat(dc.db, darurat)
+	nama := petaUsername(dc.db, []uint{uid})
+	pesan := nama[uid] + " " + strings.ToLower(labelTanggapanDarurat(req.TanggapanStatus))
+	if req.TanggapanPesan != "" {
+		pesan += ": " + req.TanggapanPesan
+	}
+	dc.beritahu(darurat, []uint{darurat.DilaporkanOlehID}, penerima, "darurat.ditanggapi",
+		"Peringatan darurat Anda ditanggapi", pesan,
+		fmt.Sprintf("darurat:%d:tanggapan:%d:%s", darurat.PeringatanDaruratID, uid, req.TanggapanStatus))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tanggapan berhasil dikirim",
+		"data":    TanggapanDaruratDetail{TanggapanDarurat: tanggapan, Username: nama[uid]},
+	})
+}
+
+// ✅ PUT - Menutup peringatan darurat: selesai (oleh pengurus atau pelapor) atau dibatalkan (mis. salah tekan)
+func (dc *DaruratController) UbahStatusDarurat(c *gin.Context) {
+	darurat, ok := dc.findDarurat(c)
+	if !ok {
+		return
+	}
+
+	var req UbahStatusDaruratRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+	req.Catatan = strings.TrimSpace(req.Catatan)
+	if req.Status != "selesai" && req.Status != "dibatalkan" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Status harus selesai atau dibatalkan",
+		})
+		return
+	}
+	if len(req.Catatan) > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Catatan maksimal 500 karakter",
+		})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	uid := userID.(uint)
+	if uid != darurat.DilaporkanOlehID && !isPengurusDarurat(c) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Hanya pelapor atau pengurus yang dapat menutup peringatan darurat",
+		})
+		return
+	}
+	if darurat.DaruratStatus != "aktif" && darurat.DaruratStatus != "ditangani" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Peringatan darurat sudah " + darurat.DaruratStatus,
+		})
+		return
+	}
+
+	now := time.Now()
+	darurat.DaruratStatus = req.Status
+	darurat.SelesaiPada = &now
+	darurat.DiselesaikanOlehID = &uid
+	darurat.CatatanPenutupan = req.Catatan
+	err := scopedDB(c, dc.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&darurat).Updates(map[string]interface{}{
+			"darurat_status":       darurat.DaruratStatus,
+			"selesai_pada":         now,
+			"diselesaikan_oleh_id": uid,
+			"catatan_penutupan":    darurat.CatatanPenutupan,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.LogDarurat{
+			PeringatanDaruratID: darurat.PeringatanDaruratID,
+			LogAksi:             req.Status,
+			LogKeterangan:       req.Catatan,
+			UserID:              uid,
+			RTID:                darurat.RTID,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mengubah status peringatan darurat",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	penerima, _ := penerimaDarurat(dc.db, darurat)
+	judul := fmt.Sprintf("%s di %s telah %s", labelJenisDarurat[darurat.DaruratJenis], darurat.DaruratLokasi, req.Status)
+	pesan := "Keadaan sudah aman."
+	if req.Status == "dibatalkan" {
+		pesan = "Peringatan dibatalkan."
+	}
+	if req.Catatan != "" {
+		pesan += " " + req.Catatan
+	}
+	dc.beritahu(darurat, tanpaUser(append(penerima, darurat.DilaporkanOlehID), uid), penerima, "darurat."+req.Status,
+		judul, pesan, fmt.Sprintf("darurat:%d:%s", darurat.PeringatanDaruratID, req.Status))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Peringatan darurat " + req.Status,
+		"data":    dc.lengkapiDarurat([]models.PeringatanDarurat{darurat})[0],
+	})
+}
+
+// ✅ Helper mencari peringatan darurat dari parameter :id yang boleh dilihat user
+func (dc *DaruratController) findDarurat(c *gin.Context) (models.PeringatanDarurat, bool) {
+	var darurat models.PeringatanDarurat
+
+	daruratID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID peringatan darurat tidak valid",
+		})
+		return darurat, false
+	}
+
+	query, ok := dc.queryTerlihat(c)
+	if !ok {
+		return darurat, false
+	}
+	if err := query.Preload("Rumah").First(&darurat, daruratID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Peringatan darurat tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan peringatan darurat",
+			})
+		}
+		return darurat, false
+	}
+
+	return darurat, true
+}
+
+// queryTerlihat membatasi peringatan darurat sesuai hak lihat user: pengurus melihat semua di wilayahnya,
+// warga melihat peringatan di blok tempat tinggalnya, miliknya sendiri, atau yang pernah ia tanggapi
+func (dc *DaruratController) queryTerlihat(c *gin.Context) (*gorm.DB, bool) {
+	query := scopedDB(c, dc.db).Model(&models.PeringatanDarurat{})
+	if isPengurusDarurat(c) {
+		return query, true
+	}
+
+	userID, _ := c.Get("userID")
+	audiens, err := audiensUser(dc.db, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal memuat data tempat tinggal user",
+		})
+		return nil, false
+	}
+
+	tanggapan := dc.db.Model(&models.TanggapanDarurat{}).Select("peringatan_darurat_id").Where("user_id = ?", userID)
+	if len(audiens.Blok) == 0 {
+		return query.Where("dilaporkan_oleh_id = ? OR peringatan_darurat_id IN (?)", userID, tanggapan), true
+	}
+	return query.Where("dilaporkan_oleh_id = ? OR rumah_blok IN ? OR peringatan_darurat_id IN (?)",
+		userID, audiens.Blok, tanggapan), true
+}
+
+// lengkapiDarurat menambahkan username pelapor, jumlah tanggapan, dan waktu tanggap
+func (dc *DaruratController) lengkapiDarurat(darurat []models.PeringatanDarurat) []DaruratDetail {
+	ids := make([]uint, 0, len(darurat))
+	userIDs := make([]uint, 0, len(darurat))
+	for _, d := range darurat {
+		ids = append(ids, d.PeringatanDaruratID)
+		userIDs = append(userIDs, d.DilaporkanOlehID)
+	}
+	nama := petaUsername(dc.db, userIDs)
+
+	jumlah := make(map[uint]int64)
+	if len(ids) > 0 {
+		var rows []struct {
+			PeringatanDaruratID uint
+			Jumlah              int64
+		}
+		dc.db.Model(&models.TanggapanDarurat{}).
+			Select("peringatan_darurat_id, COUNT(*) AS jumlah").
+			Where("peringatan_darurat_id IN ?", ids).
+			Group("peringatan_darurat_id").
+			Scan(&rows)
+		for _, r := range rows {
+			jumlah[r.PeringatanDaruratID] = r.Jumlah
+		}
+	}
+
+	detail := make([]DaruratDetail, 0, len(darurat))
+	for _, d := range darurat {
+		dd := DaruratDetail{
+			PeringatanDarurat: d,
+			PelaporUsername:   nama[d.DilaporkanOlehID],
+			JumlahTanggapan:   jumlah[d.PeringatanDaruratID],
+		}
+		if d.DitanganiPada != nil {
+			detik := int64(d.DitanganiPada.Sub(d.CreatedAt).Seconds())
+			dd.WaktuTanggapDetik = &detik
+		}
+		detail = append(detail, dd)
+	}
+	return detail
+}
+
+// beritahu mengantrikan notifikasi ke penerimaNotif lalu langsung memproses antrian tanpa menunggu
+// jadwal worker (notifikasi darurat diproses lebih dulu dari antrian lain), dan mendorong event
+// ke pelapor, penerimaEvent, serta pengurus RT
+func (dc *DaruratController) beritahu(darurat models.PeringatanDarurat, penerimaNotif, penerimaEvent []uint, jenis, judul, pesan, kunci string) {
+	daruratID := darurat.PeringatanDaruratID
+	if jumlah, _ := dc.notifikasi.KirimKeUser(penerimaNotif, notification.Notifikasi{
+		Jenis:    notification.JenisDarurat,
+		Judul:    judul,
+		Pesan:    pesan,
+		URL:      fmt.Sprintf("/darurat/%d", darurat.PeringatanDaruratID),
+		Sumber:   "peringatan_darurat",
+		SumberID: &daruratID,
+		Kunci:    kunci,
+		RTID:     darurat.RTID,
+	}); jumlah > 0 {
+		go dc.notifikasi.ProsesAntrian(time.Now())
+	}
+
+	dc.hub.Publikasikan(realtime.Event{
+		Topik: realtime.TopikDarurat,
+		Jenis: jenis,
+		Data: gin.H{
+			"peringatan_darurat_id": darurat.PeringatanDaruratID,
+			"darurat_jenis":         darurat.DaruratJenis,
+			"darurat_status":        darurat.DaruratStatus,
+			"darurat_lokasi":        darurat.DaruratLokasi,
+			"rumah_id":              darurat.RumahID,
+			"rumah_blok":            darurat.RumahBlok,
+			"dilaporkan_oleh_id":    darurat.DilaporkanOlehID,
+			"judul":                 judul,
+			"pesan":                 pesan,
+		},
+		RTID:     darurat.RTID,
+		UserIDs:  append(penerimaEvent, darurat.DilaporkanOlehID),
+		LevelIDs: levelPengurusDarurat,
+	})
+}
+
+// penerimaDarurat mengembalikan pengurus RT dan warga yang menghuni rumah di blok yang sama, tanpa pelapor
+func penerimaDarurat(db *gorm.DB, darurat models.PeringatanDarurat) ([]uint, error) {
+	query := db.Model(&models.User{}).Where("level_id IN ?", levelPengurusDarurat)
+	if darurat.RTID != nil {
+		query = query.Where("rt_id = ? OR rt_id IS NULL", *darurat.RTID)
+	}
+	var userIDs []uint
+	if err := query.Pluck("user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+
+	if darurat.RumahBlok != "" {
+		rumahQuery := db.Model(&models.Rumah{}).Where("rumah_blok = ?", darurat.RumahBlok)
+		if darurat.RTID != nil {
+			rumahQuery = rumahQuery.Where("rt_id = ?", *darurat.RTID)
+		}
+		now := time.Now()
+		var tetangga []uint
+		if err := db.Model(&models.User{}).
+			Joins("JOIN wargas ON wargas.warga_id = users.warga_id").
+			Joins("JOIN hunian_rumahs ON hunian_rumahs.keluarga_id = wargas.keluarga_id AND hunian_rumahs.hunian_tanggal_mulai <= ? AND (hunian_rumahs.hunian_tanggal_selesai IS NULL OR hunian_rumahs.hunian_tanggal_selesai > ?)", now, now).
+			Where("hunian_rumahs.rumah_id IN (?)", rumahQuery.Select("rumah_id")).
+			Distinct().
+			Pluck("users.user_id", &tetangga).Error; err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, tetangga...)
+	}
+
+	unik := make(map[uint]bool, len(userIDs))
+	hasil := make([]uint, 0, len(userIDs))
+	for _, id := range userIDs {
+		if id != darurat.DilaporkanOlehID && !unik[id] {
+			unik[id] = true
+			hasil = append(hasil, id)
+		}
+	}
+	return hasil, nil
+}
+
+// rumahUser mengembalikan rumah yang sedang dihuni keluarga user, nil jika tidak ada
+func rumahUser(db *gorm.DB, userID uint) *models.Rumah {
+	var user models.User
+	if err := db.Select("user_id", "warga_id").First(&user, userID).Error; err != nil || user.WargaID == nil {
+		return nil
+	}
+	var warga models.Warga
+	if err := db.Select("warga_id", "keluarga_id").First(&warga, *user.WargaID).Error; err != nil {
+		return nil
+	}
+
+	var hunian models.HunianRumah
+	if err := db.Preload("Rumah").
+		Where("keluarga_id = ?", warga.KeluargaID).
+		Scopes(hunianAktif(time.Now())).
+		Order("hunian_tanggal_mulai DESC").
+		First(&hunian).Error; err != nil {
+		return nil
+	}
+	return hunian.Rumah
+}
+
+func isPengurusDarurat(c *gin.Context) bool {
+	levelID, _ := c.Get("levelID")
+	for _, level := range levelPengurusDarurat {
+		if levelID == level {
+			return true
+		}
+	}
+	return false
+}
+
+func labelTanggapanDarurat(status string) string {
+	switch status {
+	case "di_lokasi":
+		return "Sudah di lokasi"
+	case "tidak_bisa":
+		return "Tidak bisa datang"
+	default:
+		return "Menuju lokasi"
+	}
+}
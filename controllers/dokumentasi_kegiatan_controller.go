@@ -0,0 +1,744 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"rt-management/helper"
+	"rt-management/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Batas jumlah foto dalam satu kali upload galeri kegiatan
+const maxFotoKegiatanPerUpload = 10
+
+type DokumentasiKegiatanController struct {
+	db *gorm.DB
+}
+
+func NewDokumentasiKegiatanController(db *gorm.DB) *DokumentasiKegiatanController {
+	return &DokumentasiKegiatanController{db: db}
+}
+
+// Request structs
+type UpdateFotoKegiatanRequest struct {
+	Keterangan string `form:"keterangan"`
+}
+
+type SimpanNotulenRequest struct {
+	NotulenIsi       *string `form:"notulen_isi"`
+	NotulenKeputusan *string `form:"notulen_keputusan"`
+	NotulenPeserta   *string `form:"notulen_peserta"`
+	HapusDokumen     bool    `form:"hapus_dokumen"`
+}
+
+type CreateTindakLanjutRequest struct {
+	Deskripsi         string `form:"deskripsi" binding:"required"`
+	PenanggungJawabID uint   `form:"penanggung_jawab_id"`
+	Tenggat           string `form:"tenggat"` // YYYY-MM-DD
+	Catatan           string `form:"catatan"`
+}
+
+type UpdateTindakLanjutRequest struct {
+	Deskripsi         *string `form:"deskripsi"`
+	PenanggungJawabID *uint   `form:"penanggung_jawab_id"` // 0 = hapus penanggung jawab
+	Tenggat           *string `form:"tenggat"`             // kosong = hapus tenggat
+	Status            *string `form:"status"`
+	Catatan           *string `form:"catatan"`
+}
+
+// ✅ GET - Galeri foto dokumentasi kegiatan
+func (dc *DokumentasiKegiatanController) GetFotoKegiatan(c *gin.Context) {
+	kegiatan, ok := dc.findKegiatan(c)
+	if !ok {
+		return
+	}
+
+	var foto []models.FotoKegiatan
+	if err := scopedDB(c, dc.db).
+		Where("kegiatan_id = ?", kegiatan.KegiatanID).
+		Order("created_at ASC").
+		Find(&foto).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil foto kegiatan",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  foto,
+		"total": len(foto),
+	})
+}
+
+// ✅ POST - Upload satu atau beberapa foto dokumentasi (form-data field kegiatan_foto, boleh berulang)
+func (dc *DokumentasiKegiatanController) UploadFotoKegiatan(c *gin.Context) {
+	kegiatan, ok := dc.findKegiatan(c)
+	if !ok {
+		return
+	}
+
+	filenames, err := helper.HandleMultipleFileImageUpload(c, "kegiatan_foto", maxFotoKegiatanPerUpload)
+	if err != nil {
+		if err == http.ErrMissingFile {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Minimal satu foto harus diupload pada field kegiatan_foto",
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Gagal mengupload foto kegiatan",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	diunggahOleh := userID.(uint)
+	keterangan := strings.TrimSpace(c.PostForm("keterangan"))
+
+	foto := make([]models.FotoKegiatan, 0, len(filenames))
+	for _, filename := range filenames {
+		foto = append(foto, models.FotoKegiatan{
+			KegiatanID:     kegiatan.KegiatanID,
+			FotoFile:       filename,
+			Keterangan:     keterangan,
+			DiunggahOlehID: &diunggahOleh,
+			RTID:           kegiatan.RTID,
+		})
+	}
+
+	if err := scopedDB(c, dc.db).Create(&foto).Error; err != nil {
+		// Rollback file upload jika gagal menyimpan ke database
+		for _, filename := range filenames {
+			helper.DeleteOldPhoto(filename, "kegiatan_foto")
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menyimpan foto kegiatan",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": fmt.Sprintf("%d foto kegiatan berhasil diupload", len(foto)),
+		"data":    foto,
+	})
+}
+
+// ✅ PUT - Mengubah keterangan foto kegiatan
+func (dc *DokumentasiKegiatanController) UpdateFotoKegiatan(c *gin.Context) {
+	foto, ok := dc.findFoto(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateFotoKegiatanRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	foto.Keterangan = strings.TrimSpace(req.Keterangan)
+	if err := scopedDB(c, dc.db).Save(&foto).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengupdate foto kegiatan",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Foto kegiatan berhasil diupdate",
+		"data":    foto,
+	})
+}
+
+// ✅ DELETE - Menghapus foto dari galeri kegiatan beserta filenya
+func (dc *DokumentasiKegiatanController) DeleteFotoKegiatan(c *gin.Context) {
+	foto, ok := dc.findFoto(c)
+	if !ok {
+		return
+	}
+
+	if err := scopedDB(c, dc.db).Delete(&foto).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal menghapus foto kegiatan",
+		})
+		return
+	}
+	helper.DeleteOldPhoto(foto.FotoFile, "kegiatan_foto")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Foto kegiatan berhasil dihapus",
+	})
+}
+
+// ✅ GET - Serve file foto kegiatan
+func (dc *DokumentasiKegiatanController) GetFotoKegiatanImage(c *gin.Context) {
+	serveStorageFile(c, "kegiatan_foto", "File foto tidak ditemukan")
+}
+
+// ✅ GET - Notulen kegiatan beserta tindak lanjutnya
+func (dc *DokumentasiKegiatanController) GetNotulenKegiatan(c *gin.Context) {
+	kegiatan, ok := dc.findKegiatan(c)
+	if !ok {
+		return
+	}
+
+	var notulen models.NotulenKegiatan
+	if err := scopedDB(c, dc.db).
+		Preload("TindakLanjut", func(db *gorm.DB) *gorm.DB {
+			return db.Order("tenggat IS NULL, tenggat ASC, tindak_lanjut_kegiatan_id ASC")
+		}).
+		Preload("TindakLanjut.PenanggungJawab").
+		Where("kegiatan_id = ?", kegiatan.KegiatanID).
+		First(&notulen).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Notulen kegiatan belum dibuat",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal mengambil notulen kegiatan",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": notulen,
+	})
+}
+
+// ✅ PUT - Membuat atau mengubah notulen kegiatan (form-data, dokumen opsional di field notulen_dokumen)
+func (dc *DokumentasiKegiatanController) SimpanNotulenKegiatan(c *gin.Context) {
+	kegiatan, ok := dc.findKegiatan(c)
+	if !ok {
+		return
+	}
+
+	var req SimpanNotulenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var notulen models.NotulenKegiatan
+	err := scopedDB(c, dc.db).Where("kegiatan_id = ?", kegiatan.KegiatanID).First(&notulen).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil notulen kegiatan",
+		})
+		return
+	}
+	baru := err == gorm.ErrRecordNotFound
+
+	// Isi rich-text selalu disanitasi sebelum disimpan
+	if req.NotulenIsi != nil {
+		notulen.NotulenIsi = helper.SanitizeRichText(*req.NotulenIsi)
+	}
+	if req.NotulenKeputusan != nil {
+		notulen.NotulenKeputusan = helper.SanitizeRichText(*req.NotulenKeputusan)
+	}
+	if req.NotulenPeserta != nil {
+		notulen.NotulenPeserta = strings.TrimSpace(*req.NotulenPeserta)
+	}
+
+	if baru && helper.RichTextToPlain(notulen.NotulenIsi) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Isi notulen wajib diisi",
+		})
+		return
+	}
+
+	dokumenLama := notulen.NotulenDokumen
+	dokumen, err := helper.HandleFileDokumenUpload(c, "notulen_dokumen", "")
+	if err != nil && err != http.ErrNotMultipart {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Gagal mengupload dokumen notulen",
+			"details": err.Error(),
+		})
+		return
+	}
+	if dokumen != "" {
+		notulen.NotulenDokumen = dokumen
+	} else if req.HapusDokumen {
+		notulen.NotulenDokumen = ""
+	}
+
+	userID, _ := c.Get("userID")
+	ditulisOleh := userID.(uint)
+	notulen.DitulisOlehID = &ditulisOleh
+
+	if baru {
+		notulen.KegiatanID = kegiatan.KegiatanID
+		notulen.RTID = kegiatan.RTID
+		err = scopedDB(c, dc.db).Create(&notulen).Error
+	} else {
+		err = scopedDB(c, dc.db).Save(&notulen).Error
+	}
+	if err != nil {
+		if dokumen != "" {
+			helper.DeleteOldDocument(dokumen, "notulen_dokumen")
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menyimpan notulen kegiatan",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Dokumen lama baru dihapus setelah notulen tersimpan
+	if dokumenLama != "" && dokumenLama != notulen.NotulenDokumen {
+		helper.DeleteOldDocument(dokumenLama, "notulen_dokumen")
+	}
+
+	status := http.StatusOK
+	message := "Notulen kegiatan berhasil diupdate"
+	if baru {
+		status = http.StatusCreated
+		message = "Notulen kegiatan berhasil dibuat"
+	}
+
+	c.JSON(status, gin.H{
+		"message": message,
+		"data":    notulen,
+	})
+}
+
+// ✅ DELETE - Menghapus notulen kegiatan beserta seluruh tindak lanjutnya
+func (dc *DokumentasiKegiatanController) DeleteNotulenKegiatan(c *gin.Context) {
+	kegiatan, ok := dc.findKegiatan(c)
+	if !ok {
+		return
+	}
+
+	var notulen models.NotulenKegiatan
+	if err := scopedDB(c, dc.db).Where("kegiatan_id = ?", kegiatan.KegiatanID).First(&notulen).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Notulen kegiatan tidak ditemukan",
+		})
+		return
+	}
+
+	err := scopedDB(c, dc.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("notulen_kegiatan_id = ?", notulen.NotulenKegiatanID).Delete(&models.TindakLanjutKegiatan{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&notulen).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal menghapus notulen kegiatan",
+		})
+		return
+	}
+	helper.DeleteOldDocument(notulen.NotulenDokumen, "notulen_dokumen")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notulen kegiatan berhasil dihapus",
+	})
+}
+
+// ✅ GET - Serve file dokumen notulen
+func (dc *DokumentasiKegiatanController) GetNotulenDokumen(c *gin.Context) {
+	serveStorageFile(c, "notulen_dokumen", "File dokumen tidak ditemukan")
+}
+
+// ✅ POST - Menambah tindak lanjut (action item) pada notulen kegiatan
+func (dc *DokumentasiKegiatanController) CreateTindakLanjut(c *gin.Context) {
+	kegiatan, ok := dc.findKegiatan(c)
+	if !ok {
+		return
+	}
+
+	var notulen models.NotulenKegiatan
+	if err := scopedDB(c, dc.db).Where("kegiatan_id = ?", kegiatan.KegiatanID).First(&notulen).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Buat notulen kegiatan terlebih dahulu sebelum menambah tindak lanjut",
+		})
+		return
+	}
+
+	var req CreateTindakLanjutRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tindakLanjut := models.TindakLanjutKegiatan{
+		NotulenKegiatanID: notulen.NotulenKegiatanID,
+		KegiatanID:        kegiatan.KegiatanID,
+		Deskripsi:         strings.TrimSpace(req.Deskripsi),
+		Status:            "terbuka",
+		Catatan:           strings.TrimSpace(req.Catatan),
+		RTID:              kegiatan.RTID,
+	}
+	if len(tindakLanjut.Deskripsi) < 3 || len(tindakLanjut.Deskripsi) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Deskripsi tindak lanjut harus 3-255 karakter",
+		})
+		return
+	}
+
+	if req.PenanggungJawabID != 0 {
+		if !dc.validatePenanggungJawab(c, kegiatan, req.PenanggungJawabID) {
+			return
+		}
+		tindakLanjut.PenanggungJawabID = &req.PenanggungJawabID
+	}
+
+	if req.Tenggat != "" {
+		tenggat, err := time.ParseInLocation("2006-01-02", req.Tenggat, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Format tenggat tidak valid. Gunakan format YYYY-MM-DD",
+			})
+			return
+		}
+		tindakLanjut.Tenggat = &tenggat
+	}
+
+	if err := scopedDB(c, dc.db).Create(&tindakLanjut).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menambah tindak lanjut",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	scopedDB(c, dc.db).Preload("PenanggungJawab").First(&tindakLanjut, tindakLanjut.TindakLanjutKegiatanID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Tindak lanjut berhasil ditambahkan",
+		"data":    tindakLanjut,
+	})
+}
+
+// ✅ PUT - Mengubah tindak lanjut (deskripsi, penanggung jawab, tenggat, status)
+func (dc *DokumentasiKegiatanController) UpdateTindakLanjut(c *gin.Context) {
+	tindakLanjut, ok := dc.findTindakLanjut(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateTindakLanjutRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.Deskripsi != nil {
+		deskripsi := strings.TrimSpace(*req.Deskripsi)
+		if len(deskripsi) < 3 || len(deskripsi) > 255 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Deskripsi tindak lanjut harus 3-255 karakter",
+			})
+			return
+		}
+		tindakLanjut.Deskripsi = deskripsi
+	}
+
+	if req.PenanggungJawabID != nil {
+		if *req.PenanggungJawabID == 0 {
+			tindakLanjut.PenanggungJawabID = nil
+		} else {
+			if !dc.validatePenanggungJawab(c, *tindakLanjut.Kegiatan, *req.PenanggungJawabID) {
+				return
+			}
+			tindakLanjut.PenanggungJawabID = req.PenanggungJawabID
+		}
+		tindakLanjut.PenanggungJawab = nil
+	}
+
+	if req.Tenggat != nil {
+		if *req.Tenggat == "" {
+			tindakLanjut.Tenggat = nil
+		} else {
+			tenggat, err := time.ParseInLocation("2006-01-02", *req.Tenggat, time.Local)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "Format tenggat tidak valid. Gunakan format YYYY-MM-DD",
+				})
+				return
+			}
+			tindakLanjut.Tenggat = &tenggat
+		}
+	}
+
+	if req.Status != nil {
+		status := strings.ToLower(strings.TrimSpace(*req.Status))
+		if !isValidStatusTindakLanjut(status) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Status harus terbuka, proses, selesai, atau batal",
+			})
+			return
+		}
+		if status == "selesai" && tindakLanjut.Status != "selesai" {
+			now := time.Now()
+			tindakLanjut.SelesaiPada = &now
+		} else if status != "selesai" {
+			tindakLanjut.SelesaiPada = nil
+		}
+		tindakLanjut.Status = status
+	}
+
+	if req.Catatan != nil {
+		tindakLanjut.Catatan = strings.TrimSpace(*req.Catatan)
+	}
+
+	if err := scopedDB(c, dc.db).Omit("Kegiatan", "PenanggungJawab").Save(&tindakLanjut).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengupdate tindak lanjut",
+		})
+		return
+	}
+
+	scopedDB(c, dc.db).Preload("PenanggungJawab").Preload("Kegiatan").First(&tindakLanjut, tindakLanjut.TindakLanjutKegiatanID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tindak lanjut berhasil diupdate",
+		"data":    tindakLanjut,
+	})
+}
+
+// ✅ DELETE - Menghapus tindak lanjut
+func (dc *DokumentasiKegiatanController) DeleteTindakLanjut(c *gin.Context) {
+	tindakLanjut, ok := dc.findTindakLanjut(c)
+	if !ok {
+		return
+	}
+
+	if err := scopedDB(c, dc.db).Delete(&tindakLanjut).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal menghapus tindak lanjut",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tindak lanjut berhasil dihapus",
+	})
+}
+
+// ✅ GET - Daftar tindak lanjut yang belum selesai dari seluruh kegiatan
+// Query: status (default terbuka & proses), penanggung_jawab_id, kegiatan_id, terlambat=true
+func (dc *DokumentasiKegiatanController) GetTindakLanjutTerbuka(c *gin.Context) {
+	query := scopedDB(c, dc.db).
+		Preload("PenanggungJawab").
+		Preload("Kegiatan").
+		Joins("JOIN kegiatans ON kegiatans.kegiatan_id = tindak_lanjut_kegiatans.kegiatan_id AND kegiatans.deleted_at IS NULL")
+
+	if status := strings.ToLower(c.Query("status")); status != "" {
+		if !isValidStatusTindakLanjut(status) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Status harus terbuka, proses, selesai, atau batal",
+			})
+			return
+		}
+		query = query.Where("tindak_lanjut_kegiatans.status = ?", status)
+	} else {
+		query = query.Where("tindak_lanjut_kegiatans.status IN ?", []string{"terbuka", "proses"})
+	}
+
+	if penanggungJawabID := c.Query("penanggung_jawab_id"); penanggungJawabID != "" {
+		query = query.Where("tindak_lanjut_kegiatans.penanggung_jawab_id = ?", penanggungJawabID)
+	}
+	if kegiatanID := c.Query("kegiatan_id"); kegiatanID != "" {
+		query = query.Where("tindak_lanjut_kegiatans.kegiatan_id = ?", kegiatanID)
+	}
+
+	hariIni := startOfDay(time.Now())
+	if c.Query("terlambat") == "true" {
+		query = query.Where("tindak_lanjut_kegiatans.tenggat < ?", hariIni)
+	}
+
+	var tindakLanjut []models.TindakLanjutKegiatan
+	if err := query.
+		Order("tindak_lanjut_kegiatans.tenggat IS NULL, tindak_lanjut_kegiatans.tenggat ASC, tindak_lanjut_kegiatans.created_at ASC").
+		Find(&tindakLanjut).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data tindak lanjut",
+		})
+		return
+	}
+
+	terlambat := 0
+	for _, item := range tindakLanjut {
+		if item.Tenggat != nil && item.Tenggat.Before(hariIni) && (item.Status == "terbuka" || item.Status == "proses") {
+			terlambat++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      tindakLanjut,
+		"total":     len(tindakLanjut),
+		"terlambat": terlambat,
+	})
+}
+
+// ✅ Helper mengambil kegiatan dari parameter :id
+func (dc *DokumentasiKegiatanController) findKegiatan(c *gin.Context) (models.Kegiatan, bool) {
+	var kegiatan models.Kegiatan
+
+	kegiatanID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID kegiatan tidak valid",
+		})
+		return kegiatan, false
+	}
+
+	if err := scopedDB(c, dc.db).First(&kegiatan, kegiatanID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Kegiatan tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan kegiatan",
+			})
+		}
+		return kegiatan, false
+	}
+
+	return kegiatan, true
+}
+
+// ✅ Helper mengambil foto dari parameter :foto_id yang milik kegiatan :id
+func (dc *DokumentasiKegiatanController) findFoto(c *gin.Context) (models.FotoKegiatan, bool) {
+	var foto models.FotoKegiatan
+
+	fotoID, err := strconv.ParseUint(c.Param("foto_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID foto tidak valid",
+		})
+		return foto, false
+	}
+
+	if err := scopedDB(c, dc.db).
+		Where("kegiatan_id = ?", c.Param("id")).
+		First(&foto, fotoID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Foto kegiatan tidak ditemukan",
+		})
+		return foto, false
+	}
+
+	return foto, true
+}
+
+// ✅ Helper mengambil tindak lanjut dari parameter :tindak_lanjut_id
+func (dc *DokumentasiKegiatanController) findTindakLanjut(c *gin.Context) (models.TindakLanjutKegiatan, bool) {
+	var tindakLanjut models.TindakLanjutKegiatan
+
+	tindakLanjutID, err := strconv.ParseUint(c.Param("tindak_lanjut_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID tindak lanjut tidak valid",
+		})
+		return tindakLanjut, false
+	}
+
+	if err := scopedDB(c, dc.db).Preload("Kegiatan").First(&tindakLanjut, tindakLanjutID).Error; err != nil || tindakLanjut.Kegiatan == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Tindak lanjut tidak ditemukan",
+		})
+		return tindakLanjut, false
+	}
+
+	return tindakLanjut, true
+}
+
+// ✅ Helper validasi penanggung jawab adalah warga di RT penyelenggara kegiatan
+func (dc *DokumentasiKegiatanController) validatePenanggungJawab(c *gin.Context, kegiatan models.Kegiatan, wargaID uint) bool {
+	var warga models.Warga
+	if err := scopedDB(c, dc.db).First(&warga, wargaID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Warga penanggung jawab tidak ditemukan",
+		})
+		return false
+	}
+
+	if kegiatan.RTID != nil && warga.RTID != nil && *kegiatan.RTID != *warga.RTID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Warga penanggung jawab tidak berada di RT penyelenggara kegiatan",
+		})
+		return false
+	}
+
+	return true
+}
+
+func isValidStatusTindakLanjut(status string) bool {
+	switch status {
+	case "terbuka", "proses", "selesai", "batal":
+		return true
+	}
+	return false
+}
+
+// ✅ Helper serve file foto/dokumen dari storage berdasarkan fieldName upload
+func serveStorageFile(c *gin.Context, fieldName, notFoundMessage string) {
+	filename := c.Param("filename")
+	if filename == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Nama file tidak valid",
+		})
+		return
+	}
+
+	serveStorageFileName(c, fieldName, filename, notFoundMessage)
+}
+
+// ✅ Helper serve file storage yang namanya sudah diketahui (misalnya dari database)
+func serveStorageFileName(c *gin.Context, fieldName, filename, notFoundMessage string) {
+	file, err := helper.GetFileByFileName(fieldName, filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": notFoundMessage,
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Gagal membuka file",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mendapatkan info file",
+		})
+		return
+	}
+
+	c.Header("Content-Type", helper.GetContentType(filepath.Ext(filename)))
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", filename))
+
+	http.ServeContent(c.Writer, c.Request, filename, fileInfo.ModTime(), file)
+}
@@ -0,0 +1,1159 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"rt-management/models"
+	"rt-management/realtime"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Batas lama satu peminjaman fasilitas
+const maxDurasiPeminjaman = 14 * 24 * time.Hour
+
+var (
+	errFasilitasTidakTersedia  = errors.New("fasilitas tidak tersedia")
+	errPeminjamanSudahDiproses = errors.New("peminjaman sudah diproses")
+)
+
+// Batas rentang tampilan kalender fasilitas
+const maxHariKalenderFasilitas = 92
+
+// Nama kategori pemasukan untuk pembayaran sewa fasilitas
+const kategoriPemasukanSewaFasilitas = "Sewa Fasilitas"
+
+type FasilitasController struct {
+	db  *gorm.DB
+	hub *realtime.Hub
+}
+
+func NewFasilitasController(db *gorm.DB, hub *realtime.Hub) *FasilitasController {
+	return &FasilitasController{db: db, hub: hub}
+}
+
+// Request structs
+type CreateFasilitasRequest struct {
+	FasilitasNama        string  `form:"fasilitas_nama" binding:"required"`
+	FasilitasJenis       string  `form:"fasilitas_jenis"`
+	FasilitasLokasi      string  `form:"fasilitas_lokasi"`
+	FasilitasJumlah      int     `form:"fasilitas_jumlah"`
+	FasilitasTarif       float64 `form:"fasilitas_tarif"`
+	FasilitasSatuanTarif string  `form:"fasilitas_satuan_tarif"`
+	FasilitasDeskripsi   string  `form:"fasilitas_deskripsi"`
+}
+
+type UpdateFasilitasRequest struct {
+	FasilitasNama        *string  `form:"fasilitas_nama"`
+	FasilitasJenis       *string  `form:"fasilitas_jenis"`
+	FasilitasLokasi      *string  `form:"fasilitas_lokasi"`
+	FasilitasJumlah      *int     `form:"fasilitas_jumlah"`
+	FasilitasTarif       *float64 `form:"fasilitas_tarif"`
+	FasilitasSatuanTarif *string  `form:"fasilitas_satuan_tarif"`
+	FasilitasDeskripsi   *string  `form:"fasilitas_deskripsi"`
+	FasilitasAktif       *bool    `form:"fasilitas_aktif"`
+}
+
+type PeminjamanFasilitasRequest struct {
+	FasilitasID  uint   `form:"fasilitas_id" binding:"required"`
+	WargaID      uint   `form:"warga_id"` // hanya dipakai pengurus, warga memakai data akunnya
+	Keperluan    string `form:"keperluan" binding:"required"`
+	WaktuMulai   string `form:"waktu_mulai" binding:"required"`   // YYYY-MM-DD HH:MM
+	WaktuSelesai string `form:"waktu_selesai" binding:"required"` // YYYY-MM-DD HH:MM
+	Jumlah       int    `form:"jumlah"`
+}
+
+type ProsesPeminjamanRequest struct {
+	Biaya           *float64 `form:"biaya"` // pengurus dapat mengubah biaya hasil hitung tarif
+	CatatanPengurus string   `form:"catatan_pengurus"`
+}
+
+// Jadwal lain yang beririsan dengan rentang waktu peminjaman
+type BentrokFasilitas struct {
+	Jenis   string    `json:"jenis"` // peminjaman, kegiatan
+	ID      uint      `json:"id"`
+	Judul   string    `json:"judul"`
+	Mulai   time.Time `json:"mulai"`
+	Selesai time.Time `json:"selesai"`
+	Jumlah  int       `json:"jumlah"`
+	Status  string    `json:"status"`
+}
+
+// Hasil pengecekan ketersediaan fasilitas pada satu rentang waktu
+type KetersediaanFasilitas struct {
+	Tersedia bool               `json:"tersedia"`
+	SisaUnit int                `json:"sisa_unit"`
+	Biaya    float64            `json:"biaya"`
+	Bentrok  []BentrokFasilitas `json:"bentrok"`  // peminjaman disetujui dan kegiatan di lokasi yang sama
+	Menunggu []BentrokFasilitas `json:"menunggu"` // pengajuan lain yang belum diproses (hanya peringatan)
+}
+
+// Satu baris kalender fasilitas
+type JadwalFasilitas struct {
+	FasilitasID   uint   `json:"fasilitas_id"`
+	FasilitasNama string `json:"fasilitas_nama"`
+	BentrokFasilitas
+}
+
+// ✅ GET - Daftar fasilitas (filter jenis, aktif)
+func (fc *FasilitasController) GetAllFasilitas(c *gin.Context) {
+	query := scopedDB(c, fc.db)
+
+	if jenis := c.Query("jenis"); jenis != "" {
+		query = query.Where("fasilitas_jenis = ?", jenis)
+	}
+	if aktif := c.Query("aktif"); aktif != "" {
+		query = query.Where("fasilitas_aktif = ?", aktif == "true")
+	}
+
+	var fasilitas []models.Fasilitas
+	if err := query.Order("fasilitas_nama ASC").Find(&fasilitas).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data fasilitas",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  fasilitas,
+		"total": len(fasilitas),
+	})
+}
+
+// ✅ GET - Detail fasilitas beserta peminjaman yang akan datang
+func (fc *FasilitasController) GetFasilitasByID(c *gin.Context) {
+	fasilitas, ok := fc.findFasilitas(c)
+	if !ok {
+		return
+	}
+
+	var peminjaman []models.PeminjamanFasilitas
+	scopedDB(c, fc.db).
+		Preload("Warga").
+		Where("fasilitas_id = ? AND status IN ? AND waktu_selesai >= ?", fasilitas.FasilitasID, []string{"menunggu", "disetujui"}, time.Now()).
+		Order("waktu_mulai ASC").
+		Find(&peminjaman)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":                 fasilitas,
+		"peminjaman_mendatang": peminjaman,
+	})
+}
+
+// ✅ POST - Menambah fasilitas
+func (fc *FasilitasController) CreateFasilitas(c *gin.Context) {
+	var req CreateFasilitasRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	fasilitas := models.Fasilitas{
+		FasilitasNama:        strings.TrimSpace(req.FasilitasNama),
+		FasilitasJenis:       strings.ToLower(strings.TrimSpace(req.FasilitasJenis)),
+		FasilitasLokasi:      strings.TrimSpace(req.FasilitasLokasi),
+		FasilitasJumlah:      req.FasilitasJumlah,
+		FasilitasTarif:       req.FasilitasTarif,
+		FasilitasSatuanTarif: strings.ToLower(strings.TrimSpace(req.FasilitasSatuanTarif)),
+		FasilitasDeskripsi:   req.FasilitasDeskripsi,
+		FasilitasAktif:       true,
+	}
+	if fasilitas.FasilitasJenis == "" {
+		fasilitas.FasilitasJenis = "peralatan"
+	}
+	if fasilitas.FasilitasJumlah == 0 {
+		fasilitas.FasilitasJumlah = 1
+	}
+	if fasilitas.FasilitasSatuanTarif == "" {
+		fasilitas.FasilitasSatuanTarif = "peminjaman"
+	}
+
+	if !fc.validateFasilitas(c, fasilitas) {
+		return
+	}
+
+	var existing models.Fasilitas
+	if err := scopedDB(c, fc.db).Where("fasilitas_nama = ?", fasilitas.FasilitasNama).First(&existing).Error; err == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Fasilitas dengan nama tersebut sudah ada",
+		})
+		return
+	}
+
+	if err := scopedDB(c, fc.db).Create(&fasilitas).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal membuat fasilitas",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Fasilitas berhasil dibuat",
+		"data":    fasilitas,
+	})
+}
+
+// ✅ PUT - Mengupdate fasilitas
+func (fc *FasilitasController) UpdateFasilitas(c *gin.Context) {
+	fasilitas, ok := fc.findFasilitas(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateFasilitasRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.FasilitasNama != nil {
+		nama := strings.TrimSpace(*req.FasilitasNama)
+		var existing models.Fasilitas
+		if err := scopedDB(c, fc.db).Where("fasilitas_nama = ? AND fasilitas_id <> ?", nama, fasilitas.FasilitasID).First(&existing).Error; err == nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Fasilitas dengan nama tersebut sudah ada",
+			})
+			return
+		}
+		fasilitas.FasilitasNama = nama
+	}
+	if req.FasilitasJenis != nil {
+		fasilitas.FasilitasJenis = strings.ToLower(strings.TrimSpace(*req.FasilitasJenis))
+	}
+	if req.FasilitasLokasi != nil {
+		fasilitas.FasilitasLokasi = strings.TrimSpace(*req.FasilitasLokasi)
+	}
+	if req.FasilitasJumlah != nil {
+		fasilitas.FasilitasJumlah = *req.FasilitasJumlah
+	}
+	if req.FasilitasTarif != nil {
+		fasilitas.FasilitasTarif = *req.FasilitasTarif
+	}
+	if req.FasilitasSatuanTarif != nil {
+		fasilitas.FasilitasSatuanTarif = strings.ToLower(strings.TrimSpace(*req.FasilitasSatuanTarif))
+	}
+	if req.FasilitasDeskripsi != nil {
+		fasilitas.FasilitasDeskripsi = *req.FasilitasDeskripsi
+	}
+	if req.FasilitasAktif != nil {
+		fasilitas.FasilitasAktif = *req.FasilitasAktif
+	}
+
+	if !fc.validateFasilitas(c, fasilitas) {
+		return
+	}
+
+	if err := scopedDB(c, fc.db).Save(&fasilitas).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengupdate fasilitas",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Fasilitas berhasil diupdate",
+		"data":    fasilitas,
+	})
+}
+
+// ✅ DELETE - Menghapus fasilitas (ditolak jika masih ada peminjaman yang akan datang)
+func (fc *FasilitasController) DeleteFasilitas(c *gin.Context) {
+	fasilitas, ok := fc.findFasilitas(c)
+	if !ok {
+		return
+	}
+
+	var aktif int64
+	scopedDB(c, fc.db).Model(&models.PeminjamanFasilitas{}).
+		Where("fasilitas_id = ? AND status IN ? AND waktu_selesai >= ?", fasilitas.FasilitasID, []string{"menunggu", "disetujui"}, time.Now()).
+		Count(&aktif)
+	if aktif > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Fasilitas masih memiliki %d peminjaman yang akan datang", aktif),
+		})
+		return
+	}
+
+	if err := scopedDB(c, fc.db).Delete(&fasilitas).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal menghapus fasilitas",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Fasilitas berhasil dihapus",
+	})
+}
+
+// ✅ GET - Cek ketersediaan fasilitas (query: waktu_mulai, waktu_selesai, jumlah)
+func (fc *FasilitasController) GetKetersediaanFasilitas(c *gin.Context) {
+	fasilitas, ok := fc.findFasilitas(c)
+	if !ok {
+		return
+	}
+
+	mulai, selesai, ok := parseRentangPeminjaman(c, c.Query("waktu_mulai"), c.Query("waktu_selesai"))
+	if !ok {
+		return
+	}
+	jumlah, _ := strconv.Atoi(c.DefaultQuery("jumlah", "1"))
+	if jumlah < 1 {
+		jumlah = 1
+	}
+
+	hasil, err := fc.cekKetersediaan(c, fasilitas, mulai, selesai, jumlah, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal memeriksa ketersediaan fasilitas",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": hasil,
+	})
+}
+
+// ✅ GET - Kalender pemakaian fasilitas (query: dari, sampai, fasilitas_id)
+func (fc *FasilitasController) GetKalenderFasilitas(c *gin.Context) {
+	now := time.Now()
+	dari := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	sampai := dari.AddDate(0, 1, -1)
+
+	var err error
+	if v := c.Query("dari"); v != "" {
+		if dari, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Format dari tidak valid. Gunakan format YYYY-MM-DD",
+			})
+			return
+		}
+	}
+	if v := c.Query("sampai"); v != "" {
+		if sampai, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Format sampai tidak valid. Gunakan format YYYY-MM-DD",
+			})
+			return
+		}
+	}
+	dari, sampai = startOfDay(dari), endOfDay(sampai)
+	if sampai.Before(dari) || sampai.Sub(dari) > maxHariKalenderFasilitas*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Rentang kalender harus 1-%d hari", maxHariKalenderFasilitas),
+		})
+		return
+	}
+
+	fasilitasQuery := scopedDB(c, fc.db)
+	if fasilitasID := c.Query("fasilitas_id"); fasilitasID != "" {
+		fasilitasQuery = fasilitasQuery.Where("fasilitas_id = ?", fasilitasID)
+	}
+	var fasilitas []models.Fasilitas
+	if err := fasilitasQuery.Find(&fasilitas).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data fasilitas",
+		})
+		return
+	}
+
+	namaFasilitas := make(map[uint]string, len(fasilitas))
+	fasilitasIDs := make([]uint, 0, len(fasilitas))
+	for _, f := range fasilitas {
+		namaFasilitas[f.FasilitasID] = f.FasilitasNama
+		fasilitasIDs = append(fasilitasIDs, f.FasilitasID)
+	}
+
+	jadwal := []JadwalFasilitas{}
+	if len(fasilitasIDs) > 0 {
+		var peminjaman []models.PeminjamanFasilitas
+		if err := scopedDB(c, fc.db).
+			Preload("Warga").
+			Where("fasilitas_id IN ? AND status IN ?", fasilitasIDs, []string{"menunggu", "disetujui"}).
+			Where("waktu_mulai <= ? AND waktu_selesai >= ?", sampai, dari).
+			Find(&peminjaman).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal mengambil data peminjaman",
+			})
+			return
+		}
+		for _, p := range peminjaman {
+			jadwal = append(jadwal, JadwalFasilitas{
+				FasilitasID:      p.FasilitasID,
+				FasilitasNama:    namaFasilitas[p.FasilitasID],
+				BentrokFasilitas: bentrokPeminjaman(p),
+			})
+		}
+
+		kegiatan, err := fc.kegiatanDiLokasi(c, fasilitas, dari, sampai)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal mengambil data kegiatan",
+			})
+			return
+		}
+		jadwal = append(jadwal, kegiatan...)
+	}
+
+	sort.SliceStable(jadwal, func(i, j int) bool {
+		return jadwal[i].Mulai.Before(jadwal[j].Mulai)
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   jadwal,
+		"total":  len(jadwal),
+		"dari":   dari.Format("2006-01-02"),
+		"sampai": sampai.Format("2006-01-02"),
+	})
+}
+
+// ✅ POST - Mengajukan peminjaman fasilitas
+func (fc *FasilitasController) AjukanPeminjaman(c *gin.Context) {
+	var req PeminjamanFasilitasRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var fasilitas models.Fasilitas
+	if err := scopedDB(c, fc.db).First(&fasilitas, req.FasilitasID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Fasilitas tidak ditemukan",
+		})
+		return
+	}
+	if !fasilitas.FasilitasAktif {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Fasilitas sedang tidak dapat dipinjam",
+		})
+		return
+	}
+
+	// Warga hanya boleh meminjam atas nama dirinya sendiri
+	if levelID, _ := c.Get("levelID"); levelID == uint(6) {
+		userID, _ := c.Get("userID")
+		var user models.User
+		if err := fc.db.Select("user_id", "warga_id").First(&user, userID).Error; err != nil || user.WargaID == nil {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Akun belum ditautkan ke data warga",
+			})
+			return
+		}
+		req.WargaID = *user.WargaID
+	} else if req.WargaID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Warga peminjam wajib diisi",
+		})
+		return
+	}
+
+	var warga models.Warga
+	if err := scopedDB(c, fc.db).First(&warga, req.WargaID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Warga peminjam tidak ditemukan",
+		})
+		return
+	}
+	if fasilitas.RTID != nil && warga.RTID != nil && *fasilitas.RTID != *warga.RTID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Warga tidak berada di RT pemilik fasilitas",
+		})
+		return
+	}
+
+	mulai, selesai, ok := parseRentangPeminjaman(c, req.WaktuMulai, req.WaktuSelesai)
+	if !ok {
+		return
+	}
+	if mulai.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Waktu mulai peminjaman sudah lewat",
+		})
+		return
+	}
+
+	if req.Jumlah == 0 {
+		req.Jumlah = 1
+	}
+	if req.Jumlah < 1 || req.Jumlah > fasilitas.FasilitasJumlah {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Jumlah unit harus 1-%d", fasilitas.FasilitasJumlah),
+		})
+		return
+	}
+
+	keperluan := strings.TrimSpace(req.Keperluan)
+	if len(keperluan) < 3 || len(keperluan) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Keperluan harus 3-255 karakter",
+		})
+		return
+	}
+
+	hasil, err := fc.cekKetersediaan(c, fasilitas, mulai, selesai, req.Jumlah, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal memeriksa ketersediaan fasilitas",
+		})
+		return
+	}
+	if !hasil.Tersedia {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Fasilitas tidak tersedia pada waktu tersebut",
+			"data":  hasil,
+		})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	peminjaman := models.PeminjamanFasilitas{
+		FasilitasID:    fasilitas.FasilitasID,
+		WargaID:        warga.WargaID,
+		Keperluan:      keperluan,
+		WaktuMulai:     mulai,
+		WaktuSelesai:   selesai,
+		Jumlah:         req.Jumlah,
+		Status:         "menunggu",
+		Biaya:          hasil.Biaya,
+		DiajukanOlehID: userID.(uint),
+		RTID:           fasilitas.RTID,
+	}
+
+	if err := scopedDB(c, fc.db).Create(&peminjaman).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mengajukan peminjaman",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	peminjaman.Fasilitas = &fasilitas
+	peminjaman.Warga = &warga
+
+	response := gin.H{
+		"message": "Pengajuan peminjaman berhasil dibuat, menunggu persetujuan pengurus",
+		"data":    peminjaman,
+	}
+	if len(hasil.Menunggu) > 0 {
+		response["peringatan"] = "Ada pengajuan lain pada waktu yang sama yang belum diproses"
+		response["menunggu"] = hasil.Menunggu
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// ✅ GET - Daftar peminjaman (warga hanya melihat pengajuannya sendiri)
+func (fc *FasilitasController) GetAllPeminjaman(c *gin.Context) {
+	query := scopedDB(c, fc.db).
+		Preload("Fasilitas").
+		Preload("Warga")
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if fasilitasID := c.Query("fasilitas_id"); fasilitasID != "" {
+		query = query.Where("fasilitas_id = ?", fasilitasID)
+	}
+	if c.Query("mendatang") == "true" {
+		query = query.Where("waktu_selesai >= ?", time.Now())
+	}
+	if levelID, _ := c.Get("levelID"); levelID == uint(6) {
+		userID, _ := c.Get("userID")
+		query = query.Where("diajukan_oleh_id = ?", userID)
+	}
+
+	var peminjaman []models.PeminjamanFasilitas
+	if err := query.Order("waktu_mulai DESC").Find(&peminjaman).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data peminjaman",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  peminjaman,
+		"total": len(peminjaman),
+	})
+}
+
+// ✅ GET - Detail peminjaman
+func (fc *FasilitasController) GetPeminjamanByID(c *gin.Context) {
+	peminjaman, ok := fc.findPeminjaman(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": peminjaman,
+	})
+}
+
+// ✅ PUT - Menyetujui peminjaman, ketersediaan diperiksa ulang terhadap jadwal terbaru
+func (fc *FasilitasController) SetujuiPeminjaman(c *gin.Context) {
+	peminjaman, req, ok := fc.findPeminjamanMenunggu(c)
+	if !ok {
+		return
+	}
+
+	if !peminjaman.WaktuSelesai.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Waktu peminjaman sudah lewat",
+		})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":           "disetujui",
+		"catatan_pengurus": strings.TrimSpace(req.CatatanPengurus),
+		"diproses_oleh_id": userID.(uint),
+		"diproses_pada":    now,
+		"updated_at":       now,
+	}
+	if req.Biaya != nil {
+		if *req.Biaya < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Biaya tidak boleh negatif",
+			})
+			return
+		}
+		updates["biaya"] = *req.Biaya
+	}
+
+	// Fasilitas dikunci (SELECT ... FOR UPDATE) agar dua persetujuan bersamaan
+	// tidak sama-sama lolos pemeriksaan ketersediaan
+	var hasil KetersediaanFasilitas
+	err := scopedDB(c, fc.db).Transaction(func(tx *gorm.DB) error {
+		var fasilitas models.Fasilitas
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&fasilitas, peminjaman.FasilitasID).Error; err != nil {
+			return err
+		}
+
+		var err error
+		hasil, err = (&FasilitasController{db: tx}).cekKetersediaan(c, fasilitas, peminjaman.WaktuMulai, peminjaman.WaktuSelesai, peminjaman.Jumlah, peminjaman.PeminjamanFasilitasID)
+		if err != nil {
+			return err
+		}
+		if !hasil.Tersedia {
+			return errFasilitasTidakTersedia
+		}
+
+		result := tx.Model(&peminjaman).Where("status = ?", "menunggu").Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errPeminjamanSudahDiproses
+		}
+		return nil
+	})
+	if err != nil {
+		switch err {
+		case errFasilitasTidakTersedia:
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Fasilitas tidak tersedia pada waktu tersebut",
+				"data":  hasil,
+			})
+		case errPeminjamanSudahDiproses:
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Peminjaman ini sudah diproses",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal memproses peminjaman",
+			})
+		}
+		return
+	}
+
+	scopedDB(c, fc.db).Preload("Fasilitas").Preload("Warga").First(&peminjaman, peminjaman.PeminjamanFasilitasID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Peminjaman fasilitas disetujui",
+		"data":    peminjaman,
+	})
+}
+
+// ✅ PUT - Menolak peminjaman
+func (fc *FasilitasController) TolakPeminjaman(c *gin.Context) {
+	peminjaman, req, ok := fc.findPeminjamanMenunggu(c)
+	if !ok {
+		return
+	}
+
+	if !fc.prosesPeminjaman(c, &peminjaman, map[string]interface{}{
+		"status":           "ditolak",
+		"catatan_pengurus": strings.TrimSpace(req.CatatanPengurus),
+	}) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Peminjaman fasilitas ditolak",
+		"data":    peminjaman,
+	})
+}
+
+// ✅ PUT - Membatalkan peminjaman oleh pemohon atau pengurus sebelum waktu mulai
+func (fc *FasilitasController) BatalkanPeminjaman(c *gin.Context) {
+	peminjaman, ok := fc.findPeminjaman(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	if levelID, _ := c.Get("levelID"); levelID == uint(6) && peminjaman.DiajukanOlehID != userID.(uint) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Anda hanya dapat membatalkan pengajuan sendiri",
+		})
+		return
+	}
+
+	if peminjaman.Status != "menunggu" && peminjaman.Status != "disetujui" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Peminjaman ini sudah " + peminjaman.Status,
+		})
+		return
+	}
+	if !peminjaman.WaktuMulai.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Peminjaman yang sudah dimulai tidak dapat dibatalkan",
+		})
+		return
+	}
+	if peminjaman.BiayaLunas {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Biaya sewa sudah dibayar, hubungi bendahara untuk pengembalian dana",
+		})
+		return
+	}
+
+	if err := scopedDB(c, fc.db).Model(&peminjaman).Updates(map[string]interface{}{
+		"status":     "dibatalkan",
+		"updated_at": time.Now(),
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal membatalkan peminjaman",
+		})
+		return
+	}
+	peminjaman.Status = "dibatalkan"
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Peminjaman fasilitas dibatalkan",
+		"data":    peminjaman,
+	})
+}
+
+// ✅ PUT - Mencatat pembayaran biaya sewa, otomatis masuk ke pemasukan kas
+func (fc *FasilitasController) BayarPeminjaman(c *gin.Context) {
+	peminjaman, ok := fc.findPeminjaman(c)
+	if !ok {
+		return
+	}
+
+	if peminjaman.Status != "disetujui" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Hanya peminjaman yang disetujui yang dapat dibayar",
+		})
+		return
+	}
+	if peminjaman.Biaya <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Peminjaman ini tidak dikenakan biaya",
+		})
+		return
+	}
+	if peminjaman.BiayaLunas {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Biaya sewa sudah dibayar",
+		})
+		return
+	}
+
+	var pemasukan models.Pemasukan
+	err := scopedDB(c, fc.db).Transaction(func(tx *gorm.DB) error {
+		nama := fmt.Sprintf("Sewa %s - %s (%s)", peminjaman.Fasilitas.FasilitasNama, peminjaman.Warga.WargaNama, peminjaman.WaktuMulai.Format("02-01-2006"))
+		var err error
+		pemasukan, err = catatPemasukanOtomatis(tx, kategoriPemasukanSewaFasilitas, nama, peminjaman.Biaya, peminjaman.RTID)
+		if err != nil {
+			return err
+		}
+		peminjaman.BiayaLunas = true
+		peminjaman.PemasukanID = &pemasukan.PemasukanID
+		return tx.Model(&peminjaman).Updates(map[string]interface{}{
+			"biaya_lunas":  true,
+			"pemasukan_id": pemasukan.PemasukanID,
+			"updated_at":   time.Now(),
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mencatat pembayaran sewa",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	publikasikanTagihanDibayar(fc.hub, "peminjaman_fasilitas", peminjaman.PeminjamanFasilitasID, pemasukan, []uint{peminjaman.DiajukanOlehID})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Pembayaran sewa fasilitas berhasil dicatat",
+		"data":    peminjaman,
+	})
+}
+
+// ✅ Helper memeriksa bentrok peminjaman lain dan kegiatan pada lokasi fasilitas.
+// kecualiID dipakai saat memeriksa ulang pengajuan yang sudah tersimpan.
+func (fc *FasilitasController) cekKetersediaan(c *gin.Context, fasilitas models.Fasilitas, mulai, selesai time.Time, jumlah int, kecualiID uint) (KetersediaanFasilitas, error) {
+	hasil := KetersediaanFasilitas{
+		Biaya:    hitungBiayaPeminjaman(fasilitas, mulai, selesai, jumlah),
+		Bentrok:  []BentrokFasilitas{},
+		Menunggu: []BentrokFasilitas{},
+	}
+
+	var peminjaman []models.PeminjamanFasilitas
+	if err := scopedDB(c, fc.db).
+		Preload("Warga").
+		Where("fasilitas_id = ? AND status IN ? AND peminjaman_fasilitas_id <> ?", fasilitas.FasilitasID, []string{"menunggu", "disetujui"}, kecualiID).
+		Where("waktu_mulai < ? AND waktu_selesai > ?", selesai, mulai).
+		Order("waktu_mulai ASC").
+		Find(&peminjaman).Error; err != nil {
+		return hasil, err
+	}
+
+	var disetujui []models.PeminjamanFasilitas
+	for _, p := range peminjaman {
+		if p.Status == "disetujui" {
+			disetujui = append(disetujui, p)
+			hasil.Bentrok = append(hasil.Bentrok, bentrokPeminjaman(p))
+		} else {
+			hasil.Menunggu = append(hasil.Menunggu, bentrokPeminjaman(p))
+		}
+	}
+	hasil.SisaUnit = fasilitas.FasilitasJumlah - puncakPemakaian(disetujui, mulai, selesai)
+
+	kegiatan, err := fc.kegiatanDiLokasi(c, []models.Fasilitas{fasilitas}, mulai.Add(-durasiKegiatanKalender), selesai)
+	if err != nil {
+		return hasil, err
+	}
+	for _, k := range kegiatan {
+		if k.Mulai.Before(selesai) && k.Selesai.After(mulai) {
+			hasil.Bentrok = append(hasil.Bentrok, k.BentrokFasilitas)
+			// Kegiatan RT memakai seluruh ruangan
+			hasil.SisaUnit = 0
+		}
+	}
+
+	if hasil.SisaUnit < 0 {
+		hasil.SisaUnit = 0
+	}
+	hasil.Tersedia = jumlah <= hasil.SisaUnit
+	return hasil, nil
+}
+
+// ✅ Helper mencari jadwal kegiatan (termasuk kegiatan rutin) yang lokasinya sama dengan lokasi fasilitas
+func (fc *FasilitasController) kegiatanDiLokasi(c *gin.Context, fasilitas []models.Fasilitas, dari, sampai time.Time) ([]JadwalFasilitas, error) {
+	perLokasi := make(map[string][]models.Fasilitas)
+	for _, f := range fasilitas {
+		if lokasi := normalizeLokasi(f.FasilitasLokasi); lokasi != "" {
+			perLokasi[lokasi] = append(perLokasi[lokasi], f)
+		}
+	}
+	if len(perLokasi) == 0 {
+		return nil, nil
+	}
+
+	kegiatan, err := (&KegiatanController{db: fc.db}).jadwalKegiatan(c, dari, sampai)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []JadwalFasilitas
+	for _, k := range kegiatan {
+		for _, f := range perLokasi[normalizeLokasi(k.KegiatanLokasi)] {
+			result = append(result, JadwalFasilitas{
+				FasilitasID:   f.FasilitasID,
+				FasilitasNama: f.FasilitasNama,
+				BentrokFasilitas: BentrokFasilitas{
+					Jenis:   "kegiatan",
+					ID:      k.KegiatanID,
+					Judul:   k.KegiatanNama,
+					Mulai:   k.KegiatanTanggal,
+					Selesai: k.KegiatanTanggal.Add(durasiKegiatanKalender),
+					Jumlah:  f.FasilitasJumlah,
+					Status:  "kegiatan",
+				},
+			})
+		}
+	}
+	return result, nil
+}
+
+// ✅ Helper menyimpan hasil persetujuan/penolakan peminjaman
+func (fc *FasilitasController) prosesPeminjaman(c *gin.Context, peminjaman *models.PeminjamanFasilitas, updates map[string]interface{}) bool {
+	userID, _ := c.Get("userID")
+	now := time.Now()
+	updates["diproses_oleh_id"] = userID.(uint)
+	updates["diproses_pada"] = now
+	updates["updated_at"] = now
+
+	if err := scopedDB(c, fc.db).Model(peminjaman).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal memproses peminjaman",
+		})
+		return false
+	}
+
+	scopedDB(c, fc.db).Preload("Fasilitas").Preload("Warga").First(peminjaman, peminjaman.PeminjamanFasilitasID)
+	return true
+}
+
+// ✅ Helper mengambil fasilitas dari parameter :id
+func (fc *FasilitasController) findFasilitas(c *gin.Context) (models.Fasilitas, bool) {
+	var fasilitas models.Fasilitas
+
+	fasilitasID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID fasilitas tidak valid",
+		})
+		return fasilitas, false
+	}
+
+	if err := scopedDB(c, fc.db).First(&fasilitas, fasilitasID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Fasilitas tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan fasilitas",
+			})
+		}
+		return fasilitas, false
+	}
+
+	return fasilitas, true
+}
+
+// ✅ Helper mengambil peminjaman dari parameter :id
+func (fc *FasilitasController) findPeminjaman(c *gin.Context) (models.PeminjamanFasilitas, bool) {
+	var peminjaman models.PeminjamanFasilitas
+
+	peminjamanID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID peminjaman tidak valid",
+		})
+		return peminjaman, false
+	}
+
+	if err := scopedDB(c, fc.db).
+		Preload("Fasilitas", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Warga", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		First(&peminjaman, peminjamanID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Peminjaman tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan peminjaman",
+			})
+		}
+		return peminjaman, false
+	}
+
+	if levelID, _ := c.Get("levelID"); levelID == uint(6) {
+		userID, _ := c.Get("userID")
+		if peminjaman.DiajukanOlehID != userID.(uint) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Peminjaman tidak ditemukan",
+			})
+			return peminjaman, false
+		}
+	}
+
+	return peminjaman, true
+}
+
+// ✅ Helper mengambil peminjaman berstatus menunggu beserta data proses dari pengurus
+func (fc *FasilitasController) findPeminjamanMenunggu(c *gin.Context) (models.PeminjamanFasilitas, ProsesPeminjamanRequest, bool) {
+	var req ProsesPeminjamanRequest
+
+	peminjaman, ok := fc.findPeminjaman(c)
+	if !ok {
+		return peminjaman, req, false
+	}
+
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return peminjaman, req, false
+	}
+
+	if peminjaman.Status != "menunggu" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Peminjaman ini sudah " + peminjaman.Status,
+		})
+		return peminjaman, req, false
+	}
+
+	return peminjaman, req, true
+}
+
+// ✅ Helper validasi data fasilitas
+func (fc *FasilitasController) validateFasilitas(c *gin.Context, fasilitas models.Fasilitas) bool {
+	var errMsg string
+	switch {
+	case len(fasilitas.FasilitasNama) < 2 || len(fasilitas.FasilitasNama) > 100:
+		errMsg = "Nama fasilitas harus 2-100 karakter"
+	case fasilitas.FasilitasJenis != "ruangan" && fasilitas.FasilitasJenis != "peralatan":
+		errMsg = "Jenis fasilitas harus ruangan atau peralatan"
+	case fasilitas.FasilitasJumlah < 1:
+		errMsg = "Jumlah unit fasilitas minimal 1"
+	case fasilitas.FasilitasTarif < 0:
+		errMsg = "Tarif tidak boleh negatif"
+	case fasilitas.FasilitasSatuanTarif != "peminjaman" && fasilitas.FasilitasSatuanTarif != "hari" && fasilitas.FasilitasSatuanTarif != "jam":
+		errMsg = "Satuan tarif harus peminjaman, hari, atau jam"
+	case fasilitas.FasilitasJenis == "ruangan" && fasilitas.FasilitasLokasi == "":
+		errMsg = "Lokasi wajib diisi untuk fasilitas ruangan"
+	}
+
+	if errMsg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errMsg,
+		})
+		return false
+	}
+	return true
+}
+
+// ✅ Helper parsing rentang waktu peminjaman (format YYYY-MM-DD HH:MM)
+func parseRentangPeminjaman(c *gin.Context, mulaiStr, selesaiStr string) (time.Time, time.Time, bool) {
+	mulai, err := time.ParseInLocation("2006-01-02 15:04", strings.TrimSpace(mulaiStr), time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Format waktu_mulai tidak valid. Gunakan format YYYY-MM-DD HH:MM",
+		})
+		return mulai, mulai, false
+	}
+	selesai, err := time.ParseInLocation("2006-01-02 15:04", strings.TrimSpace(selesaiStr), time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Format waktu_selesai tidak valid. Gunakan format YYYY-MM-DD HH:MM",
+		})
+		return mulai, selesai, false
+	}
+
+	if !selesai.After(mulai) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Waktu selesai harus setelah waktu mulai",
+		})
+		return mulai, selesai, false
+	}
+	if selesai.Sub(mulai) > maxDurasiPeminjaman {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Lama peminjaman maksimal %d hari", int(maxDurasiPeminjaman.Hours()/24)),
+		})
+		return mulai, selesai, false
+	}
+
+	return mulai, selesai, true
+}
+
+// hitungBiayaPeminjaman menghitung biaya sewa: tarif x jumlah unit x durasi (dibulatkan ke atas)
+func hitungBiayaPeminjaman(fasilitas models.Fasilitas, mulai, selesai time.Time, jumlah int) float64 {
+	durasi := 1.0
+	switch fasilitas.FasilitasSatuanTarif {
+	case "hari":
+		durasi = math.Ceil(selesai.Sub(mulai).Hours() / 24)
+	case "jam":
+		durasi = math.Ceil(selesai.Sub(mulai).Hours())
+	}
+	return fasilitas.FasilitasTarif * float64(jumlah) * durasi
+}
+
+// puncakPemakaian menghitung jumlah unit terbanyak yang dipakai bersamaan dalam rentang [mulai, selesai)
+func puncakPemakaian(peminjaman []models.PeminjamanFasilitas, mulai, selesai time.Time) int {
+	type titik struct {
+		waktu  time.Time
+		jumlah int
+	}
+
+	titikWaktu := make([]titik, 0, len(peminjaman)*2)
+	for _, p := range peminjaman {
+		awal, akhir := p.WaktuMulai, p.WaktuSelesai
+		if awal.Before(mulai) {
+			awal = mulai
+		}
+		if akhir.After(selesai) {
+			akhir = selesai
+		}
+		if !akhir.After(awal) {
+			continue
+		}
+		titikWaktu = append(titikWaktu, titik{awal, p.Jumlah}, titik{akhir, -p.Jumlah})
+	}
+
+	// Pada waktu yang sama, pengembalian dihitung sebelum peminjaman berikutnya
+	sort.Slice(titikWaktu, func(i, j int) bool {
+		if titikWaktu[i].waktu.Equal(titikWaktu[j].waktu) {
+			return titikWaktu[i].jumlah < titikWaktu[j].jumlah
+		}
+		return titikWaktu[i].waktu.Before(titikWaktu[j].waktu)
+	})
+
+	puncak, dipakai := 0, 0
+	for _, t := range titikWaktu {
+		dipakai += t.jumlah
+		if dipakai > puncak {
+			puncak = dipakai
+		}
+	}
+	return puncak
+}
+
+func bentrokPeminjaman(p models.PeminjamanFasilitas) BentrokFasilitas {
+	judul := p.Keperluan
+	if p.Warga != nil {
+		judul = fmt.Sprintf("%s (%s)", p.Keperluan, p.Warga.WargaNama)
+	}
+	return BentrokFasilitas{
+		Jenis:   "peminjaman",
+		ID:      p.PeminjamanFasilitasID,
+		Judul:   judul,
+		Mulai:   p.WaktuMulai,
+		Selesai: p.WaktuSelesai,
+		Jumlah:  p.Jumlah,
+		Status:  p.Status,
+	}
+}
+
+func normalizeLokasi(lokasi string) string {
+	return strings.ToLower(strings.Join(strings.Fields(lokasi), " "))
+}
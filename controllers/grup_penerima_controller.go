@@ -0,0 +1,366 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"rt-management/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type GrupPenerimaController struct {
+	db *gorm.DB
+}
+
+func NewGrupPenerimaController(db *gorm.DB) *GrupPenerimaController {
+	return &GrupPenerimaController{db: db}
+}
+
+// Request structs
+type CreateGrupPenerimaRequest struct {
+	GrupNama      string `form:"grup_nama" binding:"required"`
+	GrupDeskripsi string `form:"grup_deskripsi"`
+	UserID        []uint `form:"user_id"` // anggota awal, boleh dikirim berulang
+}
+
+type UpdateGrupPenerimaRequest struct {
+	GrupNama      *string `form:"grup_nama"`
+	GrupDeskripsi *string `form:"grup_deskripsi"`
+}
+
+type AnggotaGrupPenerimaRequest struct {
+	UserID []uint `form:"user_id" binding:"required"`
+}
+
+// Grup penerima beserta jumlah anggotanya
+type GrupPenerimaRingkas struct {
+	models.GrupPenerima
+	JumlahAnggota int64 `json:"jumlah_anggota"`
+}
+
+// ✅ GET - Daftar grup penerima broadcast
+func (gc *GrupPenerimaController) GetAllGrupPenerima(c *gin.Context) {
+	query := scopedDB(c, gc.db)
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		query = query.Where("grup_nama LIKE ?", "%"+search+"%")
+	}
+
+	var grup []models.GrupPenerima
+	if err := query.Order("grup_nama ASC").Find(&grup).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data grup penerima",
+		})
+		return
+	}
+
+	data := make([]GrupPenerimaRingkas, 0, len(grup))
+	for _, g := range grup {
+		var jumlah int64
+		scopedDB(c, gc.db).Model(&models.GrupPenerimaAnggota{}).
+			Where("grup_penerima_id = ?", g.GrupPenerimaID).
+			Count(&jumlah)
+		data = append(data, GrupPenerimaRingkas{GrupPenerima: g, JumlahAnggota: jumlah})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  data,
+		"total": len(data),
+	})
+}
+
+// ✅ GET - Detail grup penerima beserta anggotanya
+func (gc *GrupPenerimaController) GetGrupPenerimaByID(c *gin.Context) {
+	grup, ok := gc.findGrupPenerima(c, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Anggota.User", func(db *gorm.DB) *gorm.DB {
+			return db.Select("user_id", "username", "level_id", "warga_id", "rt_id")
+		})
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": grup,
+	})
+}
+
+// ✅ POST - Membuat grup penerima baru
+func (gc *GrupPenerimaController) CreateGrupPenerima(c *gin.Context) {
+	var req CreateGrupPenerimaRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	grup := models.GrupPenerima{
+		GrupNama:      strings.TrimSpace(req.GrupNama),
+		GrupDeskripsi: strings.TrimSpace(req.GrupDeskripsi),
+	}
+	if !gc.validateGrupPenerima(c, grup) {
+		return
+	}
+
+	userIDs, ok := gc.validateAnggota(c, req.UserID)
+	if !ok {
+		return
+	}
+
+	err := scopedDB(c, gc.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&grup).Error; err != nil {
+			return err
+		}
+		return tambahAnggotaGrup(tx, grup, userIDs)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal membuat grup penerima",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Grup penerima berhasil dibuat",
+		"data":    grup,
+	})
+}
+
+// ✅ PUT - Mengupdate nama/deskripsi grup penerima
+func (gc *GrupPenerimaController) UpdateGrupPenerima(c *gin.Context) {
+	grup, ok := gc.findGrupPenerima(c, nil)
+	if !ok {
+		return
+	}
+
+	var req UpdateGrupPenerimaRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.GrupNama != nil {
+		grup.GrupNama = strings.TrimSpace(*req.GrupNama)
+	}
+	if req.GrupDeskripsi != nil {
+		grup.GrupDeskripsi = strings.TrimSpace(*req.GrupDeskripsi)
+	}
+	if !gc.validateGrupPenerima(c, grup) {
+		return
+	}
+
+	if err := scopedDB(c, gc.db).Model(&grup).Updates(map[string]interface{}{
+		"grup_nama":      grup.GrupNama,
+		"grup_deskripsi": grup.GrupDeskripsi,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengupdate grup penerima",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Grup penerima berhasil diupdate",
+		"data":    grup,
+	})
+}
+
+// ✅ DELETE - Menghapus grup penerima (broadcast lama yang memakai grup ini tetap menjangkau anggotanya)
+func (gc *GrupPenerimaController) DeleteGrupPenerima(c *gin.Context) {
+	grup, ok := gc.findGrupPenerima(c, nil)
+	if !ok {
+		return
+	}
+
+	if err := scopedDB(c, gc.db).Delete(&grup).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal menghapus grup penerima",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Grup penerima berhasil dihapus",
+	})
+}
+
+// ✅ POST - Menambahkan anggota ke grup penerima (user yang sudah menjadi anggota dilewati)
+func (gc *GrupPenerimaController) TambahAnggotaGrupPenerima(c *gin.Context) {
+	grup, ok := gc.findGrupPenerima(c, nil)
+	if !ok {
+		return
+	}
+
+	var req AnggotaGrupPenerimaRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	userIDs, ok := gc.validateAnggota(c, req.UserID)
+	if !ok {
+		return
+	}
+
+	var existing []uint
+	scopedDB(c, gc.db).Model(&models.GrupPenerimaAnggota{}).
+		Where("grup_penerima_id = ?", grup.GrupPenerimaID).
+		Pluck("user_id", &existing)
+	sudahAnggota := make(map[uint]bool, len(existing))
+	for _, id := range existing {
+		sudahAnggota[id] = true
+	}
+
+	var baru []uint
+	for _, id := range userIDs {
+		if !sudahAnggota[id] {
+			baru = append(baru, id)
+		}
+	}
+
+	if err := tambahAnggotaGrup(scopedDB(c, gc.db), grup, baru); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menambahkan anggota grup",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":         "Anggota grup berhasil ditambahkan",
+		"jumlah_ditambah": len(baru),
+	})
+}
+
+// ✅ DELETE - Mengeluarkan user dari grup penerima
+func (gc *GrupPenerimaController) HapusAnggotaGrupPenerima(c *gin.Context) {
+	grup, ok := gc.findGrupPenerima(c, nil)
+	if !ok {
+		return
+	}
+
+	result := scopedDB(c, gc.db).
+		Where("grup_penerima_id = ? AND user_id = ?", grup.GrupPenerimaID, c.Param("user_id")).
+		Delete(&models.GrupPenerimaAnggota{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengeluarkan anggota grup",
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User bukan anggota grup ini",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Anggota grup berhasil dikeluarkan",
+	})
+}
+
+// ✅ Helper mengambil grup penerima dari parameter :id, preload opsional
+func (gc *GrupPenerimaController) findGrupPenerima(c *gin.Context, preload func(*gorm.DB) *gorm.DB) (models.GrupPenerima, bool) {
+	var grup models.GrupPenerima
+
+	grupID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID grup penerima tidak valid",
+		})
+		return grup, false
+	}
+
+	query := scopedDB(c, gc.db)
+	if preload != nil {
+		query = preload(query)
+	}
+	if err := query.First(&grup, grupID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Grup penerima tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan grup penerima",
+			})
+		}
+		return grup, false
+	}
+
+	return grup, true
+}
+
+func (gc *GrupPenerimaController) validateGrupPenerima(c *gin.Context, grup models.GrupPenerima) bool {
+	if len(grup.GrupNama) < 2 || len(grup.GrupNama) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Nama grup harus 2-100 karakter",
+		})
+		return false
+	}
+
+	var count int64
+	scopedDB(c, gc.db).Model(&models.GrupPenerima{}).
+		Where("grup_nama = ? AND grup_penerima_id <> ?", grup.GrupNama, grup.GrupPenerimaID).
+		Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Grup dengan nama tersebut sudah ada",
+		})
+		return false
+	}
+	return true
+}
+
+// ✅ Helper memastikan semua user anggota ada di wilayah user yang login
+func (gc *GrupPenerimaController) validateAnggota(c *gin.Context, userIDs []uint) ([]uint, bool) {
+	unik := make([]uint, 0, len(userIDs))
+	seen := make(map[uint]bool, len(userIDs))
+	for _, id := range userIDs {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			unik = append(unik, id)
+		}
+	}
+	if len(unik) == 0 {
+		return unik, true
+	}
+
+	var count int64
+	scopedDB(c, gc.db).Model(&models.User{}).Where("user_id IN ?", unik).Count(&count)
+	if count != int64(len(unik)) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Sebagian user anggota tidak ditemukan",
+		})
+		return nil, false
+	}
+	return unik, true
+}
+
+func tambahAnggotaGrup(tx *gorm.DB, grup models.GrupPenerima, userIDs []uint) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	anggota := make([]models.GrupPenerimaAnggota, 0, len(userIDs))
+	for _, id := range userIDs {
+		anggota = append(anggota, models.GrupPenerimaAnggota{
+			GrupPenerimaID: grup.GrupPenerimaID,
+			UserID:         id,
+			RTID:           grup.RTID,
+		})
+	}
+	return tx.Create(&anggota).Error
+}
@@ -0,0 +1,535 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"rt-management/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type HunianRumahController struct {
+	db *gorm.DB
+}
+
+func NewHunianRumahController(db *gorm.DB) *HunianRumahController {
+	return &HunianRumahController{db: db}
+}
+
+// Request structs
+type CreateHunianRumahRequest struct {
+	RumahID               uint   `form:"rumah_id" binding:"required"`
+	KeluargaID            uint   `form:"keluarga_id" binding:"required"`
+	HunianJenis           string `form:"hunian_jenis" binding:"required"`
+	HunianTanggalMulai    string `form:"hunian_tanggal_mulai" binding:"required"`
+	HunianKontrakBerakhir string `form:"hunian_kontrak_berakhir"`
+	HunianKeterangan      string `form:"hunian_keterangan"`
+}
+
+type UpdateHunianRumahRequest struct {
+	HunianJenis           string `form:"hunian_jenis"`
+	HunianKontrakBerakhir string `form:"hunian_kontrak_berakhir"`
+	HunianKeterangan      string `form:"hunian_keterangan"`
+}
+
+type SelesaiHunianRumahRequest struct {
+	HunianTanggalSelesai string `form:"hunian_tanggal_selesai"`
+	HunianKeterangan     string `form:"hunian_keterangan"`
+}
+
+// ✅ CREATE - Mencatat keluarga mulai menempati rumah
+func (hc *HunianRumahController) CreateHunianRumah(c *gin.Context) {
+	var req CreateHunianRumahRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	req.HunianJenis = strings.TrimSpace(req.HunianJenis)
+	req.HunianKeterangan = strings.TrimSpace(req.HunianKeterangan)
+
+	if !isValidHunianJenis(req.HunianJenis) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Jenis hunian harus 'pemilik' atau 'penyewa'",
+		})
+		return
+	}
+
+	tanggalMulai, err := time.Parse("2006-01-02", strings.TrimSpace(req.HunianTanggalMulai))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Format tanggal mulai tidak valid. Gunakan format YYYY-MM-DD",
+		})
+		return
+	}
+
+	// Penyewa wajib punya tanggal berakhir kontrak
+	var kontrakBerakhir *time.Time
+	if req.HunianKontrakBerakhir != "" {
+		t, err := time.Parse("2006-01-02", strings.TrimSpace(req.HunianKontrakBerakhir))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Format tanggal kontrak berakhir tidak valid. Gunakan format YYYY-MM-DD",
+			})
+			return
+		}
+		if !t.After(tanggalMulai) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Tanggal kontrak berakhir harus setelah tanggal mulai",
+			})
+			return
+		}
+		kontrakBerakhir = &t
+	}
+	if req.HunianJenis == "penyewa" && kontrakBerakhir == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tanggal kontrak berakhir wajib diisi untuk penyewa",
+		})
+		return
+	}
+	if req.HunianJenis == "pemilik" {
+		kontrakBerakhir = nil
+	}
+
+	// Check if rumah exists
+	var rumah models.Rumah
+	if err := scopedDB(c, hc.db).First(&rumah, req.RumahID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Rumah tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal memvalidasi rumah",
+			})
+		}
+		return
+	}
+
+	// Check if keluarga exists
+	var keluarga models.Keluarga
+	if err := scopedDB(c, hc.db).First(&keluarga, req.KeluargaID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Keluarga tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal memvalidasi keluarga",
+			})
+		}
+		return
+	}
+
+	// Satu rumah hanya boleh punya satu hunian pada satu waktu. Hunian baru boleh
+	// dicatat mulai tanggal berakhirnya hunian sebelumnya walaupun belum tercapai.
+	var hunianBentrok models.HunianRumah
+	if err := scopedDB(c, hc.db).
+		Where("rumah_id = ? AND (hunian_tanggal_selesai IS NULL OR hunian_tanggal_selesai > ?)", req.RumahID, tanggalMulai).
+		First(&hunianBentrok).Error; err == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":           "Rumah masih ditempati pada tanggal tersebut, akhiri hunian sebelumnya terlebih dahulu",
+			"hunian_rumah_id": hunianBentrok.HunianRumahID,
+		})
+		return
+	}
+
+	hunian := models.HunianRumah{
+		RumahID:               req.RumahID,
+		KeluargaID:            req.KeluargaID,
+		HunianJenis:           req.HunianJenis,
+		HunianTanggalMulai:    tanggalMulai,
+		HunianKontrakBerakhir: kontrakBerakhir,
+		HunianKeterangan:      req.HunianKeterangan,
+		CreatedAt:             time.Now(),
+		UpdatedAt:             time.Now(),
+	}
+
+	// Simpan hunian dan sinkronkan status rumah dalam satu transaksi
+	err = scopedDB(c, hc.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&hunian).Error; err != nil {
+			return err
+		}
+		_, err := sinkronStatusRumah(tx.Where("rumah_id = ?", rumah.RumahID), time.Now())
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mencatat hunian rumah",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	scopedDB(c, hc.db).Preload("Rumah").Preload("Keluarga").First(&hunian, hunian.HunianRumahID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Hunian rumah berhasil dicatat",
+		"data":    hunian,
+	})
+}
+
+// ✅ READ - Mendapatkan semua hunian dengan filter
+func (hc *HunianRumahController) GetAllHunianRumah(c *gin.Context) {
+	var hunian []models.HunianRumah
+
+	jenis := c.Query("jenis")
+	aktif := c.Query("aktif")
+	keluargaID := c.Query("keluarga_id")
+
+	query := scopedDB(c, hc.db).Model(&models.HunianRumah{}).Preload("Rumah").Preload("Keluarga")
+
+	if jenis != "" && isValidHunianJenis(jenis) {
+		query = query.Where("hunian_jenis = ?", jenis)
+	}
+	if aktif == "true" {
+		query = query.Scopes(hunianAktif(time.Now()))
+	} else if aktif == "false" {
+		query = query.Where("hunian_tanggal_selesai <= ?", time.Now())
+	}
+	if keluargaID != "" {
+		if keluargaIDSafe, err := strconv.ParseUint(keluargaID, 10, 32); err == nil {
+			query = query.Where("keluarga_id = ?", keluargaIDSafe)
+		}
+	}
+
+	if err := query.Order("hunian_tanggal_mulai DESC").Find(&hunian).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data hunian rumah",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  hunian,
+		"total": len(hunian),
+	})
+}
+
+// ✅ READ - Riwayat penghuni sebuah rumah
+func (hc *HunianRumahController) GetRiwayatHunianByRumah(c *gin.Context) {
+	rumahID, err := strconv.ParseUint(c.Param("rumah_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID rumah tidak valid",
+		})
+		return
+	}
+
+	var rumah models.Rumah
+	if err := scopedDB(c, hc.db).First(&rumah, rumahID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Rumah tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal mengambil data rumah",
+			})
+		}
+		return
+	}
+
+	var hunian []models.HunianRumah
+	if err := scopedDB(c, hc.db).
+		Preload("Keluarga").
+		Where("rumah_id = ?", rumahID).
+		Order("hunian_tanggal_mulai DESC").
+		Find(&hunian).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil riwayat hunian rumah",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rumah": rumah,
+		"data":  hunian,
+		"total": len(hunian),
+	})
+}
+
+// ✅ GET - Kontrak sewa yang akan / sudah berakhir
+func (hc *HunianRumahController) GetKontrakAkanBerakhir(c *gin.Context) {
+	hari, err := strconv.Atoi(c.DefaultQuery("hari", "30"))
+	if err != nil || hari < 1 || hari > 365 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Parameter hari harus antara 1-365",
+		})
+		return
+	}
+
+	sekarang := time.Now()
+	batas := sekarang.AddDate(0, 0, hari)
+
+	var hunian []models.HunianRumah
+	if err := scopedDB(c, hc.db).
+		Preload("Rumah").
+		Preload("Keluarga").
+		Where("hunian_jenis = ?", "penyewa").
+		Where("hunian_tanggal_selesai IS NULL OR hunian_tanggal_selesai > ?", sekarang).
+		Where("hunian_kontrak_berakhir <= ?", batas).
+		Order("hunian_kontrak_berakhir ASC").
+		Find(&hunian).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data kontrak sewa",
+		})
+		return
+	}
+
+	type KontrakAlert struct {
+		models.HunianRumah
+		SisaHari   int  `json:"sisa_hari"`
+		SudahLewat bool `json:"sudah_lewat"`
+	}
+
+	var result []KontrakAlert
+	for _, h := range hunian {
+		sisa := int(h.HunianKontrakBerakhir.Sub(sekarang).Hours() / 24)
+		result = append(result, KontrakAlert{
+			HunianRumah: h,
+			SisaHari:    sisa,
+			SudahLewat:  h.HunianKontrakBerakhir.Before(sekarang),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  result,
+		"total": len(result),
+		"hari":  hari,
+	})
+}
+
+// ✅ UPDATE - Mengupdate hunian (mis. perpanjangan kontrak)
+func (hc *HunianRumahController) UpdateHunianRumah(c *gin.Context) {
+	hunianID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID hunian tidak valid",
+		})
+		return
+	}
+
+	var hunian models.HunianRumah
+	if err := scopedDB(c, hc.db).First(&hunian, hunianID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Hunian tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan hunian",
+			})
+		}
+		return
+	}
+
+	var req UpdateHunianRumahRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	updates := make(map[string]interface{})
+
+	jenis := hunian.HunianJenis
+	if req.HunianJenis != "" {
+		if !isValidHunianJenis(req.HunianJenis) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Jenis hunian harus 'pemilik' atau 'penyewa'",
+			})
+			return
+		}
+		jenis = req.HunianJenis
+		updates["hunian_jenis"] = jenis
+	}
+
+	if req.HunianKontrakBerakhir != "" {
+		t, err := time.Parse("2006-01-02", strings.TrimSpace(req.HunianKontrakBerakhir))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Format tanggal kontrak berakhir tidak valid. Gunakan format YYYY-MM-DD",
+			})
+			return
+		}
+		if !t.After(hunian.HunianTanggalMulai) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Tanggal kontrak berakhir harus setelah tanggal mulai",
+			})
+			return
+		}
+		updates["hunian_kontrak_berakhir"] = t
+	}
+
+	if jenis == "pemilik" {
+		updates["hunian_kontrak_berakhir"] = nil
+	} else if hunian.HunianKontrakBerakhir == nil && updates["hunian_kontrak_berakhir"] == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tanggal kontrak berakhir wajib diisi untuk penyewa",
+		})
+		return
+	}
+
+	if req.HunianKeterangan != "" {
+		updates["hunian_keterangan"] = strings.TrimSpace(req.HunianKeterangan)
+	}
+	updates["updated_at"] = time.Now()
+
+	if err := scopedDB(c, hc.db).Model(&hunian).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mengupdate hunian",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	scopedDB(c, hc.db).Preload("Rumah").Preload("Keluarga").First(&hunian, hunianID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Hunian berhasil diupdate",
+		"data":    hunian,
+	})
+}
+
+// ✅ UPDATE - Mengakhiri hunian (keluarga pindah / kontrak selesai)
+func (hc *HunianRumahController) SelesaiHunianRumah(c *gin.Context) {
+	hunianID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID hunian tidak valid",
+		})
+		return
+	}
+
+	var hunian models.HunianRumah
+	if err := scopedDB(c, hc.db).First(&hunian, hunianID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Hunian tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan hunian",
+			})
+		}
+		return
+	}
+
+	// Tanggal selesai yang belum tercapai masih boleh dijadwalkan ulang
+	if hunian.HunianTanggalSelesai != nil && !hunian.HunianTanggalSelesai.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Hunian sudah berakhir",
+		})
+		return
+	}
+
+	var req SelesaiHunianRumahRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tanggalSelesai := time.Now()
+	if req.HunianTanggalSelesai != "" {
+		tanggalSelesai, err = time.Parse("2006-01-02", strings.TrimSpace(req.HunianTanggalSelesai))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Format tanggal selesai tidak valid. Gunakan format YYYY-MM-DD",
+			})
+			return
+		}
+	}
+	if tanggalSelesai.Before(hunian.HunianTanggalMulai) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tanggal selesai tidak boleh sebelum tanggal mulai",
+		})
+		return
+	}
+
+	// Tanggal selesai tidak boleh melewati awal hunian berikutnya di rumah yang sama
+	var hunianBerikutnya models.HunianRumah
+	if err := scopedDB(c, hc.db).
+		Where("rumah_id = ? AND hunian_rumah_id != ?", hunian.RumahID, hunian.HunianRumahID).
+		Where("hunian_tanggal_mulai >= ? AND hunian_tanggal_mulai < ?", hunian.HunianTanggalMulai, tanggalSelesai).
+		First(&hunianBerikutnya).Error; err == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":           "Tanggal selesai melewati awal hunian berikutnya",
+			"hunian_rumah_id": hunianBerikutnya.HunianRumahID,
+		})
+		return
+	}
+
+	updates := map[string]interface{}{
+		"hunian_tanggal_selesai": tanggalSelesai,
+		"updated_at":             time.Now(),
+	}
+	if req.HunianKeterangan != "" {
+		updates["hunian_keterangan"] = strings.TrimSpace(req.HunianKeterangan)
+	}
+
+	err = scopedDB(c, hc.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&hunian).Updates(updates).Error; err != nil {
+			return err
+		}
+		// Rumah baru tersedia saat tanggal selesai tercapai; sisanya oleh penjadwal
+		_, err := sinkronStatusRumah(tx.Where("rumah_id = ?", hunian.RumahID), time.Now())
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mengakhiri hunian",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	scopedDB(c, hc.db).Preload("Rumah").Preload("Keluarga").First(&hunian, hunianID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Hunian berhasil diakhiri",
+		"data":    hunian,
+	})
+}
+
+// ✅ Scope hunian yang sedang berjalan pada waktu now. Hunian dengan tanggal
+// selesai di masa depan tetap aktif sampai tanggal tersebut tercapai.
+func hunianAktif(now time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("hunian_rumahs.hunian_tanggal_mulai <= ? AND (hunian_rumahs.hunian_tanggal_selesai IS NULL OR hunian_rumahs.hunian_tanggal_selesai > ?)", now, now)
+	}
+}
+
+// kondisiRumahDitempati bernilai benar jika rumah punya hunian aktif; dipakai sebagai sumber rumah_status
+const kondisiRumahDitempati = "EXISTS (SELECT 1 FROM hunian_rumahs h WHERE h.rumah_id = rumahs.rumah_id AND h.hunian_tanggal_mulai <= ? AND (h.hunian_tanggal_selesai IS NULL OR h.hunian_tanggal_selesai > ?))"
+
+// sinkronStatusRumah menurunkan rumah_status dari hunian aktif untuk rumah pada query db
+// dan hanya mengubah rumah yang statusnya belum sesuai.
+func sinkronStatusRumah(db *gorm.DB, now time.Time) (int64, error) {
+	status := "CASE WHEN " + kondisiRumahDitempati + " THEN 'ditempati' ELSE 'tersedia' END"
+
+	result := db.Model(&models.Rumah{}).
+		Where("rumah_status <> "+status, now, now).
+		Updates(map[string]interface{}{
+			"rumah_status": gorm.Expr(status, now, now),
+			"updated_at":   now,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// ✅ Helper function untuk validasi jenis hunian
+func isValidHunianJenis(jenis string) bool {
+	return jenis == "pemilik" || jenis == "penyewa"
+}
@@ -0,0 +1,855 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"rt-management/helper"
+	"rt-management/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Batas jumlah foto dalam satu kali upload foto inventaris
+const maxFotoInventarisPerUpload = 5
+
+type InventarisController struct {
+	db *gorm.DB
+}
+
+func NewInventarisController(db *gorm.DB) *InventarisController {
+	return &InventarisController{db: db}
+}
+
+// Request structs
+type CreateInventarisRequest struct {
+	InventarisKode     string   `form:"inventaris_kode"` // kosong = dibuat otomatis
+	InventarisNama     string   `form:"inventaris_nama" binding:"required"`
+	InventarisKategori string   `form:"inventaris_kategori"`
+	TanggalPerolehan   string   `form:"tanggal_perolehan"` // YYYY-MM-DD, default tanggal pengeluaran
+	NilaiPerolehan     *float64 `form:"nilai_perolehan"`   // default nominal pengeluaran
+	NilaiResidu        float64  `form:"nilai_residu"`
+	UmurEkonomis       int      `form:"umur_ekonomis"`
+	PengeluaranID      uint     `form:"pengeluaran_id"`
+	Lokasi             string   `form:"lokasi"`
+	PemegangID         uint     `form:"pemegang_id"`
+	Kondisi            string   `form:"kondisi"`
+	Keterangan         string   `form:"keterangan"`
+}
+
+type UpdateInventarisRequest struct {
+	InventarisKode     *string  `form:"inventaris_kode"`
+	InventarisNama     *string  `form:"inventaris_nama"`
+	InventarisKategori *string  `form:"inventaris_kategori"`
+	TanggalPerolehan   *string  `form:"tanggal_perolehan"`
+	NilaiPerolehan     *float64 `form:"nilai_perolehan"`
+	NilaiResidu        *float64 `form:"nilai_residu"`
+	UmurEkonomis       *int     `form:"umur_ekonomis"`
+	PengeluaranID      *uint    `form:"pengeluaran_id"` // 0 = lepas dari pengeluaran
+	Lokasi             *string  `form:"lokasi"`
+	PemegangID         *uint    `form:"pemegang_id"` // 0 = tanpa pemegang
+	Keterangan         *string  `form:"keterangan"`
+}
+
+type KondisiInventarisRequest struct {
+	Kondisi string `form:"kondisi" binding:"required"`
+	Tanggal string `form:"tanggal"` // YYYY-MM-DD, default hari ini
+	Catatan string `form:"catatan"`
+}
+
+// Inventaris beserta nilai buku pada tanggal tertentu
+type InventarisNilai struct {
+	models.Inventaris
+	PenyusutanPerTahun  float64 `json:"penyusutan_per_tahun"`
+	AkumulasiPenyusutan float64 `json:"akumulasi_penyusutan"`
+	NilaiBuku           float64 `json:"nilai_buku"`
+}
+
+// Satu baris jadwal penyusutan per tahun
+type JadwalPenyusutan struct {
+	Tahun               int     `json:"tahun"`
+	NilaiBukuAwal       float64 `json:"nilai_buku_awal"`
+	Penyusutan          float64 `json:"penyusutan"`
+	AkumulasiPenyusutan float64 `json:"akumulasi_penyusutan"`
+	NilaiBukuAkhir      float64 `json:"nilai_buku_akhir"`
+}
+
+// Baris laporan aset tahunan
+type LaporanInventarisTahunan struct {
+	InventarisID     uint      `json:"inventaris_id"`
+	InventarisKode   string    `json:"inventaris_kode"`
+	InventarisNama   string    `json:"inventaris_nama"`
+	Kategori         string    `json:"inventaris_kategori"`
+	Kondisi          string    `json:"kondisi"`
+	TanggalPerolehan time.Time `json:"tanggal_perolehan"`
+	NilaiPerolehan   float64   `json:"nilai_perolehan"`
+	JadwalPenyusutan
+}
+
+// ✅ GET - Daftar inventaris beserta nilai buku saat ini
+func (ic *InventarisController) GetAllInventaris(c *gin.Context) {
+	query := scopedDB(c, ic.db).Preload("Pemegang")
+
+	if kategori := c.Query("kategori"); kategori != "" {
+		query = query.Where("inventaris_kategori = ?", kategori)
+	}
+	if kondisi := c.Query("kondisi"); kondisi != "" {
+		query = query.Where("kondisi = ?", kondisi)
+	}
+	if pemegangID := c.Query("pemegang_id"); pemegangID != "" {
+		query = query.Where("pemegang_id = ?", pemegangID)
+	}
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		query = query.Where("inventaris_nama LIKE ? OR inventaris_kode LIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+
+	var inventaris []models.Inventaris
+	if err := query.Order("inventaris_kode ASC").Find(&inventaris).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data inventaris",
+		})
+		return
+	}
+
+	now := time.Now()
+	data := make([]InventarisNilai, 0, len(inventaris))
+	var totalPerolehan, totalNilaiBuku float64
+	for _, inv := range inventaris {
+		nilai := nilaiInventaris(inv, now)
+		totalPerolehan += inv.NilaiPerolehan
+		totalNilaiBuku += nilai.NilaiBuku
+		data = append(data, nilai)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  data,
+		"total": len(data),
+		"ringkasan": gin.H{
+			"total_nilai_perolehan": totalPerolehan,
+			"total_nilai_buku":      totalNilaiBuku,
+		},
+	})
+}
+
+// ✅ GET - Detail inventaris beserta riwayat kondisi, foto dan jadwal penyusutan
+func (ic *InventarisController) GetInventarisByID(c *gin.Context) {
+	inventaris, ok := ic.findInventaris(c, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Pemegang").
+			Preload("Pengeluaran").
+			Preload("Foto").
+			Preload("RiwayatKondisi", func(db *gorm.DB) *gorm.DB {
+				return db.Order("tanggal DESC, riwayat_kondisi_inventaris_id DESC")
+			})
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":              nilaiInventaris(inventaris, time.Now()),
+		"jadwal_penyusutan": jadwalPenyusutan(inventaris),
+	})
+}
+
+// ✅ POST - Mendaftarkan aset baru (boleh ditautkan ke pengeluaran pembeliannya)
+func (ic *InventarisController) CreateInventaris(c *gin.Context) {
+	var req CreateInventarisRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	inventaris := models.Inventaris{
+		InventarisKode:     strings.ToUpper(strings.TrimSpace(req.InventarisKode)),
+		InventarisNama:     strings.TrimSpace(req.InventarisNama),
+		InventarisKategori: strings.ToLower(strings.TrimSpace(req.InventarisKategori)),
+		NilaiResidu:        req.NilaiResidu,
+		UmurEkonomis:       req.UmurEkonomis,
+		Lokasi:             strings.TrimSpace(req.Lokasi),
+		Kondisi:            strings.ToLower(strings.TrimSpace(req.Kondisi)),
+		Keterangan:         req.Keterangan,
+	}
+	if inventaris.Kondisi == "" {
+		inventaris.Kondisi = "baik"
+	}
+
+	if req.PengeluaranID != 0 {
+		pengeluaran, ok := ic.findPengeluaran(c, req.PengeluaranID)
+		if !ok {
+			return
+		}
+		inventaris.PengeluaranID = &pengeluaran.PengeluaranID
+		inventaris.TanggalPerolehan = startOfDay(pengeluaran.PengeluaranTanggal)
+		inventaris.NilaiPerolehan = pengeluaran.PengeluaranNominal
+	}
+	if req.TanggalPerolehan != "" {
+		tanggal, err := time.ParseInLocation("2006-01-02", req.TanggalPerolehan, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Format tanggal_perolehan tidak valid. Gunakan format YYYY-MM-DD",
+			})
+			return
+		}
+		inventaris.TanggalPerolehan = tanggal
+	}
+	if req.NilaiPerolehan != nil {
+		inventaris.NilaiPerolehan = *req.NilaiPerolehan
+	}
+	if inventaris.TanggalPerolehan.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tanggal perolehan wajib diisi jika tidak ditautkan ke pengeluaran",
+		})
+		return
+	}
+
+	if req.PemegangID != 0 {
+		if !ic.validatePemegang(c, req.PemegangID) {
+			return
+		}
+		inventaris.PemegangID = &req.PemegangID
+	}
+
+	if !ic.validateInventaris(c, inventaris) {
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	dicatatOleh := userID.(uint)
+
+	var errMsg string
+	err := scopedDB(c, ic.db).Transaction(func(tx *gorm.DB) error {
+		if inventaris.PengeluaranID != nil {
+			if msg, err := validateNilaiPengeluaran(tx, inventaris); err != nil || msg != "" {
+				errMsg = msg
+				return fmt.Errorf("nilai pengeluaran: %v", err)
+			}
+		}
+
+		if inventaris.InventarisKode == "" {
+			kode, err := kodeInventarisBaru(tx, inventaris.TanggalPerolehan)
+			if err != nil {
+				return err
+			}
+			inventaris.InventarisKode = kode
+		} else {
+			var count int64
+			tx.Model(&models.Inventaris{}).Unscoped().Where("inventaris_kode = ?", inventaris.InventarisKode).Count(&count)
+			if count > 0 {
+				errMsg = "Kode inventaris sudah dipakai"
+				return fmt.Errorf("kode inventaris duplikat")
+			}
+		}
+
+		if err := tx.Create(&inventaris).Error; err != nil {
+			return err
+		}
+
+		// Kondisi awal menjadi baris pertama riwayat kondisi
+		return tx.Create(&models.RiwayatKondisiInventaris{
+			InventarisID:  inventaris.InventarisID,
+			Tanggal:       inventaris.TanggalPerolehan,
+			Kondisi:       inventaris.Kondisi,
+			Catatan:       "Aset didaftarkan",
+			DicatatOlehID: &dicatatOleh,
+			RTID:          inventaris.RTID,
+		}).Error
+	})
+	if err != nil {
+		if errMsg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": errMsg,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mendaftarkan inventaris",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Inventaris berhasil didaftarkan",
+		"data":    nilaiInventaris(inventaris, time.Now()),
+	})
+}
+
+// ✅ PUT - Mengupdate data inventaris (kondisi diubah lewat endpoint kondisi agar tercatat di riwayat)
+func (ic *InventarisController) UpdateInventaris(c *gin.Context) {
+	inventaris, ok := ic.findInventaris(c, nil)
+	if !ok {
+		return
+	}
+
+	var req UpdateInventarisRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.InventarisKode != nil {
+		kode := strings.ToUpper(strings.TrimSpace(*req.InventarisKode))
+		var count int64
+		scopedDB(c, ic.db).Model(&models.Inventaris{}).Unscoped().
+			Where("inventaris_kode = ? AND inventaris_id <> ?", kode, inventaris.InventarisID).
+			Count(&count)
+		if count > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Kode inventaris sudah dipakai",
+			})
+			return
+		}
+		inventaris.InventarisKode = kode
+	}
+	if req.InventarisNama != nil {
+		inventaris.InventarisNama = strings.TrimSpace(*req.InventarisNama)
+	}
+	if req.InventarisKategori != nil {
+		inventaris.InventarisKategori = strings.ToLower(strings.TrimSpace(*req.InventarisKategori))
+	}
+	if req.TanggalPerolehan != nil {
+		tanggal, err := time.ParseInLocation("2006-01-02", *req.TanggalPerolehan, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Format tanggal_perolehan tidak valid. Gunakan format YYYY-MM-DD",
+			})
+			return
+		}
+		inventaris.TanggalPerolehan = tanggal
+	}
+	if req.NilaiPerolehan != nil {
+		inventaris.NilaiPerolehan = *req.NilaiPerolehan
+	}
+	if req.NilaiResidu != nil {
+		inventaris.NilaiResidu = *req.NilaiResidu
+	}
+	if req.UmurEkonomis != nil {
+		inventaris.UmurEkonomis = *req.UmurEkonomis
+	}
+	if req.PengeluaranID != nil {
+		if *req.PengeluaranID == 0 {
+			inventaris.PengeluaranID = nil
+		} else {
+			pengeluaran, ok := ic.findPengeluaran(c, *req.PengeluaranID)
+			if !ok {
+				return
+			}
+			inventaris.PengeluaranID = &pengeluaran.PengeluaranID
+		}
+	}
+	if req.Lokasi != nil {
+		inventaris.Lokasi = strings.TrimSpace(*req.Lokasi)
+	}
+	if req.PemegangID != nil {
+		if *req.PemegangID == 0 {
+			inventaris.PemegangID = nil
+		} else {
+			if !ic.validatePemegang(c, *req.PemegangID) {
+				return
+			}
+			inventaris.PemegangID = req.PemegangID
+		}
+	}
+	if req.Keterangan != nil {
+		inventaris.Keterangan = *req.Keterangan
+	}
+
+	if !ic.validateInventaris(c, inventaris) {
+		return
+	}
+	if inventaris.PengeluaranID != nil {
+		if msg, err := validateNilaiPengeluaran(scopedDB(c, ic.db), inventaris); err != nil || msg != "" {
+			if msg == "" {
+				msg = "Gagal memeriksa nilai pengeluaran"
+			}
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": msg,
+			})
+			return
+		}
+	}
+
+	if err := scopedDB(c, ic.db).Omit("Pengeluaran", "Pemegang", "Foto", "RiwayatKondisi").Save(&inventaris).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengupdate inventaris",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Inventaris berhasil diupdate",
+		"data":    nilaiInventaris(inventaris, time.Now()),
+	})
+}
+
+// ✅ DELETE - Menghapus (soft delete) inventaris, misalnya aset dijual atau dimusnahkan
+func (ic *InventarisController) DeleteInventaris(c *gin.Context) {
+	inventaris, ok := ic.findInventaris(c, nil)
+	if !ok {
+		return
+	}
+
+	if err := scopedDB(c, ic.db).Delete(&inventaris).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal menghapus inventaris",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Inventaris berhasil dihapus",
+	})
+}
+
+// ✅ POST - Mencatat perubahan kondisi inventaris
+func (ic *InventarisController) CatatKondisiInventaris(c *gin.Context) {
+	inventaris, ok := ic.findInventaris(c, nil)
+	if !ok {
+		return
+	}
+
+	var req KondisiInventarisRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	kondisi := strings.ToLower(strings.TrimSpace(req.Kondisi))
+	if !isValidKondisiInventaris(kondisi) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Kondisi harus baik, rusak_ringan, rusak_berat, atau hilang",
+		})
+		return
+	}
+
+	tanggal := startOfDay(time.Now())
+	if req.Tanggal != "" {
+		t, err := time.ParseInLocation("2006-01-02", req.Tanggal, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Format tanggal tidak valid. Gunakan format YYYY-MM-DD",
+			})
+			return
+		}
+		tanggal = t
+	}
+	if tanggal.Before(inventaris.TanggalPerolehan) || tanggal.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tanggal harus di antara tanggal perolehan dan hari ini",
+		})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	dicatatOleh := userID.(uint)
+	riwayat := models.RiwayatKondisiInventaris{
+		InventarisID:      inventaris.InventarisID,
+		Tanggal:           tanggal,
+		KondisiSebelumnya: inventaris.Kondisi,
+		Kondisi:           kondisi,
+		Catatan:           strings.TrimSpace(req.Catatan),
+		DicatatOlehID:     &dicatatOleh,
+		RTID:              inventaris.RTID,
+	}
+
+	err := scopedDB(c, ic.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&riwayat).Error; err != nil {
+			return err
+		}
+		return tx.Model(&inventaris).Update("kondisi", kondisi).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mencatat kondisi inventaris",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Kondisi inventaris berhasil dicatat",
+		"data":    riwayat,
+	})
+}
+
+// ✅ POST - Upload foto inventaris (form-data field inventaris_foto, boleh berulang)
+func (ic *InventarisController) UploadFotoInventaris(c *gin.Context) {
+	inventaris, ok := ic.findInventaris(c, nil)
+	if !ok {
+		return
+	}
+
+	filenames, err := helper.HandleMultipleFileImageUpload(c, "inventaris_foto", maxFotoInventarisPerUpload)
+	if err != nil {
+		if err == http.ErrMissingFile {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Minimal satu foto harus diupload pada field inventaris_foto",
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Gagal mengupload foto inventaris",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	keterangan := strings.TrimSpace(c.PostForm("keterangan"))
+	foto := make([]models.FotoInventaris, 0, len(filenames))
+	for _, filename := range filenames {
+		foto = append(foto, models.FotoInventaris{
+			InventarisID: inventaris.InventarisID,
+			FotoFile:     filename,
+			Keterangan:   keterangan,
+			RTID:         inventaris.RTID,
+		})
+	}
+
+	if err := scopedDB(c, ic.db).Create(&foto).Error; err != nil {
+		// Rollback file upload jika gagal menyimpan ke database
+		for _, filename := range filenames {
+			helper.DeleteOldPhoto(filename, "inventaris_foto")
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menyimpan foto inventaris",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": fmt.Sprintf("%d foto inventaris berhasil diupload", len(foto)),
+		"data":    foto,
+	})
+}
+
+// ✅ DELETE - Menghapus foto inventaris beserta filenya
+func (ic *InventarisController) DeleteFotoInventaris(c *gin.Context) {
+	var foto models.FotoInventaris
+	if err := scopedDB(c, ic.db).
+		Where("inventaris_id = ?", c.Param("id")).
+		First(&foto, c.Param("foto_id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Foto inventaris tidak ditemukan",
+		})
+		return
+	}
+
+	if err := scopedDB(c, ic.db).Delete(&foto).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal menghapus foto inventaris",
+		})
+		return
+	}
+	helper.DeleteOldPhoto(foto.FotoFile, "inventaris_foto")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Foto inventaris berhasil dihapus",
+	})
+}
+
+// ✅ GET - Serve file foto inventaris
+func (ic *InventarisController) GetFotoInventarisImage(c *gin.Context) {
+	serveStorageFile(c, "inventaris_foto", "File foto tidak ditemukan")
+}
+
+// ✅ GET - Laporan nilai aset tahunan (query: tahun, default tahun berjalan)
+func (ic *InventarisController) GetLaporanInventarisTahunan(c *gin.Context) {
+	tahun, err := strconv.Atoi(c.DefaultQuery("tahun", strconv.Itoa(time.Now().Year())))
+	if err != nil || tahun < 2000 || tahun > 2100 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tahun tidak valid",
+		})
+		return
+	}
+	akhirTahun := time.Date(tahun+1, 1, 1, 0, 0, 0, 0, time.Local)
+
+	// Aset yang dihapus setelah tahun laporan tetap dihitung pada tahun tersebut
+	var inventaris []models.Inventaris
+	if err := scopedDB(c, ic.db).Unscoped().
+		Where("tanggal_perolehan < ?", akhirTahun).
+		Where("deleted_at IS NULL OR deleted_at >= ?", akhirTahun).
+		Order("inventaris_kode ASC").
+		Find(&inventaris).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data inventaris",
+		})
+		return
+	}
+
+	laporan := make([]LaporanInventarisTahunan, 0, len(inventaris))
+	var total JadwalPenyusutan
+	var totalPerolehan float64
+	perKategori := make(map[string]float64)
+	for _, inv := range inventaris {
+		baris := penyusutanTahun(inv, tahun)
+		laporan = append(laporan, LaporanInventarisTahunan{
+			InventarisID:     inv.InventarisID,
+			InventarisKode:   inv.InventarisKode,
+			InventarisNama:   inv.InventarisNama,
+			Kategori:         inv.InventarisKategori,
+			Kondisi:          inv.Kondisi,
+			TanggalPerolehan: inv.TanggalPerolehan,
+			NilaiPerolehan:   inv.NilaiPerolehan,
+			JadwalPenyusutan: baris,
+		})
+
+		totalPerolehan += inv.NilaiPerolehan
+		total.NilaiBukuAwal += baris.NilaiBukuAwal
+		total.Penyusutan += baris.Penyusutan
+		total.AkumulasiPenyusutan += baris.AkumulasiPenyusutan
+		total.NilaiBukuAkhir += baris.NilaiBukuAkhir
+
+		kategori := inv.InventarisKategori
+		if kategori == "" {
+			kategori = "lainnya"
+		}
+		perKategori[kategori] += baris.NilaiBukuAkhir
+	}
+	total.Tahun = tahun
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": laporan,
+		"ringkasan": gin.H{
+			"tahun":                      tahun,
+			"jumlah_aset":                len(laporan),
+			"total_nilai_perolehan":      roundRupiah(totalPerolehan),
+			"total_nilai_buku_awal":      roundRupiah(total.NilaiBukuAwal),
+			"total_penyusutan":           roundRupiah(total.Penyusutan),
+			"total_akumulasi_penyusutan": roundRupiah(total.AkumulasiPenyusutan),
+			"total_nilai_buku_akhir":     roundRupiah(total.NilaiBukuAkhir),
+			"nilai_buku_per_kategori":    perKategori,
+		},
+	})
+}
+
+// ✅ Helper mengambil inventaris dari parameter :id, preload opsional
+func (ic *InventarisController) findInventaris(c *gin.Context, preload func(*gorm.DB) *gorm.DB) (models.Inventaris, bool) {
+	var inventaris models.Inventaris
+
+	inventarisID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID inventaris tidak valid",
+		})
+		return inventaris, false
+	}
+
+	query := scopedDB(c, ic.db)
+	if preload != nil {
+		query = preload(query)
+	}
+	if err := query.First(&inventaris, inventarisID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Inventaris tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan inventaris",
+			})
+		}
+		return inventaris, false
+	}
+
+	return inventaris, true
+}
+
+func (ic *InventarisController) findPengeluaran(c *gin.Context, pengeluaranID uint) (models.Pengeluaran, bool) {
+	var pengeluaran models.Pengeluaran
+	if err := scopedDB(c, ic.db).First(&pengeluaran, pengeluaranID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Pengeluaran tidak ditemukan",
+		})
+		return pengeluaran, false
+	}
+	return pengeluaran, true
+}
+
+func (ic *InventarisController) validatePemegang(c *gin.Context, wargaID uint) bool {
+	var warga models.Warga
+	if err := scopedDB(c, ic.db).First(&warga, wargaID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Warga pemegang tidak ditemukan",
+		})
+		return false
+	}
+	return true
+}
+
+// ✅ Helper validasi data inventaris
+func (ic *InventarisController) validateInventaris(c *gin.Context, inventaris models.Inventaris) bool {
+	var errMsg string
+	switch {
+	case len(inventaris.InventarisNama) < 2 || len(inventaris.InventarisNama) > 100:
+		errMsg = "Nama inventaris harus 2-100 karakter"
+	case len(inventaris.InventarisKode) > 30:
+		errMsg = "Kode inventaris maksimal 30 karakter"
+	case inventaris.NilaiPerolehan <= 0:
+		errMsg = "Nilai perolehan harus lebih dari 0"
+	case inventaris.NilaiResidu < 0 || inventaris.NilaiResidu > inventaris.NilaiPerolehan:
+		errMsg = "Nilai residu harus antara 0 dan nilai perolehan"
+	case inventaris.UmurEkonomis < 0 || inventaris.UmurEkonomis > 50:
+		errMsg = "Umur ekonomis harus 0-50 tahun"
+	case inventaris.TanggalPerolehan.After(time.Now()):
+		errMsg = "Tanggal perolehan tidak boleh di masa depan"
+	case !isValidKondisiInventaris(inventaris.Kondisi):
+		errMsg = "Kondisi harus baik, rusak_ringan, rusak_berat, atau hilang"
+	}
+
+	if errMsg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errMsg,
+		})
+		return false
+	}
+	return true
+}
+
+// validateNilaiPengeluaran memastikan total nilai aset dari satu pengeluaran tidak melebihi nominalnya
+func validateNilaiPengeluaran(db *gorm.DB, inventaris models.Inventaris) (string, error) {
+	var pengeluaran models.Pengeluaran
+	if err := db.First(&pengeluaran, *inventaris.PengeluaranID).Error; err != nil {
+		return "Pengeluaran tidak ditemukan", err
+	}
+
+	var terpakai float64
+	if err := db.Model(&models.Inventaris{}).
+		Where("pengeluaran_id = ? AND inventaris_id <> ?", pengeluaran.PengeluaranID, inventaris.InventarisID).
+		Select("COALESCE(SUM(nilai_perolehan), 0)").
+		Scan(&terpakai).Error; err != nil {
+		return "", err
+	}
+
+	if terpakai+inventaris.NilaiPerolehan > pengeluaran.PengeluaranNominal+0.005 {
+		return fmt.Sprintf("Total nilai aset melebihi nominal pengeluaran (sisa Rp %.0f)", math.Max(pengeluaran.PengeluaranNominal-terpakai, 0)), nil
+	}
+	return "", nil
+}
+
+// kodeInventarisBaru membuat kode berurutan per tahun perolehan, misalnya INV-2026-003
+func kodeInventarisBaru(tx *gorm.DB, tanggal time.Time) (string, error) {
+	prefix := fmt.Sprintf("INV-%d-", tanggal.Year())
+
+	var kodes []string
+	if err := tx.Model(&models.Inventaris{}).Unscoped().
+		Where("inventaris_kode LIKE ?", prefix+"%").
+		Pluck("inventaris_kode", &kodes).Error; err != nil {
+		return "", err
+	}
+
+	maks := 0
+	for _, kode := range kodes {
+		if n, err := strconv.Atoi(strings.TrimPrefix(kode, prefix)); err == nil && n > maks {
+			maks = n
+		}
+	}
+	return fmt.Sprintf("%s%03d", prefix, maks+1), nil
+}
+
+func isValidKondisiInventaris(kondisi string) bool {
+	switch kondisi {
+	case "baik", "rusak_ringan", "rusak_berat", "hilang":
+		return true
+	}
+	return false
+}
+
+// akumulasiPenyusutan menghitung penyusutan garis lurus per bulan penuh sejak tanggal perolehan
+// sampai sebelum tanggal per. Aset dengan umur ekonomis 0 tidak disusutkan.
+func akumulasiPenyusutan(inv models.Inventaris, per time.Time) float64 {
+	if inv.UmurEkonomis <= 0 || !per.After(inv.TanggalPerolehan) {
+		return 0
+	}
+
+	beli := inv.TanggalPerolehan
+	bulan := (per.Year()-beli.Year())*12 + int(per.Month()-beli.Month())
+	if per.Day() < beli.Day() {
+		bulan--
+	}
+
+	totalBulan := inv.UmurEkonomis * 12
+	if bulan > totalBulan {
+		bulan = totalBulan
+	}
+	if bulan <= 0 {
+		return 0
+	}
+
+	return roundRupiah((inv.NilaiPerolehan - inv.NilaiResidu) * float64(bulan) / float64(totalBulan))
+}
+
+func nilaiInventaris(inv models.Inventaris, per time.Time) InventarisNilai {
+	akumulasi := akumulasiPenyusutan(inv, per)
+
+	var perTahun float64
+	if inv.UmurEkonomis > 0 {
+		perTahun = roundRupiah((inv.NilaiPerolehan - inv.NilaiResidu) / float64(inv.UmurEkonomis))
+	}
+
+	return InventarisNilai{
+		Inventaris:          inv,
+		PenyusutanPerTahun:  perTahun,
+		AkumulasiPenyusutan: akumulasi,
+		NilaiBuku:           roundRupiah(inv.NilaiPerolehan - akumulasi),
+	}
+}
+
+// penyusutanTahun menghitung nilai buku awal/akhir dan penyusutan aset pada satu tahun kalender
+func penyusutanTahun(inv models.Inventaris, tahun int) JadwalPenyusutan {
+	awal := time.Date(tahun, 1, 1, 0, 0, 0, 0, time.Local)
+	akhir := time.Date(tahun+1, 1, 1, 0, 0, 0, 0, time.Local)
+
+	nilaiAwal := inv.NilaiPerolehan - akumulasiPenyusutan(inv, awal)
+	if inv.TanggalPerolehan.Year() == tahun {
+		// Aset yang dibeli tahun ini dicatat sebagai penambahan, nilai awalnya sama dengan nilai perolehan
+		nilaiAwal = inv.NilaiPerolehan
+	}
+	akumulasiAkhir := akumulasiPenyusutan(inv, akhir)
+	nilaiAkhir := inv.NilaiPerolehan - akumulasiAkhir
+
+	return JadwalPenyusutan{
+		Tahun:               tahun,
+		NilaiBukuAwal:       roundRupiah(nilaiAwal),
+		Penyusutan:          roundRupiah(nilaiAwal - nilaiAkhir),
+		AkumulasiPenyusutan: akumulasiAkhir,
+		NilaiBukuAkhir:      roundRupiah(nilaiAkhir),
+	}
+}
+
+// jadwalPenyusutan menyusun penyusutan per tahun dari tahun perolehan sampai habis umur ekonomis
+func jadwalPenyusutan(inv models.Inventaris) []JadwalPenyusutan {
+	jadwal := []JadwalPenyusutan{}
+	if inv.UmurEkonomis <= 0 {
+		return jadwal
+	}
+
+	tahunAwal := inv.TanggalPerolehan.Year()
+	tahunAkhir := inv.TanggalPerolehan.AddDate(inv.UmurEkonomis, 0, 0).Year()
+	for tahun := tahunAwal; tahun <= tahunAkhir; tahun++ {
+		baris := penyusutanTahun(inv, tahun)
+		if tahun > tahunAwal && baris.Penyusutan == 0 {
+			break
+		}
+		jadwal = append(jadwal, baris)
+	}
+	return jadwal
+}
+
+func roundRupiah(nilai float64) float64 {
+	return math.Round(nilai*100) / 100
+}
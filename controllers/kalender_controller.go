@@ -0,0 +1,261 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"rt-management/helper"
+	"rt-management/models"
+	"rt-management/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Kegiatan hanya menyimpan waktu mulai, durasi event di kalender memakai nilai default
+const durasiKegiatanKalender = 2 * time.Hour
+
+// Kegiatan yang sudah lewat lebih lama dari ini tidak dimasukkan ke feed
+const riwayatFeedKalender = 180 * 24 * time.Hour
+
+type KalenderController struct {
+	db *gorm.DB
+}
+
+func NewKalenderController(db *gorm.DB) *KalenderController {
+	return &KalenderController{db: db}
+}
+
+// ✅ GET - Token dan URL feed kalender milik user yang sedang login (dibuat otomatis)
+func (kc *KalenderController) GetKalenderToken(c *gin.Context) {
+	user, ok := kc.currentUser(c)
+	if !ok {
+		return
+	}
+
+	if user.KalenderToken == nil {
+		if !kc.saveKalenderToken(c, &user) {
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": kalenderTokenResponse(c, *user.KalenderToken),
+	})
+}
+
+// ✅ POST - Membuat ulang token kalender (URL feed lama tidak berlaku lagi)
+func (kc *KalenderController) ResetKalenderToken(c *gin.Context) {
+	user, ok := kc.currentUser(c)
+	if !ok {
+		return
+	}
+
+	if !kc.saveKalenderToken(c, &user) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Token kalender berhasil dibuat ulang",
+		"data":    kalenderTokenResponse(c, *user.KalenderToken),
+	})
+}
+
+// ✅ GET - Feed iCalendar seluruh kegiatan (opsional filter kategori_id)
+func (kc *KalenderController) GetFeedKegiatan(c *gin.Context) {
+	if _, ok := kc.userByToken(c); !ok {
+		return
+	}
+
+	query := scopedDB(c, kc.db).
+		Preload("KategoriKegiatan").
+		Where("kegiatan_rrule <> ? OR kegiatan_tanggal >= ?", "", time.Now().Add(-riwayatFeedKalender))
+
+	namaKalender := "Kegiatan RT"
+	if kategoriID := c.Query("kategori_id"); kategoriID != "" {
+		id, err := strconv.ParseUint(kategoriID, 10, 32)
+		if err != nil {
+			c.String(http.StatusBadRequest, "kategori_id tidak valid")
+			return
+		}
+		var kategori models.KategoriKegiatan
+		if err := kc.db.First(&kategori, id).Error; err != nil {
+			c.String(http.StatusNotFound, "Kategori kegiatan tidak ditemukan")
+			return
+		}
+		query = query.Where("kategori_kegiatan_id = ?", id)
+		namaKalender += " - " + kategori.KategoriKegiatanNama
+	}
+
+	var kegiatan []models.Kegiatan
+	if err := query.Order("kegiatan_tanggal ASC").Find(&kegiatan).Error; err != nil {
+		c.String(http.StatusInternalServerError, "Gagal mengambil data kegiatan")
+		return
+	}
+
+	respondICalendar(c, "kegiatan.ics", helper.BuildICalendar(namaKalender, kegiatanToICalEvents(kegiatan)))
+}
+
+// ✅ GET - File .ics satu kegiatan (kegiatan rutin beserta jadwal yang diubah/dibatalkan)
+func (kc *KalenderController) GetICSKegiatan(c *gin.Context) {
+	if _, ok := kc.userByToken(c); !ok {
+		return
+	}
+
+	kegiatanID, err := strconv.ParseUint(strings.TrimSuffix(c.Param("id"), ".ics"), 10, 32)
+	if err != nil {
+		c.String(http.StatusBadRequest, "ID kegiatan tidak valid")
+		return
+	}
+
+	var kegiatan models.Kegiatan
+	if err := scopedDB(c, kc.db).Preload("KategoriKegiatan").First(&kegiatan, kegiatanID).Error; err != nil {
+		c.String(http.StatusNotFound, "Kegiatan tidak ditemukan")
+		return
+	}
+
+	events := kegiatanToICalEvents([]models.Kegiatan{kegiatan})
+	if kegiatan.KegiatanIndukID != nil {
+		// Jadwal turunan yang diunduh sendiri menjadi event tunggal
+		events = []helper.ICalEvent{kegiatanToICalEvent(kegiatan, uidKegiatan(kegiatan.KegiatanID))}
+	} else if kegiatan.KegiatanRRule != "" {
+		var turunan []models.Kegiatan
+		scopedDB(c, kc.db).Preload("KategoriKegiatan").Where("kegiatan_induk_id = ?", kegiatan.KegiatanID).Find(&turunan)
+		events = kegiatanToICalEvents(append([]models.Kegiatan{kegiatan}, turunan...))
+	}
+
+	respondICalendar(c, fmt.Sprintf("kegiatan-%d.ics", kegiatan.KegiatanID), helper.BuildICalendar(kegiatan.KegiatanNama, events))
+}
+
+// ✅ Helper mengambil user pemilik token kalender dan memasang scope wilayahnya ke request
+func (kc *KalenderController) userByToken(c *gin.Context) (models.User, bool) {
+	var user models.User
+	token := strings.TrimSpace(c.Param("token"))
+	if token == "" || kc.db.Where("kalender_token = ?", token).First(&user).Error != nil {
+		c.String(http.StatusNotFound, "Feed kalender tidak ditemukan")
+		return user, false
+	}
+
+	ctx := utils.WithTenantScope(c.Request.Context(), userTenantScope(user))
+	c.Request = c.Request.WithContext(ctx)
+	return user, true
+}
+
+func (kc *KalenderController) currentUser(c *gin.Context) (models.User, bool) {
+	var user models.User
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return user, false
+	}
+
+	if err := kc.db.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User tidak ditemukan",
+		})
+		return user, false
+	}
+	return user, true
+}
+
+func (kc *KalenderController) saveKalenderToken(c *gin.Context, user *models.User) bool {
+	token := generateRandomToken()
+	if err := kc.db.Model(user).Update("kalender_token", token).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal membuat token kalender",
+		})
+		return false
+	}
+	user.KalenderToken = &token
+	return true
+}
+
+func kalenderTokenResponse(c *gin.Context, token string) gin.H {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	base := fmt.Sprintf("%s://%s/kalender/%s", scheme, c.Request.Host, token)
+
+	return gin.H{
+		"token":         token,
+		"feed_url":      base + "/kegiatan.ics",
+		"kegiatan_url":  base + "/kegiatan/{kegiatan_id}.ics",
+		"kategori_feed": base + "/kegiatan.ics?kategori_id={kategori_kegiatan_id}",
+	}
+}
+
+// kegiatanToICalEvents mengubah kegiatan menjadi VEVENT. Seri kegiatan rutin memakai RRULE,
+// sedangkan jadwal turunannya memakai UID seri + RECURRENCE-ID agar aplikasi kalender
+// mengganti (atau membatalkan) jadwal yang tepat.
+func kegiatanToICalEvents(kegiatan []models.Kegiatan) []helper.ICalEvent {
+	seri := make(map[uint]bool)
+	for _, k := range kegiatan {
+		if k.KegiatanRRule != "" {
+			seri[k.KegiatanID] = true
+		}
+	}
+
+	events := make([]helper.ICalEvent, 0, len(kegiatan))
+	for _, k := range kegiatan {
+		if k.KegiatanIndukID == nil {
+			events = append(events, kegiatanToICalEvent(k, uidKegiatan(k.KegiatanID)))
+			continue
+		}
+		if !seri[*k.KegiatanIndukID] || k.KegiatanTanggalAsli == nil {
+			continue
+		}
+		event := kegiatanToICalEvent(k, uidKegiatan(*k.KegiatanIndukID))
+		event.RecurrenceID = k.KegiatanTanggalAsli
+		events = append(events, event)
+	}
+	return events
+}
+
+func kegiatanToICalEvent(k models.Kegiatan, uid string) helper.ICalEvent {
+	deskripsi := k.KegiatanDeskripsi
+	if k.KegiatanPJ != "" {
+		if deskripsi != "" {
+			deskripsi += "\n\n"
+		}
+		deskripsi += "PJ: " + k.KegiatanPJ
+	}
+
+	event := helper.ICalEvent{
+		UID:          uid,
+		Sequence:     k.KegiatanSequence,
+		Start:        k.KegiatanTanggal,
+		End:          k.KegiatanTanggal.Add(durasiKegiatanKalender),
+		Summary:      k.KegiatanNama,
+		Description:  deskripsi,
+		Location:     k.KegiatanLokasi,
+		Cancelled:    k.KegiatanDibatalkan,
+		Created:      k.CreatedAt,
+		LastModified: k.UpdatedAt,
+	}
+	if k.KategoriKegiatan.KategoriKegiatanNama != "" {
+		event.Categories = []string{k.KategoriKegiatan.KategoriKegiatanNama}
+	}
+	if k.KegiatanRRule != "" && k.KegiatanIndukID == nil {
+		if rule, err := helper.ParseRRule(k.KegiatanRRule); err == nil {
+			event.RRule = rule.ICalString()
+		}
+	}
+	return event
+}
+
+// UID stabil per kegiatan agar perubahan memperbarui event yang sama di aplikasi kalender
+func uidKegiatan(kegiatanID uint) string {
+	return fmt.Sprintf("kegiatan-%d@rt-management", kegiatanID)
+}
+
+func respondICalendar(c *gin.Context, filename, body string) {
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename))
+	c.Header("Cache-Control", "private, max-age=900")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(body))
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"rt-management/models"
+	"rt-management/utils"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -102,7 +103,7 @@ func (kc *KategoriKegiatanController) GetAllKategoriKegiatan(c *gin.Context) {
 	search := strings.TrimSpace(c.Query("search"))
 
 	// Build query dengan GORM (AMAN - parameterized queries)
-	query := kc.db.Model(&models.KategoriKegiatan{})
+	query := scopedDB(c, kc.db).Model(&models.KategoriKegiatan{})
 
 	// Apply search filter jika ada
 	if search != "" {
@@ -149,7 +150,7 @@ func (kc *KategoriKegiatanController) GetKategoriKegiatanByID(c *gin.Context) {
 	}
 
 	var kategori models.KategoriKegiatan
-	if err := kc.db.Preload("Kegiatans").First(&kategori, kategoriID).Error; err != nil {
+	if err := scopedDB(c, kc.db).Preload("Kegiatans").First(&kategori, kategoriID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Kategori kegiatan tidak ditemukan",
@@ -244,7 +245,7 @@ func (kc *KategoriKegiatanController) UpdateKategoriKegiatan(c *gin.Context) {
 	}
 
 	// Reload dengan data terbaru termasuk kegiatans
-	if err := kc.db.Preload("Kegiatans").First(&kategori, kategoriID).Error; err != nil {
+	if err := scopedDB(c, kc.db).Preload("Kegiatans").First(&kategori, kategoriID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Gagal memuat data kategori kegiatan yang diupdate",
 		})
@@ -271,7 +272,7 @@ func (kc *KategoriKegiatanController) DeleteKategoriKegiatan(c *gin.Context) {
 	}
 
 	var kategori models.KategoriKegiatan
-	if err := kc.db.Preload("Kegiatans").First(&kategori, kategoriID).Error; err != nil {
+	if err := kc.db.First(&kategori, kategoriID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Kategori kegiatan tidak ditemukan",
@@ -284,12 +285,22 @@ func (kc *KategoriKegiatanController) DeleteKategoriKegiatan(c *gin.Context) {
 		return
 	}
 
-	// Check jika kategori memiliki kegiatan
-	if len(kategori.Kegiatans) > 0 {
+	// Check jika kategori memiliki kegiatan. Kategori dipakai bersama seluruh RT,
+	// jadi hitungan mencakup semua RT tanpa mengembalikan data kegiatan RT lain.
+	// Kegiatan di trash ikut dihitung karena masih mereferensikan kategori (FK).
+	var totalKegiatan, kegiatanTrash int64
+	kc.db.Unscoped().Model(&models.Kegiatan{}).Where("kategori_kegiatan_id = ?", kategori.KategoriKegiatanID).Count(&totalKegiatan)
+	kc.db.Unscoped().Model(&models.Kegiatan{}).Where("kategori_kegiatan_id = ? AND deleted_at IS NOT NULL", kategori.KategoriKegiatanID).Count(&kegiatanTrash)
+	if totalKegiatan > 0 {
+		message := "Tidak dapat menghapus kategori yang memiliki kegiatan"
+		if totalKegiatan == kegiatanTrash {
+			message = "Tidak dapat menghapus kategori yang masih dipakai kegiatan di trash, hapus permanen kegiatan tersebut terlebih dahulu"
+		}
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Tidak dapat menghapus kategori yang memiliki kegiatan",
+			"error": message,
 			"details": gin.H{
-				"total_kegiatan": len(kategori.Kegiatans),
+				"total_kegiatan": totalKegiatan,
+				"kegiatan_trash": kegiatanTrash,
 			},
 		})
 		return
@@ -323,7 +334,7 @@ func (kc *KategoriKegiatanController) SearchKategoriKegiatan(c *gin.Context) {
 	var kategori []models.KategoriKegiatan
 
 	// Execute search query dengan GORM (AMAN - parameterized)
-	if err := kc.db.
+	if err := scopedDB(c, kc.db).
 		Where("kategori_kegiatan_nama LIKE ?", "%"+search+"%").
 		Preload("Kegiatans").
 		Limit(20).
@@ -350,12 +361,25 @@ func (kc *KategoriKegiatanController) GetKategoriKegiatanWithStats(c *gin.Contex
 
 	var results []KategoriWithStats
 
+	// Kategori dipakai bersama, kegiatan yang dihitung hanya milik wilayah user
+	join := "LEFT JOIN kegiatans ON kegiatans.kategori_kegiatan_id = kategori_kegiatans.kategori_kegiatan_id AND kegiatans.deleted_at IS NULL"
+	var joinArgs []interface{}
+	scope, _ := utils.TenantScopeFromContext(c.Request.Context())
+	if scope.RTID != 0 {
+		join += " AND kegiatans.rt_id = ?"
+		joinArgs = append(joinArgs, scope.RTID)
+	}
+	if scope.RWID != 0 {
+		join += " AND kegiatans.rt_id IN (SELECT rt_id FROM rts WHERE rw_id = ?)"
+		joinArgs = append(joinArgs, scope.RWID)
+	}
+
 	// Query aman menggunakan GORM Joins
-	if err := kc.db.
+	if err := scopedDB(c, kc.db).
 		Model(&models.KategoriKegiatan{}).
-		Select("kategori_kegiatan.*, COUNT(kegiatans.kegiatan_id) as total_kegiatan").
-		Joins("LEFT JOIN kegiatans ON kegiatans.kategori_kegiatan_id = kategori_kegiatan.kategori_kegiatan_id").
-		Group("kategori_kegiatan.kategori_kegiatan_id").
+		Select("kategori_kegiatans.*, COUNT(kegiatans.kegiatan_id) as total_kegiatan").
+		Joins(join, joinArgs...).
+		Group("kategori_kegiatans.kategori_kegiatan_id").
 		Order("total_kegiatan DESC").
 		Find(&results).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
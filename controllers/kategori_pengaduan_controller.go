@@ -0,0 +1,296 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"rt-management/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type KategoriPengaduanController struct {
+	db *gorm.DB
+}
+
+func NewKategoriPengaduanController(db *gorm.DB) *KategoriPengaduanController {
+	return &KategoriPengaduanController{db: db}
+}
+
+// SLA bawaan jika kategori dibuat tanpa target waktu
+const (
+	slaResponPengaduanDefault  = 24
+	slaSelesaiPengaduanDefault = 72
+)
+
+// Request structs
+type CreateKategoriPengaduanRequest struct {
+	KategoriPengaduanNama string `form:"kategori_pengaduan_nama" binding:"required"`
+	KategoriDeskripsi     string `form:"kategori_deskripsi"`
+	SLAResponJam          int    `form:"sla_respon_jam"`  // default 24 jam
+	SLASelesaiJam         int    `form:"sla_selesai_jam"` // default 72 jam
+}
+
+type UpdateKategoriPengaduanRequest struct {
+	KategoriPengaduanNama *string `form:"kategori_pengaduan_nama"`
+	KategoriDeskripsi     *string `form:"kategori_deskripsi"`
+	SLAResponJam          *int    `form:"sla_respon_jam"`
+	SLASelesaiJam         *int    `form:"sla_selesai_jam"`
+}
+
+// ✅ CREATE - Membuat kategori pengaduan baru
+func (kpc *KategoriPengaduanController) CreateKategoriPengaduan(c *gin.Context) {
+	var req CreateKategoriPengaduanRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	kategori := models.KategoriPengaduan{
+		KategoriPengaduanNama: strings.TrimSpace(req.KategoriPengaduanNama),
+		KategoriDeskripsi:     strings.TrimSpace(req.KategoriDeskripsi),
+		SLAResponJam:          req.SLAResponJam,
+		SLASelesaiJam:         req.SLASelesaiJam,
+	}
+	if kategori.SLAResponJam == 0 {
+		kategori.SLAResponJam = slaResponPengaduanDefault
+	}
+	if kategori.SLASelesaiJam == 0 {
+		kategori.SLASelesaiJam = slaSelesaiPengaduanDefault
+	}
+
+	if !kpc.validateKategori(c, &kategori) {
+		return
+	}
+
+	if err := scopedDB(c, kpc.db).Create(&kategori).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal membuat kategori pengaduan",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Kategori pengaduan berhasil dibuat",
+		"data":    kategori,
+	})
+}
+
+// ✅ READ - Mendapatkan semua kategori pengaduan
+func (kpc *KategoriPengaduanController) GetAllKategoriPengaduan(c *gin.Context) {
+	var kategori []models.KategoriPengaduan
+
+	query := scopedDB(c, kpc.db).Model(&models.KategoriPengaduan{})
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		query = query.Where("kategori_pengaduan_nama LIKE ?", "%"+search+"%")
+	}
+
+	if err := query.Order("kategori_pengaduan_nama ASC").Find(&kategori).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data kategori pengaduan",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": kategori,
+	})
+}
+
+// ✅ READ - Mendapatkan kategori pengaduan by ID
+func (kpc *KategoriPengaduanController) GetKategoriPengaduanByID(c *gin.Context) {
+	kategori, ok := kpc.findKategori(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": kategori,
+	})
+}
+
+// ✅ UPDATE - Mengupdate kategori pengaduan. Perubahan SLA hanya berlaku untuk pengaduan baru.
+func (kpc *KategoriPengaduanController) UpdateKategoriPengaduan(c *gin.Context) {
+	kategori, ok := kpc.findKategori(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateKategoriPengaduanRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.KategoriPengaduanNama != nil {
+		kategori.KategoriPengaduanNama = strings.TrimSpace(*req.KategoriPengaduanNama)
+	}
+	if req.KategoriDeskripsi != nil {
+		kategori.KategoriDeskripsi = strings.TrimSpace(*req.KategoriDeskripsi)
+	}
+	if req.SLAResponJam != nil {
+		kategori.SLAResponJam = *req.SLAResponJam
+	}
+	if req.SLASelesaiJam != nil {
+		kategori.SLASelesaiJam = *req.SLASelesaiJam
+	}
+
+	if !kpc.validateKategori(c, &kategori) {
+		return
+	}
+
+	if err := scopedDB(c, kpc.db).Model(&kategori).Updates(map[string]interface{}{
+		"kategori_pengaduan_nama": kategori.KategoriPengaduanNama,
+		"kategori_deskripsi":      kategori.KategoriDeskripsi,
+		"sla_respon_jam":          kategori.SLAResponJam,
+		"sla_selesai_jam":         kategori.SLASelesaiJam,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mengupdate kategori pengaduan",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Kategori pengaduan berhasil diupdate",
+		"data":    kategori,
+	})
+}
+
+// ✅ DELETE - Menghapus kategori pengaduan
+func (kpc *KategoriPengaduanController) DeleteKategoriPengaduan(c *gin.Context) {
+	kategori, ok := kpc.findKategori(c)
+	if !ok {
+		return
+	}
+
+	// Check jika kategori sudah dipakai pengaduan
+	var totalPengaduan int64
+	scopedDB(c, kpc.db).Model(&models.Pengaduan{}).
+		Where("kategori_pengaduan_id = ?", kategori.KategoriPengaduanID).
+		Count(&totalPengaduan)
+	if totalPengaduan > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tidak dapat menghapus kategori yang memiliki pengaduan",
+			"details": gin.H{
+				"total_pengaduan": totalPengaduan,
+			},
+		})
+		return
+	}
+
+	if err := scopedDB(c, kpc.db).Delete(&kategori).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menghapus kategori pengaduan",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Kategori pengaduan berhasil dihapus",
+	})
+}
+
+// ✅ GET - Dropdown kategori pengaduan
+func (kpc *KategoriPengaduanController) GetKategoriPengaduanDropdown(c *gin.Context) {
+	var kategori []struct {
+		KategoriPengaduanID   uint   `json:"kategori_pengaduan_id"`
+		KategoriPengaduanNama string `json:"kategori_pengaduan_nama"`
+		SLAResponJam          int    `json:"sla_respon_jam"`
+		SLASelesaiJam         int    `json:"sla_selesai_jam"`
+	}
+
+	if err := scopedDB(c, kpc.db).
+		Model(&models.KategoriPengaduan{}).
+		Select("kategori_pengaduan_id, kategori_pengaduan_nama, sla_respon_jam, sla_selesai_jam").
+		Order("kategori_pengaduan_nama ASC").
+		Find(&kategori).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data dropdown kategori pengaduan",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": kategori,
+	})
+}
+
+// ✅ Helper mencari kategori pengaduan dari parameter :id
+func (kpc *KategoriPengaduanController) findKategori(c *gin.Context) (models.KategoriPengaduan, bool) {
+	var kategori models.KategoriPengaduan
+
+	kategoriID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID kategori pengaduan tidak valid",
+		})
+		return kategori, false
+	}
+
+	if err := scopedDB(c, kpc.db).First(&kategori, kategoriID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Kategori pengaduan tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan kategori pengaduan",
+			})
+		}
+		return kategori, false
+	}
+
+	return kategori, true
+}
+
+// ✅ Helper validasi nama unik dan target SLA kategori
+func (kpc *KategoriPengaduanController) validateKategori(c *gin.Context, kategori *models.KategoriPengaduan) bool {
+	if len(kategori.KategoriPengaduanNama) < 2 || len(kategori.KategoriPengaduanNama) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Nama kategori pengaduan harus 2-100 karakter",
+		})
+		return false
+	}
+	if len(kategori.KategoriDeskripsi) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Deskripsi kategori maksimal 255 karakter",
+		})
+		return false
+	}
+	if kategori.SLAResponJam < 1 || kategori.SLASelesaiJam < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "SLA respon dan SLA selesai minimal 1 jam",
+		})
+		return false
+	}
+	if kategori.SLASelesaiJam < kategori.SLAResponJam {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "SLA selesai tidak boleh lebih singkat dari SLA respon",
+		})
+		return false
+	}
+
+	var existing models.KategoriPengaduan
+	if err := scopedDB(c, kpc.db).
+		Where("kategori_pengaduan_nama = ? AND kategori_pengaduan_id != ?", kategori.KategoriPengaduanNama, kategori.KategoriPengaduanID).
+		First(&existing).Error; err == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Kategori pengaduan dengan nama tersebut sudah ada",
+		})
+		return false
+	}
+
+	return true
+}
@@ -2,22 +2,26 @@ package controllers
 
 import (
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"rt-management/helper"
 	"rt-management/models"
+	"rt-management/realtime"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type KegiatanController struct {
-	db *gorm.DB
+	db  *gorm.DB
+	hub *realtime.Hub
 }
 
-func NewKegiatanController(db *gorm.DB) *KegiatanController {
-	return &KegiatanController{db: db}
+func NewKegiatanController(db *gorm.DB, hub *realtime.Hub) *KegiatanController {
+	return &KegiatanController{db: db, hub: hub}
 }
 
 // Request structs
@@ -28,6 +32,8 @@ type CreateKegiatanRequest struct {
 	KegiatanLokasi     string    `form:"kegiatan_lokasi"`
 	KegiatanPJ         string    `form:"kegiatan_pj"`
 	KegiatanDeskripsi  string    `form:"kegiatan_deskripsi"`
+	KegiatanWajib      bool      `form:"kegiatan_wajib"`
+	KegiatanRRule      string    `form:"kegiatan_rrule"` // contoh: FREQ=WEEKLY;BYDAY=FR
 }
 
 type UpdateKegiatanRequest struct {
@@ -37,6 +43,8 @@ type UpdateKegiatanRequest struct {
 	KegiatanLokasi     string    `form:"kegiatan_lokasi"`
 	KegiatanPJ         string    `form:"kegiatan_pj"`
 	KegiatanDeskripsi  string    `form:"kegiatan_deskripsi"`
+	KegiatanWajib      *bool     `form:"kegiatan_wajib"`
+	KegiatanRRule      *string   `form:"kegiatan_rrule"` // kosongkan untuk menghentikan pengulangan
 }
 
 // ✅ CREATE - Membuat kegiatan baru
@@ -80,9 +88,15 @@ func (kc *KegiatanController) CreateKegiatan(c *gin.Context) {
 		return
 	}
 
+	// Validasi aturan pengulangan untuk kegiatan rutin
+	rrule, ok := normalizeRRule(c, req.KegiatanRRule)
+	if !ok {
+		return
+	}
+
 	// Check if kategori kegiatan exists
 	var kategori models.KategoriKegiatan
-	if err := kc.db.First(&kategori, req.KategoriKegiatanID).Error; err != nil {
+	if err := scopedDB(c, kc.db).First(&kategori, req.KategoriKegiatanID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": "Kategori kegiatan tidak ditemukan",
@@ -97,7 +111,7 @@ func (kc *KegiatanController) CreateKegiatan(c *gin.Context) {
 
 	// Check if kegiatan dengan nama yang sama sudah ada dalam rentang waktu yang sama
 	var existingKegiatan models.Kegiatan
-	if err := kc.db.Where("kegiatan_nama = ? AND DATE(kegiatan_tanggal) = ?", 
+	if err := scopedDB(c, kc.db).Where("kegiatan_nama = ? AND DATE(kegiatan_tanggal) = ?", 
 		req.KegiatanNama, 
 		req.KegiatanTanggal.Format("2006-01-02")).
 		First(&existingKegiatan).Error; err == nil {
@@ -115,11 +129,14 @@ func (kc *KegiatanController) CreateKegiatan(c *gin.Context) {
 		KegiatanLokasi:     req.KegiatanLokasi,
 		KegiatanPJ:         req.KegiatanPJ,
 		KegiatanDeskripsi:  req.KegiatanDeskripsi,
+		KegiatanWajib:      req.KegiatanWajib,
+		KegiatanQRToken:    generateRandomToken(),
+		KegiatanRRule:      rrule,
 		CreatedAt:          time.Now(),
 		UpdatedAt:          time.Now(),
 	}
 
-	if err := kc.db.Create(&kegiatan).Error; err != nil {
+	if err := scopedDB(c, kc.db).Create(&kegiatan).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Gagal membuat kegiatan",
 			"details": err.Error(),
@@ -128,13 +145,15 @@ func (kc *KegiatanController) CreateKegiatan(c *gin.Context) {
 	}
 
 	// Reload dengan data kategori
-	if err := kc.db.Preload("KategoriKegiatan").First(&kegiatan, kegiatan.KegiatanID).Error; err != nil {
+	if err := scopedDB(c, kc.db).Preload("KategoriKegiatan").First(&kegiatan, kegiatan.KegiatanID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Gagal memuat data kegiatan yang dibuat",
 		})
 		return
 	}
 
+	kc.publikasikanKegiatan("kegiatan.dibuat", kegiatan)
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Kegiatan berhasil dibuat",
 		"data":    kegiatan,
@@ -157,7 +176,8 @@ func (kc *KegiatanController) GetAllKegiatan(c *gin.Context) {
 	search := c.Query("search")
 
 	// Build query dengan GORM (AMAN - parameterized queries)
-	query := kc.db.Model(&models.Kegiatan{}).Preload("KategoriKegiatan")
+	query := scopedDB(c, kc.db).Model(&models.Kegiatan{}).Preload("KategoriKegiatan").
+		Where("kegiatan_dibatalkan = ?", false)
 
 	// Apply search filter
 	if search != "" {
@@ -226,7 +246,7 @@ func (kc *KegiatanController) GetKegiatanByID(c *gin.Context) {
 	}
 
 	var kegiatan models.Kegiatan
-	if err := kc.db.Preload("KategoriKegiatan").First(&kegiatan, kegiatanID).Error; err != nil {
+	if err := scopedDB(c, kc.db).Preload("KategoriKegiatan").First(&kegiatan, kegiatanID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Kegiatan tidak ditemukan",
@@ -258,7 +278,7 @@ func (kc *KegiatanController) UpdateKegiatan(c *gin.Context) {
 	}
 
 	var kegiatan models.Kegiatan
-	if err := kc.db.First(&kegiatan, kegiatanID).Error; err != nil {
+	if err := scopedDB(c, kc.db).First(&kegiatan, kegiatanID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Kegiatan tidak ditemukan",
@@ -310,7 +330,7 @@ func (kc *KegiatanController) UpdateKegiatan(c *gin.Context) {
 		}
 
 		var existingKegiatan models.Kegiatan
-		if err := kc.db.Where("kegiatan_nama = ? AND DATE(kegiatan_tanggal) = ? AND kegiatan_id != ?", 
+		if err := scopedDB(c, kc.db).Where("kegiatan_nama = ? AND DATE(kegiatan_tanggal) = ? AND kegiatan_id != ?", 
 			req.KegiatanNama, 
 			tanggalUntukValidasi.Format("2006-01-02"),
 			kegiatanID).
@@ -335,7 +355,7 @@ func (kc *KegiatanController) UpdateKegiatan(c *gin.Context) {
 	// Validasi kategori kegiatan jika diupdate
 	if req.KategoriKegiatanID != 0 {
 		var kategori models.KategoriKegiatan
-		if err := kc.db.First(&kategori, req.KategoriKegiatanID).Error; err != nil {
+		if err := scopedDB(c, kc.db).First(&kategori, req.KategoriKegiatanID).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				c.JSON(http.StatusBadRequest, gin.H{
 					"error": "Kategori kegiatan tidak ditemukan",
@@ -370,10 +390,66 @@ func (kc *KegiatanController) UpdateKegiatan(c *gin.Context) {
 	if req.KegiatanDeskripsi != "" {
 		updates["kegiatan_deskripsi"] = req.KegiatanDeskripsi
 	}
+	if req.KegiatanWajib != nil {
+		updates["kegiatan_wajib"] = *req.KegiatanWajib
+	}
+	if req.KegiatanRRule != nil {
+		if kegiatan.KegiatanIndukID != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Jadwal turunan dari kegiatan rutin tidak dapat diberi aturan pengulangan",
+			})
+			return
+		}
+		rrule, ok := normalizeRRule(c, *req.KegiatanRRule)
+		if !ok {
+			return
+		}
+		updates["kegiatan_rrule"] = rrule
+	}
 	
+	updates["kegiatan_sequence"] = gorm.Expr("kegiatan_sequence + 1")
 	updates["updated_at"] = time.Now()
 
-	if err := kc.db.Model(&kegiatan).Updates(updates).Error; err != nil {
+	// Nilai seri sebelum diubah disalin lebih dulu, karena Updates menulis nilai baru ke struct kegiatan
+	var nilaiSeri map[string]interface{}
+	if kegiatan.KegiatanRRule != "" {
+		nilaiSeri = map[string]interface{}{
+			"kegiatan_nama":        kegiatan.KegiatanNama,
+			"kategori_kegiatan_id": kegiatan.KategoriKegiatanID,
+			"kegiatan_lokasi":      kegiatan.KegiatanLokasi,
+			"kegiatan_pj":          kegiatan.KegiatanPJ,
+			"kegiatan_deskripsi":   kegiatan.KegiatanDeskripsi,
+			"kegiatan_wajib":       kegiatan.KegiatanWajib,
+		}
+	}
+
+	// Seri dan jadwal turunannya diubah dalam satu transaksi agar tidak tertinggal sebagian
+	err = scopedDB(c, kc.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&kegiatan).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		// Jadwal turunan seri yang belum lewat (mis. dibuat saat RSVP) ikut berubah,
+		// kecuali kolom yang memang sudah diubah khusus untuk jadwal tersebut
+		for kolom, lama := range nilaiSeri {
+			baru, ok := updates[kolom]
+			if !ok {
+				continue
+			}
+			if err := tx.Model(&models.Kegiatan{}).
+				Where("kegiatan_induk_id = ? AND kegiatan_tanggal_asli >= ?", kegiatan.KegiatanID, startOfDay(time.Now())).
+				Where(kolom+" = ?", lama).
+				Updates(map[string]interface{}{
+					kolom:               baru,
+					"kegiatan_sequence": gorm.Expr("kegiatan_sequence + 1"),
+					"updated_at":        time.Now(),
+				}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Gagal mengupdate kegiatan",
 			"details": err.Error(),
@@ -382,20 +458,22 @@ func (kc *KegiatanController) UpdateKegiatan(c *gin.Context) {
 	}
 
 	// Reload dengan data terbaru termasuk kategori
-	if err := kc.db.Preload("KategoriKegiatan").First(&kegiatan, kegiatanID).Error; err != nil {
+	if err := scopedDB(c, kc.db).Preload("KategoriKegiatan").First(&kegiatan, kegiatanID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Gagal memuat data kegiatan yang diupdate",
 		})
 		return
 	}
 
+	kc.publikasikanKegiatan("kegiatan.diubah", kegiatan)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Kegiatan berhasil diupdate",
 		"data":    kegiatan,
 	})
 }
 
-// ✅ DELETE - Menghapus kegiatan
+// ✅ DELETE - Memindahkan kegiatan ke trash (soft delete)
 func (kc *KegiatanController) DeleteKegiatan(c *gin.Context) {
 	id := c.Param("id")
 
@@ -409,7 +487,7 @@ func (kc *KegiatanController) DeleteKegiatan(c *gin.Context) {
 	}
 
 	var kegiatan models.Kegiatan
-	if err := kc.db.First(&kegiatan, kegiatanID).Error; err != nil {
+	if err := scopedDB(c, kc.db).First(&kegiatan, kegiatanID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Kegiatan tidak ditemukan",
@@ -422,8 +500,14 @@ func (kc *KegiatanController) DeleteKegiatan(c *gin.Context) {
 		return
 	}
 
-	// Delete menggunakan GORM Delete (AMAN)
-	if err := kc.db.Delete(&kegiatan).Error; err != nil {
+	// Delete menggunakan GORM Delete (AMAN), jadwal turunan kegiatan rutin ikut dihapus
+	err = scopedDB(c, kc.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("kegiatan_induk_id = ?", kegiatan.KegiatanID).Delete(&models.Kegiatan{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&kegiatan).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Gagal menghapus kegiatan",
 			"details": err.Error(),
@@ -431,30 +515,122 @@ func (kc *KegiatanController) DeleteKegiatan(c *gin.Context) {
 		return
 	}
 
+	kc.publikasikanKegiatan("kegiatan.dihapus", kegiatan)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Kegiatan berhasil dihapus",
 	})
 }
 
-// ✅ GET - Mendapatkan kegiatan mendatang
-func (kc *KegiatanController) GetKegiatanMendatang(c *gin.Context) {
+// ✅ GET - Mendapatkan kegiatan yang ada di trash
+func (kc *KegiatanController) GetTrashKegiatan(c *gin.Context) {
 	var kegiatan []models.Kegiatan
 
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
-
-	// Query kegiatan dengan tanggal >= hari ini (AMAN)
-	if err := kc.db.
+	if err := scopedDB(c, kc.db).Unscoped().
 		Preload("KategoriKegiatan").
-		Where("kegiatan_tanggal >= ?", time.Now().Format("2006-01-02")).
-		Order("kegiatan_tanggal ASC").
-		Limit(limit).
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
 		Find(&kegiatan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data trash kegiatan",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  kegiatan,
+		"total": len(kegiatan),
+	})
+}
+
+// ✅ PUT - Mengembalikan kegiatan dari trash
+func (kc *KegiatanController) RestoreKegiatan(c *gin.Context) {
+	id := c.Param("id")
+
+	// Validasi ID (AMAN - dikonversi ke uint)
+	kegiatanID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID kegiatan tidak valid",
+		})
+		return
+	}
+
+	var kegiatan models.Kegiatan
+	if err := scopedDB(c, kc.db).Unscoped().Where("deleted_at IS NOT NULL").First(&kegiatan, kegiatanID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Kegiatan tidak ditemukan di trash",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan kegiatan",
+			})
+		}
+		return
+	}
+
+	// Nama kegiatan harus unik pada tanggal yang sama
+	var existingKegiatan models.Kegiatan
+	if err := scopedDB(c, kc.db).Where("kegiatan_nama = ? AND DATE(kegiatan_tanggal) = ? AND kegiatan_id != ?",
+		kegiatan.KegiatanNama,
+		kegiatan.KegiatanTanggal.Format("2006-01-02"),
+		kegiatanID).
+		First(&existingKegiatan).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Kegiatan dengan nama tersebut sudah ada pada tanggal yang sama",
+		})
+		return
+	}
+
+	// Jadwal turunan yang terhapus bersama seri ikut dipulihkan
+	err = scopedDB(c, kc.db).Unscoped().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Kegiatan{}).
+			Where("kegiatan_induk_id = ? AND deleted_at >= ?", kegiatan.KegiatanID, kegiatan.DeletedAt.Time).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return tx.Model(&kegiatan).Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal memulihkan kegiatan",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	kc.publikasikanKegiatan("kegiatan.dipulihkan", kegiatan)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Kegiatan berhasil dipulihkan",
+		"data":    kegiatan,
+	})
+}
+
+// ✅ GET - Mendapatkan kegiatan mendatang (termasuk jadwal kegiatan rutin)
+func (kc *KegiatanController) GetKegiatanMendatang(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	hari, err := strconv.Atoi(c.DefaultQuery("hari", "90"))
+	if err != nil || hari < 1 || hari > 366 {
+		hari = 90
+	}
+
+	dari := startOfDay(time.Now())
+	sampai := endOfDay(dari.AddDate(0, 0, hari))
+
+	kegiatan, err := kc.jadwalKegiatan(c, dari, sampai)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Gagal mengambil data kegiatan mendatang",
 		})
 		return
 	}
 
+	if limit > 0 && len(kegiatan) > limit {
+		kegiatan = kegiatan[:limit]
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"data":  kegiatan,
 		"total": len(kegiatan),
@@ -484,17 +660,12 @@ func (kc *KegiatanController) GetKegiatanByBulanTahun(c *gin.Context) {
 	}
 
 	// Buat tanggal awal dan akhir bulan
-	awalBulan := time.Date(tahunInt, time.Month(bulanInt), 1, 0, 0, 0, 0, time.UTC)
-	akhirBulan := awalBulan.AddDate(0, 1, -1)
-
-	var kegiatan []models.Kegiatan
+	awalBulan := time.Date(tahunInt, time.Month(bulanInt), 1, 0, 0, 0, 0, time.Local)
+	akhirBulan := endOfDay(awalBulan.AddDate(0, 1, -1))
 
-	// Query kegiatan by bulan dan tahun (AMAN - parameterized)
-	if err := kc.db.
-		Preload("KategoriKegiatan").
-		Where("kegiatan_tanggal BETWEEN ? AND ?", awalBulan.Format("2006-01-02"), akhirBulan.Format("2006-01-02")).
-		Order("kegiatan_tanggal ASC").
-		Find(&kegiatan).Error; err != nil {
+	// Kegiatan rutin diekspansi menjadi jadwal-jadwal di bulan tersebut
+	kegiatan, err := kc.jadwalKegiatan(c, awalBulan, akhirBulan)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Gagal mengambil data kegiatan",
 		})
@@ -532,7 +703,7 @@ func (kc *KegiatanController) GetStatistikKegiatan(c *gin.Context) {
 		akhirBulan := awalBulan.AddDate(0, 1, -1)
 
 		// Hitung total kegiatan per bulan (AMAN - parameterized query)
-		kc.db.Model(&models.Kegiatan{}).
+		scopedDB(c, kc.db).Model(&models.Kegiatan{}).
 			Where("kegiatan_tanggal BETWEEN ? AND ?", awalBulan, akhirBulan).
 			Count(&total)
 
@@ -563,7 +734,7 @@ func (kc *KegiatanController) SearchKegiatan(c *gin.Context) {
 	var kegiatan []models.Kegiatan
 
 	// Execute search query dengan GORM (AMAN - parameterized)
-	if err := kc.db.
+	if err := scopedDB(c, kc.db).
 		Preload("KategoriKegiatan").
 		Where("kegiatan_nama LIKE ? OR kegiatan_lokasi LIKE ? OR kegiatan_pj LIKE ? OR kegiatan_deskripsi LIKE ?",
 			"%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%").
@@ -581,4 +752,410 @@ func (kc *KegiatanController) SearchKegiatan(c *gin.Context) {
 		"total":        len(kegiatan),
 		"search_query": search,
 	})
-}
\ No newline at end of file
+}
+
+// KegiatanJadwal adalah satu jadwal pada kalender kegiatan. Untuk kegiatan rutin, data seri
+// disalin dengan tanggal jadwal tersebut dan SeriID menunjuk ke kegiatan induknya.
+type KegiatanJadwal struct {
+	models.Kegiatan
+	SeriID   *uint `json:"seri_id"`
+	Berulang bool  `json:"berulang"`
+}
+
+type UpdateJadwalKegiatanRequest struct {
+	TanggalAsli       string    `form:"tanggal_asli" binding:"required"` // YYYY-MM-DD jadwal yang diubah
+	KegiatanNama      string    `form:"kegiatan_nama"`
+	KegiatanTanggal   time.Time `form:"kegiatan_tanggal"`
+	KegiatanLokasi    string    `form:"kegiatan_lokasi"`
+	KegiatanPJ        string    `form:"kegiatan_pj"`
+	KegiatanDeskripsi string    `form:"kegiatan_deskripsi"`
+}
+
+// ✅ GET - Daftar jadwal satu kegiatan rutin (termasuk jadwal yang diubah/dibatalkan)
+func (kc *KegiatanController) GetJadwalKegiatan(c *gin.Context) {
+	seri, ok := kc.findSeriKegiatan(c)
+	if !ok {
+		return
+	}
+
+	dari := startOfDay(time.Now())
+	sampai := endOfDay(dari.AddDate(0, 3, 0))
+	if value := c.Query("tanggal_from"); value != "" {
+		if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+			dari = t
+		}
+	}
+	if value := c.Query("tanggal_to"); value != "" {
+		if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+			sampai = endOfDay(t)
+		}
+	}
+
+	var turunan []models.Kegiatan
+	if err := scopedDB(c, kc.db).
+		Preload("KategoriKegiatan").
+		Where("kegiatan_induk_id = ?", seri.KegiatanID).
+		Find(&turunan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil jadwal kegiatan",
+		})
+		return
+	}
+
+	jadwal := expandSeriKegiatan(seri, tanggalTurunan(turunan), dari, sampai)
+	for _, t := range turunan {
+		tanggalAsli := t.KegiatanTanggal
+		if t.KegiatanTanggalAsli != nil {
+			tanggalAsli = *t.KegiatanTanggalAsli
+		}
+		if inRange(t.KegiatanTanggal, dari, sampai) || inRange(tanggalAsli, dari, sampai) {
+			jadwal = append(jadwal, KegiatanJadwal{Kegiatan: t, SeriID: t.KegiatanIndukID, Berulang: true})
+		}
+	}
+	sortJadwal(jadwal)
+
+	c.JSON(http.StatusOK, gin.H{
+		"seri":  seri,
+		"data":  jadwal,
+		"total": len(jadwal),
+	})
+}
+
+// ✅ PUT - Mengubah satu jadwal kegiatan rutin tanpa mengubah seluruh seri.
+// Jadwal disimpan sebagai kegiatan turunan sehingga juga bisa dipakai untuk absensi.
+func (kc *KegiatanController) UpdateJadwalKegiatan(c *gin.Context) {
+	seri, ok := kc.findSeriKegiatan(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateJadwalKegiatanRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tanggalAsli, ok := kc.findTanggalJadwal(c, seri, req.TanggalAsli)
+	if !ok {
+		return
+	}
+
+	req.KegiatanNama = strings.TrimSpace(req.KegiatanNama)
+	if req.KegiatanNama != "" && (len(req.KegiatanNama) < 2 || len(req.KegiatanNama) > 200) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Nama kegiatan harus 2-200 karakter",
+		})
+		return
+	}
+
+	jadwal, err := kc.findOrNewJadwalTurunan(c, seri, tanggalAsli)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal memuat jadwal kegiatan",
+		})
+		return
+	}
+
+	if req.KegiatanNama != "" {
+		jadwal.KegiatanNama = req.KegiatanNama
+	}
+	if !req.KegiatanTanggal.IsZero() {
+		jadwal.KegiatanTanggal = req.KegiatanTanggal
+	}
+	if lokasi := strings.TrimSpace(req.KegiatanLokasi); lokasi != "" {
+		jadwal.KegiatanLokasi = lokasi
+	}
+	if pj := strings.TrimSpace(req.KegiatanPJ); pj != "" {
+		jadwal.KegiatanPJ = pj
+	}
+	if deskripsi := strings.TrimSpace(req.KegiatanDeskripsi); deskripsi != "" {
+		jadwal.KegiatanDeskripsi = deskripsi
+	}
+	jadwal.KegiatanDibatalkan = false
+	jadwal.KegiatanSequence++
+	jadwal.UpdatedAt = time.Now()
+
+	if err := scopedDB(c, kc.db).Save(&jadwal).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mengupdate jadwal kegiatan",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	kc.publikasikanKegiatan("kegiatan.diubah", jadwal)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Jadwal kegiatan berhasil diupdate",
+		"data":    KegiatanJadwal{Kegiatan: jadwal, SeriID: jadwal.KegiatanIndukID, Berulang: true},
+	})
+}
+
+// ✅ DELETE - Membatalkan satu jadwal kegiatan rutin (seri tetap berjalan)
+func (kc *KegiatanController) BatalkanJadwalKegiatan(c *gin.Context) {
+	seri, ok := kc.findSeriKegiatan(c)
+	if !ok {
+		return
+	}
+
+	tanggalAsli, ok := kc.findTanggalJadwal(c, seri, c.Query("tanggal_asli"))
+	if !ok {
+		return
+	}
+
+	jadwal, err := kc.findOrNewJadwalTurunan(c, seri, tanggalAsli)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal memuat jadwal kegiatan",
+		})
+		return
+	}
+
+	jadwal.KegiatanDibatalkan = true
+	jadwal.KegiatanSequence++
+	jadwal.UpdatedAt = time.Now()
+
+	if err := scopedDB(c, kc.db).Save(&jadwal).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal membatalkan jadwal kegiatan",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	kc.publikasikanKegiatan("kegiatan.dibatalkan", jadwal)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Jadwal kegiatan berhasil dibatalkan",
+		"data":    KegiatanJadwal{Kegiatan: jadwal, SeriID: jadwal.KegiatanIndukID, Berulang: true},
+	})
+}
+
+// ✅ Helper menyusun kalender kegiatan dalam rentang waktu, kegiatan rutin diekspansi per jadwal
+func (kc *KegiatanController) jadwalKegiatan(c *gin.Context, dari, sampai time.Time) ([]KegiatanJadwal, error) {
+	return jadwalKegiatanDB(scopedDB(c, kc.db), dari, sampai)
+}
+
+// jadwalKegiatanDB sama dengan jadwalKegiatan untuk koneksi db apa pun, dipakai juga oleh
+// penjadwal pengingat yang berjalan tanpa request
+func jadwalKegiatanDB(db *gorm.DB, dari, sampai time.Time) ([]KegiatanJadwal, error) {
+	// Kegiatan tunggal dan jadwal turunan kegiatan rutin
+	var biasa []models.Kegiatan
+	if err := db.
+		Preload("KategoriKegiatan").
+		Where("kegiatan_rrule = ? AND kegiatan_dibatalkan = ?", "", false).
+		Where("kegiatan_tanggal BETWEEN ? AND ?", dari, sampai).
+		Find(&biasa).Error; err != nil {
+		return nil, err
+	}
+
+	jadwal := make([]KegiatanJadwal, 0, len(biasa))
+	for _, k := range biasa {
+		jadwal = append(jadwal, KegiatanJadwal{Kegiatan: k, SeriID: k.KegiatanIndukID, Berulang: k.KegiatanIndukID != nil})
+	}
+
+	// Seri kegiatan rutin yang sudah dimulai sebelum akhir rentang
+	var seri []models.Kegiatan
+	if err := db.
+		Preload("KategoriKegiatan").
+		Where("kegiatan_rrule <> ? AND kegiatan_tanggal <= ?", "", sampai).
+		Find(&seri).Error; err != nil {
+		return nil, err
+	}
+	if len(seri) == 0 {
+		sortJadwal(jadwal)
+		return jadwal, nil
+	}
+
+	seriIDs := make([]uint, 0, len(seri))
+	for _, s := range seri {
+		seriIDs = append(seriIDs, s.KegiatanID)
+	}
+
+	var turunan []models.Kegiatan
+	if err := db.
+		Select("kegiatan_id, kegiatan_induk_id, kegiatan_tanggal, kegiatan_tanggal_asli").
+		Where("kegiatan_induk_id IN ?", seriIDs).
+		Find(&turunan).Error; err != nil {
+		return nil, err
+	}
+
+	turunanPerSeri := make(map[uint][]models.Kegiatan)
+	for _, t := range turunan {
+		turunanPerSeri[*t.KegiatanIndukID] = append(turunanPerSeri[*t.KegiatanIndukID], t)
+	}
+
+	for _, s := range seri {
+		jadwal = append(jadwal, expandSeriKegiatan(s, tanggalTurunan(turunanPerSeri[s.KegiatanID]), dari, sampai)...)
+	}
+
+	sortJadwal(jadwal)
+	return jadwal, nil
+}
+
+// publikasikanKegiatan mendorong event perubahan kegiatan ke warga di RT kegiatan tersebut
+func (kc *KegiatanController) publikasikanKegiatan(jenis string, kegiatan models.Kegiatan) {
+	kc.hub.Publikasikan(realtime.Event{
+		Topik: realtime.TopikKegiatan,
+		Jenis: jenis,
+		Data: gin.H{
+			"kegiatan_id":       kegiatan.KegiatanID,
+			"kegiatan_nama":     kegiatan.KegiatanNama,
+			"kegiatan_tanggal":  kegiatan.KegiatanTanggal,
+			"kegiatan_induk_id": kegiatan.KegiatanIndukID,
+		},
+		RTID: kegiatan.RTID,
+	})
+}
+
+// ✅ Helper mengambil kegiatan rutin (seri) dari parameter :id
+func (kc *KegiatanController) findSeriKegiatan(c *gin.Context) (models.Kegiatan, bool) {
+	var seri models.Kegiatan
+
+	kegiatanID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID kegiatan tidak valid",
+		})
+		return seri, false
+	}
+
+	if err := scopedDB(c, kc.db).Preload("KategoriKegiatan").First(&seri, kegiatanID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Kegiatan tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan kegiatan",
+			})
+		}
+		return seri, false
+	}
+
+	if seri.KegiatanRRule == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Kegiatan ini bukan kegiatan rutin",
+		})
+		return seri, false
+	}
+
+	return seri, true
+}
+
+// ✅ Helper memastikan tanggal_asli adalah salah satu jadwal seri, mengembalikan waktu jadwalnya
+func (kc *KegiatanController) findTanggalJadwal(c *gin.Context, seri models.Kegiatan, value string) (time.Time, bool) {
+	tanggal, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(value), time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Format tanggal_asli harus YYYY-MM-DD",
+		})
+		return tanggal, false
+	}
+
+	rule, err := helper.ParseRRule(seri.KegiatanRRule)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Aturan pengulangan kegiatan tidak valid",
+		})
+		return tanggal, false
+	}
+
+	occurrences := rule.Occurrences(seri.KegiatanTanggal, tanggal, endOfDay(tanggal))
+	if len(occurrences) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tidak ada jadwal kegiatan rutin pada tanggal tersebut",
+		})
+		return tanggal, false
+	}
+
+	return occurrences[0], true
+}
+
+// ✅ Helper mengambil jadwal turunan yang sudah ada, atau menyalin data seri untuk jadwal baru
+func (kc *KegiatanController) findOrNewJadwalTurunan(c *gin.Context, seri models.Kegiatan, tanggalAsli time.Time) (models.Kegiatan, error) {
+	var jadwal models.Kegiatan
+	err := scopedDB(c, kc.db).
+		Where("kegiatan_induk_id = ? AND DATE(kegiatan_tanggal_asli) = ?", seri.KegiatanID, tanggalAsli.Format("2006-01-02")).
+		First(&jadwal).Error
+	if err != gorm.ErrRecordNotFound {
+		return jadwal, err
+	}
+
+	return models.Kegiatan{
+		KegiatanNama:        seri.KegiatanNama,
+		KategoriKegiatanID:  seri.KategoriKegiatanID,
+		KegiatanTanggal:     tanggalAsli,
+		KegiatanLokasi:      seri.KegiatanLokasi,
+		KegiatanPJ:          seri.KegiatanPJ,
+		KegiatanDeskripsi:   seri.KegiatanDeskripsi,
+		KegiatanWajib:       seri.KegiatanWajib,
+		KegiatanQRToken:     generateRandomToken(),
+		KegiatanIndukID:     &seri.KegiatanID,
+		KegiatanTanggalAsli: &tanggalAsli,
+		KegiatanSequence:    seri.KegiatanSequence,
+		RTID:                seri.RTID,
+		CreatedAt:           time.Now(),
+	}, nil
+}
+
+// ✅ Helper validasi dan normalisasi aturan RRULE (kosong berarti kegiatan tidak berulang)
+func normalizeRRule(c *gin.Context, value string) (string, bool) {
+	if strings.TrimSpace(value) == "" {
+		return "", true
+	}
+
+	rule, err := helper.ParseRRule(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Aturan pengulangan kegiatan tidak valid",
+			"details": err.Error(),
+		})
+		return "", false
+	}
+
+	return rule.String(), true
+}
+
+// expandSeriKegiatan menghasilkan jadwal virtual sebuah seri dalam rentang waktu,
+// kecuali tanggal yang sudah memiliki jadwal turunan (diubah atau dibatalkan)
+func expandSeriKegiatan(seri models.Kegiatan, tanggalTurunan map[string]bool, dari, sampai time.Time) []KegiatanJadwal {
+	rule, err := helper.ParseRRule(seri.KegiatanRRule)
+	if err != nil {
+		return nil
+	}
+
+	var jadwal []KegiatanJadwal
+	for _, t := range rule.Occurrences(seri.KegiatanTanggal, dari, sampai) {
+		if tanggalTurunan[t.Format("2006-01-02")] {
+			continue
+		}
+		k := seri
+		k.KegiatanTanggal = t
+		jadwal = append(jadwal, KegiatanJadwal{Kegiatan: k, SeriID: &seri.KegiatanID, Berulang: true})
+	}
+	return jadwal
+}
+
+func tanggalTurunan(turunan []models.Kegiatan) map[string]bool {
+	result := make(map[string]bool, len(turunan))
+	for _, t := range turunan {
+		if t.KegiatanTanggalAsli != nil {
+			result[t.KegiatanTanggalAsli.Format("2006-01-02")] = true
+		}
+	}
+	return result
+}
+
+func sortJadwal(jadwal []KegiatanJadwal) {
+	sort.SliceStable(jadwal, func(i, j int) bool {
+		return jadwal[i].KegiatanTanggal.Before(jadwal[j].KegiatanTanggal)
+	})
+}
+
+func inRange(t, dari, sampai time.Time) bool {
+	return !t.Before(dari) && !t.After(sampai)
+}
@@ -0,0 +1,1023 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"rt-management/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Absensi via QR dibuka 2 jam sebelum kegiatan sampai akhir hari kegiatan
+const absenQRDibukaSebelum = 2 * time.Hour
+
+type KehadiranKegiatanController struct {
+	db *gorm.DB
+}
+
+func NewKehadiranKegiatanController(db *gorm.DB) *KehadiranKegiatanController {
+	return &KehadiranKegiatanController{db: db}
+}
+
+// Request structs
+type RSVPKegiatanRequest struct {
+	KeluargaID  uint   `form:"keluarga_id"` // hanya dipakai pengurus, warga memakai keluarga akunnya
+	WargaID     uint   `form:"warga_id"`
+	RSVPStatus  string `form:"rsvp_status" binding:"required"`
+	JumlahHadir int    `form:"jumlah_hadir"`
+	Keterangan  string `form:"keterangan"`
+}
+
+type CatatKehadiranRequest struct {
+	KeluargaID  uint   `form:"keluarga_id" binding:"required"`
+	WargaID     uint   `form:"warga_id"`
+	Hadir       *bool  `form:"hadir"`
+	JumlahHadir int    `form:"jumlah_hadir"`
+	Keterangan  string `form:"keterangan"`
+}
+
+type AbsenQRRequest struct {
+	Token       string `form:"token" binding:"required"`
+	WargaNIK    string `form:"warga_nik"` // hanya dipakai pengurus, warga memakai data akunnya
+	JumlahHadir int    `form:"jumlah_hadir"`
+}
+
+// Ringkasan kehadiran satu kegiatan
+type RingkasanKehadiran struct {
+	TotalKeluarga       int64   `json:"total_keluarga"`
+	RSVPHadir           int64   `json:"rsvp_hadir"`
+	RSVPTidak           int64   `json:"rsvp_tidak"`
+	RSVPMungkin         int64   `json:"rsvp_mungkin"`
+	JumlahKeluargaHadir int64   `json:"jumlah_keluarga_hadir"`
+	JumlahOrangHadir    int64   `json:"jumlah_orang_hadir"`
+	PersentaseKehadiran float64 `json:"persentase_kehadiran"`
+}
+
+// Baris laporan kehadiran per kegiatan
+type LaporanKehadiranKegiatan struct {
+	KegiatanID          uint      `json:"kegiatan_id"`
+	SeriID              *uint     `json:"seri_id"` // terisi untuk jadwal kegiatan rutin
+	KegiatanNama        string    `json:"kegiatan_nama"`
+	KegiatanTanggal     time.Time `json:"kegiatan_tanggal"`
+	KegiatanWajib       bool      `json:"kegiatan_wajib"`
+	RTID                *uint     `json:"rt_id"`
+	RSVPHadir           int64     `json:"rsvp_hadir"`
+	JumlahKeluargaHadir int64     `json:"jumlah_keluarga_hadir"`
+	JumlahOrangHadir    int64     `json:"jumlah_orang_hadir"`
+	TotalKeluarga       int64     `json:"total_keluarga"`
+	PersentaseKehadiran float64   `json:"persentase_kehadiran"`
+}
+
+// Baris laporan kehadiran per keluarga dalam satu periode
+type LaporanKehadiranKeluarga struct {
+	KeluargaID          uint    `json:"keluarga_id"`
+	KeluargaNama        string  `json:"keluarga_nama"`
+	TotalKegiatan       int     `json:"total_kegiatan"`
+	JumlahHadir         int     `json:"jumlah_hadir"`
+	TotalKegiatanWajib  int     `json:"total_kegiatan_wajib"`
+	JumlahHadirWajib    int     `json:"jumlah_hadir_wajib"`
+	PersentaseKehadiran float64 `json:"persentase_kehadiran"`
+}
+
+// Keluarga yang tidak hadir pada kegiatan wajib
+type KeluargaAbsenWajib struct {
+	KeluargaID   uint                `json:"keluarga_id"`
+	KeluargaNama string              `json:"keluarga_nama"`
+	JumlahAbsen  int                 `json:"jumlah_absen"`
+	Kegiatan     []KegiatanRingkasan `json:"kegiatan"`
+}
+
+type KegiatanRingkasan struct {
+	KegiatanID      uint      `json:"kegiatan_id"`
+	KegiatanNama    string    `json:"kegiatan_nama"`
+	KegiatanTanggal time.Time `json:"kegiatan_tanggal"`
+}
+
+// ✅ POST - RSVP keluarga untuk sebuah kegiatan (hadir/tidak/mungkin)
+func (hc *KehadiranKegiatanController) RSVPKegiatan(c *gin.Context) {
+	kegiatan, ok := hc.findKegiatan(c)
+	if !ok {
+		return
+	}
+
+	var req RSVPKegiatanRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	req.RSVPStatus = strings.ToLower(strings.TrimSpace(req.RSVPStatus))
+	if !isValidRSVPStatus(req.RSVPStatus) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Status RSVP harus hadir, tidak, atau mungkin",
+		})
+		return
+	}
+	if req.JumlahHadir < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Jumlah hadir tidak boleh negatif",
+		})
+		return
+	}
+
+	if kegiatan.KegiatanTanggal.Before(startOfDay(time.Now())) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "RSVP tidak dapat diubah untuk kegiatan yang sudah lewat",
+		})
+		return
+	}
+
+	// Warga hanya boleh RSVP atas nama keluarganya sendiri
+	if !isPengurusKehadiran(c) {
+		warga, ok := hc.wargaLogin(c)
+		if !ok {
+			return
+		}
+		req.KeluargaID = warga.KeluargaID
+		req.WargaID = warga.WargaID
+	} else if req.KeluargaID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Keluarga wajib diisi",
+		})
+		return
+	}
+
+	if !hc.validateKeluargaWarga(c, kegiatan, req.KeluargaID, req.WargaID) {
+		return
+	}
+
+	kehadiran, err := hc.findOrNewKehadiran(c, kegiatan, req.KeluargaID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal memuat data kehadiran",
+		})
+		return
+	}
+
+	now := time.Now()
+	kehadiran.RSVPStatus = req.RSVPStatus
+	kehadiran.RSVPWaktu = &now
+	if req.WargaID != 0 {
+		kehadiran.WargaID = &req.WargaID
+	}
+	if !kehadiran.Hadir {
+		kehadiran.JumlahHadir = req.JumlahHadir
+	}
+	if keterangan := strings.TrimSpace(req.Keterangan); keterangan != "" {
+		kehadiran.Keterangan = keterangan
+	}
+
+	if err := scopedDB(c, hc.db).Save(&kehadiran).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menyimpan RSVP",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "RSVP berhasil disimpan",
+		"data":    kehadiran,
+	})
+}
+
+// ✅ POST - PJ mencatat kehadiran keluarga secara manual
+func (hc *KehadiranKegiatanController) CatatKehadiran(c *gin.Context) {
+	kegiatan, ok := hc.findKegiatan(c)
+	if !ok {
+		return
+	}
+
+	var req CatatKehadiranRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.JumlahHadir < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Jumlah hadir tidak boleh negatif",
+		})
+		return
+	}
+
+	if kegiatan.KegiatanTanggal.After(endOfDay(time.Now())) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Kehadiran belum dapat dicatat untuk kegiatan yang belum berlangsung",
+		})
+		return
+	}
+
+	if !hc.validateKeluargaWarga(c, kegiatan, req.KeluargaID, req.WargaID) {
+		return
+	}
+
+	kehadiran, err := hc.findOrNewKehadiran(c, kegiatan, req.KeluargaID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal memuat data kehadiran",
+		})
+		return
+	}
+
+	hadir := true
+	if req.Hadir != nil {
+		hadir = *req.Hadir
+	}
+
+	userID, _ := c.Get("userID")
+	dicatatOleh := userID.(uint)
+	kehadiran.DicatatOlehID = &dicatatOleh
+	kehadiran.Hadir = hadir
+	if hadir {
+		now := time.Now()
+		kehadiran.WaktuHadir = &now
+		kehadiran.MetodeAbsen = "manual"
+		kehadiran.JumlahHadir = req.JumlahHadir
+		if kehadiran.JumlahHadir == 0 {
+			kehadiran.JumlahHadir = 1
+		}
+	} else {
+		kehadiran.WaktuHadir = nil
+		kehadiran.MetodeAbsen = ""
+		kehadiran.JumlahHadir = 0
+	}
+	if req.WargaID != 0 {
+		kehadiran.WargaID = &req.WargaID
+	}
+	if keterangan := strings.TrimSpace(req.Keterangan); keterangan != "" {
+		kehadiran.Keterangan = keterangan
+	}
+
+	if err := scopedDB(c, hc.db).Save(&kehadiran).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mencatat kehadiran",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Kehadiran berhasil dicatat",
+		"data":    kehadiran,
+	})
+}
+
+// ✅ POST - Warga absen dengan memindai QR kegiatan
+func (hc *KehadiranKegiatanController) AbsenQR(c *gin.Context) {
+	var req AbsenQRRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	req.Token = strings.TrimSpace(req.Token)
+	req.WargaNIK = strings.TrimSpace(req.WargaNIK)
+	if req.JumlahHadir < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Jumlah hadir tidak boleh negatif",
+		})
+		return
+	}
+
+	var kegiatan models.Kegiatan
+	if err := scopedDB(c, hc.db).Where("kegiatan_qr_token = ?", req.Token).First(&kegiatan).Error; err != nil || req.Token == "" {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "QR kegiatan tidak valid",
+		})
+		return
+	}
+
+	// QR milik seri kegiatan rutin berlaku untuk jadwal hari ini
+	now := time.Now()
+	if kegiatan.KegiatanRRule != "" {
+		var ok bool
+		if kegiatan, ok = hc.jadwalSeri(c, kegiatan, now.Format("2006-01-02")); !ok {
+			return
+		}
+	} else if kegiatan.KegiatanDibatalkan {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Jadwal kegiatan ini dibatalkan",
+		})
+		return
+	}
+
+	if now.Before(kegiatan.KegiatanTanggal.Add(-absenQRDibukaSebelum)) || now.After(endOfDay(kegiatan.KegiatanTanggal)) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Absensi QR hanya dibuka pada hari kegiatan",
+		})
+		return
+	}
+
+	// Warga hanya boleh absen untuk dirinya sendiri, NIK hanya dipakai pengurus
+	var warga models.Warga
+	if !isPengurusKehadiran(c) {
+		var ok bool
+		if warga, ok = hc.wargaLogin(c); !ok {
+			return
+		}
+	} else if req.WargaNIK == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "NIK warga wajib diisi",
+		})
+		return
+	} else if err := scopedDB(c, hc.db).Where("warga_nik = ?", req.WargaNIK).First(&warga).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Warga dengan NIK tersebut tidak ditemukan",
+		})
+		return
+	}
+
+	if !hc.validateKeluargaWarga(c, kegiatan, warga.KeluargaID, warga.WargaID) {
+		return
+	}
+
+	kehadiran, err := hc.findOrNewKehadiran(c, kegiatan, warga.KeluargaID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal memuat data kehadiran",
+		})
+		return
+	}
+
+	if kehadiran.Hadir {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Keluarga sudah tercatat hadir",
+			"data":    kehadiran,
+		})
+		return
+	}
+
+	jumlahHadir := req.JumlahHadir
+	if jumlahHadir == 0 {
+		jumlahHadir = 1
+	}
+
+	kehadiran.Hadir = true
+	kehadiran.JumlahHadir = jumlahHadir
+	kehadiran.WaktuHadir = &now
+	kehadiran.MetodeAbsen = "qr"
+	kehadiran.WargaID = &warga.WargaID
+
+	if err := scopedDB(c, hc.db).Save(&kehadiran).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mencatat kehadiran",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Absensi berhasil, terima kasih sudah hadir",
+		"data":    kehadiran,
+	})
+}
+
+// ✅ GET - Daftar RSVP & kehadiran satu kegiatan beserta ringkasannya
+func (hc *KehadiranKegiatanController) GetKehadiranKegiatan(c *gin.Context) {
+	kegiatan, ok := hc.findKegiatan(c)
+	if !ok {
+		return
+	}
+
+	var kehadiran []models.KehadiranKegiatan
+	if err := scopedDB(c, hc.db).
+		Preload("Keluarga").
+		Preload("Warga").
+		Where("kegiatan_id = ?", kegiatan.KegiatanID).
+		Order("hadir DESC, waktu_hadir ASC").
+		Find(&kehadiran).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data kehadiran",
+		})
+		return
+	}
+
+	totalPerRT, totalSemua, err := hc.totalKeluargaAktifPerRT(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal menghitung jumlah keluarga",
+		})
+		return
+	}
+
+	ringkasan := RingkasanKehadiran{TotalKeluarga: totalKeluargaKegiatan(kegiatan.RTID, totalPerRT, totalSemua)}
+	for _, k := range kehadiran {
+		switch k.RSVPStatus {
+		case "hadir":
+			ringkasan.RSVPHadir++
+		case "tidak":
+			ringkasan.RSVPTidak++
+		case "mungkin":
+			ringkasan.RSVPMungkin++
+		}
+		if k.Hadir {
+			ringkasan.JumlahKeluargaHadir++
+			ringkasan.JumlahOrangHadir += int64(k.JumlahHadir)
+		}
+	}
+	ringkasan.PersentaseKehadiran = persentase(ringkasan.JumlahKeluargaHadir, ringkasan.TotalKeluarga)
+
+	c.JSON(http.StatusOK, gin.H{
+		"kegiatan":  kegiatan,
+		"ringkasan": ringkasan,
+		"data":      kehadiran,
+	})
+}
+
+// ✅ GET - Token QR absensi kegiatan (dibuat otomatis untuk kegiatan lama)
+func (hc *KehadiranKegiatanController) GetQRKegiatan(c *gin.Context) {
+	kegiatan, ok := hc.findKegiatan(c)
+	if !ok {
+		return
+	}
+
+	if kegiatan.KegiatanQRToken == "" {
+		if !hc.saveQRToken(c, &kegiatan) {
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"kegiatan_id":   kegiatan.KegiatanID,
+			"kegiatan_nama": kegiatan.KegiatanNama,
+			"qr_token":      kegiatan.KegiatanQRToken,
+		},
+	})
+}
+
+// ✅ PUT - Membuat ulang token QR (QR lama tidak berlaku lagi)
+func (hc *KehadiranKegiatanController) ResetQRKegiatan(c *gin.Context) {
+	kegiatan, ok := hc.findKegiatan(c)
+	if !ok {
+		return
+	}
+
+	if !hc.saveQRToken(c, &kegiatan) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "QR kegiatan berhasil dibuat ulang",
+		"data": gin.H{
+			"kegiatan_id":   kegiatan.KegiatanID,
+			"kegiatan_nama": kegiatan.KegiatanNama,
+			"qr_token":      kegiatan.KegiatanQRToken,
+		},
+	})
+}
+
+// ✅ GET - Laporan persentase kehadiran per kegiatan dalam periode
+func (hc *KehadiranKegiatanController) GetLaporanKehadiranKegiatan(c *gin.Context) {
+	dari, sampai, ok := parsePeriodeKehadiran(c)
+	if !ok {
+		return
+	}
+
+	// Kegiatan rutin diekspansi per jadwal, termasuk jadwal yang belum pernah di-RSVP
+	jadwal, err := jadwalKegiatanDB(scopedDB(c, hc.db), dari, endOfDay(sampai))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil laporan kehadiran",
+		})
+		return
+	}
+
+	kategoriID, errKategori := strconv.ParseUint(c.Query("kategori_id"), 10, 32)
+	hanyaWajib := c.Query("wajib") == "true"
+
+	laporan := []LaporanKehadiranKegiatan{}
+	var kegiatanIDs []uint
+	for _, j := range jadwal {
+		if (errKategori == nil && j.KategoriKegiatanID != uint(kategoriID)) || (hanyaWajib && !j.KegiatanWajib) {
+			continue
+		}
+		laporan = append(laporan, LaporanKehadiranKegiatan{
+			KegiatanID:      j.KegiatanID,
+			SeriID:          j.SeriID,
+			KegiatanNama:    j.KegiatanNama,
+			KegiatanTanggal: j.KegiatanTanggal,
+			KegiatanWajib:   j.KegiatanWajib,
+			RTID:            j.RTID,
+		})
+		if !jadwalVirtual(j) {
+			kegiatanIDs = append(kegiatanIDs, j.KegiatanID)
+		}
+	}
+
+	type rekapKehadiran struct {
+		KegiatanID          uint
+		RSVPHadir           int64
+		JumlahKeluargaHadir int64
+		JumlahOrangHadir    int64
+	}
+	rekap := make(map[uint]rekapKehadiran)
+	if len(kegiatanIDs) > 0 {
+		var rows []rekapKehadiran
+		if err := scopedDB(c, hc.db).Model(&models.KehadiranKegiatan{}).
+			Select(`kegiatan_id,
+				SUM(CASE WHEN rsvp_status = 'hadir' THEN 1 ELSE 0 END) AS rsvp_hadir,
+				SUM(CASE WHEN hadir THEN 1 ELSE 0 END) AS jumlah_keluarga_hadir,
+				SUM(CASE WHEN hadir THEN jumlah_hadir ELSE 0 END) AS jumlah_orang_hadir`).
+			Where("kegiatan_id IN ?", kegiatanIDs).
+			Group("kegiatan_id").
+			Scan(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal mengambil laporan kehadiran",
+			})
+			return
+		}
+		for _, r := range rows {
+			rekap[r.KegiatanID] = r
+		}
+	}
+
+	// Jadwal virtual memakai ID seri, rekap hanya berlaku untuk jadwal yang tersimpan
+	for i := range laporan {
+		if laporan[i].SeriID != nil && laporan[i].KegiatanID == *laporan[i].SeriID {
+			continue
+		}
+		r := rekap[laporan[i].KegiatanID]
+		laporan[i].RSVPHadir = r.RSVPHadir
+		laporan[i].JumlahKeluargaHadir = r.JumlahKeluargaHadir
+		laporan[i].JumlahOrangHadir = r.JumlahOrangHadir
+	}
+	sort.SliceStable(laporan, func(i, j int) bool {
+		return laporan[i].KegiatanTanggal.After(laporan[j].KegiatanTanggal)
+	})
+
+	totalPerRT, totalSemua, err := hc.totalKeluargaAktifPerRT(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal menghitung jumlah keluarga",
+		})
+		return
+	}
+
+	var totalHadir, totalKeluarga int64
+	for i := range laporan {
+		laporan[i].TotalKeluarga = totalKeluargaKegiatan(laporan[i].RTID, totalPerRT, totalSemua)
+		laporan[i].PersentaseKehadiran = persentase(laporan[i].JumlahKeluargaHadir, laporan[i].TotalKeluarga)
+		totalHadir += laporan[i].JumlahKeluargaHadir
+		totalKeluarga += laporan[i].TotalKeluarga
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"periode": gin.H{
+			"dari":   dari.Format("2006-01-02"),
+			"sampai": sampai.Format("2006-01-02"),
+		},
+		"rata_rata_kehadiran": persentase(totalHadir, totalKeluarga),
+		"data":                laporan,
+	})
+}
+
+// ✅ GET - Laporan kehadiran per keluarga dalam periode
+func (hc *KehadiranKegiatanController) GetLaporanKehadiranKeluarga(c *gin.Context) {
+	dari, sampai, ok := parsePeriodeKehadiran(c)
+	if !ok {
+		return
+	}
+
+	kegiatan, keluarga, hadir, err := hc.loadDataPeriode(c, dari, sampai, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil laporan kehadiran keluarga",
+		})
+		return
+	}
+
+	laporan := make([]LaporanKehadiranKeluarga, 0, len(keluarga))
+	for _, kel := range keluarga {
+		row := LaporanKehadiranKeluarga{
+			KeluargaID:   kel.KeluargaID,
+			KeluargaNama: kel.KeluargaNama,
+		}
+		for _, keg := range kegiatan {
+			if !kegiatanBerlakuUntuk(keg, kel) {
+				continue
+			}
+			row.TotalKegiatan++
+			if keg.KegiatanWajib {
+				row.TotalKegiatanWajib++
+			}
+			if hadir[keg.KegiatanID][kel.KeluargaID] {
+				row.JumlahHadir++
+				if keg.KegiatanWajib {
+					row.JumlahHadirWajib++
+				}
+			}
+		}
+		row.PersentaseKehadiran = persentase(int64(row.JumlahHadir), int64(row.TotalKegiatan))
+		laporan = append(laporan, row)
+	}
+
+	sort.SliceStable(laporan, func(i, j int) bool {
+		return laporan[i].PersentaseKehadiran > laporan[j].PersentaseKehadiran
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"periode": gin.H{
+			"dari":   dari.Format("2006-01-02"),
+			"sampai": sampai.Format("2006-01-02"),
+		},
+		"data": laporan,
+	})
+}
+
+// ✅ GET - Daftar keluarga yang tidak hadir pada kegiatan wajib (mis. kerja bakti)
+func (hc *KehadiranKegiatanController) GetKeluargaAbsenWajib(c *gin.Context) {
+	dari, sampai, ok := parsePeriodeKehadiran(c)
+	if !ok {
+		return
+	}
+
+	// Hanya kegiatan wajib yang sudah berlangsung yang dihitung
+	if today := startOfDay(time.Now()); !sampai.Before(today) {
+		sampai = today.AddDate(0, 0, -1)
+	}
+
+	kegiatan, keluarga, hadir, err := hc.loadDataPeriode(c, dari, sampai, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data absen kegiatan wajib",
+		})
+		return
+	}
+
+	result := []KeluargaAbsenWajib{}
+	for _, kel := range keluarga {
+		row := KeluargaAbsenWajib{
+			KeluargaID:   kel.KeluargaID,
+			KeluargaNama: kel.KeluargaNama,
+		}
+		for _, keg := range kegiatan {
+			if !kegiatanBerlakuUntuk(keg, kel) || hadir[keg.KegiatanID][kel.KeluargaID] {
+				continue
+			}
+			row.Kegiatan = append(row.Kegiatan, KegiatanRingkasan{
+				KegiatanID:      keg.KegiatanID,
+				KegiatanNama:    keg.KegiatanNama,
+				KegiatanTanggal: keg.KegiatanTanggal,
+			})
+		}
+		row.JumlahAbsen = len(row.Kegiatan)
+		if row.JumlahAbsen > 0 {
+			result = append(result, row)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].JumlahAbsen > result[j].JumlahAbsen
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"periode": gin.H{
+			"dari":   dari.Format("2006-01-02"),
+			"sampai": sampai.Format("2006-01-02"),
+		},
+		"total_kegiatan_wajib": len(kegiatan),
+		"data":                 result,
+	})
+}
+
+// ✅ Helper mengambil kegiatan dari parameter :id
+func (hc *KehadiranKegiatanController) findKegiatan(c *gin.Context) (models.Kegiatan, bool) {
+	var kegiatan models.Kegiatan
+
+	kegiatanID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID kegiatan tidak valid",
+		})
+		return kegiatan, false
+	}
+
+	if err := scopedDB(c, hc.db).First(&kegiatan, kegiatanID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Kegiatan tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan kegiatan",
+			})
+		}
+		return kegiatan, false
+	}
+
+	if kegiatan.KegiatanRRule != "" {
+		return hc.jadwalSeri(c, kegiatan, c.Query("tanggal_asli"))
+	}
+	if kegiatan.KegiatanDibatalkan {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Jadwal kegiatan ini dibatalkan",
+		})
+		return kegiatan, false
+	}
+
+	return kegiatan, true
+}
+
+// ✅ Helper kegiatan rutin: RSVP dan absensi dicatat pada jadwal turunan per tanggal_asli,
+// sehingga kehadiran terkunci pada pasangan (seri, tanggal jadwal). Jadwal dibuat jika belum ada.
+func (hc *KehadiranKegiatanController) jadwalSeri(c *gin.Context, seri models.Kegiatan, tanggalAsli string) (models.Kegiatan, bool) {
+	if strings.TrimSpace(tanggalAsli) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Kegiatan rutin: pilih jadwal melalui parameter tanggal_asli (YYYY-MM-DD)",
+		})
+		return seri, false
+	}
+
+	kc := &KegiatanController{db: hc.db}
+	tanggal, ok := kc.findTanggalJadwal(c, seri, tanggalAsli)
+	if !ok {
+		return seri, false
+	}
+
+	jadwal, err := kc.findOrNewJadwalTurunan(c, seri, tanggal)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal memuat jadwal kegiatan",
+		})
+		return seri, false
+	}
+	if jadwal.KegiatanDibatalkan {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Jadwal kegiatan ini dibatalkan",
+		})
+		return seri, false
+	}
+
+	if jadwal.KegiatanID == 0 {
+		if err := scopedDB(c, hc.db).Create(&jadwal).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Gagal menyiapkan jadwal kegiatan",
+				"details": err.Error(),
+			})
+			return seri, false
+		}
+	}
+
+	return jadwal, true
+}
+
+// ✅ Helper validasi keluarga (dan warga jika diisi) berada di RT kegiatan
+func (hc *KehadiranKegiatanController) validateKeluargaWarga(c *gin.Context, kegiatan models.Kegiatan, keluargaID, wargaID uint) bool {
+	var keluarga models.Keluarga
+	if err := scopedDB(c, hc.db).First(&keluarga, keluargaID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Keluarga tidak ditemukan",
+		})
+		return false
+	}
+
+	if kegiatan.RTID != nil && keluarga.RTID != nil && *kegiatan.RTID != *keluarga.RTID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Keluarga tidak berada di RT penyelenggara kegiatan",
+		})
+		return false
+	}
+
+	if wargaID != 0 {
+		var warga models.Warga
+		if err := scopedDB(c, hc.db).First(&warga, wargaID).Error; err != nil || warga.KeluargaID != keluargaID {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Warga tidak ditemukan pada keluarga tersebut",
+			})
+			return false
+		}
+	}
+
+	return true
+}
+
+// ✅ Helper mengambil data warga yang ditautkan ke akun user yang login
+func (hc *KehadiranKegiatanController) wargaLogin(c *gin.Context) (models.Warga, bool) {
+	var warga models.Warga
+
+	userID, _ := c.Get("userID")
+	var user models.User
+	if err := hc.db.Select("user_id", "warga_id").First(&user, userID).Error; err != nil || user.WargaID == nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Akun belum ditautkan ke data warga",
+		})
+		return warga, false
+	}
+	if err := scopedDB(c, hc.db).First(&warga, *user.WargaID).Error; err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Data warga akun tidak ditemukan",
+		})
+		return warga, false
+	}
+	return warga, true
+}
+
+// ✅ Helper pengurus (ADM, Sekretaris) boleh mengisi kehadiran atas nama keluarga lain
+func isPengurusKehadiran(c *gin.Context) bool {
+	levelID, _ := c.Get("levelID")
+	return levelID == uint(1) || levelID == uint(2)
+}
+
+// ✅ Helper mengambil data kehadiran keluarga, atau menyiapkan data baru jika belum ada
+func (hc *KehadiranKegiatanController) findOrNewKehadiran(c *gin.Context, kegiatan models.Kegiatan, keluargaID uint) (models.KehadiranKegiatan, error) {
+	var kehadiran models.KehadiranKegiatan
+	err := scopedDB(c, hc.db).
+		Where("kegiatan_id = ? AND keluarga_id = ?", kegiatan.KegiatanID, keluargaID).
+		First(&kehadiran).Error
+	if err == gorm.ErrRecordNotFound {
+		return models.KehadiranKegiatan{
+			KegiatanID: kegiatan.KegiatanID,
+			KeluargaID: keluargaID,
+			RTID:       kegiatan.RTID,
+		}, nil
+	}
+	return kehadiran, err
+}
+
+// ✅ Helper menyimpan token QR baru untuk kegiatan
+func (hc *KehadiranKegiatanController) saveQRToken(c *gin.Context, kegiatan *models.Kegiatan) bool {
+	token := generateRandomToken()
+	if err := scopedDB(c, hc.db).Model(kegiatan).Update("kegiatan_qr_token", token).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal membuat QR kegiatan",
+		})
+		return false
+	}
+	kegiatan.KegiatanQRToken = token
+	return true
+}
+
+// ✅ Helper jumlah keluarga aktif per RT (dan totalnya) sebagai pembagi persentase kehadiran
+func (hc *KehadiranKegiatanController) totalKeluargaAktifPerRT(c *gin.Context) (map[uint]int64, int64, error) {
+	var rows []struct {
+		RTID  *uint
+		Total int64
+	}
+	if err := scopedDB(c, hc.db).Model(&models.Keluarga{}).
+		Select("rt_id, COUNT(*) AS total").
+		Where("keluarga_status = ?", "aktif").
+		Group("rt_id").
+		Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	perRT := make(map[uint]int64)
+	var total int64
+	for _, row := range rows {
+		if row.RTID != nil {
+			perRT[*row.RTID] = row.Total
+		}
+		total += row.Total
+	}
+	return perRT, total, nil
+}
+
+// ✅ Helper memuat kegiatan, keluarga aktif, dan peta kehadiran dalam satu periode
+func (hc *KehadiranKegiatanController) loadDataPeriode(c *gin.Context, dari, sampai time.Time, hanyaWajib bool) ([]models.Kegiatan, []models.Keluarga, map[uint]map[uint]bool, error) {
+	// Kegiatan rutin diekspansi per jadwal agar jadwal tanpa kehadiran tetap terhitung
+	jadwal, err := jadwalKegiatanDB(scopedDB(c, hc.db), dari, endOfDay(sampai))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var kegiatan []models.Kegiatan
+	kegiatanIDs := make([]uint, 0, len(jadwal))
+	for _, j := range jadwal {
+		if hanyaWajib && !j.KegiatanWajib {
+			continue
+		}
+		kegiatan = append(kegiatan, j.Kegiatan)
+		if !jadwalVirtual(j) {
+			kegiatanIDs = append(kegiatanIDs, j.KegiatanID)
+		}
+	}
+
+	var keluarga []models.Keluarga
+	if err := scopedDB(c, hc.db).
+		Where("keluarga_status = ?", "aktif").
+		Order("keluarga_nama ASC").
+		Find(&keluarga).Error; err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Jadwal virtual belum punya data kehadiran, sehingga dianggap tidak hadir
+	hadir := make(map[uint]map[uint]bool)
+	if len(kegiatanIDs) == 0 {
+		return kegiatan, keluarga, hadir, nil
+	}
+
+	var kehadiran []models.KehadiranKegiatan
+	if err := scopedDB(c, hc.db).
+		Where("kegiatan_id IN ? AND hadir = ?", kegiatanIDs, true).
+		Find(&kehadiran).Error; err != nil {
+		return nil, nil, nil, err
+	}
+	for _, k := range kehadiran {
+		if hadir[k.KegiatanID] == nil {
+			hadir[k.KegiatanID] = make(map[uint]bool)
+		}
+		hadir[k.KegiatanID][k.KeluargaID] = true
+	}
+
+	return kegiatan, keluarga, hadir, nil
+}
+
+// ✅ Helper parse periode laporan (tanggal_from & tanggal_to), default awal tahun sampai hari ini
+func parsePeriodeKehadiran(c *gin.Context) (time.Time, time.Time, bool) {
+	now := time.Now()
+	dari := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.Local)
+	sampai := startOfDay(now)
+
+	if value := c.Query("tanggal_from"); value != "" {
+		t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Format tanggal_from harus YYYY-MM-DD",
+			})
+			return dari, sampai, false
+		}
+		dari = t
+	}
+	if value := c.Query("tanggal_to"); value != "" {
+		t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Format tanggal_to harus YYYY-MM-DD",
+			})
+			return dari, sampai, false
+		}
+		sampai = t
+	}
+
+	if sampai.Before(dari) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "tanggal_to tidak boleh sebelum tanggal_from",
+		})
+		return dari, sampai, false
+	}
+
+	return dari, sampai, true
+}
+
+// jadwalVirtual bernilai benar untuk jadwal kegiatan rutin yang belum tersimpan sebagai jadwal turunan
+func jadwalVirtual(j KegiatanJadwal) bool {
+	return j.SeriID != nil && j.KegiatanID == *j.SeriID
+}
+
+// ✅ Helper kegiatan tingkat RW/global (tanpa RT) berlaku untuk semua keluarga
+func kegiatanBerlakuUntuk(kegiatan models.Kegiatan, keluarga models.Keluarga) bool {
+	return kegiatan.RTID == nil || (keluarga.RTID != nil && *keluarga.RTID == *kegiatan.RTID)
+}
+
+func totalKeluargaKegiatan(rtID *uint, perRT map[uint]int64, total int64) int64 {
+	if rtID == nil {
+		return total
+	}
+	return perRT[*rtID]
+}
+
+func persentase(bagian, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(int(float64(bagian)/float64(total)*10000+0.5)) / 100
+}
+
+func isValidRSVPStatus(status string) bool {
+	return status == "hadir" || status == "tidak" || status == "mungkin"
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func endOfDay(t time.Time) time.Time {
+	return startOfDay(t).AddDate(0, 0, 1).Add(-time.Nanosecond)
+}
+
+// ✅ Helper membuat token acak (QR absensi kegiatan, token feed kalender)
+func generateRandomToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
@@ -8,6 +8,7 @@ import (
 	"rt-management/models"
 	"strconv"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
 
@@ -74,7 +75,7 @@ func (kc *KeluargaController) GetAllKeluarga(c *gin.Context) {
 	log.Println("🔄 Fetching all families from database...")
 
 	// ✅ SAFE: GORM menggunakan parameterized queries
-	if err := kc.db.Preload("Wargas").Find(&families).Error; err != nil {
+	if err := scopedDB(c, kc.db).Preload("Wargas").Find(&families).Error; err != nil {
 		log.Printf("❌ Error fetching families: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch families",
@@ -105,7 +106,7 @@ func (kc *KeluargaController) GetKeluargaByID(c *gin.Context) {
 
 	var keluarga models.Keluarga
 	// ✅ SAFE: GORM menggunakan prepared statements
-	if err := kc.db.Preload("Wargas").First(&keluarga, keluargaID).Error; err != nil {
+	if err := scopedDB(c, kc.db).Preload("Wargas").First(&keluarga, keluargaID).Error; err != nil {
 		log.Printf("❌ Error fetching family %s: %v", keluargaID, err)
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Family not found"})
@@ -139,7 +140,7 @@ func (kc *KeluargaController) GetKeluargaWithDetails(c *gin.Context) {
 
 	var keluarga models.Keluarga
 	// ✅ SAFE: Semua menggunakan parameterized queries
-	if err := kc.db.
+	if err := scopedDB(c, kc.db).
 		Preload("Wargas", func(db *gorm.DB) *gorm.DB {
 			return db.Preload("Agama").Preload("Pekerjaan")
 		}).
@@ -203,7 +204,7 @@ func (kc *KeluargaController) CreateKeluarga(c *gin.Context) {
 	}
 
 	// ✅ SAFE: GORM Create dengan parameterized queries
-	if err := kc.db.Create(&keluarga).Error; err != nil {
+	if err := scopedDB(c, kc.db).Create(&keluarga).Error; err != nil {
 		log.Printf("❌ Error creating family: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create family",
@@ -251,7 +252,7 @@ func (kc *KeluargaController) UpdateKeluarga(c *gin.Context) {
 
 	var keluarga models.Keluarga
 	// ✅ SAFE: GORM First dengan parameterized query
-	if err := kc.db.First(&keluarga, keluargaID).Error; err != nil {
+	if err := scopedDB(c, kc.db).First(&keluarga, keluargaID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Family not found"})
 		} else {
@@ -283,7 +284,7 @@ func (kc *KeluargaController) UpdateKeluarga(c *gin.Context) {
 	}
 
 	// ✅ SAFE: GORM Save dengan parameterized queries
-	if err := kc.db.Save(&keluarga).Error; err != nil {
+	if err := scopedDB(c, kc.db).Save(&keluarga).Error; err != nil {
 		log.Printf("❌ Error updating family: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to update family",
@@ -292,7 +293,7 @@ func (kc *KeluargaController) UpdateKeluarga(c *gin.Context) {
 	}
 
 	// ✅ Reload dengan data terbaru - SAFE
-	if err := kc.db.Preload("Wargas").First(&keluarga, keluargaID).Error; err != nil {
+	if err := scopedDB(c, kc.db).Preload("Wargas").First(&keluarga, keluargaID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to load updated family",
 		})
@@ -306,7 +307,7 @@ func (kc *KeluargaController) UpdateKeluarga(c *gin.Context) {
 	})
 }
 
-// DeleteKeluarga deletes keluarga dengan security checks
+// DeleteKeluarga memindahkan keluarga ke trash (soft delete)
 func (kc *KeluargaController) DeleteKeluarga(c *gin.Context) {
 	keluargaID := c.Param("id")
 
@@ -322,7 +323,7 @@ func (kc *KeluargaController) DeleteKeluarga(c *gin.Context) {
 
 	var keluarga models.Keluarga
 	// ✅ SAFE: GORM First dengan parameterized query
-	if err := kc.db.First(&keluarga, keluargaID).Error; err != nil {
+	if err := scopedDB(c, kc.db).First(&keluarga, keluargaID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Family not found"})
 		} else {
@@ -333,7 +334,7 @@ func (kc *KeluargaController) DeleteKeluarga(c *gin.Context) {
 
 	// ✅ Check jika keluarga masih memiliki warga - SAFE: parameterized query
 	var wargaCount int64
-	if err := kc.db.Model(&models.Warga{}).Where("keluarga_id = ?", keluargaID).Count(&wargaCount).Error; err != nil {
+	if err := scopedDB(c, kc.db).Model(&models.Warga{}).Where("keluarga_id = ?", keluargaID).Count(&wargaCount).Error; err != nil {
 		log.Printf("❌ Error checking family members: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to check family members",
@@ -349,8 +350,22 @@ func (kc *KeluargaController) DeleteKeluarga(c *gin.Context) {
 		return
 	}
 
+	// Check jika keluarga masih menempati rumah
+	var hunianCount int64
+	if err := scopedDB(c, kc.db).Model(&models.HunianRumah{}).Where("keluarga_id = ? AND (hunian_tanggal_selesai IS NULL OR hunian_tanggal_selesai > ?)", keluargaID, time.Now()).Count(&hunianCount).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check family occupancy"})
+		return
+	}
+
+	if hunianCount > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Cannot delete family that still occupies a house",
+		})
+		return
+	}
+
 	// ✅ SAFE: GORM Delete dengan parameterized query
-	if err := kc.db.Delete(&keluarga).Error; err != nil {
+	if err := scopedDB(c, kc.db).Delete(&keluarga).Error; err != nil {
 		log.Printf("❌ Error deleting family: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to delete family",
@@ -375,10 +390,10 @@ func (kc *KeluargaController) GetKeluargaStats(c *gin.Context) {
 	}
 
 	// ✅ SAFE: Semua count queries menggunakan parameterized queries internally
-	kc.db.Model(&models.Keluarga{}).Count(&stats.TotalKeluarga)
-	kc.db.Model(&models.Keluarga{}).Where("keluarga_status = ?", "aktif").Count(&stats.KeluargaAktif)
-	kc.db.Model(&models.Keluarga{}).Where("keluarga_status = ?", "nonaktif").Count(&stats.KeluargaNonaktif)
-	kc.db.Model(&models.Warga{}).Count(&stats.TotalWarga)
+	scopedDB(c, kc.db).Model(&models.Keluarga{}).Count(&stats.TotalKeluarga)
+	scopedDB(c, kc.db).Model(&models.Keluarga{}).Where("keluarga_status = ?", "aktif").Count(&stats.KeluargaAktif)
+	scopedDB(c, kc.db).Model(&models.Keluarga{}).Where("keluarga_status = ?", "nonaktif").Count(&stats.KeluargaNonaktif)
+	scopedDB(c, kc.db).Model(&models.Warga{}).Count(&stats.TotalWarga)
 
 	log.Printf("📊 Family stats: Total=%d, Active=%d, Inactive=%d, Members=%d",
 		stats.TotalKeluarga, stats.KeluargaAktif, stats.KeluargaNonaktif, stats.TotalWarga)
@@ -415,7 +430,7 @@ func (kc *KeluargaController) SearchKeluarga(c *gin.Context) {
 	var families []models.Keluarga
 
 	// ✅ SAFE: Gunakan parameterized query
-	if err := kc.db.
+	if err := scopedDB(c, kc.db).
 		Preload("Wargas").
 		Where("keluarga_nama LIKE ?", "%"+query+"%").
 		Find(&families).Error; err != nil {
@@ -440,7 +455,7 @@ func (kc *KeluargaController) GetKeluargaAktif(c *gin.Context) {
 	var families []models.Keluarga
 
 	// ✅ SAFE: Parameterized query
-	if err := kc.db.
+	if err := scopedDB(c, kc.db).
 		Preload("Wargas").
 		Where("keluarga_status = ?", "aktif").
 		Find(&families).Error; err != nil {
@@ -462,7 +477,7 @@ func (kc *KeluargaController) GetKeluargaAktif(c *gin.Context) {
 func (kc *KeluargaController) GetTotalKeluarga(c *gin.Context) {
 	var total int64
 	// ✅ SAFE: Parameterized query
-	if err := kc.db.Model(&models.Keluarga{}).Count(&total).Error; err != nil {
+	if err := scopedDB(c, kc.db).Model(&models.Keluarga{}).Count(&total).Error; err != nil {
 		log.Printf("❌ Error fetching total families: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch total families",
@@ -476,3 +491,62 @@ func (kc *KeluargaController) GetTotalKeluarga(c *gin.Context) {
 		"count": total,
 	})
 }
+
+// GetTrashKeluarga returns keluarga yang sudah dihapus (soft delete)
+func (kc *KeluargaController) GetTrashKeluarga(c *gin.Context) {
+	var families []models.Keluarga
+
+	// ✅ SAFE: Unscoped agar data yang sudah di-soft delete ikut terbaca
+	if err := scopedDB(c, kc.db).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Find(&families).Error; err != nil {
+		log.Printf("❌ Error fetching deleted families: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch deleted families",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  families,
+		"count": len(families),
+	})
+}
+
+// RestoreKeluarga mengembalikan keluarga dari trash
+func (kc *KeluargaController) RestoreKeluarga(c *gin.Context) {
+	keluargaID := c.Param("id")
+
+	// ✅ Validasi ID input
+	if !isValidKeluargaID(keluargaID) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid family ID format",
+		})
+		return
+	}
+
+	var keluarga models.Keluarga
+	if err := scopedDB(c, kc.db).Unscoped().Where("deleted_at IS NOT NULL").First(&keluarga, keluargaID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Family not found in trash"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch family"})
+		}
+		return
+	}
+
+	if err := scopedDB(c, kc.db).Unscoped().Model(&keluarga).Update("deleted_at", nil).Error; err != nil {
+		log.Printf("❌ Error restoring family: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to restore family",
+		})
+		return
+	}
+
+	log.Printf("♻️ Restored family: %s (ID: %d)", keluarga.KeluargaNama, keluarga.KeluargaID)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Family restored successfully",
+		"data":    keluarga,
+	})
+}
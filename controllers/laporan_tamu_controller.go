@@ -0,0 +1,600 @@
+package controllers
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"rt-management/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Batas waktu wajib lapor tamu menginap
+const batasLaporTamu = 24 * time.Hour
+
+type LaporanTamuController struct {
+	db *gorm.DB
+}
+
+func NewLaporanTamuController(db *gorm.DB) *LaporanTamuController {
+	return &LaporanTamuController{db: db}
+}
+
+// Request structs
+type CreateLaporanTamuRequest struct {
+	RumahID           uint   `form:"rumah_id"`    // wajib untuk pengurus, warga memakai rumah yang dihuninya
+	KeluargaID        uint   `form:"keluarga_id"` // warga selalu memakai keluarganya sendiri
+	TamuNama          string `form:"tamu_nama" binding:"required"`
+	TamuNIK           string `form:"tamu_nik" binding:"required"`
+	TamuJenisKelamin  string `form:"tamu_jenis_kelamin"`
+	TamuNoTlp         string `form:"tamu_no_tlp"`
+	TamuAsal          string `form:"tamu_asal" binding:"required"`
+	TamuHubungan      string `form:"tamu_hubungan"`
+	TamuTanggalDatang string `form:"tamu_tanggal_datang" binding:"required"`
+	TamuRencanaPulang string `form:"tamu_rencana_pulang" binding:"required"`
+	TamuKeterangan    string `form:"tamu_keterangan"`
+}
+
+type UpdateLaporanTamuRequest struct {
+	TamuNama          string `form:"tamu_nama"`
+	TamuNoTlp         string `form:"tamu_no_tlp"`
+	TamuAsal          string `form:"tamu_asal"`
+	TamuHubungan      string `form:"tamu_hubungan"`
+	TamuRencanaPulang string `form:"tamu_rencana_pulang"`
+	TamuKeterangan    string `form:"tamu_keterangan"`
+}
+
+type PulangLaporanTamuRequest struct {
+	TamuTanggalPulang string `form:"tamu_tanggal_pulang"`
+}
+
+// ✅ CREATE - Melaporkan tamu yang menginap
+func (lc *LaporanTamuController) CreateLaporanTamu(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not authenticated",
+		})
+		return
+	}
+
+	var req CreateLaporanTamuRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	req.TamuNama = strings.TrimSpace(req.TamuNama)
+	req.TamuNIK = strings.TrimSpace(req.TamuNIK)
+	req.TamuAsal = strings.TrimSpace(req.TamuAsal)
+	req.TamuHubungan = strings.TrimSpace(req.TamuHubungan)
+	req.TamuKeterangan = strings.TrimSpace(req.TamuKeterangan)
+
+	if !isValidTamuNIK(req.TamuNIK) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "NIK tamu harus 16 digit angka",
+		})
+		return
+	}
+
+	if req.TamuJenisKelamin == "" {
+		req.TamuJenisKelamin = "L"
+	}
+	if req.TamuJenisKelamin != "L" && req.TamuJenisKelamin != "P" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Jenis kelamin harus 'L' atau 'P'",
+		})
+		return
+	}
+
+	tanggalDatang, err := parseTanggalTamu(req.TamuTanggalDatang)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Format tanggal datang tidak valid. Gunakan format YYYY-MM-DD atau YYYY-MM-DD HH:MM",
+		})
+		return
+	}
+	if tanggalDatang.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tanggal datang tidak boleh di masa depan",
+		})
+		return
+	}
+
+	rencanaPulang, err := parseTanggalTamu(req.TamuRencanaPulang)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Format rencana pulang tidak valid. Gunakan format YYYY-MM-DD atau YYYY-MM-DD HH:MM",
+		})
+		return
+	}
+	if !rencanaPulang.After(tanggalDatang) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Rencana pulang harus setelah tanggal datang",
+		})
+		return
+	}
+
+	// Warga hanya boleh melaporkan tamu di rumah yang dihuni keluarganya sendiri
+	if levelID, _ := c.Get("levelID"); levelID == uint(6) {
+		var user models.User
+		if err := lc.db.Select("user_id", "warga_id").First(&user, userID).Error; err != nil || user.WargaID == nil {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Akun belum ditautkan ke data warga",
+			})
+			return
+		}
+		var warga models.Warga
+		if err := scopedDB(c, lc.db).Select("warga_id", "keluarga_id").First(&warga, *user.WargaID).Error; err != nil {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Data warga akun tidak ditemukan",
+			})
+			return
+		}
+		var hunian models.HunianRumah
+		if err := scopedDB(c, lc.db).Where("keluarga_id = ?", warga.KeluargaID).
+			Scopes(hunianAktif(time.Now())).First(&hunian).Error; err != nil {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Keluarga Anda tidak tercatat menghuni rumah",
+			})
+			return
+		}
+		if (req.RumahID != 0 && req.RumahID != hunian.RumahID) || (req.KeluargaID != 0 && req.KeluargaID != warga.KeluargaID) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Warga hanya dapat melaporkan tamu di rumahnya sendiri",
+			})
+			return
+		}
+		req.RumahID = hunian.RumahID
+		req.KeluargaID = warga.KeluargaID
+	} else if req.RumahID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Rumah tuan rumah wajib diisi",
+		})
+		return
+	}
+
+	// Check if rumah exists
+	var rumah models.Rumah
+	if err := scopedDB(c, lc.db).First(&rumah, req.RumahID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Rumah tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal memvalidasi rumah",
+			})
+		}
+		return
+	}
+
+	// Keluarga tuan rumah default ke penghuni aktif rumah tersebut
+	if req.KeluargaID == 0 {
+		var hunian models.HunianRumah
+		if err := scopedDB(c, lc.db).Where("rumah_id = ?", rumah.RumahID).
+			Scopes(hunianAktif(time.Now())).First(&hunian).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Rumah tidak memiliki penghuni aktif, keluarga tuan rumah harus diisi",
+			})
+			return
+		}
+		req.KeluargaID = hunian.KeluargaID
+	}
+
+	var keluarga models.Keluarga
+	if err := scopedDB(c, lc.db).First(&keluarga, req.KeluargaID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Keluarga tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal memvalidasi keluarga",
+			})
+		}
+		return
+	}
+
+	// Tamu yang sama tidak boleh dilaporkan dua kali selama masih menginap
+	var existing models.LaporanTamu
+	if err := scopedDB(c, lc.db).Where("tamu_nik = ? AND tamu_tanggal_pulang IS NULL", req.TamuNIK).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":           "Tamu dengan NIK tersebut masih tercatat menginap",
+			"laporan_tamu_id": existing.LaporanTamuID,
+		})
+		return
+	}
+
+	laporan := models.LaporanTamu{
+		RumahID:           rumah.RumahID,
+		KeluargaID:        keluarga.KeluargaID,
+		TamuNama:          req.TamuNama,
+		TamuNIK:           req.TamuNIK,
+		TamuJenisKelamin:  req.TamuJenisKelamin,
+		TamuNoTlp:         strings.TrimSpace(req.TamuNoTlp),
+		TamuAsal:          req.TamuAsal,
+		TamuHubungan:      req.TamuHubungan,
+		TamuTanggalDatang: tanggalDatang,
+		TamuRencanaPulang: rencanaPulang,
+		TamuKeterangan:    req.TamuKeterangan,
+		LaporanTerlambat:  time.Since(tanggalDatang) > batasLaporTamu,
+		DilaporkanOlehID:  userID.(uint),
+	}
+
+	if err := scopedDB(c, lc.db).Create(&laporan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menyimpan laporan tamu",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	scopedDB(c, lc.db).Preload("Rumah").Preload("Keluarga").First(&laporan, laporan.LaporanTamuID)
+
+	response := gin.H{
+		"message": "Laporan tamu berhasil disimpan",
+		"data":    laporan,
+	}
+	if laporan.LaporanTerlambat {
+		response["warning"] = "Laporan melewati batas wajib lapor 1x24 jam"
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// ✅ READ - Mendapatkan semua laporan tamu
+func (lc *LaporanTamuController) GetAllLaporanTamu(c *gin.Context) {
+	var laporan []models.LaporanTamu
+
+	query := scopedDB(c, lc.db).Model(&models.LaporanTamu{}).Preload("Rumah").Preload("Keluarga")
+
+	// Warga hanya melihat laporan yang dibuatnya sendiri
+	if levelID, _ := c.Get("levelID"); levelID == uint(6) {
+		userID, _ := c.Get("userID")
+		query = query.Where("dilaporkan_oleh_id = ?", userID)
+	}
+
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		query = query.Where("tamu_nama LIKE ? OR tamu_nik LIKE ? OR tamu_asal LIKE ?",
+			"%"+search+"%", "%"+search+"%", "%"+search+"%")
+	}
+	if rumahID := c.Query("rumah_id"); rumahID != "" {
+		if id, err := strconv.ParseUint(rumahID, 10, 32); err == nil {
+			query = query.Where("rumah_id = ?", id)
+		}
+	}
+	if keluargaID := c.Query("keluarga_id"); keluargaID != "" {
+		if id, err := strconv.ParseUint(keluargaID, 10, 32); err == nil {
+			query = query.Where("keluarga_id = ?", id)
+		}
+	}
+	switch c.Query("status") {
+	case "menginap":
+		query = query.Where("tamu_tanggal_pulang IS NULL")
+	case "pulang":
+		query = query.Where("tamu_tanggal_pulang IS NOT NULL")
+	}
+	if c.Query("terlambat") == "true" {
+		query = query.Where("laporan_terlambat = ?", true)
+	}
+
+	if err := query.Order("tamu_tanggal_datang DESC").Find(&laporan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data laporan tamu",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  laporan,
+		"total": len(laporan),
+	})
+}
+
+// ✅ READ - Mendapatkan laporan tamu by ID
+func (lc *LaporanTamuController) GetLaporanTamuByID(c *gin.Context) {
+	id := c.Param("id")
+
+	var laporan models.LaporanTamu
+	if err := scopedDB(c, lc.db).Preload("Rumah").Preload("Keluarga").Preload("DilaporkanOleh").
+		First(&laporan, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Laporan tamu tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal mengambil data laporan tamu",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": laporan,
+	})
+}
+
+// ✅ GET - Tamu yang saat ini masih menginap
+func (lc *LaporanTamuController) GetTamuMenginap(c *gin.Context) {
+	var laporan []models.LaporanTamu
+
+	if err := scopedDB(c, lc.db).Preload("Rumah").Preload("Keluarga").
+		Where("tamu_tanggal_pulang IS NULL").
+		Order("tamu_rencana_pulang ASC").
+		Find(&laporan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data tamu menginap",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  laporan,
+		"total": len(laporan),
+	})
+}
+
+// ✅ GET - Tamu yang sudah lewat rencana pulang tapi belum tercatat pulang
+func (lc *LaporanTamuController) GetTamuTerlambatPulang(c *gin.Context) {
+	var laporan []models.LaporanTamu
+
+	if err := scopedDB(c, lc.db).Preload("Rumah").Preload("Keluarga").
+		Where("tamu_tanggal_pulang IS NULL AND tamu_rencana_pulang < ?", time.Now()).
+		Order("tamu_rencana_pulang ASC").
+		Find(&laporan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data tamu terlambat pulang",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  laporan,
+		"total": len(laporan),
+	})
+}
+
+// ✅ GET - Riwayat tamu per rumah
+func (lc *LaporanTamuController) GetRiwayatTamuByRumah(c *gin.Context) {
+	rumahID := c.Param("rumah_id")
+
+	var rumah models.Rumah
+	if err := scopedDB(c, lc.db).First(&rumah, rumahID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Rumah tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan rumah",
+			})
+		}
+		return
+	}
+
+	var laporan []models.LaporanTamu
+	if err := scopedDB(c, lc.db).Preload("Keluarga").
+		Where("rumah_id = ?", rumah.RumahID).
+		Order("tamu_tanggal_datang DESC").
+		Find(&laporan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil riwayat tamu",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rumah": rumah,
+		"data":  laporan,
+		"total": len(laporan),
+	})
+}
+
+// ✅ UPDATE - Mengupdate laporan tamu (pelapor atau pengurus)
+func (lc *LaporanTamuController) UpdateLaporanTamu(c *gin.Context) {
+	laporan, ok := lc.findLaporanForWrite(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateLaporanTamuRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if v := strings.TrimSpace(req.TamuNama); v != "" {
+		updates["tamu_nama"] = v
+	}
+	if v := strings.TrimSpace(req.TamuNoTlp); v != "" {
+		updates["tamu_no_tlp"] = v
+	}
+	if v := strings.TrimSpace(req.TamuAsal); v != "" {
+		updates["tamu_asal"] = v
+	}
+	if v := strings.TrimSpace(req.TamuHubungan); v != "" {
+		updates["tamu_hubungan"] = v
+	}
+	if v := strings.TrimSpace(req.TamuKeterangan); v != "" {
+		updates["tamu_keterangan"] = v
+	}
+	if req.TamuRencanaPulang != "" {
+		rencanaPulang, err := parseTanggalTamu(req.TamuRencanaPulang)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Format rencana pulang tidak valid. Gunakan format YYYY-MM-DD atau YYYY-MM-DD HH:MM",
+			})
+			return
+		}
+		if !rencanaPulang.After(laporan.TamuTanggalDatang) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Rencana pulang harus setelah tanggal datang",
+			})
+			return
+		}
+		updates["tamu_rencana_pulang"] = rencanaPulang
+	}
+
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tidak ada data yang diupdate",
+		})
+		return
+	}
+
+	if err := scopedDB(c, lc.db).Model(&laporan).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mengupdate laporan tamu",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	scopedDB(c, lc.db).Preload("Rumah").Preload("Keluarga").First(&laporan, laporan.LaporanTamuID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Laporan tamu berhasil diupdate",
+		"data":    laporan,
+	})
+}
+
+// ✅ PUT - Mencatat tamu sudah pulang
+func (lc *LaporanTamuController) PulangLaporanTamu(c *gin.Context) {
+	laporan, ok := lc.findLaporanForWrite(c)
+	if !ok {
+		return
+	}
+
+	if laporan.TamuTanggalPulang != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tamu sudah tercatat pulang",
+		})
+		return
+	}
+
+	var req PulangLaporanTamuRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tanggalPulang := time.Now()
+	if req.TamuTanggalPulang != "" {
+		t, err := parseTanggalTamu(req.TamuTanggalPulang)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Format tanggal pulang tidak valid. Gunakan format YYYY-MM-DD atau YYYY-MM-DD HH:MM",
+			})
+			return
+		}
+		tanggalPulang = t
+	}
+	if tanggalPulang.Before(laporan.TamuTanggalDatang) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tanggal pulang tidak boleh sebelum tanggal datang",
+		})
+		return
+	}
+
+	if err := scopedDB(c, lc.db).Model(&laporan).Update("tamu_tanggal_pulang", tanggalPulang).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mencatat kepulangan tamu",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Kepulangan tamu berhasil dicatat",
+		"data":    laporan,
+	})
+}
+
+// ✅ DELETE - Menghapus laporan tamu
+func (lc *LaporanTamuController) DeleteLaporanTamu(c *gin.Context) {
+	id := c.Param("id")
+
+	var laporan models.LaporanTamu
+	if err := scopedDB(c, lc.db).First(&laporan, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Laporan tamu tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan laporan tamu",
+			})
+		}
+		return
+	}
+
+	if err := scopedDB(c, lc.db).Delete(&laporan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menghapus laporan tamu",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Laporan tamu berhasil dihapus",
+	})
+}
+
+// ✅ Helper mengambil laporan yang boleh diubah oleh user saat ini (warga hanya laporannya sendiri)
+func (lc *LaporanTamuController) findLaporanForWrite(c *gin.Context) (models.LaporanTamu, bool) {
+	var laporan models.LaporanTamu
+	if err := scopedDB(c, lc.db).First(&laporan, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Laporan tamu tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan laporan tamu",
+			})
+		}
+		return laporan, false
+	}
+
+	userID, _ := c.Get("userID")
+	levelID, _ := c.Get("levelID")
+	if levelID == uint(6) && userID != laporan.DilaporkanOlehID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Hanya pelapor atau pengurus yang dapat mengubah laporan ini",
+		})
+		return laporan, false
+	}
+
+	return laporan, true
+}
+
+// ✅ Helper parse tanggal tamu, menerima tanggal saja atau tanggal dengan jam
+func parseTanggalTamu(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.ParseInLocation("2006-01-02 15:04", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+// ✅ Helper validasi NIK tamu
+func isValidTamuNIK(nik string) bool {
+	return regexp.MustCompile(`^[0-9]{16}$`).MatchString(nik)
+}
@@ -76,7 +76,7 @@ func (mc *MutasiKeluargaController) CreateMutasiKeluarga(c *gin.Context) {
 
 	// Check if keluarga exists
 	var keluarga models.Keluarga
-	if err := mc.db.First(&keluarga, req.KeluargaID).Error; err != nil {
+	if err := scopedDB(c, mc.db).First(&keluarga, req.KeluargaID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": "Keluarga tidak ditemukan",
@@ -99,7 +99,7 @@ func (mc *MutasiKeluargaController) CreateMutasiKeluarga(c *gin.Context) {
 		UpdatedAt:           time.Now(),
 	}
 
-	if err := mc.db.Create(&mutasi).Error; err != nil {
+	if err := scopedDB(c, mc.db).Create(&mutasi).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Gagal membuat mutasi keluarga",
 			"details": err.Error(),
@@ -108,7 +108,7 @@ func (mc *MutasiKeluargaController) CreateMutasiKeluarga(c *gin.Context) {
 	}
 
 	// Reload dengan data keluarga
-	if err := mc.db.Preload("Keluarga").First(&mutasi, mutasi.MutasiKeluargaID).Error; err != nil {
+	if err := scopedDB(c, mc.db).Preload("Keluarga").First(&mutasi, mutasi.MutasiKeluargaID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Gagal memuat data mutasi keluarga yang dibuat",
 		})
@@ -137,7 +137,7 @@ func (mc *MutasiKeluargaController) GetAllMutasiKeluarga(c *gin.Context) {
 	tanggalTo := c.Query("tanggal_to")
 
 	// Build query dengan GORM (AMAN - parameterized queries)
-	query := mc.db.Model(&models.MutasiKeluarga{}).Preload("Keluarga")
+	query := scopedDB(c, mc.db).Model(&models.MutasiKeluarga{}).Preload("Keluarga")
 
 	// Apply filters
 	if keluargaID != "" {
@@ -202,7 +202,7 @@ func (mc *MutasiKeluargaController) GetMutasiKeluargaByID(c *gin.Context) {
 	}
 
 	var mutasi models.MutasiKeluarga
-	if err := mc.db.Preload("Keluarga").First(&mutasi, mutasiID).Error; err != nil {
+	if err := scopedDB(c, mc.db).Preload("Keluarga").First(&mutasi, mutasiID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Mutasi keluarga tidak ditemukan",
@@ -234,7 +234,7 @@ func (mc *MutasiKeluargaController) UpdateMutasiKeluarga(c *gin.Context) {
 	}
 
 	var mutasi models.MutasiKeluarga
-	if err := mc.db.First(&mutasi, mutasiID).Error; err != nil {
+	if err := scopedDB(c, mc.db).First(&mutasi, mutasiID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Mutasi keluarga tidak ditemukan",
@@ -285,7 +285,7 @@ func (mc *MutasiKeluargaController) UpdateMutasiKeluarga(c *gin.Context) {
 	// Validasi keluarga jika diupdate
 	if req.KeluargaID != 0 {
 		var keluarga models.Keluarga
-		if err := mc.db.First(&keluarga, req.KeluargaID).Error; err != nil {
+		if err := scopedDB(c, mc.db).First(&keluarga, req.KeluargaID).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				c.JSON(http.StatusBadRequest, gin.H{
 					"error": "Keluarga tidak ditemukan",
@@ -317,7 +317,7 @@ func (mc *MutasiKeluargaController) UpdateMutasiKeluarga(c *gin.Context) {
 	
 	updates["updated_at"] = time.Now()
 
-	if err := mc.db.Model(&mutasi).Updates(updates).Error; err != nil {
+	if err := scopedDB(c, mc.db).Model(&mutasi).Updates(updates).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Gagal mengupdate mutasi keluarga",
 			"details": err.Error(),
@@ -326,7 +326,7 @@ func (mc *MutasiKeluargaController) UpdateMutasiKeluarga(c *gin.Context) {
 	}
 
 	// Reload dengan data terbaru termasuk keluarga
-	if err := mc.db.Preload("Keluarga").First(&mutasi, mutasiID).Error; err != nil {
+	if err := scopedDB(c, mc.db).Preload("Keluarga").First(&mutasi, mutasiID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Gagal memuat data mutasi keluarga yang diupdate",
 		})
@@ -353,7 +353,7 @@ func (mc *MutasiKeluargaController) DeleteMutasiKeluarga(c *gin.Context) {
 	}
 
 	var mutasi models.MutasiKeluarga
-	if err := mc.db.First(&mutasi, mutasiID).Error; err != nil {
+	if err := scopedDB(c, mc.db).First(&mutasi, mutasiID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Mutasi keluarga tidak ditemukan",
@@ -367,7 +367,7 @@ func (mc *MutasiKeluargaController) DeleteMutasiKeluarga(c *gin.Context) {
 	}
 
 	// Delete menggunakan GORM Delete (AMAN)
-	if err := mc.db.Delete(&mutasi).Error; err != nil {
+	if err := scopedDB(c, mc.db).Delete(&mutasi).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Gagal menghapus mutasi keluarga",
 			"details": err.Error(),
@@ -395,7 +395,7 @@ func (mc *MutasiKeluargaController) GetMutasiByKeluargaID(c *gin.Context) {
 
 	// Check if keluarga exists
 	var keluarga models.Keluarga
-	if err := mc.db.First(&keluarga, keluargaIDSafe).Error; err != nil {
+	if err := scopedDB(c, mc.db).First(&keluarga, keluargaIDSafe).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Keluarga tidak ditemukan",
@@ -411,7 +411,7 @@ func (mc *MutasiKeluargaController) GetMutasiByKeluargaID(c *gin.Context) {
 	var mutasi []models.MutasiKeluarga
 
 	// Query mutasi by keluarga ID (AMAN - parameterized)
-	if err := mc.db.
+	if err := scopedDB(c, mc.db).
 		Preload("Keluarga").
 		Where("keluarga_id = ?", keluargaIDSafe).
 		Order("mutasi_keluarga_tanggal DESC").
@@ -444,21 +444,21 @@ func (mc *MutasiKeluargaController) GetStatistikMutasiKeluarga(c *gin.Context) {
 	var statistik StatistikResult
 
 	// Hitung total mutasi (AMAN)
-	mc.db.Model(&models.MutasiKeluarga{}).Count(&statistik.TotalMutasi)
+	scopedDB(c, mc.db).Model(&models.MutasiKeluarga{}).Count(&statistik.TotalMutasi)
 
 	// Hitung mutasi masuk (AMAN)
-	mc.db.Model(&models.MutasiKeluarga{}).
+	scopedDB(c, mc.db).Model(&models.MutasiKeluarga{}).
 		Where("mutasi_keluarga_jenis = ?", "masuk").
 		Count(&statistik.Masuk)
 
 	// Hitung mutasi keluar (AMAN)
-	mc.db.Model(&models.MutasiKeluarga{}).
+	scopedDB(c, mc.db).Model(&models.MutasiKeluarga{}).
 		Where("mutasi_keluarga_jenis = ?", "keluar").
 		Count(&statistik.Keluar)
 
 	// Hitung mutasi bulan ini (AMAN)
 	awalBulan := time.Now().AddDate(0, 0, -time.Now().Day()+1)
-	mc.db.Model(&models.MutasiKeluarga{}).
+	scopedDB(c, mc.db).Model(&models.MutasiKeluarga{}).
 		Where("mutasi_keluarga_tanggal >= ?", awalBulan).
 		Count(&statistik.BulanIni)
 
@@ -495,12 +495,12 @@ func (mc *MutasiKeluargaController) GetLaporanMutasiBulanan(c *gin.Context) {
 		akhirBulan := awalBulan.AddDate(0, 1, -1)
 
 		// Hitung mutasi masuk per bulan (AMAN)
-		mc.db.Model(&models.MutasiKeluarga{}).
+		scopedDB(c, mc.db).Model(&models.MutasiKeluarga{}).
 			Where("mutasi_keluarga_jenis = ? AND mutasi_keluarga_tanggal BETWEEN ? AND ?", "masuk", awalBulan, akhirBulan).
 			Count(&masuk)
 
 		// Hitung mutasi keluar per bulan (AMAN)
-		mc.db.Model(&models.MutasiKeluarga{}).
+		scopedDB(c, mc.db).Model(&models.MutasiKeluarga{}).
 			Where("mutasi_keluarga_jenis = ? AND mutasi_keluarga_tanggal BETWEEN ? AND ?", "keluar", awalBulan, akhirBulan).
 			Count(&keluar)
 
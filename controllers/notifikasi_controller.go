@@ -0,0 +1,467 @@
+package controllers
+
+import (
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"rt-management/models"
+	"rt-management/notification"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type NotifikasiController struct {
+	db         *gorm.DB
+	notifikasi *notification.Service
+}
+
+func NewNotifikasiController(db *gorm.DB, notifikasi *notification.Service) *NotifikasiController {
+	return &NotifikasiController{db: db, notifikasi: notifikasi}
+}
+
+// Request structs
+type UpdatePreferensiNotifikasiRequest struct {
+	Email          *string `form:"email"`
+	EmailAktif     *bool   `form:"email_aktif"`
+	WhatsAppAktif  *bool   `form:"whatsapp_aktif"`
+	PushAktif      *bool   `form:"push_aktif"`
+	BroadcastAktif *bool   `form:"broadcast_aktif"`
+	TagihanAktif   *bool   `form:"tagihan_aktif"`
+	KegiatanAktif  *bool   `form:"kegiatan_aktif"`
+}
+
+// Field dari PushSubscription browser: endpoint, keys.p256dh, keys.auth
+type LanggananPushRequest struct {
+	Endpoint string `form:"endpoint" binding:"required"`
+	P256dh   string `form:"p256dh" binding:"required"`
+	Auth     string `form:"auth" binding:"required"`
+}
+
+type HapusLanggananPushRequest struct {
+	Endpoint string `form:"endpoint" binding:"required"`
+}
+
+type NotifikasiTesRequest struct {
+	UserID []uint `form:"user_id"` // kosong berarti dikirim ke diri sendiri
+	Judul  string `form:"judul"`
+	Pesan  string `form:"pesan"`
+}
+
+// ✅ GET - Preferensi notifikasi milik user yang login
+func (nc *NotifikasiController) GetPreferensiNotifikasi(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	preferensi, err := nc.findPreferensi(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil preferensi notifikasi",
+		})
+		return
+	}
+
+	// Nomor WhatsApp mengikuti data warga yang ditautkan ke akun
+	var user models.User
+	nc.db.Preload("Warga").First(&user, userID)
+	nomorWhatsApp := ""
+	if user.Warga != nil {
+		nomorWhatsApp = notification.NormalisasiNomorWA(user.Warga.WargaNoTlp)
+	}
+
+	var jumlahPerangkat int64
+	nc.db.Model(&models.LanggananPush{}).Where("user_id = ?", userID).Count(&jumlahPerangkat)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":           preferensi,
+		"nomor_whatsapp": nomorWhatsApp,
+		"perangkat_push": jumlahPerangkat,
+		"kanal_tersedia": nc.notifikasi.KanalAktif(),
+	})
+}
+
+// ✅ PUT - Mengubah preferensi notifikasi milik user yang login
+func (nc *NotifikasiController) UpdatePreferensiNotifikasi(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req UpdatePreferensiNotifikasiRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	preferensi, err := nc.findPreferensi(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil preferensi notifikasi",
+		})
+		return
+	}
+
+	if req.Email != nil {
+		email := strings.TrimSpace(*req.Email)
+		if email != "" {
+			alamat, err := mail.ParseAddress(email)
+			if err != nil || alamat.Address != email {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "Format email tidak valid",
+				})
+				return
+			}
+		}
+		preferensi.Email = email
+	}
+	if req.EmailAktif != nil {
+		preferensi.EmailAktif = *req.EmailAktif
+	}
+	if req.WhatsAppAktif != nil {
+		preferensi.WhatsAppAktif = *req.WhatsAppAktif
+	}
+	if req.PushAktif != nil {
+		preferensi.PushAktif = *req.PushAktif
+	}
+	if req.BroadcastAktif != nil {
+		preferensi.BroadcastAktif = *req.BroadcastAktif
+	}
+	if req.TagihanAktif != nil {
+		preferensi.TagihanAktif = *req.TagihanAktif
+	}
+	if req.KegiatanAktif != nil {
+		preferensi.KegiatanAktif = *req.KegiatanAktif
+	}
+
+	if preferensi.EmailAktif && preferensi.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Isi alamat email sebelum mengaktifkan notifikasi email",
+		})
+		return
+	}
+
+	// Preferensi adalah data pribadi user, disimpan tanpa scope wilayah agar user RW juga bisa mengatur
+	if preferensi.PreferensiNotifikasiID == 0 {
+		preferensi.RTID = nc.rtUser(userID.(uint))
+	}
+	if err := nc.db.Save(&preferensi).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menyimpan preferensi notifikasi",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Preferensi notifikasi berhasil disimpan",
+		"data":    preferensi,
+	})
+}
+
+// ✅ GET - Kunci publik VAPID untuk pushManager.subscribe di browser
+func (nc *NotifikasiController) GetVAPIDPublicKey(c *gin.Context) {
+	publicKey := nc.notifikasi.VAPIDPublicKey()
+	if publicKey == "" {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Web push belum dikonfigurasi di server",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"public_key": publicKey,
+	})
+}
+
+// ✅ POST - Mendaftarkan langganan web push dari browser/perangkat user
+func (nc *NotifikasiController) SimpanLanggananPush(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req LanggananPushRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	endpoint, err := url.Parse(strings.TrimSpace(req.Endpoint))
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" || len(req.Endpoint) > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Endpoint push harus berupa URL https",
+		})
+		return
+	}
+	if !notification.EndpointPushDiizinkan(endpoint.String()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Endpoint push bukan push service browser yang dikenal",
+		})
+		return
+	}
+
+	// Endpoint unik per browser; jika browser dipakai akun lain, langganan dipindahkan ke akun ini
+	var langganan models.LanggananPush
+	err = nc.db.Where("endpoint = ?", endpoint.String()).First(&langganan).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal memeriksa langganan push",
+		})
+		return
+	}
+
+	langganan.UserID = userID.(uint)
+	langganan.Endpoint = endpoint.String()
+	langganan.P256dh = strings.TrimSpace(req.P256dh)
+	langganan.Auth = strings.TrimSpace(req.Auth)
+	langganan.UserAgent = c.Request.UserAgent()
+	if len(langganan.UserAgent) > 255 {
+		langganan.UserAgent = langganan.UserAgent[:255]
+	}
+	langganan.RTID = nc.rtUser(userID.(uint))
+
+	if err := nc.db.Save(&langganan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menyimpan langganan push",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Langganan push berhasil disimpan",
+		"data":    langganan,
+	})
+}
+
+// ✅ DELETE - Menghapus langganan web push (saat user logout atau menonaktifkan notifikasi di browser)
+func (nc *NotifikasiController) HapusLanggananPush(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req HapusLanggananPushRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	result := nc.db.Where("user_id = ? AND endpoint = ?", userID, strings.TrimSpace(req.Endpoint)).
+		Delete(&models.LanggananPush{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal menghapus langganan push",
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Langganan push tidak ditemukan",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Langganan push berhasil dihapus",
+	})
+}
+
+// ✅ GET - Daftar antrian outbox notifikasi untuk memantau pengiriman
+func (nc *NotifikasiController) GetAllOutbox(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	query := scopedDB(c, nc.db).Model(&models.NotifikasiOutbox{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if kanal := c.Query("kanal"); kanal != "" {
+		query = query.Where("kanal = ?", kanal)
+	}
+	if sumber := c.Query("sumber"); sumber != "" {
+		query = query.Where("sumber = ?", sumber)
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var outbox []models.NotifikasiOutbox
+	if err := query.Order("notifikasi_outbox_id DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&outbox).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data outbox notifikasi",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": outbox,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// ✅ POST - Mengantrikan ulang notifikasi yang gagal
+func (nc *NotifikasiController) UlangiNotifikasi(c *gin.Context) {
+	outboxID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID notifikasi tidak valid",
+		})
+		return
+	}
+
+	var outbox models.NotifikasiOutbox
+	if err := scopedDB(c, nc.db).First(&outbox, outboxID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Notifikasi tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan notifikasi",
+			})
+		}
+		return
+	}
+
+	if outbox.Status != "gagal" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Hanya notifikasi berstatus gagal yang dapat dikirim ulang",
+		})
+		return
+	}
+
+	if err := scopedDB(c, nc.db).Model(&outbox).Updates(map[string]interface{}{
+		"status":       "menunggu",
+		"percobaan":    0,
+		"jadwal_kirim": time.Now(),
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengantrikan ulang notifikasi",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notifikasi diantrikan ulang",
+		"data":    outbox,
+	})
+}
+
+// ✅ POST - Mengirim notifikasi percobaan untuk memeriksa konfigurasi kanal
+func (nc *NotifikasiController) KirimNotifikasiTes(c *gin.Context) {
+	var req NotifikasiTesRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	userIDs := req.UserID
+	if len(userIDs) == 0 {
+		userID, _ := c.Get("userID")
+		userIDs = []uint{userID.(uint)}
+	} else {
+		// Hanya user di wilayah pengirim yang boleh dituju
+		var valid []uint
+		scopedDB(c, nc.db).Model(&models.User{}).Where("user_id IN ?", userIDs).Pluck("user_id", &valid)
+		if len(valid) != len(userIDs) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Sebagian user tujuan tidak ditemukan",
+			})
+			return
+		}
+	}
+
+	judul := strings.TrimSpace(req.Judul)
+	if judul == "" {
+		judul = "Tes notifikasi"
+	}
+	pesan := strings.TrimSpace(req.Pesan)
+	if pesan == "" {
+		pesan = "Ini adalah notifikasi percobaan dari aplikasi RT."
+	}
+
+	jumlah, err := nc.notifikasi.KirimKeUser(userIDs, notification.Notifikasi{
+		Judul:  judul,
+		Pesan:  pesan,
+		Sumber: "tes",
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mengantrikan notifikasi",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":           "Notifikasi percobaan diantrikan",
+		"jumlah_diantrikan": jumlah,
+		"kanal_tersedia":    nc.notifikasi.KanalAktif(),
+	})
+}
+
+// ✅ GET - Kanal yang aktif dan ringkasan status antrian outbox
+func (nc *NotifikasiController) GetStatusKanal(c *gin.Context) {
+	type jumlahStatus struct {
+		Kanal  string `json:"kanal"`
+		Status string `json:"status"`
+		Jumlah int64  `json:"jumlah"`
+	}
+
+	var ringkasan []jumlahStatus
+	if err := scopedDB(c, nc.db).Model(&models.NotifikasiOutbox{}).
+		Select("kanal, status, COUNT(*) AS jumlah").
+		Group("kanal, status").
+		Scan(&ringkasan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil ringkasan outbox",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"kanal_aktif": nc.notifikasi.KanalAktif(),
+		"ringkasan":   ringkasan,
+	})
+}
+
+// ✅ Helper mengambil preferensi user, atau preferensi default jika belum pernah diatur
+func (nc *NotifikasiController) findPreferensi(userID uint) (models.PreferensiNotifikasi, error) {
+	var preferensi models.PreferensiNotifikasi
+	err := nc.db.Where("user_id = ?", userID).First(&preferensi).Error
+	if err == gorm.ErrRecordNotFound {
+		return notification.PreferensiDefault(userID), nil
+	}
+	return preferensi, err
+}
+
+func (nc *NotifikasiController) rtUser(userID uint) *uint {
+	var user models.User
+	if err := nc.db.Select("user_id", "rt_id").First(&user, userID).Error; err != nil {
+		return nil
+	}
+	return user.RTID
+}
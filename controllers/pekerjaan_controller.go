@@ -0,0 +1,358 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"rt-management/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PekerjaanController struct {
+	db *gorm.DB
+}
+
+func NewPekerjaanController(db *gorm.DB) *PekerjaanController {
+	return &PekerjaanController{db: db}
+}
+
+// Request structs
+type PekerjaanRequest struct {
+	PekerjaanNama string `form:"pekerjaan_nama" binding:"required"`
+}
+
+type MergePekerjaanRequest struct {
+	SumberID uint `form:"sumber_id" binding:"required"`
+}
+
+// Pekerjaan beserta jumlah warga yang menggunakannya
+type PekerjaanWithUsage struct {
+	models.Pekerjaan
+	JumlahWarga int64 `json:"jumlah_warga"`
+}
+
+// ✅ CREATE - Membuat pekerjaan baru
+func (pc *PekerjaanController) CreatePekerjaan(c *gin.Context) {
+	var req PekerjaanRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Sanitize input
+	req.PekerjaanNama = strings.TrimSpace(req.PekerjaanNama)
+
+	// Validasi panjang nama
+	if len(req.PekerjaanNama) < 2 || len(req.PekerjaanNama) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Nama pekerjaan harus 2-100 karakter",
+		})
+		return
+	}
+
+	// Check if pekerjaan dengan nama yang sama sudah ada
+	var existing models.Pekerjaan
+	if err := pc.db.Where("pekerjaan_nama = ?", req.PekerjaanNama).First(&existing).Error; err == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Pekerjaan dengan nama tersebut sudah ada",
+		})
+		return
+	}
+
+	pekerjaan := models.Pekerjaan{
+		PekerjaanNama: req.PekerjaanNama,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+
+	if err := pc.db.Create(&pekerjaan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal membuat pekerjaan",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Pekerjaan berhasil dibuat",
+		"data":    pekerjaan,
+	})
+}
+
+// ✅ READ - Mendapatkan semua pekerjaan beserta jumlah pemakaian
+func (pc *PekerjaanController) GetAllPekerjaan(c *gin.Context) {
+	var pekerjaan []PekerjaanWithUsage
+
+	query := pc.db.Model(&models.Pekerjaan{}).
+		Select("pekerjaans.*, COUNT(wargas.warga_id) AS jumlah_warga").
+		Joins("LEFT JOIN wargas ON wargas.pekerjaan_id = pekerjaans.pekerjaan_id AND wargas.deleted_at IS NULL").
+		Group("pekerjaans.pekerjaan_id")
+
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		query = query.Where("pekerjaans.pekerjaan_nama LIKE ?", "%"+search+"%")
+	}
+
+	if err := query.Order("pekerjaans.pekerjaan_nama ASC").Scan(&pekerjaan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data pekerjaan",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": pekerjaan,
+	})
+}
+
+// ✅ READ - Mendapatkan pekerjaan by ID
+func (pc *PekerjaanController) GetPekerjaanByID(c *gin.Context) {
+	pekerjaanID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID pekerjaan tidak valid",
+		})
+		return
+	}
+
+	var pekerjaan models.Pekerjaan
+	if err := pc.db.First(&pekerjaan, pekerjaanID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Pekerjaan tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal mengambil data pekerjaan",
+			})
+		}
+		return
+	}
+
+	var jumlahWarga int64
+	pc.db.Model(&models.Warga{}).Where("pekerjaan_id = ?", pekerjaan.PekerjaanID).Count(&jumlahWarga)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": PekerjaanWithUsage{Pekerjaan: pekerjaan, JumlahWarga: jumlahWarga},
+	})
+}
+
+// ✅ UPDATE - Mengupdate pekerjaan
+func (pc *PekerjaanController) UpdatePekerjaan(c *gin.Context) {
+	pekerjaanID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID pekerjaan tidak valid",
+		})
+		return
+	}
+
+	var pekerjaan models.Pekerjaan
+	if err := pc.db.First(&pekerjaan, pekerjaanID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Pekerjaan tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan pekerjaan",
+			})
+		}
+		return
+	}
+
+	var req PekerjaanRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	req.PekerjaanNama = strings.TrimSpace(req.PekerjaanNama)
+	if len(req.PekerjaanNama) < 2 || len(req.PekerjaanNama) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Nama pekerjaan harus 2-100 karakter",
+		})
+		return
+	}
+
+	// Check if pekerjaan dengan nama yang sama sudah ada (exclude current)
+	var existing models.Pekerjaan
+	if err := pc.db.Where("pekerjaan_nama = ? AND pekerjaan_id != ?", req.PekerjaanNama, pekerjaanID).First(&existing).Error; err == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Pekerjaan dengan nama tersebut sudah ada",
+		})
+		return
+	}
+
+	pekerjaan.PekerjaanNama = req.PekerjaanNama
+	pekerjaan.UpdatedAt = time.Now()
+
+	if err := pc.db.Save(&pekerjaan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mengupdate pekerjaan",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Pekerjaan berhasil diupdate",
+		"data":    pekerjaan,
+	})
+}
+
+// ✅ DELETE - Menghapus pekerjaan yang tidak dipakai warga (admin global)
+func (pc *PekerjaanController) DeletePekerjaan(c *gin.Context) {
+	if !requireGlobalMasterData(c) {
+		return
+	}
+
+	pekerjaanID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID pekerjaan tidak valid",
+		})
+		return
+	}
+
+	var pekerjaan models.Pekerjaan
+	if err := pc.db.First(&pekerjaan, pekerjaanID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Pekerjaan tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan pekerjaan",
+			})
+		}
+		return
+	}
+
+	// Check jika pekerjaan masih dipakai warga (termasuk warga di trash)
+	var jumlahWarga int64
+	pc.db.Unscoped().Model(&models.Warga{}).Where("pekerjaan_id = ?", pekerjaan.PekerjaanID).Count(&jumlahWarga)
+	if jumlahWarga > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tidak dapat menghapus pekerjaan yang masih dipakai warga",
+			"details": gin.H{
+				"total_warga": jumlahWarga,
+			},
+		})
+		return
+	}
+
+	if err := pc.db.Delete(&pekerjaan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menghapus pekerjaan",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Pekerjaan berhasil dihapus",
+	})
+}
+
+// ✅ POST - Menggabungkan pekerjaan sumber ke pekerjaan :id lalu menghapus pekerjaan sumber (admin global)
+func (pc *PekerjaanController) MergePekerjaan(c *gin.Context) {
+	if !requireGlobalMasterData(c) {
+		return
+	}
+
+	pekerjaanID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID pekerjaan tidak valid",
+		})
+		return
+	}
+
+	var req MergePekerjaanRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if uint(pekerjaanID) == req.SumberID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Pekerjaan tujuan dan sumber tidak boleh sama",
+		})
+		return
+	}
+
+	var tujuan, sumber models.Pekerjaan
+	if err := pc.db.First(&tujuan, pekerjaanID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Pekerjaan tujuan tidak ditemukan",
+		})
+		return
+	}
+	if err := pc.db.First(&sumber, req.SumberID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Pekerjaan sumber tidak ditemukan",
+		})
+		return
+	}
+
+	var jumlahWarga int64
+	err = pc.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(&models.Warga{}).
+			Where("pekerjaan_id = ?", sumber.PekerjaanID).
+			Update("pekerjaan_id", tujuan.PekerjaanID)
+		if result.Error != nil {
+			return result.Error
+		}
+		jumlahWarga = result.RowsAffected
+		return tx.Delete(&sumber).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menggabungkan pekerjaan",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":             "Pekerjaan berhasil digabungkan",
+		"data":                tujuan,
+		"jumlah_warga_pindah": jumlahWarga,
+	})
+}
+
+// ✅ GET - Dropdown pekerjaan
+func (pc *PekerjaanController) GetPekerjaanDropdown(c *gin.Context) {
+	var pekerjaan []struct {
+		PekerjaanID   uint   `json:"pekerjaan_id"`
+		PekerjaanNama string `json:"pekerjaan_nama"`
+	}
+
+	if err := pc.db.
+		Model(&models.Pekerjaan{}).
+		Select("pekerjaan_id, pekerjaan_nama").
+		Order("pekerjaan_nama ASC").
+		Find(&pekerjaan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data dropdown pekerjaan",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": pekerjaan,
+	})
+}
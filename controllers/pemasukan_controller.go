@@ -11,17 +11,19 @@ import (
 
 	"rt-management/helper"
 	"rt-management/models"
+	"rt-management/realtime"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type PemasukanController struct {
-	db *gorm.DB
+	db  *gorm.DB
+	hub *realtime.Hub
 }
 
-func NewPemasukanController(db *gorm.DB) *PemasukanController {
-	return &PemasukanController{db: db}
+func NewPemasukanController(db *gorm.DB, hub *realtime.Hub) *PemasukanController {
+	return &PemasukanController{db: db, hub: hub}
 }
 
 // Request structs
@@ -102,7 +104,7 @@ func (pc *PemasukanController) CreatePemasukan(c *gin.Context) {
 
 	// Check if kategori pemasukan exists
 	var kategori models.KategoriPemasukan
-	if err := pc.db.First(&kategori, uint(kategoriPemasukanID)).Error; err != nil {
+	if err := scopedDB(c, pc.db).First(&kategori, uint(kategoriPemasukanID)).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": "Kategori pemasukan tidak ditemukan",
@@ -141,7 +143,7 @@ func (pc *PemasukanController) CreatePemasukan(c *gin.Context) {
 		UpdatedAt:           time.Now(),
 	}
 
-	if err := pc.db.Create(&pemasukan).Error; err != nil {
+	if err := scopedDB(c, pc.db).Create(&pemasukan).Error; err != nil {
 		// Jika gagal create, hapus file yang sudah diupload
 		if pemasukanBuktiFilename != "" {
 			helper.DeleteOldPhoto(pemasukanBuktiFilename, "pemasukan_bukti")
@@ -154,13 +156,16 @@ func (pc *PemasukanController) CreatePemasukan(c *gin.Context) {
 	}
 
 	// Reload dengan data kategori
-	if err := pc.db.Preload("KategoriPemasukan").First(&pemasukan, pemasukan.PemasukanID).Error; err != nil {
+	if err := scopedDB(c, pc.db).Preload("KategoriPemasukan").First(&pemasukan, pemasukan.PemasukanID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Gagal memuat data pemasukan yang dibuat",
 		})
 		return
 	}
 
+	// Pembayaran iuran yang dicatat bendahara diumumkan ke pengurus keuangan
+	publikasikanTagihanDibayar(pc.hub, "pemasukan", pemasukan.PemasukanID, pemasukan, nil)
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Pemasukan berhasil dibuat",
 		"data":    pemasukan,
@@ -182,7 +187,7 @@ func (pc *PemasukanController) GetAllPemasukan(c *gin.Context) {
 	search := c.Query("search")
 
 	// Build query dengan GORM (AMAN - parameterized queries)
-	query := pc.db.Model(&models.Pemasukan{}).Preload("KategoriPemasukan")
+	query := scopedDB(c, pc.db).Model(&models.Pemasukan{}).Preload("KategoriPemasukan")
 
 	// Apply filters
 	if search != "" {
@@ -248,7 +253,7 @@ func (pc *PemasukanController) GetPemasukanByID(c *gin.Context) {
 	}
 
 	var pemasukan models.Pemasukan
-	if err := pc.db.Preload("KategoriPemasukan").First(&pemasukan, pemasukanID).Error; err != nil {
+	if err := scopedDB(c, pc.db).Preload("KategoriPemasukan").First(&pemasukan, pemasukanID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Pemasukan tidak ditemukan",
@@ -280,7 +285,7 @@ func (pc *PemasukanController) UpdatePemasukan(c *gin.Context) {
 	}
 
 	var pemasukan models.Pemasukan
-	if err := pc.db.First(&pemasukan, pemasukanID).Error; err != nil {
+	if err := scopedDB(c, pc.db).First(&pemasukan, pemasukanID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Pemasukan tidak ditemukan",
@@ -314,7 +319,7 @@ func (pc *PemasukanController) UpdatePemasukan(c *gin.Context) {
 
 		// Validasi kategori pemasukan jika diupdate
 		var kategori models.KategoriPemasukan
-		if err := pc.db.First(&kategori, uint(kategoriPemasukanID)).Error; err != nil {
+		if err := scopedDB(c, pc.db).First(&kategori, uint(kategoriPemasukanID)).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				c.JSON(http.StatusBadRequest, gin.H{
 					"error": "Kategori pemasukan tidak ditemukan",
@@ -397,7 +402,7 @@ func (pc *PemasukanController) UpdatePemasukan(c *gin.Context) {
 
 	// Eksekusi update hanya jika ada field yang diupdate
 	if len(updates) > 0 {
-		if err := pc.db.Model(&pemasukan).Updates(updates).Error; err != nil {
+		if err := scopedDB(c, pc.db).Model(&pemasukan).Updates(updates).Error; err != nil {
 			// Jika gagal update, hapus file baru yang sudah diupload
 			if pemasukanBuktiFilename != pemasukan.PemasukanBukti {
 				helper.DeleteOldPhoto(pemasukanBuktiFilename, "pemasukan_bukti")
@@ -411,7 +416,7 @@ func (pc *PemasukanController) UpdatePemasukan(c *gin.Context) {
 	}
 
 	// Reload dengan data terbaru termasuk kategori
-	if err := pc.db.Preload("KategoriPemasukan").First(&pemasukan, pemasukanID).Error; err != nil {
+	if err := scopedDB(c, pc.db).Preload("KategoriPemasukan").First(&pemasukan, pemasukanID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Gagal memuat data pemasukan yang diupdate",
 		})
@@ -424,7 +429,7 @@ func (pc *PemasukanController) UpdatePemasukan(c *gin.Context) {
 	})
 }
 
-// ✅ DELETE - Menghapus pemasukan
+// ✅ DELETE - Memindahkan pemasukan ke trash (soft delete)
 func (pc *PemasukanController) DeletePemasukan(c *gin.Context) {
 	id := c.Param("id")
 
@@ -438,7 +443,7 @@ func (pc *PemasukanController) DeletePemasukan(c *gin.Context) {
 	}
 
 	var pemasukan models.Pemasukan
-	if err := pc.db.First(&pemasukan, pemasukanID).Error; err != nil {
+	if err := scopedDB(c, pc.db).First(&pemasukan, pemasukanID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Pemasukan tidak ditemukan",
@@ -451,8 +456,8 @@ func (pc *PemasukanController) DeletePemasukan(c *gin.Context) {
 		return
 	}
 
-	// Delete menggunakan GORM Delete (AMAN)
-	if err := pc.db.Delete(&pemasukan).Error; err != nil {
+	// Soft delete (AMAN) - file bukti tetap disimpan sampai trash di-purge
+	if err := scopedDB(c, pc.db).Delete(&pemasukan).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Gagal menghapus pemasukan",
 			"details": err.Error(),
@@ -460,13 +465,70 @@ func (pc *PemasukanController) DeletePemasukan(c *gin.Context) {
 		return
 	}
 
-	// Hapus file foto jika ada
-	if pemasukan.PemasukanBukti != "" {
-		helper.DeleteOldPhoto(pemasukan.PemasukanBukti, "pemasukan_bukti")
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Pemasukan berhasil dihapus",
+	})
+}
+
+// ✅ GET - Mendapatkan pemasukan yang ada di trash
+func (pc *PemasukanController) GetTrashPemasukan(c *gin.Context) {
+	var pemasukan []models.Pemasukan
+
+	if err := scopedDB(c, pc.db).Unscoped().
+		Preload("KategoriPemasukan").
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Find(&pemasukan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data trash pemasukan",
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Pemasukan berhasil dihapus",
+		"data":  pemasukan,
+		"total": len(pemasukan),
+	})
+}
+
+// ✅ PUT - Mengembalikan pemasukan dari trash
+func (pc *PemasukanController) RestorePemasukan(c *gin.Context) {
+	id := c.Param("id")
+
+	// Validasi ID (AMAN - dikonversi ke uint)
+	pemasukanID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID pemasukan tidak valid",
+		})
+		return
+	}
+
+	var pemasukan models.Pemasukan
+	if err := scopedDB(c, pc.db).Unscoped().Where("deleted_at IS NOT NULL").First(&pemasukan, pemasukanID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Pemasukan tidak ditemukan di trash",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan pemasukan",
+			})
+		}
+		return
+	}
+
+	if err := scopedDB(c, pc.db).Unscoped().Model(&pemasukan).Update("deleted_at", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal memulihkan pemasukan",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Pemasukan berhasil dipulihkan",
+		"data":    pemasukan,
 	})
 }
 
@@ -483,22 +545,22 @@ func (pc *PemasukanController) GetStatistikPemasukan(c *gin.Context) {
 	var statistik StatistikResult
 
 	// Hitung total pemasukan (AMAN)
-	pc.db.Model(&models.Pemasukan{}).Count(&statistik.TotalPemasukan)
+	scopedDB(c, pc.db).Model(&models.Pemasukan{}).Count(&statistik.TotalPemasukan)
 
 	// Hitung pemasukan bulan ini (AMAN)
 	awalBulan := time.Now().AddDate(0, 0, -time.Now().Day()+1)
-	pc.db.Model(&models.Pemasukan{}).
+	scopedDB(c, pc.db).Model(&models.Pemasukan{}).
 		Where("pemasukan_tanggal >= ?", awalBulan).
 		Count(&statistik.BulanIni)
 
 	// Hitung pemasukan minggu ini (AMAN)
 	awalMinggu := time.Now().AddDate(0, 0, -int(time.Now().Weekday())+1)
-	pc.db.Model(&models.Pemasukan{}).
+	scopedDB(c, pc.db).Model(&models.Pemasukan{}).
 		Where("pemasukan_tanggal >= ?", awalMinggu).
 		Count(&statistik.MingguIni)
 
 	// Hitung total nominal (AMAN)
-	pc.db.Model(&models.Pemasukan{}).
+	scopedDB(c, pc.db).Model(&models.Pemasukan{}).
 		Select("COALESCE(SUM(pemasukan_nominal), 0)").
 		Row().
 		Scan(&statistik.TotalNominal)
@@ -525,7 +587,7 @@ func (pc *PemasukanController) GetTotalNominalPerKategori(c *gin.Context) {
 	var results []TotalPerKategori
 
 	// Query total nominal per kategori (AMAN)
-	if err := pc.db.
+	if err := scopedDB(c, pc.db).
 		Model(&models.Pemasukan{}).
 		Select("kategori_pemasukan.kategori_pemasukan_nama, SUM(pemasukan_nominal) as total_nominal").
 		Joins("LEFT JOIN kategori_pemasukans ON kategori_pemasukans.kategori_pemasukan_id = pemasukans.kategori_pemasukan_id").
@@ -583,12 +645,12 @@ func (pc *PemasukanController) GetLaporanPemasukanBulanan(c *gin.Context) {
 		akhirBulan := awalBulan.AddDate(0, 1, -1)
 
 		// Hitung total pemasukan per bulan (AMAN)
-		pc.db.Model(&models.Pemasukan{}).
+		scopedDB(c, pc.db).Model(&models.Pemasukan{}).
 			Where("pemasukan_tanggal BETWEEN ? AND ?", awalBulan, akhirBulan).
 			Count(&jumlah)
 
 		// Hitung jumlah transaksi per bulan (AMAN)
-		pc.db.Model(&models.Pemasukan{}).
+		scopedDB(c, pc.db).Model(&models.Pemasukan{}).
 			Select("COALESCE(SUM(pemasukan_nominal), 0)").
 			Where("pemasukan_tanggal BETWEEN ? AND ?", awalBulan, akhirBulan).
 			Row().
@@ -654,4 +716,44 @@ func (pc *PemasukanController) GetPemasukanBuktiImage(c *gin.Context) {
 
 	// Serve file
 	http.ServeContent(c.Writer, c.Request, filename, fileInfo.ModTime(), file)
-}
\ No newline at end of file
+}
+
+// ✅ Helper mencatat pemasukan otomatis dari modul lain (mis. denda ronda, sewa fasilitas).
+// Kategori dicari berdasarkan nama dan dibuat jika belum ada.
+func catatPemasukanOtomatis(tx *gorm.DB, kategoriNama, nama string, nominal float64, rtID *uint) (models.Pemasukan, error) {
+	var kategori models.KategoriPemasukan
+	if err := tx.Where("kategori_pemasukan_nama = ?", kategoriNama).
+		FirstOrCreate(&kategori, models.KategoriPemasukan{KategoriPemasukanNama: kategoriNama}).Error; err != nil {
+		return models.Pemasukan{}, err
+	}
+
+	pemasukan := models.Pemasukan{
+		KategoriPemasukanID: kategori.KategoriPemasukanID,
+		PemasukanNama:       nama,
+		PemasukanTanggal:    time.Now(),
+		PemasukanNominal:    nominal,
+		RTID:                rtID,
+		CreatedAt:           time.Now(),
+		UpdatedAt:           time.Now(),
+	}
+	err := tx.Create(&pemasukan).Error
+	return pemasukan, err
+}
+
+// ✅ Helper mendorong event tagihan.dibayar ke pengurus keuangan di RT tersebut dan ke user pembayar
+func publikasikanTagihanDibayar(hub *realtime.Hub, sumber string, sumberID uint, pemasukan models.Pemasukan, pembayar []uint) {
+	hub.Publikasikan(realtime.Event{
+		Topik: realtime.TopikTagihan,
+		Jenis: "tagihan.dibayar",
+		Data: gin.H{
+			"sumber":       sumber,
+			"sumber_id":    sumberID,
+			"pemasukan_id": pemasukan.PemasukanID,
+			"nama":         pemasukan.PemasukanNama,
+			"nominal":      pemasukan.PemasukanNominal,
+		},
+		RTID:     pemasukan.RTID,
+		UserIDs:  pembayar,
+		LevelIDs: []uint{1, 2, 3},
+	})
+}
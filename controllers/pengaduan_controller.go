@@ -0,0 +1,1127 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"rt-management/helper"
+	"rt-management/models"
+	"rt-management/notification"
+	"rt-management/realtime"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PengaduanController struct {
+	db         *gorm.DB
+	notifikasi *notification.Service
+	hub        *realtime.Hub
+}
+
+func NewPengaduanController(db *gorm.DB, notifikasi *notification.Service, hub *realtime.Hub) *PengaduanController {
+	return &PengaduanController{db: db, notifikasi: notifikasi, hub: hub}
+}
+
+// Jumlah maksimal foto per upload pengaduan
+const maxFotoPengaduanPerUpload = 5
+
+// Request structs
+type CreatePengaduanRequest struct {
+	KategoriPengaduanID  uint   `form:"kategori_pengaduan_id" binding:"required"`
+	PengaduanJudul       string `form:"pengaduan_judul" binding:"required"`
+	PengaduanDeskripsi   string `form:"pengaduan_deskripsi" binding:"required"`
+	RumahID              *uint  `form:"rumah_id"`
+	PengaduanLokasi      string `form:"pengaduan_lokasi"`
+	PengaduanVisibilitas string `form:"pengaduan_visibilitas"` // publik (default) atau privat
+}
+
+type UbahStatusPengaduanRequest struct {
+	Status  string `form:"status" binding:"required"` // diproses, selesai, ditutup
+	Catatan string `form:"catatan"`
+}
+
+type TugaskanPetugasPengaduanRequest struct {
+	PetugasID uint   `form:"petugas_id" binding:"required"`
+	Catatan   string `form:"catatan"`
+}
+
+type CreateKomentarPengaduanRequest struct {
+	KomentarIsi      string `form:"komentar_isi" binding:"required"`
+	KomentarInternal bool   `form:"komentar_internal"`
+}
+
+// Pengaduan beserta nama pelapor/petugas dan status SLA saat ini
+type PengaduanDetail struct {
+	models.Pengaduan
+	PelaporUsername string `json:"pelapor_username"`
+	PetugasUsername string `json:"petugas_username,omitempty"`
+	SLARespon       string `json:"sla_respon"`  // berjalan, melewati, tepat_waktu, terlambat
+	SLASelesai      string `json:"sla_selesai"` // berjalan, melewati, tepat_waktu, terlambat, tidak_berlaku
+}
+
+type KomentarPengaduanDetail struct {
+	models.KomentarPengaduan
+	PenulisUsername string `json:"penulis_username"`
+}
+
+type RiwayatStatusPengaduanDetail struct {
+	models.RiwayatStatusPengaduan
+	DiubahOlehUsername string `json:"diubah_oleh_username"`
+}
+
+type StatistikKategoriPengaduan struct {
+	KategoriPengaduanID   uint             `json:"kategori_pengaduan_id"`
+	KategoriPengaduanNama string           `json:"kategori_pengaduan_nama"`
+	Total                 int64            `json:"total"`
+	PerStatus             map[string]int64 `json:"per_status"`
+	RataResponJam         float64          `json:"rata_respon_jam"`
+	RataSelesaiJam        float64          `json:"rata_selesai_jam"`
+	KepatuhanSLARespon    float64          `json:"kepatuhan_sla_respon"`  // persentase
+	KepatuhanSLASelesai   float64          `json:"kepatuhan_sla_selesai"` // persentase
+
+	jumlahRespon, totalMenitRespon, responTepat, responDinilai     int64
+	jumlahSelesai, totalMenitSelesai, selesaiTepat, selesaiDinilai int64
+}
+
+// ✅ GET - Daftar pengaduan. Warga hanya melihat pengaduan publik, miliknya, atau yang ditugaskan kepadanya.
+func (pc *PengaduanController) GetAllPengaduan(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	userID, _ := c.Get("userID")
+	query := pc.queryTerlihat(c, userID.(uint))
+
+	if status := c.Query("status"); status != "" {
+		if !isValidStatusPengaduan(status) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Status harus terbuka, diproses, selesai, atau ditutup",
+			})
+			return
+		}
+		query = query.Where("pengaduan_status = ?", status)
+	}
+	if kategoriID := c.Query("kategori_id"); kategoriID != "" {
+		query = query.Where("kategori_pengaduan_id = ?", kategoriID)
+	}
+	if petugasID := c.Query("petugas_id"); petugasID != "" {
+		query = query.Where("petugas_id = ?", petugasID)
+	}
+	if visibilitas := c.Query("visibilitas"); visibilitas != "" {
+		query = query.Where("pengaduan_visibilitas = ?", visibilitas)
+	}
+	if c.Query("saya") == "true" {
+		query = query.Where("dilaporkan_oleh_id = ?", userID)
+	}
+	if c.Query("sla") == "melewati" {
+		now := time.Now()
+		query = query.Where("pengaduan_status IN ?", []string{"terbuka", "diproses"}).
+			Where("(direspon_pada IS NULL AND tenggat_respon < ?) OR tenggat_selesai < ?", now, now)
+	}
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		query = query.Where("pengaduan_kode LIKE ? OR pengaduan_judul LIKE ? OR pengaduan_lokasi LIKE ?",
+			"%"+search+"%", "%"+search+"%", "%"+search+"%")
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var pengaduan []models.Pengaduan
+	if err := query.Preload("Kategori").Preload("Rumah").
+		Order("created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&pengaduan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data pengaduan",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": pc.lengkapiPengaduan(pengaduan, time.Now()),
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// ✅ GET - Detail pengaduan beserta foto, komentar, dan riwayat status
+func (pc *PengaduanController) GetPengaduanByID(c *gin.Context) {
+	pengaduan, ok := pc.findPengaduan(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	komentarQuery := scopedDB(c, pc.db).Where("pengaduan_id = ?", pengaduan.PengaduanID)
+	if !pc.bolehKomentarInternal(c, pengaduan, userID.(uint)) {
+		komentarQuery = komentarQuery.Where("komentar_internal = ?", false)
+	}
+	var komentar []models.KomentarPengaduan
+	komentarQuery.Order("created_at ASC").Find(&komentar)
+
+	var riwayat []models.RiwayatStatusPengaduan
+	scopedDB(c, pc.db).Where("pengaduan_id = ?", pengaduan.PengaduanID).
+		Order("created_at ASC, riwayat_status_pengaduan_id ASC").
+		Find(&riwayat)
+
+	userIDs := make([]uint, 0, len(komentar)+len(riwayat))
+	for _, k := range komentar {
+		userIDs = append(userIDs, k.UserID)
+	}
+	for _, r := range riwayat {
+		userIDs = append(userIDs, r.DiubahOlehID)
+	}
+	nama := petaUsername(pc.db, userIDs)
+
+	komentarDetail := make([]KomentarPengaduanDetail, 0, len(komentar))
+	for _, k := range komentar {
+		komentarDetail = append(komentarDetail, KomentarPengaduanDetail{KomentarPengaduan: k, PenulisUsername: nama[k.UserID]})
+	}
+	riwayatDetail := make([]RiwayatStatusPengaduanDetail, 0, len(riwayat))
+	for _, r := range riwayat {
+		riwayatDetail = append(riwayatDetail, RiwayatStatusPengaduanDetail{RiwayatStatusPengaduan: r, DiubahOlehUsername: nama[r.DiubahOlehID]})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":     pc.lengkapiPengaduan([]models.Pengaduan{pengaduan}, time.Now())[0],
+		"komentar": komentarDetail,
+		"riwayat":  riwayatDetail,
+	})
+}
+
+// ✅ POST - Warga membuat pengaduan baru, foto laporan opsional pada field pengaduan_foto
+func (pc *PengaduanController) CreatePengaduan(c *gin.Context) {
+	var req CreatePengaduanRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	req.PengaduanJudul = strings.TrimSpace(req.PengaduanJudul)
+	req.PengaduanDeskripsi = strings.TrimSpace(req.PengaduanDeskripsi)
+	req.PengaduanLokasi = strings.TrimSpace(req.PengaduanLokasi)
+	if req.PengaduanVisibilitas == "" {
+		req.PengaduanVisibilitas = "publik"
+	}
+
+	if len(req.PengaduanJudul) < 5 || len(req.PengaduanJudul) > 150 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Judul pengaduan harus 5-150 karakter",
+		})
+		return
+	}
+	if req.PengaduanDeskripsi == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Deskripsi pengaduan harus diisi",
+		})
+		return
+	}
+	if req.PengaduanVisibilitas != "publik" && req.PengaduanVisibilitas != "privat" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Visibilitas harus publik atau privat",
+		})
+		return
+	}
+	if (req.RumahID == nil || *req.RumahID == 0) && req.PengaduanLokasi == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Lokasi harus diisi: pilih rumah_id atau tuliskan pengaduan_lokasi",
+		})
+		return
+	}
+	if len(req.PengaduanLokasi) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Lokasi maksimal 255 karakter",
+		})
+		return
+	}
+
+	var kategori models.KategoriPengaduan
+	if err := scopedDB(c, pc.db).First(&kategori, req.KategoriPengaduanID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Kategori pengaduan tidak ditemukan",
+		})
+		return
+	}
+
+	if req.RumahID != nil && *req.RumahID == 0 {
+		req.RumahID = nil
+	}
+	if req.RumahID != nil {
+		var rumah models.Rumah
+		if err := scopedDB(c, pc.db).First(&rumah, *req.RumahID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Rumah tidak ditemukan",
+			})
+			return
+		}
+	}
+
+	// Foto bersifat opsional; request tanpa multipart atau tanpa file tetap diterima
+	filenames, err := helper.HandleMultipleFileImageUpload(c, "pengaduan_foto", maxFotoPengaduanPerUpload)
+	if err != nil && err != http.ErrMissingFile && err != http.ErrNotMultipart {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Gagal mengupload foto pengaduan",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	pelapor := userID.(uint)
+	now := time.Now()
+	pengaduan := models.Pengaduan{
+		KategoriPengaduanID:  kategori.KategoriPengaduanID,
+		PengaduanJudul:       req.PengaduanJudul,
+		PengaduanDeskripsi:   req.PengaduanDeskripsi,
+		RumahID:              req.RumahID,
+		PengaduanLokasi:      req.PengaduanLokasi,
+		PengaduanVisibilitas: req.PengaduanVisibilitas,
+		PengaduanStatus:      "terbuka",
+		DilaporkanOlehID:     pelapor,
+		TenggatRespon:        now.Add(time.Duration(kategori.SLAResponJam) * time.Hour),
+		TenggatSelesai:       now.Add(time.Duration(kategori.SLASelesaiJam) * time.Hour),
+		CreatedAt:            now,
+	}
+
+	err = scopedDB(c, pc.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&pengaduan).Error; err != nil {
+			return err
+		}
+
+		// Kode tiket memakai ID agar unik tanpa perlu menghitung urutan harian
+		pengaduan.PengaduanKode = fmt.Sprintf("ADU-%s-%04d", now.Format("060102"), pengaduan.PengaduanID)
+		if err := tx.Model(&pengaduan).Update("pengaduan_kode", pengaduan.PengaduanKode).Error; err != nil {
+			return err
+		}
+
+		for _, filename := range filenames {
+			foto := models.FotoPengaduan{
+				PengaduanID:    pengaduan.PengaduanID,
+				FotoFile:       filename,
+				FotoTahap:      "laporan",
+				DiunggahOlehID: &pelapor,
+				RTID:           pengaduan.RTID,
+			}
+			if err := tx.Create(&foto).Error; err != nil {
+				return err
+			}
+			pengaduan.Foto = append(pengaduan.Foto, foto)
+		}
+
+		return tx.Create(&models.RiwayatStatusPengaduan{
+			PengaduanID:  pengaduan.PengaduanID,
+			StatusKe:     "terbuka",
+			Catatan:      "Pengaduan dibuat",
+			DiubahOlehID: pelapor,
+			RTID:         pengaduan.RTID,
+		}).Error
+	})
+	if err != nil {
+		// Rollback file upload jika gagal menyimpan ke database
+		for _, filename := range filenames {
+			helper.DeleteOldPhoto(filename, "pengaduan_foto")
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal membuat pengaduan",
+			"details": err.Error(),
+		})
+		return
+	}
+	pengaduan.Kategori = &kategori
+
+	pengurus, _ := pengurusPengaduan(pc.db, pengaduan.RTID)
+	pc.beritahu(pengaduan, pengurus, "pengaduan.dibuat",
+		"Pengaduan baru: "+pengaduan.PengaduanJudul,
+		fmt.Sprintf("%s (%s). Target respon %s.", ringkasTeks(pengaduan.PengaduanDeskripsi, 200), kategori.KategoriPengaduanNama, pengaduan.TenggatRespon.Format("02-01-2006 15:04")),
+		fmt.Sprintf("pengaduan:%d:dibuat", pengaduan.PengaduanID))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Pengaduan berhasil dibuat dengan kode " + pengaduan.PengaduanKode,
+		"data":    pc.lengkapiPengaduan([]models.Pengaduan{pengaduan}, now)[0],
+	})
+}
+
+// ✅ PUT - Mengubah status pengaduan.
+// Pengurus/petugas: terbuka -> diproses -> selesai. Pelapor/pengurus: selesai -> ditutup atau dibuka kembali (diproses).
+// Pengurus dapat menutup pengaduan yang belum selesai (mis. bukan wewenang RT) dengan catatan.
+func (pc *PengaduanController) UbahStatusPengaduan(c *gin.Context) {
+	pengaduan, ok := pc.findPengaduan(c)
+	if !ok {
+		return
+	}
+
+	var req UbahStatusPengaduanRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+	req.Catatan = strings.TrimSpace(req.Catatan)
+	if len(req.Catatan) > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Catatan maksimal 500 karakter",
+		})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	uid := userID.(uint)
+	pengurus := isPengurusPengaduan(c)
+	petugas := pengaduan.PetugasID != nil && *pengaduan.PetugasID == uid
+	pelapor := pengaduan.DilaporkanOlehID == uid
+	dari := pengaduan.PengaduanStatus
+
+	var boleh bool
+	switch {
+	case dari == "terbuka" && req.Status == "diproses",
+		dari == "diproses" && req.Status == "selesai":
+		boleh = pengurus || petugas
+	case dari == "selesai" && (req.Status == "ditutup" || req.Status == "diproses"):
+		boleh = pengurus || pelapor
+		if req.Status == "diproses" && req.Catatan == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Catatan alasan harus diisi saat membuka kembali pengaduan",
+			})
+			return
+		}
+	case (dari == "terbuka" || dari == "diproses") && req.Status == "ditutup":
+		boleh = pengurus
+		if req.Catatan == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Catatan alasan harus diisi saat menutup pengaduan yang belum selesai",
+			})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Status pengaduan tidak dapat diubah dari %s ke %s", dari, req.Status),
+		})
+		return
+	}
+	if !boleh {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Anda tidak berhak mengubah status pengaduan ini",
+		})
+		return
+	}
+	if req.Status == "selesai" && req.Catatan == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Catatan penyelesaian harus diisi",
+		})
+		return
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"pengaduan_status": req.Status}
+	switch req.Status {
+	case "diproses":
+		if pengaduan.DiresponPada == nil {
+			updates["direspon_pada"] = now
+		}
+		if dari == "selesai" {
+			updates["diselesaikan_pada"] = nil
+		}
+	case "selesai":
+		updates["diselesaikan_pada"] = now
+		updates["pengaduan_penyelesaian"] = req.Catatan
+	case "ditutup":
+		updates["ditutup_pada"] = now
+		if pengaduan.DiresponPada == nil {
+			updates["direspon_pada"] = now
+		}
+	}
+
+	riwayat := models.RiwayatStatusPengaduan{
+		PengaduanID:  pengaduan.PengaduanID,
+		StatusDari:   dari,
+		StatusKe:     req.Status,
+		Catatan:      req.Catatan,
+		DiubahOlehID: uid,
+		RTID:         pengaduan.RTID,
+	}
+	err := scopedDB(c, pc.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&pengaduan).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Create(&riwayat).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mengubah status pengaduan",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	pengaduan, _ = pc.findPengaduan(c)
+
+	// Pelapor dan petugas diberi tahu, kecuali yang melakukan perubahan
+	penerima := []uint{pengaduan.DilaporkanOlehID}
+	if pengaduan.PetugasID != nil {
+		penerima = append(penerima, *pengaduan.PetugasID)
+	}
+	pesan := "Status pengaduan " + pengaduan.PengaduanKode + " menjadi " + req.Status
+	if req.Catatan != "" {
+		pesan += ": " + ringkasTeks(req.Catatan, 200)
+	}
+	pc.beritahu(pengaduan, tanpaUser(penerima, uid), "pengaduan.status",
+		fmt.Sprintf("Pengaduan \"%s\" %s", pengaduan.PengaduanJudul, req.Status), pesan,
+		fmt.Sprintf("pengaduan:%d:riwayat:%d", pengaduan.PengaduanID, riwayat.RiwayatStatusPengaduanID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Status pengaduan berhasil diubah menjadi " + req.Status,
+		"data":    pc.lengkapiPengaduan([]models.Pengaduan{pengaduan}, now)[0],
+	})
+}
+
+// ✅ PUT - Menugaskan petugas penanganan pengaduan. Penugasan dihitung sebagai respon pertama.
+func (pc *PengaduanController) TugaskanPetugas(c *gin.Context) {
+	pengaduan, ok := pc.findPengaduan(c)
+	if !ok {
+		return
+	}
+
+	var req TugaskanPetugasPengaduanRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+	req.Catatan = strings.TrimSpace(req.Catatan)
+
+	if pengaduan.PengaduanStatus == "ditutup" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Pengaduan yang sudah ditutup tidak dapat ditugaskan",
+		})
+		return
+	}
+
+	// Petugas harus user di RT yang sama atau user global
+	var petugas models.User
+	query := pc.db.Select("user_id", "username", "rt_id").Where("user_id = ?", req.PetugasID)
+	if pengaduan.RTID != nil {
+		query = query.Where("rt_id = ? OR rt_id IS NULL", *pengaduan.RTID)
+	}
+	if err := query.First(&petugas).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Petugas tidak ditemukan di wilayah pengaduan",
+		})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	now := time.Now()
+	updates := map[string]interface{}{"petugas_id": petugas.UserID}
+	if pengaduan.DiresponPada == nil {
+		updates["direspon_pada"] = now
+	}
+
+	catatan := "Ditugaskan ke " + petugas.Username
+	if req.Catatan != "" {
+		catatan += ": " + req.Catatan
+	}
+	riwayat := models.RiwayatStatusPengaduan{
+		PengaduanID:  pengaduan.PengaduanID,
+		StatusDari:   pengaduan.PengaduanStatus,
+		StatusKe:     pengaduan.PengaduanStatus,
+		Catatan:      ringkasTeks(catatan, 450),
+		DiubahOlehID: userID.(uint),
+		RTID:         pengaduan.RTID,
+	}
+	err := scopedDB(c, pc.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&pengaduan).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Create(&riwayat).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menugaskan petugas",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	pengaduan, _ = pc.findPengaduan(c)
+	pc.beritahu(pengaduan, tanpaUser([]uint{petugas.UserID, pengaduan.DilaporkanOlehID}, userID.(uint)), "pengaduan.petugas",
+		fmt.Sprintf("Pengaduan \"%s\" ditugaskan ke %s", pengaduan.PengaduanJudul, petugas.Username),
+		fmt.Sprintf("%s. Target selesai %s.", pengaduan.PengaduanKode, pengaduan.TenggatSelesai.Format("02-01-2006 15:04")),
+		fmt.Sprintf("pengaduan:%d:riwayat:%d", pengaduan.PengaduanID, riwayat.RiwayatStatusPengaduanID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Petugas berhasil ditugaskan",
+		"data":    pc.lengkapiPengaduan([]models.Pengaduan{pengaduan}, now)[0],
+	})
+}
+
+// ✅ POST - Menambahkan komentar. Komentar internal hanya untuk pengurus dan petugas.
+func (pc *PengaduanController) CreateKomentarPengaduan(c *gin.Context) {
+	pengaduan, ok := pc.findPengaduan(c)
+	if !ok {
+		return
+	}
+
+	var req CreateKomentarPengaduanRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+	req.KomentarIsi = strings.TrimSpace(req.KomentarIsi)
+	if req.KomentarIsi == "" || len([]rune(req.KomentarIsi)) > 2000 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Komentar harus 1-2000 karakter",
+		})
+		return
+	}
+	if pengaduan.PengaduanStatus == "ditutup" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Pengaduan sudah ditutup",
+		})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	uid := userID.(uint)
+	if req.KomentarInternal && !pc.bolehKomentarInternal(c, pengaduan, uid) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Komentar internal hanya untuk pengurus dan petugas",
+		})
+		return
+	}
+
+	komentar := models.KomentarPengaduan{
+		PengaduanID:      pengaduan.PengaduanID,
+		UserID:           uid,
+		KomentarIsi:      req.KomentarIsi,
+		KomentarInternal: req.KomentarInternal,
+		RTID:             pengaduan.RTID,
+	}
+	err := scopedDB(c, pc.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&komentar).Error; err != nil {
+			return err
+		}
+		// Balasan publik pertama dari pengurus/petugas dihitung sebagai respon
+		if pengaduan.DiresponPada == nil && !komentar.KomentarInternal && uid != pengaduan.DilaporkanOlehID &&
+			pc.bolehKomentarInternal(c, pengaduan, uid) {
+			return tx.Model(&pengaduan).Update("direspon_pada", komentar.CreatedAt).Error
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menyimpan komentar",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	penerima := []uint{}
+	if pengaduan.PetugasID != nil {
+		penerima = append(penerima, *pengaduan.PetugasID)
+	}
+	if !komentar.KomentarInternal {
+		penerima = append(penerima, pengaduan.DilaporkanOlehID)
+	}
+	nama := petaUsername(pc.db, []uint{uid})
+	pc.beritahu(pengaduan, tanpaUser(penerima, uid), "pengaduan.komentar",
+		fmt.Sprintf("%s mengomentari pengaduan \"%s\"", nama[uid], pengaduan.PengaduanJudul),
+		ringkasTeks(komentar.KomentarIsi, 300),
+		fmt.Sprintf("pengaduan_komentar:%d", komentar.KomentarPengaduanID))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Komentar berhasil ditambahkan",
+		"data":    KomentarPengaduanDetail{KomentarPengaduan: komentar, PenulisUsername: nama[uid]},
+	})
+}
+
+// ✅ POST - Upload foto tambahan: tahap laporan oleh pelapor, tahap penyelesaian oleh pengurus/petugas
+func (pc *PengaduanController) UploadFotoPengaduan(c *gin.Context) {
+	pengaduan, ok := pc.findPengaduan(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	uid := userID.(uint)
+	tahap := c.DefaultPostForm("foto_tahap", "laporan")
+	switch tahap {
+	case "laporan":
+		if uid != pengaduan.DilaporkanOlehID && !isPengurusPengaduan(c) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Foto laporan hanya dapat ditambahkan oleh pelapor",
+			})
+			return
+		}
+	case "penyelesaian":
+		if !pc.bolehKomentarInternal(c, pengaduan, uid) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Foto penyelesaian hanya dapat ditambahkan oleh pengurus atau petugas",
+			})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tahap foto harus laporan atau penyelesaian",
+		})
+		return
+	}
+	if pengaduan.PengaduanStatus == "ditutup" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Pengaduan sudah ditutup",
+		})
+		return
+	}
+
+	filenames, err := helper.HandleMultipleFileImageUpload(c, "pengaduan_foto", maxFotoPengaduanPerUpload)
+	if err != nil {
+		if err == http.ErrMissingFile {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Minimal satu foto harus diupload pada field pengaduan_foto",
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Gagal mengupload foto pengaduan",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	foto := make([]models.FotoPengaduan, 0, len(filenames))
+	for _, filename := range filenames {
+		foto = append(foto, models.FotoPengaduan{
+			PengaduanID:    pengaduan.PengaduanID,
+			FotoFile:       filename,
+			FotoTahap:      tahap,
+			DiunggahOlehID: &uid,
+			RTID:           pengaduan.RTID,
+		})
+	}
+
+	if err := scopedDB(c, pc.db).Create(&foto).Error; err != nil {
+		// Rollback file upload jika gagal menyimpan ke database
+		for _, filename := range filenames {
+			helper.DeleteOldPhoto(filename, "pengaduan_foto")
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menyimpan foto pengaduan",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": fmt.Sprintf("%d foto pengaduan berhasil diupload", len(foto)),
+		"data":    foto,
+	})
+}
+
+// ✅ GET - Serve file foto pengaduan, hanya untuk user yang boleh melihat pengaduannya
+func (pc *PengaduanController) GetFotoPengaduanImage(c *gin.Context) {
+	var foto models.FotoPengaduan
+	if err := scopedDB(c, pc.db).Where("foto_file = ?", c.Param("filename")).First(&foto).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "File foto tidak ditemukan",
+		})
+		return
+	}
+
+	var pengaduan models.Pengaduan
+	userID, _ := c.Get("userID")
+	if err := scopedDB(c, pc.db).First(&pengaduan, foto.PengaduanID).Error; err != nil ||
+		!bolehLihatPengaduan(c, pengaduan, userID.(uint)) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "File foto tidak ditemukan",
+		})
+		return
+	}
+
+	serveStorageFileName(c, "pengaduan_foto", foto.FotoFile, "File foto tidak ditemukan")
+}
+
+// ✅ DELETE - Menghapus pengaduan beserta foto, komentar, dan riwayatnya
+func (pc *PengaduanController) DeletePengaduan(c *gin.Context) {
+	pengaduan, ok := pc.findPengaduan(c)
+	if !ok {
+		return
+	}
+
+	err := scopedDB(c, pc.db).Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&models.FotoPengaduan{}, &models.KomentarPengaduan{}, &models.RiwayatStatusPengaduan{}} {
+			if err := tx.Where("pengaduan_id = ?", pengaduan.PengaduanID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&pengaduan).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menghapus pengaduan",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	for _, foto := range pengaduan.Foto {
+		helper.DeleteOldPhoto(foto.FotoFile, "pengaduan_foto")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Pengaduan berhasil dihapus",
+	})
+}
+
+// ✅ GET - Statistik pengaduan per kategori: jumlah per status, rata-rata waktu respon/penyelesaian, dan kepatuhan SLA
+func (pc *PengaduanController) GetStatistikPengaduan(c *gin.Context) {
+	query := scopedDB(c, pc.db).Model(&models.Pengaduan{})
+	if dari := c.Query("dari"); dari != "" {
+		t, err := time.ParseInLocation("2006-01-02", dari, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Format dari harus YYYY-MM-DD",
+			})
+			return
+		}
+		query = query.Where("created_at >= ?", t)
+	}
+	if sampai := c.Query("sampai"); sampai != "" {
+		t, err := time.ParseInLocation("2006-01-02", sampai, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Format sampai harus YYYY-MM-DD",
+			})
+			return
+		}
+		query = query.Where("created_at < ?", t.AddDate(0, 0, 1))
+	}
+
+	var pengaduan []models.Pengaduan
+	if err := query.Select("pengaduan_id", "kategori_pengaduan_id", "pengaduan_status", "tenggat_respon", "tenggat_selesai",
+		"direspon_pada", "diselesaikan_pada", "created_at").
+		Find(&pengaduan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil statistik pengaduan",
+		})
+		return
+	}
+
+	var kategori []models.KategoriPengaduan
+	scopedDB(c, pc.db).Unscoped().Order("kategori_pengaduan_nama ASC").Find(&kategori)
+
+	now := time.Now()
+	semua := &StatistikKategoriPengaduan{KategoriPengaduanNama: "Semua", PerStatus: map[string]int64{}}
+	perKategori := make(map[uint]*StatistikKategoriPengaduan)
+	urutan := make([]*StatistikKategoriPengaduan, 0, len(kategori))
+	for _, k := range kategori {
+		s := &StatistikKategoriPengaduan{
+			KategoriPengaduanID:   k.KategoriPengaduanID,
+			KategoriPengaduanNama: k.KategoriPengaduanNama,
+			PerStatus:             map[string]int64{},
+		}
+		perKategori[k.KategoriPengaduanID] = s
+		urutan = append(urutan, s)
+	}
+
+	for _, p := range pengaduan {
+		s, ok := perKategori[p.KategoriPengaduanID]
+		if !ok {
+			continue
+		}
+		s.tambah(p, now)
+		semua.tambah(p, now)
+	}
+
+	hasil := make([]StatistikKategoriPengaduan, 0, len(urutan))
+	for _, s := range urutan {
+		// Kategori terhapus hanya ditampilkan jika masih memiliki pengaduan pada periode ini
+		if s.Total == 0 && !kategoriAktif(kategori, s.KategoriPengaduanID) {
+			continue
+		}
+		hasil = append(hasil, s.hitung())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  hasil,
+		"total": semua.hitung(),
+	})
+}
+
+// tambah memasukkan satu pengaduan ke akumulasi statistik
+func (s *StatistikKategoriPengaduan) tambah(p models.Pengaduan, now time.Time) {
+	s.Total++
+	s.PerStatus[p.PengaduanStatus]++
+
+	switch slaRespon := statusSLARespon(p, now); slaRespon {
+	case "tepat_waktu", "terlambat":
+		s.responDinilai++
+		if slaRespon == "tepat_waktu" {
+			s.responTepat++
+		}
+		s.jumlahRespon++
+		s.totalMenitRespon += int64(p.DiresponPada.Sub(p.CreatedAt).Minutes())
+	case "melewati":
+		s.responDinilai++
+	}
+
+	switch slaSelesai := statusSLASelesai(p, now); slaSelesai {
+	case "tepat_waktu", "terlambat":
+		s.selesaiDinilai++
+		if slaSelesai == "tepat_waktu" {
+			s.selesaiTepat++
+		}
+		s.jumlahSelesai++
+		s.totalMenitSelesai += int64(p.DiselesaikanPada.Sub(p.CreatedAt).Minutes())
+	case "melewati":
+		s.selesaiDinilai++
+	}
+}
+
+// hitung mengisi rata-rata (dalam jam, 1 desimal) dan persentase kepatuhan SLA
+func (s *StatistikKategoriPengaduan) hitung() StatistikKategoriPengaduan {
+	if s.jumlahRespon > 0 {
+		s.RataResponJam = float64(int(float64(s.totalMenitRespon)/float64(s.jumlahRespon)/60*10+0.5)) / 10
+	}
+	if s.jumlahSelesai > 0 {
+		s.RataSelesaiJam = float64(int(float64(s.totalMenitSelesai)/float64(s.jumlahSelesai)/60*10+0.5)) / 10
+	}
+	s.KepatuhanSLARespon = persentase(s.responTepat, s.responDinilai)
+	s.KepatuhanSLASelesai = persentase(s.selesaiTepat, s.selesaiDinilai)
+	return *s
+}
+
+func kategoriAktif(kategori []models.KategoriPengaduan, id uint) bool {
+	for _, k := range kategori {
+		if k.KategoriPengaduanID == id {
+			return !k.DeletedAt.Valid
+		}
+	}
+	return false
+}
+
+// ✅ Helper mencari pengaduan dari parameter :id yang boleh dilihat user
+func (pc *PengaduanController) findPengaduan(c *gin.Context) (models.Pengaduan, bool) {
+	var pengaduan models.Pengaduan
+
+	pengaduanID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID pengaduan tidak valid",
+		})
+		return pengaduan, false
+	}
+
+	if err := scopedDB(c, pc.db).
+		Preload("Kategori", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Rumah").
+		Preload("Foto", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		First(&pengaduan, pengaduanID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Pengaduan tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan pengaduan",
+			})
+		}
+		return pengaduan, false
+	}
+
+	// Pengaduan privat milik orang lain diperlakukan seperti tidak ada
+	userID, _ := c.Get("userID")
+	if !bolehLihatPengaduan(c, pengaduan, userID.(uint)) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Pengaduan tidak ditemukan",
+		})
+		return pengaduan, false
+	}
+
+	return pengaduan, true
+}
+
+// queryTerlihat membatasi daftar pengaduan sesuai hak lihat user
+func (pc *PengaduanController) queryTerlihat(c *gin.Context, userID uint) *gorm.DB {
+	query := scopedDB(c, pc.db).Model(&models.Pengaduan{})
+	if isPengurusPengaduan(c) {
+		return query
+	}
+	return query.Where("pengaduan_visibilitas = ? OR dilaporkan_oleh_id = ? OR petugas_id = ?", "publik", userID, userID)
+}
+
+// lengkapiPengaduan menambahkan username pelapor/petugas dan status SLA
+func (pc *PengaduanController) lengkapiPengaduan(pengaduan []models.Pengaduan, now time.Time) []PengaduanDetail {
+	userIDs := make([]uint, 0, len(pengaduan)*2)
+	for _, p := range pengaduan {
+		userIDs = append(userIDs, p.DilaporkanOlehID)
+		if p.PetugasID != nil {
+			userIDs = append(userIDs, *p.PetugasID)
+		}
+	}
+	nama := petaUsername(pc.db, userIDs)
+
+	detail := make([]PengaduanDetail, 0, len(pengaduan))
+	for _, p := range pengaduan {
+		d := PengaduanDetail{
+			Pengaduan:       p,
+			PelaporUsername: nama[p.DilaporkanOlehID],
+			SLARespon:       statusSLARespon(p, now),
+			SLASelesai:      statusSLASelesai(p, now),
+		}
+		if p.PetugasID != nil {
+			d.PetugasUsername = nama[*p.PetugasID]
+		}
+		detail = append(detail, d)
+	}
+	return detail
+}
+
+// bolehKomentarInternal: pengurus dan petugas yang ditugaskan dapat melihat dan menulis komentar internal
+func (pc *PengaduanController) bolehKomentarInternal(c *gin.Context, pengaduan models.Pengaduan, userID uint) bool {
+	return isPengurusPengaduan(c) || (pengaduan.PetugasID != nil && *pengaduan.PetugasID == userID)
+}
+
+// beritahu mengantrikan notifikasi ke penerima dan mendorong event realtime.
+// Pengaduan privat hanya didorong ke pelapor, petugas, dan pengurus.
+func (pc *PengaduanController) beritahu(pengaduan models.Pengaduan, penerima []uint, jenis, judul, pesan, kunci string) {
+	pengaduanID := pengaduan.PengaduanID
+	pc.notifikasi.KirimKeUser(penerima, notification.Notifikasi{
+		Jenis:    notification.JenisPengaduan,
+		Judul:    judul,
+		Pesan:    pesan,
+		URL:      fmt.Sprintf("/pengaduan/%d", pengaduan.PengaduanID),
+		Sumber:   "pengaduan",
+		SumberID: &pengaduanID,
+		Kunci:    kunci,
+		RTID:     pengaduan.RTID,
+	})
+
+	event := realtime.Event{
+		Topik: realtime.TopikPengaduan,
+		Jenis: jenis,
+		Data: gin.H{
+			"pengaduan_id":     pengaduan.PengaduanID,
+			"pengaduan_kode":   pengaduan.PengaduanKode,
+			"pengaduan_status": pengaduan.PengaduanStatus,
+			"petugas_id":       pengaduan.PetugasID,
+		},
+		RTID: pengaduan.RTID,
+	}
+	if pengaduan.PengaduanVisibilitas == "privat" || jenis == "pengaduan.komentar" {
+		event.UserIDs = []uint{pengaduan.DilaporkanOlehID}
+		if pengaduan.PetugasID != nil {
+			event.UserIDs = append(event.UserIDs, *pengaduan.PetugasID)
+		}
+		event.LevelIDs = []uint{1, 2}
+	}
+	pc.hub.Publikasikan(event)
+}
+
+// petaUsername mengembalikan peta user_id -> username tanpa memuat data sensitif user
+func petaUsername(db *gorm.DB, userIDs []uint) map[uint]string {
+	nama := make(map[uint]string)
+	if len(userIDs) == 0 {
+		return nama
+	}
+
+	var users []models.User
+	db.Select("user_id", "username").Where("user_id IN ?", userIDs).Find(&users)
+	for _, u := range users {
+		nama[u.UserID] = u.Username
+	}
+	return nama
+}
+
+// pengurusPengaduan mengembalikan admin dan sekretaris yang menangani pengaduan di RT tersebut
+func pengurusPengaduan(db *gorm.DB, rtID *uint) ([]uint, error) {
+	query := db.Model(&models.User{}).Where("level_id IN ?", []uint{1, 2})
+	if rtID != nil {
+		query = query.Where("rt_id = ? OR rt_id IS NULL", *rtID)
+	}
+
+	var userIDs []uint
+	err := query.Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// bolehLihatPengaduan: pengaduan publik terlihat semua user di wilayahnya, privat hanya pelapor, petugas, dan pengurus
+func bolehLihatPengaduan(c *gin.Context, pengaduan models.Pengaduan, userID uint) bool {
+	return pengaduan.PengaduanVisibilitas == "publik" || isPengurusPengaduan(c) ||
+		pengaduan.DilaporkanOlehID == userID ||
+		(pengaduan.PetugasID != nil && *pengaduan.PetugasID == userID)
+}
+
+// Pengurus pengaduan adalah admin dan sekretaris
+func isPengurusPengaduan(c *gin.Context) bool {
+	levelID, _ := c.Get("levelID")
+	return levelID == uint(1) || levelID == uint(2)
+}
+
+func isValidStatusPengaduan(status string) bool {
+	return status == "terbuka" || status == "diproses" || status == "selesai" || status == "ditutup"
+}
+
+// statusSLARespon menilai respon pertama terhadap tenggat respon
+func statusSLARespon(p models.Pengaduan, now time.Time) string {
+	if p.DiresponPada != nil {
+		if p.DiresponPada.After(p.TenggatRespon) {
+			return "terlambat"
+		}
+		return "tepat_waktu"
+	}
+	if now.After(p.TenggatRespon) {
+		return "melewati"
+	}
+	return "berjalan"
+}
+
+// statusSLASelesai menilai penyelesaian terhadap tenggat selesai; pengaduan yang ditutup tanpa
+// diselesaikan (mis. bukan wewenang RT) tidak dinilai
+func statusSLASelesai(p models.Pengaduan, now time.Time) string {
+	if p.DiselesaikanPada != nil {
+		if p.DiselesaikanPada.After(p.TenggatSelesai) {
+			return "terlambat"
+		}
+		return "tepat_waktu"
+	}
+	if p.PengaduanStatus == "ditutup" {
+		return "tidak_berlaku"
+	}
+	if now.After(p.TenggatSelesai) {
+		return "melewati"
+	}
+	return "berjalan"
+}
+
+// tanpaUser membuang userID dari daftar penerima, dipakai agar pelaku tidak menerima notifikasinya sendiri
+func tanpaUser(userIDs []uint, userID uint) []uint {
+	hasil := make([]uint, 0, len(userIDs))
+	for _, id := range userIDs {
+		if id != userID {
+			hasil = append(hasil, id)
+		}
+	}
+	return hasil
+}
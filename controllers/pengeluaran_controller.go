@@ -102,7 +102,7 @@ func (pc *PengeluaranController) CreatePengeluaran(c *gin.Context) {
 
 	// Check if kategori pengeluaran exists
 	var kategori models.KategoriPengeluaran
-	if err := pc.db.First(&kategori, uint(kategoriPengeluaranID)).Error; err != nil {
+	if err := scopedDB(c, pc.db).First(&kategori, uint(kategoriPengeluaranID)).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": "Kategori pengeluaran tidak ditemukan",
@@ -141,7 +141,7 @@ func (pc *PengeluaranController) CreatePengeluaran(c *gin.Context) {
 		UpdatedAt:             time.Now(),
 	}
 
-	if err := pc.db.Create(&pengeluaran).Error; err != nil {
+	if err := scopedDB(c, pc.db).Create(&pengeluaran).Error; err != nil {
 		// Jika gagal create, hapus file yang sudah diupload
 		if pengeluaranBuktiFilename != "" {
 			helper.DeleteOldPhoto(pengeluaranBuktiFilename, "pengeluaran_bukti")
@@ -154,7 +154,7 @@ func (pc *PengeluaranController) CreatePengeluaran(c *gin.Context) {
 	}
 
 	// Reload dengan data kategori
-	if err := pc.db.Preload("KategoriPengeluaran").First(&pengeluaran, pengeluaran.PengeluaranID).Error; err != nil {
+	if err := scopedDB(c, pc.db).Preload("KategoriPengeluaran").First(&pengeluaran, pengeluaran.PengeluaranID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Gagal memuat data pengeluaran yang dibuat",
 		})
@@ -183,7 +183,7 @@ func (pc *PengeluaranController) GetAllPengeluaran(c *gin.Context) {
 	search := c.Query("search")
 
 	// Build query dengan GORM (AMAN - parameterized queries)
-	query := pc.db.Model(&models.Pengeluaran{}).Preload("KategoriPengeluaran")
+	query := scopedDB(c, pc.db).Model(&models.Pengeluaran{}).Preload("KategoriPengeluaran")
 
 	// Apply filters
 	if search != "" {
@@ -249,7 +249,7 @@ func (pc *PengeluaranController) GetPengeluaranByID(c *gin.Context) {
 	}
 
 	var pengeluaran models.Pengeluaran
-	if err := pc.db.Preload("KategoriPengeluaran").First(&pengeluaran, pengeluaranID).Error; err != nil {
+	if err := scopedDB(c, pc.db).Preload("KategoriPengeluaran").First(&pengeluaran, pengeluaranID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Pengeluaran tidak ditemukan",
@@ -281,7 +281,7 @@ func (pc *PengeluaranController) UpdatePengeluaran(c *gin.Context) {
 	}
 
 	var pengeluaran models.Pengeluaran
-	if err := pc.db.First(&pengeluaran, pengeluaranID).Error; err != nil {
+	if err := scopedDB(c, pc.db).First(&pengeluaran, pengeluaranID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Pengeluaran tidak ditemukan",
@@ -315,7 +315,7 @@ func (pc *PengeluaranController) UpdatePengeluaran(c *gin.Context) {
 
 		// Validasi kategori pengeluaran jika diupdate
 		var kategori models.KategoriPengeluaran
-		if err := pc.db.First(&kategori, uint(kategoriPengeluaranID)).Error; err != nil {
+		if err := scopedDB(c, pc.db).First(&kategori, uint(kategoriPengeluaranID)).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				c.JSON(http.StatusBadRequest, gin.H{
 					"error": "Kategori pengeluaran tidak ditemukan",
@@ -398,7 +398,7 @@ func (pc *PengeluaranController) UpdatePengeluaran(c *gin.Context) {
 
 	// Eksekusi update hanya jika ada field yang diupdate
 	if len(updates) > 0 {
-		if err := pc.db.Model(&pengeluaran).Updates(updates).Error; err != nil {
+		if err := scopedDB(c, pc.db).Model(&pengeluaran).Updates(updates).Error; err != nil {
 			// Jika gagal update, hapus file baru yang sudah diupload
 			if pengeluaranBuktiFilename != pengeluaran.PengeluaranBukti {
 				helper.DeleteOldPhoto(pengeluaranBuktiFilename, "pengeluaran_bukti")
@@ -412,7 +412,7 @@ func (pc *PengeluaranController) UpdatePengeluaran(c *gin.Context) {
 	}
 
 	// Reload dengan data terbaru termasuk kategori
-	if err := pc.db.Preload("KategoriPengeluaran").First(&pengeluaran, pengeluaranID).Error; err != nil {
+	if err := scopedDB(c, pc.db).Preload("KategoriPengeluaran").First(&pengeluaran, pengeluaranID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Gagal memuat data pengeluaran yang diupdate",
 		})
@@ -425,7 +425,7 @@ func (pc *PengeluaranController) UpdatePengeluaran(c *gin.Context) {
 	})
 }
 
-// ✅ DELETE - Menghapus pengeluaran
+// ✅ DELETE - Memindahkan pengeluaran ke trash (soft delete)
 func (pc *PengeluaranController) DeletePengeluaran(c *gin.Context) {
 	id := c.Param("id")
 
@@ -439,7 +439,7 @@ func (pc *PengeluaranController) DeletePengeluaran(c *gin.Context) {
 	}
 
 	var pengeluaran models.Pengeluaran
-	if err := pc.db.First(&pengeluaran, pengeluaranID).Error; err != nil {
+	if err := scopedDB(c, pc.db).First(&pengeluaran, pengeluaranID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Pengeluaran tidak ditemukan",
@@ -452,8 +452,8 @@ func (pc *PengeluaranController) DeletePengeluaran(c *gin.Context) {
 		return
 	}
 
-	// Delete menggunakan GORM Delete (AMAN)
-	if err := pc.db.Delete(&pengeluaran).Error; err != nil {
+	// Soft delete (AMAN) - file bukti tetap disimpan sampai trash di-purge
+	if err := scopedDB(c, pc.db).Delete(&pengeluaran).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Gagal menghapus pengeluaran",
 			"details": err.Error(),
@@ -461,13 +461,70 @@ func (pc *PengeluaranController) DeletePengeluaran(c *gin.Context) {
 		return
 	}
 
-	// Hapus file foto jika ada
-	if pengeluaran.PengeluaranBukti != "" {
-		helper.DeleteOldPhoto(pengeluaran.PengeluaranBukti, "pengeluaran_bukti")
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Pengeluaran berhasil dihapus",
+	})
+}
+
+// ✅ GET - Mendapatkan pengeluaran yang ada di trash
+func (pc *PengeluaranController) GetTrashPengeluaran(c *gin.Context) {
+	var pengeluaran []models.Pengeluaran
+
+	if err := scopedDB(c, pc.db).Unscoped().
+		Preload("KategoriPengeluaran").
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Find(&pengeluaran).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data trash pengeluaran",
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Pengeluaran berhasil dihapus",
+		"data":  pengeluaran,
+		"total": len(pengeluaran),
+	})
+}
+
+// ✅ PUT - Mengembalikan pengeluaran dari trash
+func (pc *PengeluaranController) RestorePengeluaran(c *gin.Context) {
+	id := c.Param("id")
+
+	// Validasi ID (AMAN - dikonversi ke uint)
+	pengeluaranID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID pengeluaran tidak valid",
+		})
+		return
+	}
+
+	var pengeluaran models.Pengeluaran
+	if err := scopedDB(c, pc.db).Unscoped().Where("deleted_at IS NOT NULL").First(&pengeluaran, pengeluaranID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Pengeluaran tidak ditemukan di trash",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan pengeluaran",
+			})
+		}
+		return
+	}
+
+	if err := scopedDB(c, pc.db).Unscoped().Model(&pengeluaran).Update("deleted_at", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal memulihkan pengeluaran",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Pengeluaran berhasil dipulihkan",
+		"data":    pengeluaran,
 	})
 }
 
@@ -483,23 +540,23 @@ func (pc *PengeluaranController) GetStatistikPengeluaran(c *gin.Context) {
 	var statistik StatistikResult
 
 	// Hitung total pengeluaran (AMAN)
-	pc.db.Model(&models.Pengeluaran{}).Count(&statistik.TotalPengeluaran)
+	scopedDB(c, pc.db).Model(&models.Pengeluaran{}).Count(&statistik.TotalPengeluaran)
 
 	// Hitung pengeluaran bulan ini (AMAN)
 	awalBulan := time.Now().AddDate(0, 0, -time.Now().Day()+1)
-	pc.db.Model(&models.Pengeluaran{}).
+	scopedDB(c, pc.db).Model(&models.Pengeluaran{}).
 		Where("pengeluaran_tanggal >= ?", awalBulan).
 		Count(&statistik.BulanIni)
 
 	// Hitung pengeluaran minggu ini (AMAN)
 	awalMinggu := time.Now().AddDate(0, 0, -int(time.Now().Weekday())+1)
-	pc.db.Model(&models.Pengeluaran{}).
+	scopedDB(c, pc.db).Model(&models.Pengeluaran{}).
 		Where("pengeluaran_tanggal >= ?", awalMinggu).
 		Count(&statistik.MingguIni)
 
 	// Hitung rata-rata bulanan (AMAN)
 	var totalNominal float64
-	pc.db.Model(&models.Pengeluaran{}).
+	scopedDB(c, pc.db).Model(&models.Pengeluaran{}).
 		Select("COALESCE(SUM(pengeluaran_nominal), 0)").
 		Row().
 		Scan(&totalNominal)
@@ -525,7 +582,7 @@ func (pc *PengeluaranController) GetTotalNominalPerKategori(c *gin.Context) {
 	var results []TotalPerKategori
 
 	// Query total nominal per kategori (AMAN)
-	if err := pc.db.
+	if err := scopedDB(c, pc.db).
 		Model(&models.Pengeluaran{}).
 		Select("kategori_pengeluaran.kategori_pengeluaran_nama, SUM(pengeluaran_nominal) as total_nominal").
 		Joins("LEFT JOIN kategori_pengeluarans ON kategori_pengeluarans.kategori_pengeluaran_id = pengeluarans.kategori_pengeluaran_id").
@@ -583,12 +640,12 @@ func (pc *PengeluaranController) GetLaporanPengeluaranBulanan(c *gin.Context) {
 		akhirBulan := awalBulan.AddDate(0, 1, -1)
 
 		// Hitung total pengeluaran per bulan (AMAN)
-		pc.db.Model(&models.Pengeluaran{}).
+		scopedDB(c, pc.db).Model(&models.Pengeluaran{}).
 			Where("pengeluaran_tanggal BETWEEN ? AND ?", awalBulan, akhirBulan).
 			Count(&jumlah)
 
 		// Hitung jumlah transaksi per bulan (AMAN)
-		pc.db.Model(&models.Pengeluaran{}).
+		scopedDB(c, pc.db).Model(&models.Pengeluaran{}).
 			Select("COALESCE(SUM(pengeluaran_nominal), 0)").
 			Where("pengeluaran_tanggal BETWEEN ? AND ?", awalBulan, akhirBulan).
 			Row().
@@ -0,0 +1,317 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"rt-management/models"
+	"rt-management/notification"
+
+	"gorm.io/gorm"
+)
+
+// PenjadwalPengingat mengantrikan pengingat kegiatan, jadwal ronda, tagihan, dan SLA pengaduan ke outbox notifikasi,
+// serta menyelaraskan status rumah dengan hunian yang aktif.
+// Setiap pengingat memakai kunci unik sehingga aman dijalankan berulang kali.
+type PenjadwalPengingat struct {
+	db         *gorm.DB
+	notifikasi *notification.Service
+}
+
+func NewPenjadwalPengingat(db *gorm.DB, notifikasi *notification.Service) *PenjadwalPengingat {
+	return &PenjadwalPengingat{db: db, notifikasi: notifikasi}
+}
+
+// Jalankan memeriksa pengingat secara berkala. Dijalankan sebagai goroutine dari main.
+func (p *PenjadwalPengingat) Jalankan(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		p.proses(time.Now())
+		<-ticker.C
+	}
+}
+
+func (p *PenjadwalPengingat) proses(now time.Time) {
+	langkah := []struct {
+		nama string
+		fn   func(time.Time) (int, error)
+	}{
+		{"kegiatan", p.ingatkanKegiatan},
+		{"ronda", p.ingatkanRonda},
+		{"biaya peminjaman", p.ingatkanBiayaPeminjaman},
+		{"denda ronda", p.ingatkanDendaRonda},
+		{"SLA pengaduan", p.ingatkanSLAPengaduan},
+	}
+
+	for _, l := range langkah {
+		jumlah, err := l.fn(now)
+		if err != nil {
+			log.Printf("⚠️ Pengingat %s: %v", l.nama, err)
+		} else if jumlah > 0 {
+			log.Printf("🔔 Pengingat %s: %d notifikasi diantrikan", l.nama, jumlah)
+		}
+	}
+
+	// Status rumah mengikuti hunian yang baru dimulai atau tanggal selesainya tercapai
+	if jumlah, err := sinkronStatusRumah(p.db, now); err != nil {
+		log.Printf("⚠️ Sinkron status rumah: %v", err)
+	} else if jumlah > 0 {
+		log.Printf("🏠 Sinkron status rumah: %d rumah diperbarui", jumlah)
+	}
+}
+
+// ingatkanKegiatan memberi tahu seluruh warga RT tentang kegiatan dalam 24 jam ke depan
+func (p *PenjadwalPengingat) ingatkanKegiatan(now time.Time) (int, error) {
+	jadwal, err := jadwalKegiatanDB(p.db, now, now.Add(24*time.Hour))
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, k := range jadwal {
+		userIDs, err := p.userRT(k.RTID)
+		if err != nil {
+			return total, err
+		}
+
+		pesan := fmt.Sprintf("%s pada %s", k.KegiatanNama, k.KegiatanTanggal.Format("02-01-2006 15:04"))
+		if k.KegiatanLokasi != "" {
+			pesan += " di " + k.KegiatanLokasi
+		}
+		if k.KegiatanWajib {
+			pesan += ". Kegiatan ini wajib diikuti setiap keluarga."
+		}
+
+		kegiatanID := k.KegiatanID
+		jumlah, err := p.notifikasi.KirimKeUser(userIDs, notification.Notifikasi{
+			Jenis:    notification.JenisKegiatan,
+			Judul:    "Pengingat kegiatan: " + k.KegiatanNama,
+			Pesan:    pesan,
+			URL:      fmt.Sprintf("/kegiatan/%d", k.KegiatanID),
+			Sumber:   "kegiatan",
+			SumberID: &kegiatanID,
+			Kunci:    fmt.Sprintf("kegiatan:%d:%s", k.KegiatanID, k.KegiatanTanggal.Format("200601021504")),
+			RTID:     k.RTID,
+		})
+		if err != nil {
+			return total, err
+		}
+		total += jumlah
+	}
+	return total, nil
+}
+
+// ingatkanRonda memberi tahu petugas ronda yang bertugas besok
+func (p *PenjadwalPengingat) ingatkanRonda(now time.Time) (int, error) {
+	besok := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+
+	var petugas []models.PetugasRonda
+	if err := p.db.Preload("JadwalRonda").
+		Where("tanggal = ? AND status = ?", besok.Format("2006-01-02"), "terjadwal").
+		Find(&petugas).Error; err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, pr := range petugas {
+		userIDs, err := p.userWarga(pr.WargaID)
+		if err != nil {
+			return total, err
+		}
+
+		namaJadwal := "ronda"
+		if pr.JadwalRonda != nil {
+			namaJadwal = pr.JadwalRonda.JadwalRondaNama
+		}
+
+		petugasID := pr.PetugasRondaID
+		jumlah, err := p.notifikasi.KirimKeUser(userIDs, notification.Notifikasi{
+			Jenis:    notification.JenisKegiatan,
+			Judul:    "Pengingat jadwal ronda",
+			Pesan:    fmt.Sprintf("Anda bertugas %s (kelompok %d) besok, %s.", namaJadwal, pr.Kelompok, besok.Format("02-01-2006")),
+			URL:      "/ronda",
+			Sumber:   "petugas_ronda",
+			SumberID: &petugasID,
+			Kunci:    fmt.Sprintf("ronda:%d", pr.PetugasRondaID),
+			RTID:     pr.RTID,
+		})
+		if err != nil {
+			return total, err
+		}
+		total += jumlah
+	}
+	return total, nil
+}
+
+// ingatkanBiayaPeminjaman mengingatkan biaya sewa fasilitas yang belum lunas: sehari sebelum
+// pemakaian, lalu seminggu sekali setelah pemakaian selesai
+func (p *PenjadwalPengingat) ingatkanBiayaPeminjaman(now time.Time) (int, error) {
+	var peminjaman []models.PeminjamanFasilitas
+	if err := p.db.Preload("Fasilitas").
+		Where("status = ? AND biaya > 0 AND biaya_lunas = ?", "disetujui", false).
+		Where("waktu_mulai <= ?", now.Add(24*time.Hour)).
+		Find(&peminjaman).Error; err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, pf := range peminjaman {
+		var kunci string
+		switch {
+		case pf.WaktuMulai.After(now):
+			kunci = fmt.Sprintf("peminjaman:%d:h-1", pf.PeminjamanFasilitasID)
+		case pf.WaktuSelesai.Before(now):
+			kunci = fmt.Sprintf("peminjaman:%d:%s", pf.PeminjamanFasilitasID, mingguKe(now))
+		default:
+			continue // sedang dipakai
+		}
+
+		namaFasilitas := "fasilitas"
+		if pf.Fasilitas != nil {
+			namaFasilitas = pf.Fasilitas.FasilitasNama
+		}
+
+		peminjamanID := pf.PeminjamanFasilitasID
+		jumlah, err := p.notifikasi.KirimKeUser([]uint{pf.DiajukanOlehID}, notification.Notifikasi{
+			Jenis: notification.JenisTagihan,
+			Judul: "Tagihan sewa " + namaFasilitas,
+			Pesan: fmt.Sprintf("Biaya sewa %s untuk %s (%s) sebesar %s belum dibayar.",
+				namaFasilitas, pf.Keperluan, pf.WaktuMulai.Format("02-01-2006"), formatRupiah(pf.Biaya)),
+			URL:      fmt.Sprintf("/fasilitas/peminjaman/%d", pf.PeminjamanFasilitasID),
+			Sumber:   "peminjaman_fasilitas",
+			SumberID: &peminjamanID,
+			Kunci:    kunci,
+			RTID:     pf.RTID,
+		})
+		if err != nil {
+			return total, err
+		}
+		total += jumlah
+	}
+	return total, nil
+}
+
+// ingatkanDendaRonda mengingatkan denda ronda yang belum lunas seminggu sekali
+func (p *PenjadwalPengingat) ingatkanDendaRonda(now time.Time) (int, error) {
+	var petugas []models.PetugasRonda
+	if err := p.db.
+		Where("denda > 0 AND denda_lunas = ?", false).
+		Find(&petugas).Error; err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, pr := range petugas {
+		userIDs, err := p.userWarga(pr.WargaID)
+		if err != nil {
+			return total, err
+		}
+
+		petugasID := pr.PetugasRondaID
+		jumlah, err := p.notifikasi.KirimKeUser(userIDs, notification.Notifikasi{
+			Jenis: notification.JenisTagihan,
+			Judul: "Tagihan denda ronda",
+			Pesan: fmt.Sprintf("Denda ronda tanggal %s sebesar %s belum dibayar.",
+				pr.Tanggal.Format("02-01-2006"), formatRupiah(pr.Denda)),
+			URL:      "/ronda",
+			Sumber:   "petugas_ronda",
+			SumberID: &petugasID,
+			Kunci:    fmt.Sprintf("denda-ronda:%d:%s", pr.PetugasRondaID, mingguKe(now)),
+			RTID:     pr.RTID,
+		})
+		if err != nil {
+			return total, err
+		}
+		total += jumlah
+	}
+	return total, nil
+}
+
+// ingatkanSLAPengaduan memberi tahu pengurus dan petugas sekali saat pengaduan melewati tenggat
+// respon pertama atau tenggat penyelesaian
+func (p *PenjadwalPengingat) ingatkanSLAPengaduan(now time.Time) (int, error) {
+	var pengaduan []models.Pengaduan
+	if err := p.db.
+		Where("pengaduan_status IN ?", []string{"terbuka", "diproses"}).
+		Where("(direspon_pada IS NULL AND tenggat_respon <= ?) OR tenggat_selesai <= ?", now, now).
+		Find(&pengaduan).Error; err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, pg := range pengaduan {
+		userIDs, err := pengurusPengaduan(p.db, pg.RTID)
+		if err != nil {
+			return total, err
+		}
+		if pg.PetugasID != nil {
+			userIDs = append(userIDs, *pg.PetugasID)
+		}
+
+		jenisSLA, judul, tenggat := "sla_respon", "Pengaduan belum direspon", pg.TenggatRespon
+		if !pg.TenggatSelesai.After(now) {
+			jenisSLA, judul, tenggat = "sla_selesai", "Pengaduan melewati target penyelesaian", pg.TenggatSelesai
+		}
+
+		pengaduanID := pg.PengaduanID
+		jumlah, err := p.notifikasi.KirimKeUser(userIDs, notification.Notifikasi{
+			Jenis: notification.JenisPengaduan,
+			Judul: judul + ": " + pg.PengaduanJudul,
+			Pesan: fmt.Sprintf("Pengaduan %s berstatus %s, target %s sudah lewat.",
+				pg.PengaduanKode, pg.PengaduanStatus, tenggat.Format("02-01-2006 15:04")),
+			URL:      fmt.Sprintf("/pengaduan/%d", pg.PengaduanID),
+			Sumber:   "pengaduan",
+			SumberID: &pengaduanID,
+			Kunci:    fmt.Sprintf("pengaduan:%d:%s", pg.PengaduanID, jenisSLA),
+			RTID:     pg.RTID,
+		})
+		if err != nil {
+			return total, err
+		}
+		total += jumlah
+	}
+	return total, nil
+}
+
+// userRT mengembalikan seluruh user di RT tersebut, atau seluruh user jika rtID kosong
+func (p *PenjadwalPengingat) userRT(rtID *uint) ([]uint, error) {
+	query := p.db.Model(&models.User{})
+	if rtID != nil {
+		query = query.Where("rt_id = ?", *rtID)
+	}
+
+	var userIDs []uint
+	err := query.Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// userWarga mengembalikan akun user yang ditautkan ke data warga
+func (p *PenjadwalPengingat) userWarga(wargaID uint) ([]uint, error) {
+	var userIDs []uint
+	err := p.db.Model(&models.User{}).Where("warga_id = ?", wargaID).Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// mingguKe mengembalikan tahun dan minggu ISO, dipakai untuk kunci pengingat mingguan
+func mingguKe(t time.Time) string {
+	tahun, minggu := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", tahun, minggu)
+}
+
+// formatRupiah memformat nominal menjadi "Rp 150.000"
+func formatRupiah(nominal float64) string {
+	angka := strconv.FormatInt(int64(nominal+0.5), 10)
+
+	var hasil []byte
+	for i := range angka {
+		if i > 0 && (len(angka)-i)%3 == 0 {
+			hasil = append(hasil, '.')
+		}
+		hasil = append(hasil, angka[i])
+	}
+	return "Rp " + string(hasil)
+}
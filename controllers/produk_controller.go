@@ -11,17 +11,19 @@ import (
 
 	"rt-management/helper"
 	"rt-management/models"
+	"rt-management/realtime"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type ProdukController struct {
-	db *gorm.DB
+	db  *gorm.DB
+	hub *realtime.Hub
 }
 
-func NewProdukController(db *gorm.DB) *ProdukController {
-	return &ProdukController{db: db}
+func NewProdukController(db *gorm.DB, hub *realtime.Hub) *ProdukController {
+	return &ProdukController{db: db, hub: hub}
 }
 
 // Request structs - ubah binding untuk file upload
@@ -96,7 +98,7 @@ func (pc *ProdukController) CreateProduk(c *gin.Context) {
 
 	// Check if kategori produk exists
 	var kategori models.KategoriProduk
-	if err := pc.db.First(&kategori, req.KategoriProdukID).Error; err != nil {
+	if err := scopedDB(c, pc.db).First(&kategori, req.KategoriProdukID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": "Kategori produk tidak ditemukan",
@@ -111,7 +113,7 @@ func (pc *ProdukController) CreateProduk(c *gin.Context) {
 
 	// Check if produk dengan nama yang sama sudah ada
 	// var existingProduk models.Produk
-	// if err := pc.db.Where("produk_nama = ?", req.ProdukNama).First(&existingProduk).Error; err == nil {
+	// if err := scopedDB(c, pc.db).Where("produk_nama = ?", req.ProdukNama).First(&existingProduk).Error; err == nil {
 	// 	c.JSON(http.StatusBadRequest, gin.H{
 	// 		"error": "Produk dengan nama tersebut sudah ada",
 	// 	})
@@ -146,7 +148,7 @@ func (pc *ProdukController) CreateProduk(c *gin.Context) {
 		UpdatedAt:        time.Now(),
 	}
 
-	if err := pc.db.Create(&produk).Error; err != nil {
+	if err := scopedDB(c, pc.db).Create(&produk).Error; err != nil {
 		// Hapus file yang sudah diupload jika gagal menyimpan ke database
 		helper.DeleteOldPhoto(fotoPath, "produk_foto")
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -157,13 +159,15 @@ func (pc *ProdukController) CreateProduk(c *gin.Context) {
 	}
 
 	// Reload dengan data kategori
-	if err := pc.db.Preload("KategoriProduk").First(&produk, produk.ProdukID).Error; err != nil {
+	if err := scopedDB(c, pc.db).Preload("KategoriProduk").First(&produk, produk.ProdukID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Gagal memuat data produk yang dibuat",
 		})
 		return
 	}
 
+	pc.publikasikanProduk("produk.dibuat", produk)
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Produk berhasil dibuat",
 		"data":    produk,
@@ -184,7 +188,7 @@ func (pc *ProdukController) UpdateProduk(c *gin.Context) {
 	}
 
 	var produk models.Produk
-	if err := pc.db.First(&produk, produkID).Error; err != nil {
+	if err := scopedDB(c, pc.db).First(&produk, produkID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Produk tidak ditemukan",
@@ -236,7 +240,7 @@ func (pc *ProdukController) UpdateProduk(c *gin.Context) {
 
 		// Check duplicate name (exclude current)
 		var existingProduk models.Produk
-		if err := pc.db.Where("produk_nama = ? AND produk_id != ?", req.ProdukNama, produkID).
+		if err := scopedDB(c, pc.db).Where("produk_nama = ? AND produk_id != ?", req.ProdukNama, produkID).
 			First(&existingProduk).Error; err == nil {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": "Produk dengan nama tersebut sudah ada",
@@ -264,7 +268,7 @@ func (pc *ProdukController) UpdateProduk(c *gin.Context) {
 	// Validasi kategori produk jika diupdate
 	if req.KategoriProdukID != 0 {
 		var kategori models.KategoriProduk
-		if err := pc.db.First(&kategori, req.KategoriProdukID).Error; err != nil {
+		if err := scopedDB(c, pc.db).First(&kategori, req.KategoriProdukID).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				c.JSON(http.StatusBadRequest, gin.H{
 					"error": "Kategori produk tidak ditemukan",
@@ -302,7 +306,7 @@ func (pc *ProdukController) UpdateProduk(c *gin.Context) {
 	
 	updates["updated_at"] = time.Now()
 
-	if err := pc.db.Model(&produk).Updates(updates).Error; err != nil {
+	if err := scopedDB(c, pc.db).Model(&produk).Updates(updates).Error; err != nil {
 		// Hapus file baru yang sudah diupload jika gagal update database
 		if fotoPath != "" && err != http.ErrMissingFile {
 			helper.DeleteOldPhoto(fotoPath, "produk_foto")
@@ -315,20 +319,24 @@ func (pc *ProdukController) UpdateProduk(c *gin.Context) {
 	}
 
 	// Reload dengan data terbaru termasuk kategori
-	if err := pc.db.Preload("KategoriProduk").First(&produk, produkID).Error; err != nil {
+	if err := scopedDB(c, pc.db).Preload("KategoriProduk").First(&produk, produkID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Gagal memuat data produk yang diupdate",
 		})
 		return
 	}
 
+	if req.ProdukStok != 0 {
+		pc.publikasikanProduk("produk.stok", produk)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Produk berhasil diupdate",
 		"data":    produk,
 	})
 }
 
-// ✅ DELETE - Menghapus produk beserta file fotonya
+// ✅ DELETE - Memindahkan produk ke trash (soft delete)
 func (pc *ProdukController) DeleteProduk(c *gin.Context) {
 	id := c.Param("id")
 
@@ -342,7 +350,7 @@ func (pc *ProdukController) DeleteProduk(c *gin.Context) {
 	}
 
 	var produk models.Produk
-	if err := pc.db.First(&produk, produkID).Error; err != nil {
+	if err := scopedDB(c, pc.db).First(&produk, produkID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Produk tidak ditemukan",
@@ -355,11 +363,8 @@ func (pc *ProdukController) DeleteProduk(c *gin.Context) {
 		return
 	}
 
-	// Simpan path foto sebelum menghapus
-	fotoPath := produk.ProdukFoto
-
-	// Hapus dari database
-	if err := pc.db.Delete(&produk).Error; err != nil {
+	// Soft delete - file foto tetap disimpan sampai trash di-purge
+	if err := scopedDB(c, pc.db).Delete(&produk).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Gagal menghapus produk",
 			"details": err.Error(),
@@ -367,16 +372,87 @@ func (pc *ProdukController) DeleteProduk(c *gin.Context) {
 		return
 	}
 
-	// Hapus file foto
-	if fotoPath != "" {
-		helper.DeleteOldPhoto(fotoPath, "produk_foto")
-	}
+	pc.publikasikanProduk("produk.dihapus", produk)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Produk berhasil dihapus",
 	})
 }
 
+// ✅ GET - Mendapatkan produk yang ada di trash
+func (pc *ProdukController) GetTrashProduk(c *gin.Context) {
+	var produk []models.Produk
+
+	if err := scopedDB(c, pc.db).Unscoped().
+		Preload("KategoriProduk").
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Find(&produk).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data trash produk",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  produk,
+		"total": len(produk),
+	})
+}
+
+// ✅ PUT - Mengembalikan produk dari trash
+func (pc *ProdukController) RestoreProduk(c *gin.Context) {
+	id := c.Param("id")
+
+	// Validasi ID (AMAN - dikonversi ke uint)
+	produkID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID produk tidak valid",
+		})
+		return
+	}
+
+	var produk models.Produk
+	if err := scopedDB(c, pc.db).Unscoped().Where("deleted_at IS NOT NULL").First(&produk, produkID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Produk tidak ditemukan di trash",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan produk",
+			})
+		}
+		return
+	}
+
+	// Nama produk harus tetap unik di antara produk aktif
+	var existingProduk models.Produk
+	if err := scopedDB(c, pc.db).Where("produk_nama = ? AND produk_id != ?", produk.ProdukNama, produkID).
+		First(&existingProduk).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Produk dengan nama tersebut sudah ada",
+		})
+		return
+	}
+
+	if err := scopedDB(c, pc.db).Unscoped().Model(&produk).Update("deleted_at", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal memulihkan produk",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	pc.publikasikanProduk("produk.dipulihkan", produk)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Produk berhasil dipulihkan",
+		"data":    produk,
+	})
+}
+
 // ✅ GET - Serve file foto produk
 func (pc *ProdukController) GetProdukFoto(c *gin.Context) {
 	filename := c.Param("filename")
@@ -442,7 +518,7 @@ func (pc *ProdukController) GetAllProduk(c *gin.Context) {
 	hargaMax := c.Query("harga_max")
 
 	// Build query dengan GORM (AMAN - parameterized queries)
-	query := pc.db.Model(&models.Produk{}).Preload("KategoriProduk")
+	query := scopedDB(c, pc.db).Model(&models.Produk{}).Preload("KategoriProduk")
 
 	// Apply filters
 	if search != "" {
@@ -521,7 +597,7 @@ func (pc *ProdukController) GetProdukByID(c *gin.Context) {
 	}
 
 	var produk models.Produk
-	if err := pc.db.Preload("KategoriProduk").First(&produk, produkID).Error; err != nil {
+	if err := scopedDB(c, pc.db).Preload("KategoriProduk").First(&produk, produkID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Produk tidak ditemukan",
@@ -545,7 +621,7 @@ func (pc *ProdukController) GetProdukTerbaru(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "8"))
 
 	// Query produk terbaru (AMAN)
-	if err := pc.db.
+	if err := scopedDB(c, pc.db).
 		Preload("KategoriProduk").
 		Order("created_at DESC").
 		Limit(limit).
@@ -567,7 +643,7 @@ func (pc *ProdukController) GetProdukStokMenipis(c *gin.Context) {
 	var produk []models.Produk
 
 	// Query produk dengan stok menipis (AMAN)
-	if err := pc.db.
+	if err := scopedDB(c, pc.db).
 		Preload("KategoriProduk").
 		Where("produk_stok < 10").
 		Order("produk_stok ASC").
@@ -597,27 +673,27 @@ func (pc *ProdukController) GetStatistikProduk(c *gin.Context) {
 	var statistik StatistikResult
 
 	// Hitung total produk (AMAN)
-	pc.db.Model(&models.Produk{}).Count(&statistik.TotalProduk)
+	scopedDB(c, pc.db).Model(&models.Produk{}).Count(&statistik.TotalProduk)
 
 	// Hitung total stok (AMAN)
-	pc.db.Model(&models.Produk{}).
+	scopedDB(c, pc.db).Model(&models.Produk{}).
 		Select("COALESCE(SUM(produk_stok), 0)").
 		Row().
 		Scan(&statistik.TotalStok)
 
 	// Hitung nilai inventori (AMAN)
-	pc.db.Model(&models.Produk{}).
+	scopedDB(c, pc.db).Model(&models.Produk{}).
 		Select("COALESCE(SUM(produk_stok * produk_harga), 0)").
 		Row().
 		Scan(&statistik.NilaiInventori)
 
 	// Hitung produk stok habis (AMAN)
-	pc.db.Model(&models.Produk{}).
+	scopedDB(c, pc.db).Model(&models.Produk{}).
 		Where("produk_stok = 0").
 		Count(&statistik.ProdukStokHabis)
 
 	// Hitung produk stok menipis (AMAN)
-	pc.db.Model(&models.Produk{}).
+	scopedDB(c, pc.db).Model(&models.Produk{}).
 		Where("produk_stok > 0 AND produk_stok < 10").
 		Count(&statistik.ProdukStokMenipis)
 
@@ -640,7 +716,7 @@ func (pc *ProdukController) UpdateStokProduk(c *gin.Context) {
 	}
 
 	var produk models.Produk
-	if err := pc.db.First(&produk, produkID).Error; err != nil {
+	if err := scopedDB(c, pc.db).First(&produk, produkID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Produk tidak ditemukan",
@@ -679,7 +755,7 @@ func (pc *ProdukController) UpdateStokProduk(c *gin.Context) {
 	produk.ProdukStok = req.ProdukStok
 	produk.UpdatedAt = time.Now()
 
-	if err := pc.db.Save(&produk).Error; err != nil {
+	if err := scopedDB(c, pc.db).Save(&produk).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Gagal mengupdate stok produk",
 			"details": err.Error(),
@@ -688,15 +764,31 @@ func (pc *ProdukController) UpdateStokProduk(c *gin.Context) {
 	}
 
 	// Reload dengan data terbaru
-	if err := pc.db.Preload("KategoriProduk").First(&produk, produkID).Error; err != nil {
+	if err := scopedDB(c, pc.db).Preload("KategoriProduk").First(&produk, produkID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Gagal memuat data produk yang diupdate",
 		})
 		return
 	}
 
+	pc.publikasikanProduk("produk.stok", produk)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Stok produk berhasil diupdate",
 		"data":    produk,
 	})
-}
\ No newline at end of file
+}
+
+// publikasikanProduk mendorong event perubahan produk dan stoknya ke user di RT produk tersebut
+func (pc *ProdukController) publikasikanProduk(jenis string, produk models.Produk) {
+	pc.hub.Publikasikan(realtime.Event{
+		Topik: realtime.TopikProduk,
+		Jenis: jenis,
+		Data: gin.H{
+			"produk_id":   produk.ProdukID,
+			"produk_nama": produk.ProdukNama,
+			"produk_stok": produk.ProdukStok,
+		},
+		RTID: produk.RTID,
+	})
+}
@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"rt-management/models"
+	"rt-management/realtime"
+	"rt-management/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type RealtimeController struct {
+	db  *gorm.DB
+	hub *realtime.Hub
+}
+
+func NewRealtimeController(db *gorm.DB, hub *realtime.Hub) *RealtimeController {
+	return &RealtimeController{db: db, hub: hub}
+}
+
+// ✅ GET - Stream event realtime (Server-Sent Events).
+// Browser memakai EventSource("/api/realtime/stream?topik=broadcast,kegiatan&token=<JWT>")
+// karena EventSource tidak dapat mengirim header Authorization.
+func (rc *RealtimeController) StreamEvent(c *gin.Context) {
+	topik, ok := parseTopikRealtime(c.Query("topik"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":          "Topik tidak dikenal",
+			"topik_tersedia": realtime.DaftarTopik,
+		})
+		return
+	}
+
+	pelanggan, err := rc.buatPelanggan(c, topik)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal menentukan wilayah user",
+		})
+		return
+	}
+
+	lastEventID, _ := strconv.ParseUint(c.GetHeader("Last-Event-ID"), 10, 64)
+	susulan := rc.hub.Langgan(pelanggan, lastEventID)
+	defer rc.hub.BerhentiLangganan(pelanggan)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // agar nginx tidak menahan stream
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	fmt.Fprint(w, "retry: 5000\n\n")
+	tulisEventSSE(w, "terhubung", 0, gin.H{"topik": daftarTopikPelanggan(topik)})
+	for _, e := range susulan {
+		tulisEventSSE(w, e.Jenis, e.ID, e)
+	}
+	w.Flush()
+
+	// Komentar berkala menjaga koneksi tetap hidup melewati proxy
+	ping := time.NewTicker(25 * time.Second)
+	defer ping.Stop()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-pelanggan.C:
+			tulisEventSSE(w, e.Jenis, e.ID, e)
+			w.Flush()
+		case <-ping.C:
+			fmt.Fprint(w, ": ping\n\n")
+			w.Flush()
+		}
+	}
+}
+
+// ✅ GET - Daftar topik realtime dan jumlah koneksi aktif
+func (rc *RealtimeController) GetTopikRealtime(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"topik":         realtime.DaftarTopik,
+		"koneksi_aktif": rc.hub.JumlahPelanggan(),
+	})
+}
+
+// ✅ Helper menyusun pelanggan dari user yang login beserta wilayah yang boleh diaksesnya
+func (rc *RealtimeController) buatPelanggan(c *gin.Context, topik map[string]bool) (*realtime.Pelanggan, error) {
+	userID, _ := c.Get("userID")
+	levelID, _ := c.Get("levelID")
+
+	pelanggan := &realtime.Pelanggan{
+		UserID:  userID.(uint),
+		LevelID: levelID.(uint),
+		RTIDs:   make(map[uint]bool),
+		Topik:   topik,
+	}
+
+	scope, _ := utils.TenantScopeFromContext(c.Request.Context())
+	switch {
+	case scope.RTID != 0:
+		pelanggan.RTIDs[scope.RTID] = true
+	case scope.RWID != 0:
+		var rtIDs []uint
+		if err := rc.db.Model(&models.RT{}).Where("rw_id = ?", scope.RWID).Pluck("rt_id", &rtIDs).Error; err != nil {
+			return nil, err
+		}
+		for _, id := range rtIDs {
+			pelanggan.RTIDs[id] = true
+		}
+	default:
+		pelanggan.Global = true
+	}
+
+	return pelanggan, nil
+}
+
+// parseTopikRealtime membaca daftar topik dipisah koma, kosong berarti semua topik
+func parseTopikRealtime(value string) (map[string]bool, bool) {
+	topik := make(map[string]bool)
+	for _, t := range strings.Split(value, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		dikenal := false
+		for _, d := range realtime.DaftarTopik {
+			if d == t {
+				dikenal = true
+				break
+			}
+		}
+		if !dikenal {
+			return nil, false
+		}
+		topik[t] = true
+	}
+	return topik, true
+}
+
+func daftarTopikPelanggan(topik map[string]bool) []string {
+	if len(topik) == 0 {
+		return realtime.DaftarTopik
+	}
+	var daftar []string
+	for _, t := range realtime.DaftarTopik {
+		if topik[t] {
+			daftar = append(daftar, t)
+		}
+	}
+	return daftar
+}
+
+func tulisEventSSE(w gin.ResponseWriter, jenis string, id uint64, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	if id > 0 {
+		fmt.Fprintf(w, "id: %d\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", jenis, payload)
+}
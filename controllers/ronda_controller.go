@@ -0,0 +1,1144 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"rt-management/models"
+	"rt-management/realtime"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Batas panjang satu periode jadwal ronda
+const maxHariJadwalRonda = 366
+
+// Nama kategori pemasukan untuk pembayaran denda ronda
+const kategoriPemasukanDendaRonda = "Denda Ronda"
+
+type RondaController struct {
+	db  *gorm.DB
+	hub *realtime.Hub
+}
+
+func NewRondaController(db *gorm.DB, hub *realtime.Hub) *RondaController {
+	return &RondaController{db: db, hub: hub}
+}
+
+// Request structs
+type KriteriaRondaRequest struct {
+	JenisKelamin    string `form:"jenis_kelamin"`
+	UsiaMin         int    `form:"usia_min"`
+	UsiaMax         int    `form:"usia_max"`
+	KecualiWargaIDs []uint `form:"kecuali_warga_ids"` // warga yang dibebaskan dari ronda
+}
+
+type GenerateRondaRequest struct {
+	KriteriaRondaRequest
+	JadwalRondaNama string  `form:"jadwal_ronda_nama" binding:"required"`
+	TanggalMulai    string  `form:"tanggal_mulai" binding:"required"`
+	TanggalSelesai  string  `form:"tanggal_selesai" binding:"required"`
+	JumlahKelompok  int     `form:"jumlah_kelompok" binding:"required"`
+	DendaPerAbsen   float64 `form:"denda_per_absen"`
+}
+
+type KehadiranRondaRequest struct {
+	Status     string `form:"status" binding:"required"`
+	Keterangan string `form:"keterangan"`
+}
+
+type TukarRondaRequest struct {
+	PetugasRondaID       uint   `form:"petugas_ronda_id" binding:"required"`
+	PetugasRondaTujuanID uint   `form:"petugas_ronda_tujuan_id" binding:"required"`
+	Alasan               string `form:"alasan"`
+}
+
+type ProsesTukarRondaRequest struct {
+	CatatanPengurus string `form:"catatan_pengurus"`
+}
+
+// Warga yang memenuhi kriteria ronda beserta jumlah shift yang pernah dijalani
+type KandidatRonda struct {
+	WargaID               uint   `json:"warga_id"`
+	WargaNama             string `json:"warga_nama"`
+	KeluargaID            uint   `json:"keluarga_id"`
+	Usia                  int    `json:"usia"`
+	JumlahShiftSebelumnya int64  `json:"jumlah_shift_sebelumnya"`
+}
+
+// Rekap kehadiran dan denda ronda per warga
+type RekapRondaWarga struct {
+	WargaID       uint    `json:"warga_id"`
+	WargaNama     string  `json:"warga_nama"`
+	Kelompok      int     `json:"kelompok"`
+	JumlahShift   int     `json:"jumlah_shift"`
+	JumlahHadir   int     `json:"jumlah_hadir"`
+	JumlahAbsen   int     `json:"jumlah_absen"`
+	JumlahIzin    int     `json:"jumlah_izin"`
+	TotalDenda    float64 `json:"total_denda"`
+	DendaTerutang float64 `json:"denda_terutang"`
+}
+
+// ✅ GET - Pratinjau warga yang memenuhi kriteria ronda
+func (rc *RondaController) GetKandidatRonda(c *gin.Context) {
+	var req KriteriaRondaRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if !normalizeKriteriaRonda(c, &req) {
+		return
+	}
+
+	kandidat, err := rc.kandidatRonda(c, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil kandidat ronda",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  kandidat,
+		"total": len(kandidat),
+	})
+}
+
+// ✅ POST - Membuat jadwal ronda bergilir untuk satu periode
+func (rc *RondaController) GenerateJadwalRonda(c *gin.Context) {
+	if _, ok := requireRTScope(c); !ok {
+		return
+	}
+
+	var req GenerateRondaRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	req.JadwalRondaNama = strings.TrimSpace(req.JadwalRondaNama)
+	if len(req.JadwalRondaNama) < 2 || len(req.JadwalRondaNama) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Nama jadwal ronda harus 2-100 karakter",
+		})
+		return
+	}
+
+	mulai, err := time.ParseInLocation("2006-01-02", req.TanggalMulai, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Format tanggal_mulai harus YYYY-MM-DD",
+		})
+		return
+	}
+	selesai, err := time.ParseInLocation("2006-01-02", req.TanggalSelesai, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Format tanggal_selesai harus YYYY-MM-DD",
+		})
+		return
+	}
+
+	jumlahHari := int(selesai.Sub(mulai).Hours()/24) + 1
+	if jumlahHari < 1 || jumlahHari > maxHariJadwalRonda {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Periode jadwal ronda harus 1-%d hari", maxHariJadwalRonda),
+		})
+		return
+	}
+
+	if req.JumlahKelompok < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Jumlah kelompok minimal 1",
+		})
+		return
+	}
+
+	if req.DendaPerAbsen < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Denda per absen tidak boleh negatif",
+		})
+		return
+	}
+
+	if !normalizeKriteriaRonda(c, &req.KriteriaRondaRequest) {
+		return
+	}
+
+	// Periode jadwal dalam satu RT tidak boleh bertumpuk
+	var bentrok models.JadwalRonda
+	if err := scopedDB(c, rc.db).
+		Where("tanggal_mulai <= ? AND tanggal_selesai >= ?", selesai.Format("2006-01-02"), mulai.Format("2006-01-02")).
+		First(&bentrok).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Periode bertumpuk dengan jadwal ronda lain",
+			"details": gin.H{
+				"jadwal_ronda_id":   bentrok.JadwalRondaID,
+				"jadwal_ronda_nama": bentrok.JadwalRondaNama,
+			},
+		})
+		return
+	}
+
+	kandidat, err := rc.kandidatRonda(c, req.KriteriaRondaRequest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil kandidat ronda",
+		})
+		return
+	}
+
+	if len(kandidat) < req.JumlahKelompok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Jumlah warga yang memenuhi kriteria lebih sedikit dari jumlah kelompok",
+			"details": gin.H{
+				"jumlah_kandidat": len(kandidat),
+				"jumlah_kelompok": req.JumlahKelompok,
+			},
+		})
+		return
+	}
+
+	jadwal := models.JadwalRonda{
+		JadwalRondaNama: req.JadwalRondaNama,
+		TanggalMulai:    mulai,
+		TanggalSelesai:  selesai,
+		JumlahKelompok:  req.JumlahKelompok,
+		DendaPerAbsen:   req.DendaPerAbsen,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+
+	kelompok := bagiKelompokRonda(kandidat, req.JumlahKelompok)
+
+	err = scopedDB(c, rc.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&jadwal).Error; err != nil {
+			return err
+		}
+
+		var anggota []models.AnggotaRonda
+		for i, k := range kelompok {
+			for _, wargaID := range k {
+				anggota = append(anggota, models.AnggotaRonda{
+					JadwalRondaID: jadwal.JadwalRondaID,
+					WargaID:       wargaID,
+					Kelompok:      i + 1,
+				})
+			}
+		}
+		if err := tx.Create(&anggota).Error; err != nil {
+			return err
+		}
+
+		// Kelompok bergiliran setiap malam: malam ke-n dijaga kelompok (n mod jumlah kelompok)
+		var petugas []models.PetugasRonda
+		for hari := 0; hari < jumlahHari; hari++ {
+			nomor := hari % req.JumlahKelompok
+			for _, wargaID := range kelompok[nomor] {
+				petugas = append(petugas, models.PetugasRonda{
+					JadwalRondaID: jadwal.JadwalRondaID,
+					WargaID:       wargaID,
+					Tanggal:       mulai.AddDate(0, 0, hari),
+					Kelompok:      nomor + 1,
+					Status:        "terjadwal",
+				})
+			}
+		}
+		return tx.CreateInBatches(&petugas, 200).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal membuat jadwal ronda",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := scopedDB(c, rc.db).
+		Preload("Anggota", func(db *gorm.DB) *gorm.DB {
+			return db.Order("kelompok ASC")
+		}).
+		Preload("Anggota.Warga").
+		First(&jadwal, jadwal.JadwalRondaID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal memuat jadwal ronda yang dibuat",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Jadwal ronda berhasil dibuat",
+		"data":    jadwal,
+	})
+}
+
+// ✅ READ - Mendapatkan semua jadwal ronda
+func (rc *RondaController) GetAllJadwalRonda(c *gin.Context) {
+	var jadwal []models.JadwalRonda
+
+	if err := scopedDB(c, rc.db).
+		Order("tanggal_mulai DESC").
+		Find(&jadwal).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data jadwal ronda",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  jadwal,
+		"total": len(jadwal),
+	})
+}
+
+// ✅ READ - Mendapatkan jadwal ronda beserta anggota tiap kelompok
+func (rc *RondaController) GetJadwalRondaByID(c *gin.Context) {
+	jadwal, ok := rc.findJadwal(c)
+	if !ok {
+		return
+	}
+
+	var anggota []models.AnggotaRonda
+	if err := scopedDB(c, rc.db).
+		Preload("Warga").
+		Where("jadwal_ronda_id = ?", jadwal.JadwalRondaID).
+		Order("kelompok ASC").
+		Find(&anggota).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil anggota ronda",
+		})
+		return
+	}
+
+	kelompok := make(map[int][]models.AnggotaRonda)
+	for _, a := range anggota {
+		kelompok[a.Kelompok] = append(kelompok[a.Kelompok], a)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":     jadwal,
+		"kelompok": kelompok,
+	})
+}
+
+// ✅ READ - Daftar petugas ronda pada satu jadwal, bisa difilter per tanggal
+func (rc *RondaController) GetPetugasRonda(c *gin.Context) {
+	jadwal, ok := rc.findJadwal(c)
+	if !ok {
+		return
+	}
+
+	query := scopedDB(c, rc.db).
+		Preload("Warga").
+		Where("jadwal_ronda_id = ?", jadwal.JadwalRondaID)
+
+	if tanggal := c.Query("tanggal"); tanggal != "" {
+		t, err := time.Parse("2006-01-02", tanggal)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Format tanggal harus YYYY-MM-DD",
+			})
+			return
+		}
+		query = query.Where("tanggal = ?", t.Format("2006-01-02"))
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var petugas []models.PetugasRonda
+	if err := query.Order("tanggal ASC, kelompok ASC").Find(&petugas).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data petugas ronda",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  petugas,
+		"total": len(petugas),
+	})
+}
+
+// ✅ READ - Petugas ronda malam ini
+func (rc *RondaController) GetPetugasRondaMalamIni(c *gin.Context) {
+	var petugas []models.PetugasRonda
+
+	if err := scopedDB(c, rc.db).
+		Preload("Warga").
+		Preload("JadwalRonda").
+		Where("tanggal = ?", time.Now().Format("2006-01-02")).
+		Order("kelompok ASC").
+		Find(&petugas).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil petugas ronda malam ini",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tanggal": time.Now().Format("2006-01-02"),
+		"data":    petugas,
+		"total":   len(petugas),
+	})
+}
+
+// ✅ READ - Rekap kehadiran dan denda per warga pada satu jadwal
+func (rc *RondaController) GetRekapRonda(c *gin.Context) {
+	jadwal, ok := rc.findJadwal(c)
+	if !ok {
+		return
+	}
+
+	var petugas []models.PetugasRonda
+	if err := scopedDB(c, rc.db).
+		Preload("Warga").
+		Where("jadwal_ronda_id = ?", jadwal.JadwalRondaID).
+		Find(&petugas).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil rekap ronda",
+		})
+		return
+	}
+
+	rekapPerWarga := make(map[uint]*RekapRondaWarga)
+	for _, p := range petugas {
+		rekap, exists := rekapPerWarga[p.WargaID]
+		if !exists {
+			rekap = &RekapRondaWarga{WargaID: p.WargaID, Kelompok: p.Kelompok}
+			if p.Warga != nil {
+				rekap.WargaNama = p.Warga.WargaNama
+			}
+			rekapPerWarga[p.WargaID] = rekap
+		}
+		rekap.JumlahShift++
+		switch p.Status {
+		case "hadir":
+			rekap.JumlahHadir++
+		case "absen":
+			rekap.JumlahAbsen++
+		case "izin":
+			rekap.JumlahIzin++
+		}
+		rekap.TotalDenda += p.Denda
+		if !p.DendaLunas {
+			rekap.DendaTerutang += p.Denda
+		}
+	}
+
+	rekap := make([]RekapRondaWarga, 0, len(rekapPerWarga))
+	for _, r := range rekapPerWarga {
+		rekap = append(rekap, *r)
+	}
+	sort.Slice(rekap, func(i, j int) bool {
+		if rekap[i].Kelompok != rekap[j].Kelompok {
+			return rekap[i].Kelompok < rekap[j].Kelompok
+		}
+		return rekap[i].WargaNama < rekap[j].WargaNama
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"jadwal": jadwal,
+		"data":   rekap,
+	})
+}
+
+// ✅ DELETE - Menghapus jadwal ronda yang belum berjalan (belum ada absensi)
+func (rc *RondaController) DeleteJadwalRonda(c *gin.Context) {
+	jadwal, ok := rc.findJadwal(c)
+	if !ok {
+		return
+	}
+
+	var tercatat int64
+	scopedDB(c, rc.db).Model(&models.PetugasRonda{}).
+		Where("jadwal_ronda_id = ? AND status <> ?", jadwal.JadwalRondaID, "terjadwal").
+		Count(&tercatat)
+	if tercatat > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tidak dapat menghapus jadwal ronda yang sudah memiliki catatan kehadiran",
+			"details": gin.H{
+				"total_tercatat": tercatat,
+			},
+		})
+		return
+	}
+
+	err := scopedDB(c, rc.db).Transaction(func(tx *gorm.DB) error {
+		petugasIDs := tx.Model(&models.PetugasRonda{}).Select("petugas_ronda_id").Where("jadwal_ronda_id = ?", jadwal.JadwalRondaID)
+		if err := tx.Where("petugas_ronda_id IN (?)", petugasIDs).Delete(&models.TukarRonda{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("jadwal_ronda_id = ?", jadwal.JadwalRondaID).Delete(&models.PetugasRonda{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("jadwal_ronda_id = ?", jadwal.JadwalRondaID).Delete(&models.AnggotaRonda{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&jadwal).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menghapus jadwal ronda",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Jadwal ronda berhasil dihapus",
+	})
+}
+
+// ✅ PUT - Mencatat kehadiran petugas ronda (hadir/absen/izin), absen dikenakan denda
+func (rc *RondaController) CatatKehadiranRonda(c *gin.Context) {
+	petugas, ok := rc.findPetugas(c)
+	if !ok {
+		return
+	}
+
+	var req KehadiranRondaRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	req.Status = strings.ToLower(strings.TrimSpace(req.Status))
+	if req.Status != "hadir" && req.Status != "absen" && req.Status != "izin" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Status kehadiran harus hadir, absen, atau izin",
+		})
+		return
+	}
+
+	if petugas.Tanggal.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Kehadiran belum dapat dicatat untuk shift yang belum berlangsung",
+		})
+		return
+	}
+
+	if petugas.DendaLunas {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Denda shift ini sudah dibayar, kehadiran tidak dapat diubah",
+		})
+		return
+	}
+
+	var jadwal models.JadwalRonda
+	if err := scopedDB(c, rc.db).First(&jadwal, petugas.JadwalRondaID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal memuat jadwal ronda",
+		})
+		return
+	}
+
+	denda := 0.0
+	if req.Status == "absen" {
+		denda = jadwal.DendaPerAbsen
+	}
+
+	userID, _ := c.Get("userID")
+	dicatatOleh := userID.(uint)
+	now := time.Now()
+
+	updates := map[string]interface{}{
+		"status":          req.Status,
+		"denda":           denda,
+		"keterangan":      strings.TrimSpace(req.Keterangan),
+		"dicatat_oleh_id": dicatatOleh,
+		"dicatat_pada":    now,
+		"updated_at":      now,
+	}
+
+	if err := scopedDB(c, rc.db).Model(&petugas).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mencatat kehadiran ronda",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Kehadiran ronda berhasil dicatat",
+		"data":    petugas,
+	})
+}
+
+// ✅ GET - Daftar denda ronda (status=belum untuk yang belum dibayar, status=lunas untuk yang sudah)
+func (rc *RondaController) GetDendaRonda(c *gin.Context) {
+	query := scopedDB(c, rc.db).
+		Preload("Warga").
+		Preload("JadwalRonda").
+		Where("status = ? AND denda > 0", "absen")
+
+	switch c.Query("status") {
+	case "belum":
+		query = query.Where("denda_lunas = ?", false)
+	case "lunas":
+		query = query.Where("denda_lunas = ?", true)
+	}
+	if jadwalID, err := strconv.ParseUint(c.Query("jadwal_ronda_id"), 10, 32); err == nil {
+		query = query.Where("jadwal_ronda_id = ?", jadwalID)
+	}
+
+	var petugas []models.PetugasRonda
+	if err := query.Order("tanggal DESC").Find(&petugas).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data denda ronda",
+		})
+		return
+	}
+
+	var totalDenda, totalTerutang float64
+	for _, p := range petugas {
+		totalDenda += p.Denda
+		if !p.DendaLunas {
+			totalTerutang += p.Denda
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":           petugas,
+		"total":          len(petugas),
+		"total_denda":    totalDenda,
+		"total_terutang": totalTerutang,
+	})
+}
+
+// ✅ PUT - Mencatat pembayaran denda ronda sebagai pemasukan kas
+func (rc *RondaController) BayarDendaRonda(c *gin.Context) {
+	petugas, ok := rc.findPetugas(c)
+	if !ok {
+		return
+	}
+
+	if petugas.Status != "absen" || petugas.Denda <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Shift ini tidak memiliki denda",
+		})
+		return
+	}
+	if petugas.DendaLunas {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Denda sudah dibayar",
+		})
+		return
+	}
+
+	var warga models.Warga
+	scopedDB(c, rc.db).Unscoped().First(&warga, petugas.WargaID)
+
+	var pemasukan models.Pemasukan
+	err := scopedDB(c, rc.db).Transaction(func(tx *gorm.DB) error {
+		nama := fmt.Sprintf("Denda ronda %s (%s)", warga.WargaNama, petugas.Tanggal.Format("02-01-2006"))
+		var err error
+		pemasukan, err = catatPemasukanOtomatis(tx, kategoriPemasukanDendaRonda, nama, petugas.Denda, petugas.RTID)
+		if err != nil {
+			return err
+		}
+		petugas.DendaLunas = true
+		petugas.PemasukanID = &pemasukan.PemasukanID
+		return tx.Model(&petugas).Updates(map[string]interface{}{
+			"denda_lunas":  true,
+			"pemasukan_id": pemasukan.PemasukanID,
+			"updated_at":   time.Now(),
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mencatat pembayaran denda",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Akun user milik warga yang didenda ikut menerima event
+	var pembayar []uint
+	rc.db.Model(&models.User{}).Where("warga_id = ?", petugas.WargaID).Pluck("user_id", &pembayar)
+	publikasikanTagihanDibayar(rc.hub, "petugas_ronda", petugas.PetugasRondaID, pemasukan, pembayar)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Pembayaran denda berhasil dicatat",
+		"data":    petugas,
+	})
+}
+
+// ✅ POST - Mengajukan tukar shift ronda dengan warga lain
+func (rc *RondaController) AjukanTukarRonda(c *gin.Context) {
+	var req TukarRondaRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if req.PetugasRondaID == req.PetugasRondaTujuanID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Shift asal dan tujuan tidak boleh sama",
+		})
+		return
+	}
+
+	var asal, tujuan models.PetugasRonda
+	if err := scopedDB(c, rc.db).First(&asal, req.PetugasRondaID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Shift asal tidak ditemukan",
+		})
+		return
+	}
+	if err := scopedDB(c, rc.db).First(&tujuan, req.PetugasRondaTujuanID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Shift tujuan tidak ditemukan",
+		})
+		return
+	}
+
+	// Warga hanya boleh menukar shift yang ditugaskan kepadanya sendiri
+	if levelID, _ := c.Get("levelID"); levelID == uint(6) {
+		userID, _ := c.Get("userID")
+		var user models.User
+		if err := rc.db.Select("user_id", "warga_id").First(&user, userID).Error; err != nil || user.WargaID == nil {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Akun belum ditautkan ke data warga",
+			})
+			return
+		}
+		if *user.WargaID != asal.WargaID {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Anda hanya dapat menukar shift ronda milik sendiri",
+			})
+			return
+		}
+	}
+
+	if errMsg := validateTukarRonda(asal, tujuan); errMsg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errMsg,
+		})
+		return
+	}
+
+	if errMsg := rc.checkBentrokTukar(c, rc.db, asal, tujuan); errMsg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errMsg,
+		})
+		return
+	}
+
+	var pending int64
+	scopedDB(c, rc.db).Model(&models.TukarRonda{}).
+		Where("status = ? AND (petugas_ronda_id IN ? OR petugas_ronda_tujuan_id IN ?)", "menunggu",
+			[]uint{asal.PetugasRondaID, tujuan.PetugasRondaID}, []uint{asal.PetugasRondaID, tujuan.PetugasRondaID}).
+		Count(&pending)
+	if pending > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Salah satu shift masih memiliki pengajuan tukar yang menunggu persetujuan",
+		})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	tukar := models.TukarRonda{
+		PetugasRondaID:       asal.PetugasRondaID,
+		PetugasRondaTujuanID: tujuan.PetugasRondaID,
+		Alasan:               strings.TrimSpace(req.Alasan),
+		Status:               "menunggu",
+		DiajukanOlehID:       userID.(uint),
+		RTID:                 asal.RTID,
+	}
+
+	if err := scopedDB(c, rc.db).Create(&tukar).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mengajukan tukar ronda",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Pengajuan tukar ronda berhasil dibuat, menunggu persetujuan pengurus",
+		"data":    tukar,
+	})
+}
+
+// ✅ GET - Daftar pengajuan tukar ronda (warga hanya melihat pengajuannya sendiri)
+func (rc *RondaController) GetAllTukarRonda(c *gin.Context) {
+	query := scopedDB(c, rc.db).
+		Preload("PetugasRonda.Warga").
+		Preload("PetugasRondaTujuan.Warga")
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if levelID, _ := c.Get("levelID"); levelID == uint(6) {
+		userID, _ := c.Get("userID")
+		query = query.Where("diajukan_oleh_id = ?", userID)
+	}
+
+	var tukar []models.TukarRonda
+	if err := query.Order("created_at DESC").Find(&tukar).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data tukar ronda",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  tukar,
+		"total": len(tukar),
+	})
+}
+
+// ✅ PUT - Menyetujui tukar ronda, petugas pada kedua shift ditukar
+func (rc *RondaController) SetujuiTukarRonda(c *gin.Context) {
+	tukar, req, ok := rc.findTukarMenunggu(c)
+	if !ok {
+		return
+	}
+
+	var errMsg string
+	err := scopedDB(c, rc.db).Transaction(func(tx *gorm.DB) error {
+		var asal, tujuan models.PetugasRonda
+		if err := tx.First(&asal, tukar.PetugasRondaID).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&tujuan, tukar.PetugasRondaTujuanID).Error; err != nil {
+			return err
+		}
+
+		// Kondisi shift bisa berubah sejak pengajuan dibuat
+		if errMsg = validateTukarRonda(asal, tujuan); errMsg != "" {
+			return nil
+		}
+		if errMsg = rc.checkBentrokTukar(c, tx, asal, tujuan); errMsg != "" {
+			return nil
+		}
+
+		now := time.Now()
+		if err := tx.Model(&asal).Updates(map[string]interface{}{"warga_id": tujuan.WargaID, "updated_at": now}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&tujuan).Updates(map[string]interface{}{"warga_id": asal.WargaID, "updated_at": now}).Error; err != nil {
+			return err
+		}
+
+		userID, _ := c.Get("userID")
+		diprosesOleh := userID.(uint)
+		tukar.Status = "disetujui"
+		tukar.DiprosesOlehID = &diprosesOleh
+		tukar.DiprosesPada = &now
+		tukar.CatatanPengurus = strings.TrimSpace(req.CatatanPengurus)
+		return tx.Save(&tukar).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menyetujui tukar ronda",
+			"details": err.Error(),
+		})
+		return
+	}
+	if errMsg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errMsg,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tukar ronda disetujui",
+		"data":    tukar,
+	})
+}
+
+// ✅ PUT - Menolak tukar ronda
+func (rc *RondaController) TolakTukarRonda(c *gin.Context) {
+	tukar, req, ok := rc.findTukarMenunggu(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	diprosesOleh := userID.(uint)
+	now := time.Now()
+	tukar.Status = "ditolak"
+	tukar.DiprosesOlehID = &diprosesOleh
+	tukar.DiprosesPada = &now
+	tukar.CatatanPengurus = strings.TrimSpace(req.CatatanPengurus)
+
+	if err := scopedDB(c, rc.db).Save(&tukar).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menolak tukar ronda",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tukar ronda ditolak",
+		"data":    tukar,
+	})
+}
+
+// ✅ Helper mengambil warga yang memenuhi kriteria ronda, diurutkan dari yang paling jarang bertugas
+func (rc *RondaController) kandidatRonda(c *gin.Context, kriteria KriteriaRondaRequest) ([]KandidatRonda, error) {
+	today := startOfDay(time.Now())
+	// usia >= min  <=> lahir <= hari ini - min tahun; usia <= max <=> lahir > hari ini - (max+1) tahun
+	lahirPalingLambat := today.AddDate(-kriteria.UsiaMin, 0, 0)
+	lahirPalingAwal := today.AddDate(-(kriteria.UsiaMax + 1), 0, 0)
+
+	query := scopedDB(c, rc.db).
+		Where("warga_jenis_kelamin = ?", kriteria.JenisKelamin).
+		Where("warga_status_aktif = ? AND warga_status_hidup = ?", "aktif", "hidup").
+		Where("warga_tanggal_lahir <= ? AND warga_tanggal_lahir > ?", lahirPalingLambat, lahirPalingAwal)
+	if len(kriteria.KecualiWargaIDs) > 0 {
+		query = query.Where("warga_id NOT IN ?", kriteria.KecualiWargaIDs)
+	}
+
+	var warga []models.Warga
+	if err := query.Order("warga_nama ASC").Find(&warga).Error; err != nil {
+		return nil, err
+	}
+
+	var riwayat []struct {
+		WargaID uint
+		Jumlah  int64
+	}
+	if err := scopedDB(c, rc.db).Model(&models.PetugasRonda{}).
+		Select("warga_id, COUNT(*) AS jumlah").
+		Group("warga_id").
+		Scan(&riwayat).Error; err != nil {
+		return nil, err
+	}
+	jumlahShift := make(map[uint]int64, len(riwayat))
+	for _, r := range riwayat {
+		jumlahShift[r.WargaID] = r.Jumlah
+	}
+
+	kandidat := make([]KandidatRonda, 0, len(warga))
+	for _, w := range warga {
+		kandidat = append(kandidat, KandidatRonda{
+			WargaID:               w.WargaID,
+			WargaNama:             w.WargaNama,
+			KeluargaID:            w.KeluargaID,
+			Usia:                  hitungUsia(w.WargaTanggalLahir, today),
+			JumlahShiftSebelumnya: jumlahShift[w.WargaID],
+		})
+	}
+
+	sort.SliceStable(kandidat, func(i, j int) bool {
+		return kandidat[i].JumlahShiftSebelumnya < kandidat[j].JumlahShiftSebelumnya
+	})
+
+	return kandidat, nil
+}
+
+// ✅ Helper memeriksa warga tidak menjadi dobel pada malam yang sama setelah ditukar
+func (rc *RondaController) checkBentrokTukar(c *gin.Context, db *gorm.DB, asal, tujuan models.PetugasRonda) string {
+	var count int64
+	scopedDB(c, db).Model(&models.PetugasRonda{}).
+		Where("(warga_id = ? AND tanggal = ?) OR (warga_id = ? AND tanggal = ?)",
+			tujuan.WargaID, asal.Tanggal.Format("2006-01-02"),
+			asal.WargaID, tujuan.Tanggal.Format("2006-01-02")).
+		Count(&count)
+	if count > 0 {
+		return "Warga sudah bertugas pada malam pengganti"
+	}
+	return ""
+}
+
+func (rc *RondaController) findJadwal(c *gin.Context) (models.JadwalRonda, bool) {
+	var jadwal models.JadwalRonda
+
+	jadwalID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID jadwal ronda tidak valid",
+		})
+		return jadwal, false
+	}
+
+	if err := scopedDB(c, rc.db).First(&jadwal, jadwalID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Jadwal ronda tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan jadwal ronda",
+			})
+		}
+		return jadwal, false
+	}
+
+	return jadwal, true
+}
+
+func (rc *RondaController) findPetugas(c *gin.Context) (models.PetugasRonda, bool) {
+	var petugas models.PetugasRonda
+
+	petugasID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID petugas ronda tidak valid",
+		})
+		return petugas, false
+	}
+
+	if err := scopedDB(c, rc.db).First(&petugas, petugasID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Petugas ronda tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan petugas ronda",
+			})
+		}
+		return petugas, false
+	}
+
+	return petugas, true
+}
+
+func (rc *RondaController) findTukarMenunggu(c *gin.Context) (models.TukarRonda, ProsesTukarRondaRequest, bool) {
+	var tukar models.TukarRonda
+	var req ProsesTukarRondaRequest
+
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return tukar, req, false
+	}
+
+	if err := scopedDB(c, rc.db).First(&tukar, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Pengajuan tukar ronda tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan pengajuan tukar ronda",
+			})
+		}
+		return tukar, req, false
+	}
+
+	if tukar.Status != "menunggu" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Pengajuan tukar ronda sudah diproses",
+		})
+		return tukar, req, false
+	}
+
+	return tukar, req, true
+}
+
+// ✅ Helper validasi dan nilai default kriteria ronda (laki-laki usia 17-60 tahun)
+func normalizeKriteriaRonda(c *gin.Context, kriteria *KriteriaRondaRequest) bool {
+	kriteria.JenisKelamin = strings.ToUpper(strings.TrimSpace(kriteria.JenisKelamin))
+	if kriteria.JenisKelamin == "" {
+		kriteria.JenisKelamin = "L"
+	}
+	if kriteria.JenisKelamin != "L" && kriteria.JenisKelamin != "P" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Jenis kelamin harus L atau P",
+		})
+		return false
+	}
+
+	if kriteria.UsiaMin == 0 {
+		kriteria.UsiaMin = 17
+	}
+	if kriteria.UsiaMax == 0 {
+		kriteria.UsiaMax = 60
+	}
+	if kriteria.UsiaMin < 0 || kriteria.UsiaMax > 120 || kriteria.UsiaMin > kriteria.UsiaMax {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Rentang usia tidak valid",
+		})
+		return false
+	}
+
+	return true
+}
+
+// ✅ Helper validasi dua shift yang akan ditukar
+func validateTukarRonda(asal, tujuan models.PetugasRonda) string {
+	if asal.JadwalRondaID != tujuan.JadwalRondaID {
+		return "Tukar ronda hanya dapat dilakukan dalam jadwal yang sama"
+	}
+	if asal.WargaID == tujuan.WargaID {
+		return "Tidak dapat menukar shift dengan diri sendiri"
+	}
+	if asal.Status != "terjadwal" || tujuan.Status != "terjadwal" {
+		return "Shift yang sudah dicatat kehadirannya tidak dapat ditukar"
+	}
+	today := startOfDay(time.Now())
+	if asal.Tanggal.Before(today) || tujuan.Tanggal.Before(today) {
+		return "Shift yang sudah lewat tidak dapat ditukar"
+	}
+	return ""
+}
+
+// bagiKelompokRonda membagi kandidat ke sejumlah kelompok dengan ukuran seimbang (selisih maksimal 1).
+// Kandidat yang paling jarang bertugas ditempatkan lebih dulu, dan anggota satu keluarga
+// sebisa mungkin dipisah agar satu rumah tidak kehilangan semua penjaganya pada malam yang sama.
+func bagiKelompokRonda(kandidat []KandidatRonda, jumlahKelompok int) [][]uint {
+	kelompok := make([][]uint, jumlahKelompok)
+	keluargaDiKelompok := make([]map[uint]bool, jumlahKelompok)
+	for i := range keluargaDiKelompok {
+		keluargaDiKelompok[i] = make(map[uint]bool)
+	}
+
+	for _, k := range kandidat {
+		ukuranMin := len(kelompok[0])
+		for _, anggota := range kelompok {
+			if len(anggota) < ukuranMin {
+				ukuranMin = len(anggota)
+			}
+		}
+
+		pilih := -1
+		for i, anggota := range kelompok {
+			if len(anggota) != ukuranMin {
+				continue
+			}
+			if pilih == -1 || (keluargaDiKelompok[pilih][k.KeluargaID] && !keluargaDiKelompok[i][k.KeluargaID]) {
+				pilih = i
+			}
+		}
+
+		kelompok[pilih] = append(kelompok[pilih], k.WargaID)
+		keluargaDiKelompok[pilih][k.KeluargaID] = true
+	}
+
+	return kelompok
+}
+
+func hitungUsia(lahir, today time.Time) int {
+	usia := today.Year() - lahir.Year()
+	if today.Month() < lahir.Month() || (today.Month() == lahir.Month() && today.Day() < lahir.Day()) {
+		usia--
+	}
+	return usia
+}
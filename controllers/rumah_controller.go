@@ -1,8 +1,10 @@
 package controllers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"rt-management/models"
@@ -22,15 +24,34 @@ func NewRumahController(db *gorm.DB) *RumahController {
 
 // Request structs
 type CreateRumahRequest struct {
-	RumahAlamat string `form:"rumah_alamat" binding:"required"`
-	RumahStatus string `form:"rumah_status"`
-	WargaID     uint   `form:"warga_id"`
+	RumahBlok      string   `form:"rumah_blok" binding:"required"`
+	RumahNomor     string   `form:"rumah_nomor" binding:"required"`
+	RumahGang      string   `form:"rumah_gang"`
+	RumahRT        string   `form:"rumah_rt"`
+	RumahRW        string   `form:"rumah_rw"`
+	RumahLatitude  *float64 `form:"rumah_latitude"`
+	RumahLongitude *float64 `form:"rumah_longitude"`
+	WargaID        uint     `form:"warga_id"`
 }
 
 type UpdateRumahRequest struct {
-	RumahAlamat string `form:"rumah_alamat"`
-	RumahStatus string `form:"rumah_status"`
-	WargaID     uint   `form:"warga_id"`
+	RumahBlok      string   `form:"rumah_blok"`
+	RumahNomor     string   `form:"rumah_nomor"`
+	RumahGang      string   `form:"rumah_gang"`
+	RumahRT        string   `form:"rumah_rt"`
+	RumahRW        string   `form:"rumah_rw"`
+	RumahLatitude  *float64 `form:"rumah_latitude"`
+	RumahLongitude *float64 `form:"rumah_longitude"`
+	WargaID        uint     `form:"warga_id"`
+}
+
+// Ringkasan rumah per blok
+type BlokRumahSummary struct {
+	RumahBlok      string         `json:"rumah_blok"`
+	TotalRumah     int            `json:"total_rumah"`
+	TotalDitempati int            `json:"total_ditempati"`
+	TotalTersedia  int            `json:"total_tersedia"`
+	Rumah          []models.Rumah `json:"rumah"`
 }
 
 // ✅ CREATE - Membuat rumah baru
@@ -44,31 +65,58 @@ func (rc *RumahController) CreateRumah(c *gin.Context) {
 		return
 	}
 
-	// Validasi required fields
-	if req.RumahAlamat == "" {
+	// Normalisasi dan validasi alamat terstruktur
+	req.RumahBlok = normalizeBlok(req.RumahBlok)
+	req.RumahNomor = strings.ToUpper(strings.TrimSpace(req.RumahNomor))
+	req.RumahGang = strings.TrimSpace(req.RumahGang)
+	if req.RumahBlok == "" || req.RumahNomor == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Alamat rumah harus diisi",
+			"error": "Blok dan nomor rumah harus diisi",
 		})
 		return
 	}
 
-	// Set default status jika tidak diisi
-	if req.RumahStatus == "" {
-		req.RumahStatus = "tersedia"
+	var ok bool
+	if req.RumahRT, ok = normalizeRTRW(req.RumahRT); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "RT harus berupa angka maksimal 3 digit",
+		})
+		return
+	}
+	if req.RumahRW, ok = normalizeRTRW(req.RumahRW); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "RW harus berupa angka maksimal 3 digit",
+		})
+		return
 	}
 
-	// Validasi status
-	if !isValidRumahStatus(req.RumahStatus) {
+	if err := validateKoordinat(req.RumahLatitude, req.RumahLongitude); err != "" {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Status rumah harus 'tersedia' atau 'ditempati'",
+			"error": err,
 		})
 		return
 	}
 
+	// Cek blok + nomor sudah dipakai (termasuk yang ada di trash)
+	var existing models.Rumah
+	if err := scopedDB(c, rc.db).Unscoped().Where("rumah_blok = ? AND rumah_nomor = ?", req.RumahBlok, req.RumahNomor).First(&existing).Error; err == nil {
+		if existing.DeletedAt.Valid {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":    "Rumah dengan blok dan nomor tersebut ada di trash, pulihkan dari trash",
+				"rumah_id": existing.RumahID,
+			})
+		} else {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Rumah dengan blok dan nomor tersebut sudah terdaftar",
+			})
+		}
+		return
+	}
+
 	// Jika WargaID diisi, validasi apakah warga exists
 	if req.WargaID != 0 {
 		var warga models.Warga
-		if err := rc.db.First(&warga, req.WargaID).Error; err != nil {
+		if err := scopedDB(c, rc.db).First(&warga, req.WargaID).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				c.JSON(http.StatusBadRequest, gin.H{
 					"error": "Warga tidak ditemukan",
@@ -84,14 +132,21 @@ func (rc *RumahController) CreateRumah(c *gin.Context) {
 
 	// Buat rumah baru
 	rumah := models.Rumah{
-		RumahAlamat: req.RumahAlamat,
-		RumahStatus: req.RumahStatus,
-		WargaID:     req.WargaID,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		RumahBlok:      req.RumahBlok,
+		RumahNomor:     req.RumahNomor,
+		RumahGang:      req.RumahGang,
+		RumahRT:        req.RumahRT,
+		RumahRW:        req.RumahRW,
+		RumahLatitude:  req.RumahLatitude,
+		RumahLongitude: req.RumahLongitude,
+		RumahStatus:    "tersedia", // status berikutnya diturunkan dari hunian
+		WargaID:        req.WargaID,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
 	}
+	rumah.RumahAlamat = formatAlamatRumah(rumah)
 
-	if err := rc.db.Create(&rumah).Error; err != nil {
+	if err := scopedDB(c, rc.db).Create(&rumah).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Gagal membuat rumah",
 			"details": err.Error(),
@@ -100,7 +155,7 @@ func (rc *RumahController) CreateRumah(c *gin.Context) {
 	}
 
 	// Reload dengan data warga
-	if err := rc.db.Preload("Warga").First(&rumah, rumah.RumahID).Error; err != nil {
+	if err := scopedDB(c, rc.db).Preload("Warga").Preload("HunianAktif", preloadHunianAktif).First(&rumah, rumah.RumahID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Gagal memuat data rumah yang dibuat",
 		})
@@ -125,8 +180,12 @@ func (rc *RumahController) GetAllRumah(c *gin.Context) {
 	// Filter parameters
 	status := c.Query("status")
 	wargaID := c.Query("warga_id")
+	blok := c.Query("blok")
+	gang := c.Query("gang")
+	rt := c.Query("rt")
+	rw := c.Query("rw")
 
-	query := rc.db.Preload("Warga")
+	query := scopedDB(c, rc.db).Preload("Warga").Preload("HunianAktif", preloadHunianAktif)
 
 	// Apply filters
 	if status != "" {
@@ -135,13 +194,29 @@ func (rc *RumahController) GetAllRumah(c *gin.Context) {
 	if wargaID != "" {
 		query = query.Where("warga_id = ?", wargaID)
 	}
+	if blok != "" {
+		query = query.Where("rumah_blok = ?", normalizeBlok(blok))
+	}
+	if gang != "" {
+		query = query.Where("rumah_gang LIKE ?", "%"+gang+"%")
+	}
+	if rt != "" {
+		if v, ok := normalizeRTRW(rt); ok {
+			query = query.Where("rumah_rt = ?", v)
+		}
+	}
+	if rw != "" {
+		if v, ok := normalizeRTRW(rw); ok {
+			query = query.Where("rumah_rw = ?", v)
+		}
+	}
 
 	// Get total count for pagination
 	var total int64
 	query.Model(&models.Rumah{}).Count(&total)
 
-	// Execute query with pagination
-	if err := query.
+	// Execute query with pagination, urut per blok lalu nomor
+	if err := orderByAlamat(query).
 		Offset(offset).
 		// Limit(limit).
 		Find(&rumah).Error; err != nil {
@@ -166,7 +241,7 @@ func (rc *RumahController) GetRumahByID(c *gin.Context) {
 	id := c.Param("id")
 
 	var rumah models.Rumah
-	if err := rc.db.Preload("Warga").First(&rumah, id).Error; err != nil {
+	if err := scopedDB(c, rc.db).Preload("Warga").Preload("HunianAktif", preloadHunianAktif).First(&rumah, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Rumah tidak ditemukan",
@@ -189,7 +264,7 @@ func (rc *RumahController) UpdateRumah(c *gin.Context) {
 	id := c.Param("id")
 
 	var rumah models.Rumah
-	if err := rc.db.First(&rumah, id).Error; err != nil {
+	if err := scopedDB(c, rc.db).First(&rumah, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Rumah tidak ditemukan",
@@ -211,18 +286,10 @@ func (rc *RumahController) UpdateRumah(c *gin.Context) {
 		return
 	}
 
-	// Validasi status jika diupdate
-	if req.RumahStatus != "" && !isValidRumahStatus(req.RumahStatus) {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Status rumah harus 'tersedia' atau 'ditempati'",
-		})
-		return
-	}
-
 	// Jika WargaID diisi, validasi apakah warga exists
 	if req.WargaID != 0 {
 		var warga models.Warga
-		if err := rc.db.First(&warga, req.WargaID).Error; err != nil {
+		if err := scopedDB(c, rc.db).First(&warga, req.WargaID).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				c.JSON(http.StatusBadRequest, gin.H{
 					"error": "Warga tidak ditemukan",
@@ -236,20 +303,82 @@ func (rc *RumahController) UpdateRumah(c *gin.Context) {
 		}
 	}
 
-	// Update fields
-	updates := make(map[string]interface{})
-	if req.RumahAlamat != "" {
-		updates["rumah_alamat"] = req.RumahAlamat
+	// Gabungkan alamat lama dengan field yang diupdate
+	updated := rumah
+	if req.RumahBlok != "" {
+		updated.RumahBlok = normalizeBlok(req.RumahBlok)
+	}
+	if req.RumahNomor != "" {
+		updated.RumahNomor = strings.ToUpper(strings.TrimSpace(req.RumahNomor))
+	}
+	if req.RumahGang != "" {
+		updated.RumahGang = strings.TrimSpace(req.RumahGang)
+	}
+	if req.RumahRT != "" {
+		v, ok := normalizeRTRW(req.RumahRT)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "RT harus berupa angka maksimal 3 digit",
+			})
+			return
+		}
+		updated.RumahRT = v
+	}
+	if req.RumahRW != "" {
+		v, ok := normalizeRTRW(req.RumahRW)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "RW harus berupa angka maksimal 3 digit",
+			})
+			return
+		}
+		updated.RumahRW = v
+	}
+	if req.RumahLatitude != nil || req.RumahLongitude != nil {
+		if req.RumahLatitude != nil {
+			updated.RumahLatitude = req.RumahLatitude
+		}
+		if req.RumahLongitude != nil {
+			updated.RumahLongitude = req.RumahLongitude
+		}
+		if err := validateKoordinat(updated.RumahLatitude, updated.RumahLongitude); err != "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err,
+			})
+			return
+		}
 	}
-	if req.RumahStatus != "" {
-		updates["rumah_status"] = req.RumahStatus
+
+	// Cek blok + nomor baru tidak bentrok dengan rumah lain
+	if updated.RumahBlok != rumah.RumahBlok || updated.RumahNomor != rumah.RumahNomor {
+		var count int64
+		scopedDB(c, rc.db).Unscoped().Model(&models.Rumah{}).
+			Where("rumah_blok = ? AND rumah_nomor = ? AND rumah_id != ?", updated.RumahBlok, updated.RumahNomor, rumah.RumahID).
+			Count(&count)
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Rumah dengan blok dan nomor tersebut sudah terdaftar",
+			})
+			return
+		}
 	}
+
+	// Update fields
+	updates := make(map[string]interface{})
+	updates["rumah_blok"] = updated.RumahBlok
+	updates["rumah_nomor"] = updated.RumahNomor
+	updates["rumah_gang"] = updated.RumahGang
+	updates["rumah_rt"] = updated.RumahRT
+	updates["rumah_rw"] = updated.RumahRW
+	updates["rumah_latitude"] = updated.RumahLatitude
+	updates["rumah_longitude"] = updated.RumahLongitude
+	updates["rumah_alamat"] = formatAlamatRumah(updated)
 	if req.WargaID != 0 {
 		updates["warga_id"] = req.WargaID
 	}
 	updates["updated_at"] = time.Now()
 
-	if err := rc.db.Model(&rumah).Updates(updates).Error; err != nil {
+	if err := scopedDB(c, rc.db).Model(&rumah).Updates(updates).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Gagal mengupdate rumah",
 			"details": err.Error(),
@@ -258,7 +387,7 @@ func (rc *RumahController) UpdateRumah(c *gin.Context) {
 	}
 
 	// Reload dengan data terbaru
-	if err := rc.db.Preload("Warga").First(&rumah, id).Error; err != nil {
+	if err := scopedDB(c, rc.db).Preload("Warga").Preload("HunianAktif", preloadHunianAktif).First(&rumah, id).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Gagal memuat data rumah yang diupdate",
 		})
@@ -271,12 +400,12 @@ func (rc *RumahController) UpdateRumah(c *gin.Context) {
 	})
 }
 
-// ✅ DELETE - Menghapus rumah
+// ✅ DELETE - Memindahkan rumah ke trash (soft delete)
 func (rc *RumahController) DeleteRumah(c *gin.Context) {
 	id := c.Param("id")
 
 	var rumah models.Rumah
-	if err := rc.db.First(&rumah, id).Error; err != nil {
+	if err := scopedDB(c, rc.db).First(&rumah, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Rumah tidak ditemukan",
@@ -289,7 +418,19 @@ func (rc *RumahController) DeleteRumah(c *gin.Context) {
 		return
 	}
 
-	if err := rc.db.Delete(&rumah).Error; err != nil {
+	// Rumah yang masih ditempati tidak boleh dihapus
+	var hunianAktif int64
+	scopedDB(c, rc.db).Model(&models.HunianRumah{}).
+		Where("rumah_id = ? AND (hunian_tanggal_selesai IS NULL OR hunian_tanggal_selesai > ?)", rumah.RumahID, time.Now()).
+		Count(&hunianAktif)
+	if hunianAktif > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tidak dapat menghapus rumah yang masih ditempati",
+		})
+		return
+	}
+
+	if err := scopedDB(c, rc.db).Delete(&rumah).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Gagal menghapus rumah",
 			"details": err.Error(),
@@ -314,7 +455,7 @@ func (rc *RumahController) GetRumahByStatus(c *gin.Context) {
 	}
 
 	var rumah []models.Rumah
-	if err := rc.db.Preload("Warga").Where("rumah_status = ?", status).Find(&rumah).Error; err != nil {
+	if err := scopedDB(c, rc.db).Preload("Warga").Preload("HunianAktif", preloadHunianAktif).Where("rumah_status = ?", status).Find(&rumah).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Gagal mengambil data rumah",
 		})
@@ -327,6 +468,180 @@ func (rc *RumahController) GetRumahByStatus(c *gin.Context) {
 	})
 }
 
+// ✅ GET - Mendapatkan rumah yang ada di trash
+func (rc *RumahController) GetTrashRumah(c *gin.Context) {
+	var rumah []models.Rumah
+
+	if err := scopedDB(c, rc.db).Unscoped().
+		Preload("Warga", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Find(&rumah).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data trash rumah",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  rumah,
+		"total": len(rumah),
+	})
+}
+
+// ✅ PUT - Mengembalikan rumah dari trash
+func (rc *RumahController) RestoreRumah(c *gin.Context) {
+	id := c.Param("id")
+
+	var rumah models.Rumah
+	if err := scopedDB(c, rc.db).Unscoped().Where("deleted_at IS NOT NULL").First(&rumah, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Rumah tidak ditemukan di trash",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan rumah",
+			})
+		}
+		return
+	}
+
+	// Blok + nomor tidak boleh dipakai rumah aktif lain
+	var conflict int64
+	scopedDB(c, rc.db).Model(&models.Rumah{}).
+		Where("rumah_blok = ? AND rumah_nomor = ? AND rumah_id != ?", rumah.RumahBlok, rumah.RumahNomor, rumah.RumahID).
+		Count(&conflict)
+	if conflict > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Blok dan nomor rumah sudah dipakai rumah lain",
+		})
+		return
+	}
+
+	// Warga penghuni harus masih ada (tidak di trash)
+	if rumah.WargaID != 0 {
+		var warga models.Warga
+		if err := scopedDB(c, rc.db).First(&warga, rumah.WargaID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Warga penghuni rumah sudah dihapus, pulihkan warga terlebih dahulu",
+			})
+			return
+		}
+	}
+
+	if err := scopedDB(c, rc.db).Unscoped().Model(&rumah).Update("deleted_at", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal memulihkan rumah",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Rumah berhasil dipulihkan",
+		"data":    rumah,
+	})
+}
+
+// ✅ GET - Mendapatkan rumah dikelompokkan per blok beserta jumlah hunian
+func (rc *RumahController) GetRumahByBlok(c *gin.Context) {
+	var rumah []models.Rumah
+
+	query := scopedDB(c, rc.db).Preload("Warga").Preload("HunianAktif", preloadHunianAktif)
+	if blok := c.Query("blok"); blok != "" {
+		query = query.Where("rumah_blok = ?", normalizeBlok(blok))
+	}
+
+	if err := orderByAlamat(query).Find(&rumah).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data rumah",
+		})
+		return
+	}
+
+	// Data sudah terurut per blok, cukup dikelompokkan berurutan
+	var result []BlokRumahSummary
+	for _, r := range rumah {
+		if len(result) == 0 || result[len(result)-1].RumahBlok != r.RumahBlok {
+			result = append(result, BlokRumahSummary{RumahBlok: r.RumahBlok, Rumah: []models.Rumah{}})
+		}
+		group := &result[len(result)-1]
+		group.TotalRumah++
+		if r.RumahStatus == "ditempati" {
+			group.TotalDitempati++
+		} else {
+			group.TotalTersedia++
+		}
+		group.Rumah = append(group.Rumah, r)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        result,
+		"total_blok":  len(result),
+		"total_rumah": len(rumah),
+	})
+}
+
+// ✅ Helper urutan rumah per blok lalu nomor (nomor numerik diurutkan natural)
+func orderByAlamat(db *gorm.DB) *gorm.DB {
+	return db.Order("rumah_blok ASC").Order("LENGTH(rumah_nomor) ASC").Order("rumah_nomor ASC")
+}
+
+// ✅ Helper format alamat rumah untuk tampilan, mis. "Blok A No. 12, Gg. Mawar, RT 001/RW 002"
+func formatAlamatRumah(r models.Rumah) string {
+	parts := []string{fmt.Sprintf("Blok %s No. %s", r.RumahBlok, r.RumahNomor)}
+	if r.RumahGang != "" {
+		parts = append(parts, r.RumahGang)
+	}
+	switch {
+	case r.RumahRT != "" && r.RumahRW != "":
+		parts = append(parts, fmt.Sprintf("RT %s/RW %s", r.RumahRT, r.RumahRW))
+	case r.RumahRT != "":
+		parts = append(parts, "RT "+r.RumahRT)
+	case r.RumahRW != "":
+		parts = append(parts, "RW "+r.RumahRW)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ✅ Helper normalisasi blok, mis. " a " menjadi "A"
+func normalizeBlok(blok string) string {
+	return strings.ToUpper(strings.TrimSpace(blok))
+}
+
+// ✅ Helper normalisasi RT/RW menjadi 3 digit, mis. "1" menjadi "001"
+func normalizeRTRW(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", true
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 || n > 999 {
+		return "", false
+	}
+	return fmt.Sprintf("%03d", n), true
+}
+
+// ✅ Helper validasi koordinat GPS, latitude dan longitude harus diisi berpasangan
+func validateKoordinat(lat, lng *float64) string {
+	if (lat == nil) != (lng == nil) {
+		return "Latitude dan longitude harus diisi bersamaan"
+	}
+	if lat != nil && (*lat < -90 || *lat > 90) {
+		return "Latitude harus di antara -90 dan 90"
+	}
+	if lng != nil && (*lng < -180 || *lng > 180) {
+		return "Longitude harus di antara -180 dan 180"
+	}
+	return ""
+}
+
+// ✅ Helper preload hunian aktif beserta keluarga penghuninya
+func preloadHunianAktif(db *gorm.DB) *gorm.DB {
+	return db.Scopes(hunianAktif(time.Now())).Preload("Keluarga")
+}
+
 // ✅ Helper function untuk validasi status rumah
 func isValidRumahStatus(status string) bool {
 	return status == "tersedia" || status == "ditempati"
@@ -61,7 +61,7 @@ func (tic *TagihanIuranController) CreateTagihanIuran(c *gin.Context) {
 
 	// Check if tagihan iuran dengan nama yang sama sudah ada
 	var existingTagihan models.TagihanIuran
-	if err := tic.db.Where("tagihan_iuran = ?", req.TagihanIuran).First(&existingTagihan).Error; err == nil {
+	if err := scopedDB(c, tic.db).Where("tagihan_iuran = ?", req.TagihanIuran).First(&existingTagihan).Error; err == nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Tagihan iuran dengan nama tersebut sudah ada",
 		})
@@ -75,7 +75,7 @@ func (tic *TagihanIuranController) CreateTagihanIuran(c *gin.Context) {
 		UpdatedAt:    time.Now(),
 	}
 
-	if err := tic.db.Create(&tagihan).Error; err != nil {
+	if err := scopedDB(c, tic.db).Create(&tagihan).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Gagal membuat tagihan iuran",
 			"details": err.Error(),
@@ -102,7 +102,7 @@ func (tic *TagihanIuranController) GetAllTagihanIuran(c *gin.Context) {
 	search := strings.TrimSpace(c.Query("search"))
 
 	// Build query dengan GORM (AMAN - parameterized queries)
-	query := tic.db.Model(&models.TagihanIuran{})
+	query := scopedDB(c, tic.db).Model(&models.TagihanIuran{})
 
 	// Apply search filter jika ada
 	if search != "" {
@@ -148,7 +148,7 @@ func (tic *TagihanIuranController) GetTagihanIuranByID(c *gin.Context) {
 	}
 
 	var tagihan models.TagihanIuran
-	if err := tic.db.First(&tagihan, tagihanID).Error; err != nil {
+	if err := scopedDB(c, tic.db).First(&tagihan, tagihanID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Tagihan iuran tidak ditemukan",
@@ -180,7 +180,7 @@ func (tic *TagihanIuranController) UpdateTagihanIuran(c *gin.Context) {
 	}
 
 	var tagihan models.TagihanIuran
-	if err := tic.db.First(&tagihan, tagihanID).Error; err != nil {
+	if err := scopedDB(c, tic.db).First(&tagihan, tagihanID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Tagihan iuran tidak ditemukan",
@@ -223,7 +223,7 @@ func (tic *TagihanIuranController) UpdateTagihanIuran(c *gin.Context) {
 
 	// Check if tagihan iuran dengan nama yang sama sudah ada (exclude current)
 	var existingTagihan models.TagihanIuran
-	if err := tic.db.Where("tagihan_iuran = ? AND id != ?", req.TagihanIuran, tagihanID).First(&existingTagihan).Error; err == nil {
+	if err := scopedDB(c, tic.db).Where("tagihan_iuran = ? AND id != ?", req.TagihanIuran, tagihanID).First(&existingTagihan).Error; err == nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Tagihan iuran dengan nama tersebut sudah ada",
 		})
@@ -234,7 +234,7 @@ func (tic *TagihanIuranController) UpdateTagihanIuran(c *gin.Context) {
 	tagihan.TagihanIuran = req.TagihanIuran
 	tagihan.UpdatedAt = time.Now()
 
-	if err := tic.db.Save(&tagihan).Error; err != nil {
+	if err := scopedDB(c, tic.db).Save(&tagihan).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Gagal mengupdate tagihan iuran",
 			"details": err.Error(),
@@ -262,7 +262,7 @@ func (tic *TagihanIuranController) DeleteTagihanIuran(c *gin.Context) {
 	}
 
 	var tagihan models.TagihanIuran
-	if err := tic.db.First(&tagihan, tagihanID).Error; err != nil {
+	if err := scopedDB(c, tic.db).First(&tagihan, tagihanID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Tagihan iuran tidak ditemukan",
@@ -276,7 +276,7 @@ func (tic *TagihanIuranController) DeleteTagihanIuran(c *gin.Context) {
 	}
 
 	// Delete menggunakan GORM Delete (AMAN)
-	if err := tic.db.Delete(&tagihan).Error; err != nil {
+	if err := scopedDB(c, tic.db).Delete(&tagihan).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Gagal menghapus tagihan iuran",
 			"details": err.Error(),
@@ -297,7 +297,7 @@ func (tic *TagihanIuranController) GetTagihanIuranDropdown(c *gin.Context) {
 	}
 
 	// Query aman - hanya mengambil field yang diperlukan
-	if err := tic.db.
+	if err := scopedDB(c, tic.db).
 		Model(&models.TagihanIuran{}).
 		Select("id, tagihan_iuran").
 		Order("tagihan_iuran ASC").
@@ -75,7 +75,7 @@ func (uc *UserController) GetAllUsers(c *gin.Context) {
 	var users []models.User
 
 	// ✅ SAFE: GORM menggunakan parameterized queries internally
-	if err := uc.db.Preload("Level").Find(&users).Error; err != nil {
+	if err := scopedDB(c, uc.db).Preload("Level").Find(&users).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch users",
 		})
@@ -107,7 +107,7 @@ func (uc *UserController) GetUserByID(c *gin.Context) {
 
 	var user models.User
 	// ✅ SAFE: GORM menggunakan prepared statements
-	if err := uc.db.Preload("Level").First(&user, userID).Error; err != nil {
+	if err := scopedDB(c, uc.db).Preload("Level").First(&user, userID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "User not found",
@@ -176,7 +176,7 @@ func (uc *UserController) CreateUser(c *gin.Context) {
 
 	// ✅ Check if level exists - SAFE: parameterized query
 	var level models.Level
-	if err := uc.db.First(&level, uint(levelID)).Error; err != nil {
+	if err := scopedDB(c, uc.db).First(&level, uint(levelID)).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": "Level not found",
@@ -189,6 +189,24 @@ func (uc *UserController) CreateUser(c *gin.Context) {
 		return
 	}
 
+	// ✅ Wilayah user (RT atau RW), divalidasi terhadap wilayah admin yang membuat
+	rtID, rwID, wilayahErr := parseWilayahUser(c, uc.db, c.PostForm("rt_id"), c.PostForm("rw_id"))
+	if wilayahErr != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": wilayahErr,
+		})
+		return
+	}
+
+	// ✅ Tautan ke data warga (opsional)
+	wargaID, wargaErr := parseWargaUser(c, uc.db, c.PostForm("warga_id"), 0)
+	if wargaErr != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": wargaErr,
+		})
+		return
+	}
+
 	// ✅ Check if username already exists - SAFE: parameterized query
 	var existingUser models.User
 	if err := uc.db.Where("username = ?", username).First(&existingUser).Error; err == nil {
@@ -227,10 +245,13 @@ func (uc *UserController) CreateUser(c *gin.Context) {
 		Password:    string(hashedPassword),
 		LevelID:     uint(levelID),
 		FotoProfile: fotoProfileFilename,
+		RTID:        rtID,
+		RWID:        rwID,
+		WargaID:     wargaID,
 	}
 
 	// ✅ SAFE: GORM create dengan parameterized queries
-	if err := uc.db.Create(&user).Error; err != nil {
+	if err := scopedDB(c, uc.db).Create(&user).Error; err != nil {
 		// Jika gagal create, hapus file yang sudah diupload
 		if fotoProfileFilename != "" {
 			helper.DeleteOldPhoto(fotoProfileFilename, "profile_foto")
@@ -242,7 +263,7 @@ func (uc *UserController) CreateUser(c *gin.Context) {
 	}
 
 	// ✅ Reload user dengan data terbaru
-	if err := uc.db.Preload("Level").First(&user, user.UserID).Error; err != nil {
+	if err := scopedDB(c, uc.db).Preload("Level").First(&user, user.UserID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to load created user",
 		})
@@ -258,6 +279,33 @@ func (uc *UserController) CreateUser(c *gin.Context) {
 	})
 }
 
+// parseWargaUser memvalidasi warga_id yang ditautkan ke akun: warga harus ada di wilayah
+// user yang login dan belum ditautkan ke akun lain (kecuali akun exceptUserID sendiri).
+func parseWargaUser(c *gin.Context, db *gorm.DB, wargaIDStr string, exceptUserID uint) (*uint, string) {
+	wargaIDStr = strings.TrimSpace(wargaIDStr)
+	if wargaIDStr == "" || wargaIDStr == "0" {
+		return nil, ""
+	}
+
+	id, err := strconv.ParseUint(wargaIDStr, 10, 32)
+	if err != nil {
+		return nil, "Warga ID tidak valid"
+	}
+
+	var warga models.Warga
+	if err := scopedDB(c, db).First(&warga, id).Error; err != nil {
+		return nil, "Warga tidak ditemukan"
+	}
+
+	var count int64
+	db.Model(&models.User{}).Where("warga_id = ? AND user_id <> ?", warga.WargaID, exceptUserID).Count(&count)
+	if count > 0 {
+		return nil, "Warga sudah ditautkan ke akun lain"
+	}
+
+	return &warga.WargaID, ""
+}
+
 // UpdateUser updates user data dengan security checks
 func (uc *UserController) UpdateUser(c *gin.Context) {
 	userID := c.Param("id")
@@ -272,7 +320,7 @@ func (uc *UserController) UpdateUser(c *gin.Context) {
 
 	var user models.User
 	// ✅ SAFE: GORM First dengan parameterized query
-	if err := uc.db.First(&user, userID).Error; err != nil {
+	if err := scopedDB(c, uc.db).First(&user, userID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "User not found",
@@ -305,7 +353,7 @@ func (uc *UserController) UpdateUser(c *gin.Context) {
 
 		var level models.Level
 		// ✅ SAFE: parameterized query
-		if err := uc.db.First(&level, uint(levelID)).Error; err != nil {
+		if err := scopedDB(c, uc.db).First(&level, uint(levelID)).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				c.JSON(http.StatusBadRequest, gin.H{
 					"error": "Level not found",
@@ -359,6 +407,33 @@ func (uc *UserController) UpdateUser(c *gin.Context) {
 		updates["password"] = string(hashedPassword)
 	}
 
+	// ✅ Update wilayah user jika dikirim (RT dan RW saling menggantikan)
+	_, hasRT := c.GetPostForm("rt_id")
+	_, hasRW := c.GetPostForm("rw_id")
+	if hasRT || hasRW {
+		rtID, rwID, wilayahErr := parseWilayahUser(c, uc.db, c.PostForm("rt_id"), c.PostForm("rw_id"))
+		if wilayahErr != "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": wilayahErr,
+			})
+			return
+		}
+		updates["rt_id"] = rtID
+		updates["rw_id"] = rwID
+	}
+
+	// ✅ Update tautan data warga jika dikirim (0 atau kosong = lepas tautan)
+	if wargaIDStr, hasWarga := c.GetPostForm("warga_id"); hasWarga {
+		wargaID, wargaErr := parseWargaUser(c, uc.db, wargaIDStr, user.UserID)
+		if wargaErr != "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": wargaErr,
+			})
+			return
+		}
+		updates["warga_id"] = wargaID
+	}
+
 	// Handle file upload untuk foto_profile
 	fotoProfileFilename := user.FotoProfile // Simpan filename lama dulu
 	if _, header, err := c.Request.FormFile("foto_profile"); err == nil && header != nil {
@@ -379,7 +454,7 @@ func (uc *UserController) UpdateUser(c *gin.Context) {
 
 	// ✅ SAFE: GORM Updates dengan parameterized queries
 	if len(updates) > 0 {
-		if err := uc.db.Model(&user).Updates(updates).Error; err != nil {
+		if err := scopedDB(c, uc.db).Model(&user).Updates(updates).Error; err != nil {
 			// Jika gagal update, hapus file baru yang sudah diupload
 			if fotoProfileFilename != user.FotoProfile {
 				helper.DeleteOldPhoto(fotoProfileFilename, "profile_foto")
@@ -392,7 +467,7 @@ func (uc *UserController) UpdateUser(c *gin.Context) {
 	}
 
 	// ✅ Reload user dengan data terbaru
-	if err := uc.db.Preload("Level").First(&user, user.UserID).Error; err != nil {
+	if err := scopedDB(c, uc.db).Preload("Level").First(&user, user.UserID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to load updated user",
 		})
@@ -422,7 +497,7 @@ func (uc *UserController) DeleteUser(c *gin.Context) {
 
 	var user models.User
 	// ✅ SAFE: GORM First dengan parameterized query
-	if err := uc.db.First(&user, userID).Error; err != nil {
+	if err := scopedDB(c, uc.db).First(&user, userID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "User not found",
@@ -445,7 +520,7 @@ func (uc *UserController) DeleteUser(c *gin.Context) {
 	}
 
 	// ✅ SAFE: GORM Delete dengan parameterized query
-	if err := uc.db.Delete(&user).Error; err != nil {
+	if err := scopedDB(c, uc.db).Delete(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to delete user",
 		})
@@ -474,7 +549,8 @@ func (uc *UserController) GetUserProfile(c *gin.Context) {
 	}
 
 	var user models.User
-	// ✅ SAFE: parameterized query
+	// ✅ SAFE: parameterized query. Baris milik user sendiri tidak difilter wilayah,
+	// karena user tingkat RW dan admin global tidak memiliki rt_id
 	if err := uc.db.Preload("Level").First(&user, userID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "User not found",
@@ -495,7 +571,7 @@ func (uc *UserController) GetTotalUser(c *gin.Context) {
 	var total int64
 
 	// ✅ SAFE: Parameterized query
-	if err := uc.db.Model(&models.User{}).Count(&total).Error; err != nil {
+	if err := scopedDB(c, uc.db).Model(&models.User{}).Count(&total).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch total users",
 		})
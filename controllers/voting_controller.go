@@ -0,0 +1,1190 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"rt-management/models"
+	"rt-management/realtime"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type VotingController struct {
+	db  *gorm.DB
+	hub *realtime.Hub
+}
+
+func NewVotingController(db *gorm.DB, hub *realtime.Hub) *VotingController {
+	return &VotingController{db: db, hub: hub}
+}
+
+// Usia minimal hak pilih pada voting per warga dewasa
+const usiaPemilihVoting = 17
+
+var (
+	errSudahMemilih    = errors.New("sudah memilih")
+	errVotingTidakBuka = errors.New("voting tidak sedang dibuka")
+)
+
+// Request structs
+type CreateVotingRequest struct {
+	VotingJudul       string   `form:"voting_judul" binding:"required"`
+	VotingDeskripsi   string   `form:"voting_deskripsi"`
+	VotingHakPilih    string   `form:"voting_hak_pilih"` // keluarga (default) atau warga_dewasa
+	VotingAnonim      bool     `form:"voting_anonim"`
+	VotingTampilHasil string   `form:"voting_tampil_hasil"` // langsung atau setelah_tutup (default)
+	VotingDibukaPada  string   `form:"voting_dibuka_pada"`  // kosong berarti dibuka sekarang
+	VotingDitutupPada string   `form:"voting_ditutup_pada" binding:"required"`
+	Pilihan           []string `form:"pilihan" binding:"required"` // dikirim berulang, urutan sesuai kiriman
+	BroadcastID       *uint    `form:"broadcast_id"`
+	KegiatanID        *uint    `form:"kegiatan_id"`
+}
+
+type UpdateVotingRequest struct {
+	VotingJudul       *string  `form:"voting_judul"`
+	VotingDeskripsi   *string  `form:"voting_deskripsi"`
+	VotingHakPilih    *string  `form:"voting_hak_pilih"`
+	VotingAnonim      *bool    `form:"voting_anonim"`
+	VotingTampilHasil *string  `form:"voting_tampil_hasil"`
+	VotingDibukaPada  *string  `form:"voting_dibuka_pada"`
+	VotingDitutupPada *string  `form:"voting_ditutup_pada"`
+	Pilihan           []string `form:"pilihan"`      // mengganti seluruh pilihan
+	BroadcastID       *uint    `form:"broadcast_id"` // 0 untuk melepas tautan
+	KegiatanID        *uint    `form:"kegiatan_id"`  // 0 untuk melepas tautan
+}
+
+type PilihVotingRequest struct {
+	VotingPilihanID uint `form:"voting_pilihan_id" binding:"required"`
+}
+
+// Voting beserta status dan hak pilih user yang login
+type VotingDetail struct {
+	models.Voting
+	Status           string `json:"status"` // belum_dibuka, dibuka, ditutup
+	HasilTampil      bool   `json:"hasil_tampil"`
+	SudahMemilih     bool   `json:"sudah_memilih"`
+	BolehMemilih     bool   `json:"boleh_memilih"`
+	AlasanTidakBoleh string `json:"alasan_tidak_boleh,omitempty"`
+}
+
+type HasilPilihanVoting struct {
+	VotingPilihanID uint    `json:"voting_pilihan_id"`
+	PilihanTeks     string  `json:"pilihan_teks"`
+	JumlahSuara     int64   `json:"jumlah_suara"`
+	Persentase      float64 `json:"persentase"`
+}
+
+type HasilVerifikasiVoting struct {
+	Valid           bool   `json:"valid"`
+	JumlahSuara     int    `json:"jumlah_suara"`
+	JumlahPemilih   int64  `json:"jumlah_pemilih"`
+	HashAkhir       string `json:"hash_akhir"`
+	RusakPadaUrutan int    `json:"rusak_pada_urutan,omitempty"`
+	Masalah         string `json:"masalah,omitempty"`
+}
+
+// Surat suara untuk audit publik, nama pemilih hanya terisi pada voting terbuka (tidak anonim)
+type SuaraVotingPublik struct {
+	models.SuaraVoting
+	PemilihNama string `json:"pemilih_nama,omitempty"`
+}
+
+// ✅ GET - Daftar voting
+func (vc *VotingController) GetAllVoting(c *gin.Context) {
+	now := time.Now()
+	query := scopedDB(c, vc.db).Model(&models.Voting{})
+
+	switch c.Query("status") {
+	case "":
+	case "belum_dibuka":
+		query = query.Where("voting_dibuka_pada > ?", now)
+	case "dibuka":
+		query = query.Where("voting_dibuka_pada <= ? AND voting_ditutup_pada > ?", now, now)
+	case "ditutup":
+		query = query.Where("voting_ditutup_pada <= ?", now)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Status harus belum_dibuka, dibuka, atau ditutup",
+		})
+		return
+	}
+	if broadcastID := c.Query("broadcast_id"); broadcastID != "" {
+		query = query.Where("broadcast_id = ?", broadcastID)
+	}
+	if kegiatanID := c.Query("kegiatan_id"); kegiatanID != "" {
+		query = query.Where("kegiatan_id = ?", kegiatanID)
+	}
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		query = query.Where("voting_judul LIKE ?", "%"+search+"%")
+	}
+
+	var voting []models.Voting
+	if err := query.Preload("Pilihan", urutanPilihanVoting).
+		Order("voting_dibuka_pada DESC").
+		Find(&voting).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data voting",
+		})
+		return
+	}
+
+	// Tanda sudah memilih dicek sekaligus untuk semua voting dari kunci keluarga dan warga user
+	sudahMemilih := make(map[uint]map[string]bool)
+	if warga, ok := vc.wargaUser(c); ok && len(voting) > 0 {
+		votingIDs := make([]uint, 0, len(voting))
+		for _, v := range voting {
+			votingIDs = append(votingIDs, v.VotingID)
+		}
+		var pemilih []models.PemilihVoting
+		vc.db.Where("voting_id IN ? AND pemilih_kunci IN ?", votingIDs, []string{
+			kunciPemilihVoting("keluarga", warga),
+			kunciPemilihVoting("warga_dewasa", warga),
+		}).Find(&pemilih)
+		for _, p := range pemilih {
+			if sudahMemilih[p.VotingID] == nil {
+				sudahMemilih[p.VotingID] = make(map[string]bool)
+			}
+			sudahMemilih[p.VotingID][p.PemilihKunci] = true
+		}
+
+		data := make([]VotingDetail, 0, len(voting))
+		for _, v := range voting {
+			detail := detailVoting(v, now)
+			detail.SudahMemilih = sudahMemilih[v.VotingID][kunciPemilihVoting(v.VotingHakPilih, warga)]
+			data = append(data, detail)
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"data":  data,
+			"total": len(data),
+		})
+		return
+	}
+
+	data := make([]VotingDetail, 0, len(voting))
+	for _, v := range voting {
+		data = append(data, detailVoting(v, now))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":  data,
+		"total": len(data),
+	})
+}
+
+// ✅ GET - Detail voting beserta hak pilih user dan hasil (jika boleh ditampilkan)
+func (vc *VotingController) GetVotingByID(c *gin.Context) {
+	voting, ok := vc.findVoting(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	detail := detailVoting(voting, now)
+
+	kunci, _, alasan := vc.pemilihVoting(c, voting, now)
+	if kunci != "" {
+		var count int64
+		vc.db.Model(&models.PemilihVoting{}).
+			Where("voting_id = ? AND pemilih_kunci = ?", voting.VotingID, kunci).
+			Count(&count)
+		detail.SudahMemilih = count > 0
+	}
+	switch {
+	case alasan != "":
+		detail.AlasanTidakBoleh = alasan
+	case detail.SudahMemilih:
+		detail.AlasanTidakBoleh = "Hak pilih sudah digunakan"
+	case detail.Status != "dibuka":
+		detail.AlasanTidakBoleh = "Voting tidak sedang dibuka"
+	default:
+		detail.BolehMemilih = true
+	}
+
+	response := gin.H{
+		"data": detail,
+	}
+	if detail.HasilTampil {
+		hasil, err := vc.hitungHasil(voting)
+		if err == nil {
+			response["hasil"] = hasil
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ✅ POST - Membuat voting baru
+func (vc *VotingController) CreateVoting(c *gin.Context) {
+	var req CreateVotingRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	now := time.Now()
+	dibukaPada, err := parseWaktuBroadcast(req.VotingDibukaPada)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Format voting_dibuka_pada harus YYYY-MM-DD HH:MM",
+		})
+		return
+	}
+	if dibukaPada == nil {
+		dibukaPada = &now
+	}
+	ditutupPada, err := parseWaktuBroadcast(req.VotingDitutupPada)
+	if err != nil || ditutupPada == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Format voting_ditutup_pada harus YYYY-MM-DD HH:MM",
+		})
+		return
+	}
+	if !ditutupPada.After(now) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Waktu tutup voting harus di masa depan",
+		})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	voting := models.Voting{
+		VotingJudul:       strings.TrimSpace(req.VotingJudul),
+		VotingDeskripsi:   strings.TrimSpace(req.VotingDeskripsi),
+		VotingHakPilih:    req.VotingHakPilih,
+		VotingAnonim:      req.VotingAnonim,
+		VotingTampilHasil: req.VotingTampilHasil,
+		VotingDibukaPada:  *dibukaPada,
+		VotingDitutupPada: *ditutupPada,
+		BroadcastID:       req.BroadcastID,
+		KegiatanID:        req.KegiatanID,
+		DibuatOlehID:      userID.(uint),
+	}
+	if voting.VotingHakPilih == "" {
+		voting.VotingHakPilih = "keluarga"
+	}
+	if voting.VotingTampilHasil == "" {
+		voting.VotingTampilHasil = "setelah_tutup"
+	}
+
+	pilihan, ok := normalizePilihanVoting(c, req.Pilihan)
+	if !ok {
+		return
+	}
+	if !vc.validateVoting(c, &voting) {
+		return
+	}
+
+	err = scopedDB(c, vc.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&voting).Error; err != nil {
+			return err
+		}
+		return simpanPilihanVoting(tx, &voting, pilihan)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal membuat voting",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Voting berhasil dibuat",
+		"data":    detailVoting(voting, now),
+	})
+}
+
+// ✅ PUT - Mengupdate voting. Setelah voting dibuka, aturan voting dan pilihan tidak dapat diubah.
+func (vc *VotingController) UpdateVoting(c *gin.Context) {
+	voting, ok := vc.findVoting(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateVotingRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	now := time.Now()
+	status := statusVoting(voting, now)
+	terkunci := status != "belum_dibuka" || voting.VotingJumlahSuara > 0
+	ubahAturan := req.VotingHakPilih != nil || req.VotingAnonim != nil || req.VotingTampilHasil != nil ||
+		req.VotingDibukaPada != nil || len(req.Pilihan) > 0
+	if terkunci && ubahAturan {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Hak pilih, kerahasiaan, tampilan hasil, waktu buka, dan pilihan tidak dapat diubah setelah voting dibuka",
+		})
+		return
+	}
+	if status == "ditutup" && req.VotingDitutupPada != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Voting yang sudah ditutup tidak dapat dibuka kembali",
+		})
+		return
+	}
+
+	if req.VotingJudul != nil {
+		voting.VotingJudul = strings.TrimSpace(*req.VotingJudul)
+	}
+	if req.VotingDeskripsi != nil {
+		voting.VotingDeskripsi = strings.TrimSpace(*req.VotingDeskripsi)
+	}
+	if req.VotingHakPilih != nil {
+		voting.VotingHakPilih = *req.VotingHakPilih
+	}
+	if req.VotingAnonim != nil {
+		voting.VotingAnonim = *req.VotingAnonim
+	}
+	if req.VotingTampilHasil != nil {
+		voting.VotingTampilHasil = *req.VotingTampilHasil
+	}
+	if req.VotingDibukaPada != nil {
+		dibukaPada, err := parseWaktuBroadcast(*req.VotingDibukaPada)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Format voting_dibuka_pada harus YYYY-MM-DD HH:MM",
+			})
+			return
+		}
+		if dibukaPada == nil {
+			dibukaPada = &now
+		}
+		voting.VotingDibukaPada = *dibukaPada
+	}
+	if req.VotingDitutupPada != nil {
+		ditutupPada, err := parseWaktuBroadcast(*req.VotingDitutupPada)
+		if err != nil || ditutupPada == nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Format voting_ditutup_pada harus YYYY-MM-DD HH:MM",
+			})
+			return
+		}
+		if !ditutupPada.After(now) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Waktu tutup voting harus di masa depan",
+			})
+			return
+		}
+		voting.VotingDitutupPada = *ditutupPada
+	}
+	if req.BroadcastID != nil {
+		voting.BroadcastID = req.BroadcastID
+		if *req.BroadcastID == 0 {
+			voting.BroadcastID = nil
+		}
+	}
+	if req.KegiatanID != nil {
+		voting.KegiatanID = req.KegiatanID
+		if *req.KegiatanID == 0 {
+			voting.KegiatanID = nil
+		}
+	}
+
+	var pilihan []string
+	if len(req.Pilihan) > 0 {
+		if pilihan, ok = normalizePilihanVoting(c, req.Pilihan); !ok {
+			return
+		}
+	}
+	if !vc.validateVoting(c, &voting) {
+		return
+	}
+
+	err := scopedDB(c, vc.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&voting).Updates(map[string]interface{}{
+			"voting_judul":        voting.VotingJudul,
+			"voting_deskripsi":    voting.VotingDeskripsi,
+			"voting_hak_pilih":    voting.VotingHakPilih,
+			"voting_anonim":       voting.VotingAnonim,
+			"voting_tampil_hasil": voting.VotingTampilHasil,
+			"voting_dibuka_pada":  voting.VotingDibukaPada,
+			"voting_ditutup_pada": voting.VotingDitutupPada,
+			"broadcast_id":        voting.BroadcastID,
+			"kegiatan_id":         voting.KegiatanID,
+		}).Error; err != nil {
+			return err
+		}
+		if len(pilihan) == 0 {
+			return nil
+		}
+		if err := tx.Where("voting_id = ?", voting.VotingID).Delete(&models.VotingPilihan{}).Error; err != nil {
+			return err
+		}
+		return simpanPilihanVoting(tx, &voting, pilihan)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mengupdate voting",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := scopedDB(c, vc.db).Preload("Pilihan", urutanPilihanVoting).First(&voting, voting.VotingID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal memuat data voting yang diupdate",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Voting berhasil diupdate",
+		"data":    detailVoting(voting, now),
+	})
+}
+
+// ✅ DELETE - Menghapus voting yang belum memiliki suara
+func (vc *VotingController) DeleteVoting(c *gin.Context) {
+	voting, ok := vc.findVoting(c)
+	if !ok {
+		return
+	}
+
+	if voting.VotingJumlahSuara > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Voting yang sudah memiliki suara tidak dapat dihapus, tutup voting sebagai gantinya",
+		})
+		return
+	}
+
+	if err := scopedDB(c, vc.db).Delete(&voting).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menghapus voting",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Voting berhasil dihapus",
+	})
+}
+
+// ✅ PUT - Menutup voting sekarang sebelum waktu tutupnya
+func (vc *VotingController) TutupVoting(c *gin.Context) {
+	voting, ok := vc.findVoting(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	status := statusVoting(voting, now)
+	if status == "ditutup" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Voting sudah ditutup",
+		})
+		return
+	}
+
+	updates := map[string]interface{}{
+		"voting_ditutup_pada": now,
+	}
+	voting.VotingDitutupPada = now
+	if status == "belum_dibuka" {
+		updates["voting_dibuka_pada"] = now
+		voting.VotingDibukaPada = now
+	}
+
+	if err := scopedDB(c, vc.db).Model(&voting).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menutup voting",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	vc.publikasikanVoting("voting.ditutup", voting)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Voting berhasil ditutup",
+		"data":    detailVoting(voting, now),
+	})
+}
+
+// ✅ POST - Memberikan suara. Suara ditambahkan ke rantai hash voting dan pemilih mendapat tanda terima
+// berisi hash suaranya untuk memeriksa bahwa suaranya tercatat tanpa diubah.
+func (vc *VotingController) PilihVoting(c *gin.Context) {
+	voting, ok := vc.findVoting(c)
+	if !ok {
+		return
+	}
+
+	var req PilihVotingRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	pilihanValid := false
+	for _, p := range voting.Pilihan {
+		if p.VotingPilihanID == req.VotingPilihanID {
+			pilihanValid = true
+			break
+		}
+	}
+	if !pilihanValid {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Pilihan tidak termasuk dalam voting ini",
+		})
+		return
+	}
+
+	now := time.Now()
+	kunci, warga, alasan := vc.pemilihVoting(c, voting, now)
+	if alasan != "" {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": alasan,
+		})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	var suara models.SuaraVoting
+
+	// Voting dikunci (SELECT ... FOR UPDATE) agar urutan dan hash sebelumnya konsisten
+	// walaupun banyak warga memilih bersamaan
+	err := vc.db.Transaction(func(tx *gorm.DB) error {
+		var terkunci models.Voting
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&terkunci, voting.VotingID).Error; err != nil {
+			return err
+		}
+		if statusVoting(terkunci, time.Now()) != "dibuka" {
+			return errVotingTidakBuka
+		}
+
+		var count int64
+		tx.Model(&models.PemilihVoting{}).
+			Where("voting_id = ? AND pemilih_kunci = ?", voting.VotingID, kunci).
+			Count(&count)
+		if count > 0 {
+			return errSudahMemilih
+		}
+
+		idPemilih, err := acakHexVoting()
+		if err != nil {
+			return err
+		}
+		pemilih := models.PemilihVoting{
+			PemilihVotingID: idPemilih,
+			VotingID:        voting.VotingID,
+			PemilihKunci:    kunci,
+			UserID:          userID.(uint),
+			WargaID:         warga.WargaID,
+			KeluargaID:      warga.KeluargaID,
+			RTID:            voting.RTID,
+			CreatedAt:       now,
+		}
+		// Pada voting anonim waktu memilih hanya dicatat tanggalnya agar tidak bisa
+		// dicocokkan dengan waktu dicatatnya surat suara
+		if voting.VotingAnonim {
+			pemilih.CreatedAt = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		}
+		if err := tx.Create(&pemilih).Error; err != nil {
+			return err
+		}
+
+		nonce, err := acakHexVoting()
+		if err != nil {
+			return err
+		}
+		suara = models.SuaraVoting{
+			VotingID:        voting.VotingID,
+			Urutan:          terkunci.VotingJumlahSuara + 1,
+			VotingPilihanID: req.VotingPilihanID,
+			Nonce:           nonce,
+			DicatatPada:     now.Truncate(time.Second),
+			HashSebelum:     terkunci.VotingHashAkhir,
+			RTID:            voting.RTID,
+		}
+		if suara.HashSebelum == "" {
+			suara.HashSebelum = hashAwalVoting(voting.VotingID)
+		}
+		if !voting.VotingAnonim {
+			suara.PemilihKunci = kunci
+		}
+		suara.Hash = hashSuaraVoting(suara)
+		if err := tx.Create(&suara).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&terkunci).Updates(map[string]interface{}{
+			"voting_jumlah_suara": suara.Urutan,
+			"voting_hash_akhir":   suara.Hash,
+		}).Error
+	})
+	if err != nil {
+		switch err {
+		case errSudahMemilih:
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Hak pilih sudah digunakan",
+			})
+		case errVotingTidakBuka:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Voting tidak sedang dibuka",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Gagal mencatat suara",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	voting.VotingJumlahSuara = suara.Urutan
+	voting.VotingHashAkhir = suara.Hash
+	vc.publikasikanVoting("voting.suara", voting)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Suara berhasil dicatat. Simpan tanda terima untuk memeriksa suara Anda.",
+		"tanda_terima": gin.H{
+			"voting_id":    suara.VotingID,
+			"urutan":       suara.Urutan,
+			"hash":         suara.Hash,
+			"dicatat_pada": suara.DicatatPada,
+		},
+	})
+}
+
+// ✅ GET - Hasil voting, disembunyikan sampai voting ditutup jika diatur demikian
+func (vc *VotingController) GetHasilVoting(c *gin.Context) {
+	voting, ok := vc.findVoting(c)
+	if !ok {
+		return
+	}
+
+	detail := detailVoting(voting, time.Now())
+	if !detail.HasilTampil {
+		c.JSON(http.StatusOK, gin.H{
+			"data":         detail,
+			"hasil_tampil": false,
+			"message":      "Hasil voting ditampilkan setelah voting ditutup",
+		})
+		return
+	}
+
+	hasil, err := vc.hitungHasil(voting)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal menghitung hasil voting",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":         detail,
+		"hasil_tampil": true,
+		"hasil":        hasil,
+	})
+}
+
+// ✅ GET - Seluruh surat suara dalam rantai hash untuk diaudit ulang oleh warga
+func (vc *VotingController) GetSuaraVoting(c *gin.Context) {
+	voting, ok := vc.findVoting(c)
+	if !ok {
+		return
+	}
+
+	if !detailVoting(voting, time.Now()).HasilTampil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Surat suara dapat diaudit setelah hasil voting ditampilkan",
+		})
+		return
+	}
+
+	var suara []models.SuaraVoting
+	if err := vc.db.Preload("Pilihan").
+		Where("voting_id = ?", voting.VotingID).
+		Order("urutan ASC").
+		Find(&suara).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil surat suara",
+		})
+		return
+	}
+
+	namaPemilih := make(map[string]string)
+	if !voting.VotingAnonim {
+		namaPemilih = vc.namaPemilihVoting(voting)
+	}
+
+	data := make([]SuaraVotingPublik, 0, len(suara))
+	for _, s := range suara {
+		data = append(data, SuaraVotingPublik{SuaraVoting: s, PemilihNama: namaPemilih[s.PemilihKunci]})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       data,
+		"total":      len(data),
+		"hash_awal":  hashAwalVoting(voting.VotingID),
+		"hash_akhir": voting.VotingHashAkhir,
+	})
+}
+
+// ✅ GET - Memverifikasi keutuhan rantai suara. Query ?hash= memeriksa tanda terima pemilih.
+func (vc *VotingController) VerifikasiVoting(c *gin.Context) {
+	voting, ok := vc.findVoting(c)
+	if !ok {
+		return
+	}
+
+	var suara []models.SuaraVoting
+	if err := vc.db.Where("voting_id = ?", voting.VotingID).Order("urutan ASC").Find(&suara).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil surat suara",
+		})
+		return
+	}
+
+	var jumlahPemilih int64
+	vc.db.Model(&models.PemilihVoting{}).Where("voting_id = ?", voting.VotingID).Count(&jumlahPemilih)
+
+	hasil := verifikasiRantaiVoting(voting, suara, jumlahPemilih)
+	response := gin.H{
+		"data": hasil,
+	}
+
+	if hash := strings.TrimSpace(c.Query("hash")); hash != "" {
+		tandaTerima := gin.H{"hash": hash, "tercatat": false}
+		for _, s := range suara {
+			if s.Hash == hash {
+				tandaTerima["tercatat"] = true
+				tandaTerima["urutan"] = s.Urutan
+				break
+			}
+		}
+		response["tanda_terima"] = tandaTerima
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ✅ Helper mengambil voting dari parameter :id beserta pilihannya
+func (vc *VotingController) findVoting(c *gin.Context) (models.Voting, bool) {
+	var voting models.Voting
+
+	votingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID voting tidak valid",
+		})
+		return voting, false
+	}
+
+	if err := scopedDB(c, vc.db).Preload("Pilihan", urutanPilihanVoting).First(&voting, votingID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Voting tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan voting",
+			})
+		}
+		return voting, false
+	}
+
+	return voting, true
+}
+
+func (vc *VotingController) validateVoting(c *gin.Context, voting *models.Voting) bool {
+	if len(voting.VotingJudul) < 3 || len(voting.VotingJudul) > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Judul voting harus 3-200 karakter",
+		})
+		return false
+	}
+	if voting.VotingHakPilih != "keluarga" && voting.VotingHakPilih != "warga_dewasa" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Hak pilih harus keluarga atau warga_dewasa",
+		})
+		return false
+	}
+	if voting.VotingTampilHasil != "langsung" && voting.VotingTampilHasil != "setelah_tutup" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tampilan hasil harus langsung atau setelah_tutup",
+		})
+		return false
+	}
+	if !voting.VotingDitutupPada.After(voting.VotingDibukaPada) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Waktu tutup voting harus setelah waktu buka",
+		})
+		return false
+	}
+
+	if voting.BroadcastID != nil {
+		var count int64
+		scopedDB(c, vc.db).Model(&models.Broadcast{}).Where("broadcast_id = ?", *voting.BroadcastID).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Broadcast tidak ditemukan",
+			})
+			return false
+		}
+	}
+	if voting.KegiatanID != nil {
+		var count int64
+		scopedDB(c, vc.db).Model(&models.Kegiatan{}).Where("kegiatan_id = ?", *voting.KegiatanID).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Kegiatan tidak ditemukan",
+			})
+			return false
+		}
+	}
+	return true
+}
+
+// ✅ Helper mengambil data warga yang ditautkan ke akun user yang login
+func (vc *VotingController) wargaUser(c *gin.Context) (models.Warga, bool) {
+	var warga models.Warga
+
+	userID, _ := c.Get("userID")
+	var user models.User
+	if err := vc.db.Select("user_id", "warga_id").First(&user, userID).Error; err != nil || user.WargaID == nil {
+		return warga, false
+	}
+	if err := vc.db.Preload("Keluarga").First(&warga, *user.WargaID).Error; err != nil {
+		return warga, false
+	}
+	return warga, true
+}
+
+// pemilihVoting menentukan kunci pemilih user yang login. Alasan terisi jika user tidak berhak memilih.
+func (vc *VotingController) pemilihVoting(c *gin.Context, voting models.Voting, now time.Time) (string, models.Warga, string) {
+	warga, ok := vc.wargaUser(c)
+	if !ok {
+		return "", warga, "Akun belum ditautkan ke data warga"
+	}
+	if voting.RTID != nil && (warga.RTID == nil || *warga.RTID != *voting.RTID) {
+		return "", warga, "Warga tidak terdaftar di RT penyelenggara voting"
+	}
+	if warga.WargaStatusAktif != "aktif" || warga.WargaStatusHidup != "hidup" {
+		return "", warga, "Status warga tidak aktif"
+	}
+	if warga.Keluarga.KeluargaStatus != "aktif" {
+		return "", warga, "Keluarga tidak aktif"
+	}
+	if voting.VotingHakPilih == "warga_dewasa" && hitungUsia(warga.WargaTanggalLahir, now) < usiaPemilihVoting {
+		return "", warga, fmt.Sprintf("Hak pilih hanya untuk warga berusia %d tahun ke atas", usiaPemilihVoting)
+	}
+	return kunciPemilihVoting(voting.VotingHakPilih, warga), warga, ""
+}
+
+// hitungHasil menghitung suara per pilihan dan partisipasi pemilih
+func (vc *VotingController) hitungHasil(voting models.Voting) (gin.H, error) {
+	var jumlah []struct {
+		VotingPilihanID uint
+		Jumlah          int64
+	}
+	if err := vc.db.Model(&models.SuaraVoting{}).
+		Select("voting_pilihan_id, COUNT(*) AS jumlah").
+		Where("voting_id = ?", voting.VotingID).
+		Group("voting_pilihan_id").
+		Scan(&jumlah).Error; err != nil {
+		return nil, err
+	}
+
+	jumlahPilihan := make(map[uint]int64, len(jumlah))
+	var total int64
+	for _, j := range jumlah {
+		jumlahPilihan[j.VotingPilihanID] = j.Jumlah
+		total += j.Jumlah
+	}
+
+	hasil := make([]HasilPilihanVoting, 0, len(voting.Pilihan))
+	var tertinggi int64
+	for _, p := range voting.Pilihan {
+		n := jumlahPilihan[p.VotingPilihanID]
+		hasil = append(hasil, HasilPilihanVoting{
+			VotingPilihanID: p.VotingPilihanID,
+			PilihanTeks:     p.PilihanTeks,
+			JumlahSuara:     n,
+			Persentase:      persentase(n, total),
+		})
+		if n > tertinggi {
+			tertinggi = n
+		}
+	}
+
+	// Pemenang hanya diumumkan setelah voting ditutup, bisa lebih dari satu jika seri
+	var pemenang []HasilPilihanVoting
+	if statusVoting(voting, time.Now()) == "ditutup" && tertinggi > 0 {
+		for _, h := range hasil {
+			if h.JumlahSuara == tertinggi {
+				pemenang = append(pemenang, h)
+			}
+		}
+	}
+
+	berhak := vc.jumlahPemilihBerhak(voting)
+	return gin.H{
+		"pilihan":        hasil,
+		"total_suara":    total,
+		"pemilih_berhak": berhak,
+		"partisipasi":    persentase(total, berhak),
+		"pemenang":       pemenang,
+		"seri":           len(pemenang) > 1,
+	}, nil
+}
+
+// jumlahPemilihBerhak menghitung keluarga aktif atau warga dewasa aktif di RT penyelenggara
+func (vc *VotingController) jumlahPemilihBerhak(voting models.Voting) int64 {
+	var count int64
+	if voting.VotingHakPilih == "warga_dewasa" {
+		now := time.Now()
+		batasLahir := time.Date(now.Year()-usiaPemilihVoting, now.Month(), now.Day(), 23, 59, 59, 0, now.Location())
+		query := vc.db.Model(&models.Warga{}).
+			Joins("JOIN keluargas ON keluargas.keluarga_id = wargas.keluarga_id AND keluargas.deleted_at IS NULL").
+			Where("wargas.warga_status_aktif = ? AND wargas.warga_status_hidup = ?", "aktif", "hidup").
+			Where("keluargas.keluarga_status = ?", "aktif").
+			Where("wargas.warga_tanggal_lahir <= ?", batasLahir)
+		if voting.RTID != nil {
+			query = query.Where("wargas.rt_id = ?", *voting.RTID)
+		}
+		query.Count(&count)
+		return count
+	}
+
+	query := vc.db.Model(&models.Keluarga{}).Where("keluarga_status = ?", "aktif")
+	if voting.RTID != nil {
+		query = query.Where("rt_id = ?", *voting.RTID)
+	}
+	query.Count(&count)
+	return count
+}
+
+// namaPemilihVoting memetakan kunci pemilih ke nama keluarga/warga untuk voting terbuka
+func (vc *VotingController) namaPemilihVoting(voting models.Voting) map[string]string {
+	nama := make(map[string]string)
+
+	var pemilih []models.PemilihVoting
+	vc.db.Where("voting_id = ?", voting.VotingID).Find(&pemilih)
+	if len(pemilih) == 0 {
+		return nama
+	}
+
+	if voting.VotingHakPilih == "warga_dewasa" {
+		ids := make([]uint, 0, len(pemilih))
+		for _, p := range pemilih {
+			ids = append(ids, p.WargaID)
+		}
+		var warga []models.Warga
+		vc.db.Unscoped().Select("warga_id", "warga_nama").Where("warga_id IN ?", ids).Find(&warga)
+		for _, w := range warga {
+			nama[fmt.Sprintf("warga:%d", w.WargaID)] = w.WargaNama
+		}
+		return nama
+	}
+
+	ids := make([]uint, 0, len(pemilih))
+	for _, p := range pemilih {
+		ids = append(ids, p.KeluargaID)
+	}
+	var keluarga []models.Keluarga
+	vc.db.Unscoped().Select("keluarga_id", "keluarga_nama").Where("keluarga_id IN ?", ids).Find(&keluarga)
+	for _, k := range keluarga {
+		nama[fmt.Sprintf("keluarga:%d", k.KeluargaID)] = k.KeluargaNama
+	}
+	return nama
+}
+
+// publikasikanVoting mendorong perubahan jumlah suara ke warga RT, hasil ikut dikirim jika boleh tampil
+func (vc *VotingController) publikasikanVoting(jenis string, voting models.Voting) {
+	data := gin.H{
+		"voting_id":           voting.VotingID,
+		"voting_judul":        voting.VotingJudul,
+		"voting_jumlah_suara": voting.VotingJumlahSuara,
+		"status":              statusVoting(voting, time.Now()),
+	}
+	if detailVoting(voting, time.Now()).HasilTampil {
+		if hasil, err := vc.hitungHasil(voting); err == nil {
+			data["hasil"] = hasil
+		}
+	}
+
+	vc.hub.Publikasikan(realtime.Event{
+		Topik: realtime.TopikVoting,
+		Jenis: jenis,
+		Data:  data,
+		RTID:  voting.RTID,
+	})
+}
+
+func detailVoting(voting models.Voting, now time.Time) VotingDetail {
+	status := statusVoting(voting, now)
+	return VotingDetail{
+		Voting:      voting,
+		Status:      status,
+		HasilTampil: voting.VotingTampilHasil == "langsung" || status == "ditutup",
+	}
+}
+
+func statusVoting(voting models.Voting, now time.Time) string {
+	switch {
+	case now.Before(voting.VotingDibukaPada):
+		return "belum_dibuka"
+	case now.Before(voting.VotingDitutupPada):
+		return "dibuka"
+	default:
+		return "ditutup"
+	}
+}
+
+func kunciPemilihVoting(hakPilih string, warga models.Warga) string {
+	if hakPilih == "warga_dewasa" {
+		return fmt.Sprintf("warga:%d", warga.WargaID)
+	}
+	return fmt.Sprintf("keluarga:%d", warga.KeluargaID)
+}
+
+func urutanPilihanVoting(db *gorm.DB) *gorm.DB {
+	return db.Order("pilihan_urutan ASC")
+}
+
+// normalizePilihanVoting merapikan teks pilihan: 2-20 pilihan, tidak kosong dan tidak kembar
+func normalizePilihanVoting(c *gin.Context, pilihan []string) ([]string, bool) {
+	hasil := make([]string, 0, len(pilihan))
+	seen := make(map[string]bool, len(pilihan))
+	for _, p := range pilihan {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if len(p) > 200 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Teks pilihan maksimal 200 karakter",
+			})
+			return nil, false
+		}
+		if seen[strings.ToLower(p)] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Pilihan tidak boleh kembar: " + p,
+			})
+			return nil, false
+		}
+		seen[strings.ToLower(p)] = true
+		hasil = append(hasil, p)
+	}
+
+	if len(hasil) < 2 || len(hasil) > 20 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Voting harus memiliki 2-20 pilihan",
+		})
+		return nil, false
+	}
+	return hasil, true
+}
+
+func simpanPilihanVoting(tx *gorm.DB, voting *models.Voting, pilihan []string) error {
+	voting.Pilihan = make([]models.VotingPilihan, 0, len(pilihan))
+	for i, teks := range pilihan {
+		voting.Pilihan = append(voting.Pilihan, models.VotingPilihan{
+			VotingID:      voting.VotingID,
+			PilihanTeks:   teks,
+			PilihanUrutan: i + 1,
+			RTID:          voting.RTID,
+		})
+	}
+	return tx.Create(&voting.Pilihan).Error
+}
+
+// hashAwalVoting adalah hash "genesis" yang menjadi HashSebelum suara pertama
+func hashAwalVoting(votingID uint) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("voting:%d", votingID)))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashSuaraVoting menghitung hash satu suara dari isi suara dan hash suara sebelumnya
+func hashSuaraVoting(suara models.SuaraVoting) string {
+	isi := fmt.Sprintf("%s|%d|%d|%d|%s|%s|%s",
+		suara.HashSebelum,
+		suara.VotingID,
+		suara.Urutan,
+		suara.VotingPilihanID,
+		suara.PemilihKunci,
+		suara.Nonce,
+		suara.DicatatPada.UTC().Format(time.RFC3339),
+	)
+	sum := sha256.Sum256([]byte(isi))
+	return hex.EncodeToString(sum[:])
+}
+
+// acakHexVoting menghasilkan 16 byte acak dalam hex untuk nonce suara dan ID pemilih
+func acakHexVoting() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// verifikasiRantaiVoting menghitung ulang seluruh rantai hash dan mencocokkannya dengan data tersimpan
+func verifikasiRantaiVoting(voting models.Voting, suara []models.SuaraVoting, jumlahPemilih int64) HasilVerifikasiVoting {
+	hasil := HasilVerifikasiVoting{
+		Valid:         true,
+		JumlahSuara:   len(suara),
+		JumlahPemilih: jumlahPemilih,
+		HashAkhir:     voting.VotingHashAkhir,
+	}
+
+	pilihanValid := make(map[uint]bool, len(voting.Pilihan))
+	for _, p := range voting.Pilihan {
+		pilihanValid[p.VotingPilihanID] = true
+	}
+
+	rusak := func(urutan int, masalah string) HasilVerifikasiVoting {
+		hasil.Valid = false
+		hasil.RusakPadaUrutan = urutan
+		hasil.Masalah = masalah
+		return hasil
+	}
+
+	sebelum := hashAwalVoting(voting.VotingID)
+	for i, s := range suara {
+		urutan := i + 1
+		switch {
+		case s.Urutan != urutan:
+			return rusak(urutan, "Urutan suara terputus, ada suara yang hilang")
+		case s.HashSebelum != sebelum:
+			return rusak(urutan, "Hash sebelumnya tidak cocok dengan suara sebelumnya")
+		case hashSuaraVoting(s) != s.Hash:
+			return rusak(urutan, "Isi suara tidak cocok dengan hash-nya")
+		case !pilihanValid[s.VotingPilihanID]:
+			return rusak(urutan, "Suara menunjuk pilihan yang tidak ada")
+		}
+		sebelum = s.Hash
+	}
+
+	if len(suara) > 0 && sebelum != voting.VotingHashAkhir {
+		return rusak(len(suara), "Hash akhir voting tidak cocok dengan suara terakhir")
+	}
+	if len(suara) != voting.VotingJumlahSuara {
+		return rusak(len(suara)+1, "Jumlah suara tidak cocok dengan catatan voting")
+	}
+	if int64(len(suara)) != jumlahPemilih {
+		return rusak(0, "Jumlah suara tidak sama dengan jumlah pemilih yang tercatat")
+	}
+	return hasil
+}
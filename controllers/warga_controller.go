@@ -2,11 +2,15 @@
 package controllers
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"rt-management/helper"
 	"rt-management/models"
 	"log"
 
@@ -40,6 +44,19 @@ type CreateWargaRequest struct {
 	PekerjaanID       uint      `form:"pekerjaan_id"`
 }
 
+type MergeWargaRequest struct {
+	DuplikatID uint   `form:"duplikat_id" binding:"required"`
+	Alasan     string `form:"alasan"`
+}
+
+// Pasangan warga yang terindikasi data ganda
+type DuplikatWargaCandidate struct {
+	WargaA models.Warga `json:"warga_a"`
+	WargaB models.Warga `json:"warga_b"`
+	Skor   float64      `json:"skor"`
+	Alasan []string     `json:"alasan"`
+}
+
 type UpdateWargaRequest struct {
 	KeluargaID        uint      `form:"keluarga_id"`
 	WargaNama         string    `form:"warga_nama"`
@@ -126,7 +143,7 @@ func (wc *WargaController) GetAllWarga(c *gin.Context) {
 	log.Println("🔄 Fetching all residents from database...")
 	
 	// ✅ SAFE: GORM menggunakan parameterized queries
-	if err := wc.db.
+	if err := scopedDB(c, wc.db).
 		Preload("Keluarga").
 		Preload("Agama").
 		Preload("Pekerjaan").
@@ -153,7 +170,7 @@ func (wc *WargaController) GetTotalWarga(c *gin.Context) {
 	log.Println("🔄 Fetching total residents count...")
 	
 	// ✅ SAFE: Parameterized query
-	if err := wc.db.Model(&models.Warga{}).Count(&total).Error; err != nil {
+	if err := scopedDB(c, wc.db).Model(&models.Warga{}).Count(&total).Error; err != nil {
 		log.Printf("❌ Error fetching total residents: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch total residents",
@@ -184,7 +201,7 @@ func (wc *WargaController) GetWargaByID(c *gin.Context) {
 
 	var warga models.Warga
 	// ✅ SAFE: GORM menggunakan prepared statements
-	if err := wc.db.
+	if err := scopedDB(c, wc.db).
 		Preload("Keluarga").
 		Preload("Agama").
 		Preload("Pekerjaan").
@@ -223,7 +240,7 @@ func (wc *WargaController) GetWargaByKeluarga(c *gin.Context) {
 
 	var wargas []models.Warga
 	// ✅ SAFE: Parameterized query
-	if err := wc.db.
+	if err := scopedDB(c, wc.db).
 		Preload("Keluarga").
 		Preload("Agama").
 		Preload("Pekerjaan").
@@ -334,7 +351,7 @@ func (wc *WargaController) CreateWarga(c *gin.Context) {
 
 	// ✅ Check if keluarga exists
 	var keluarga models.Keluarga
-	if err := wc.db.First(&keluarga, req.KeluargaID).Error; err != nil {
+	if err := scopedDB(c, wc.db).First(&keluarga, req.KeluargaID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": "Family not found",
@@ -350,7 +367,7 @@ func (wc *WargaController) CreateWarga(c *gin.Context) {
 	// ✅ Check if agama exists (jika provided)
 	if req.AgamaID != 0 {
 		var agama models.Agama
-		if err := wc.db.First(&agama, req.AgamaID).Error; err != nil {
+		if err := scopedDB(c, wc.db).First(&agama, req.AgamaID).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				c.JSON(http.StatusBadRequest, gin.H{
 					"error": "Religion not found",
@@ -367,7 +384,7 @@ func (wc *WargaController) CreateWarga(c *gin.Context) {
 	// ✅ Check if pekerjaan exists (jika provided)
 	if req.PekerjaanID != 0 {
 		var pekerjaan models.Pekerjaan
-		if err := wc.db.First(&pekerjaan, req.PekerjaanID).Error; err != nil {
+		if err := scopedDB(c, wc.db).First(&pekerjaan, req.PekerjaanID).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				c.JSON(http.StatusBadRequest, gin.H{
 					"error": "Occupation not found",
@@ -381,9 +398,16 @@ func (wc *WargaController) CreateWarga(c *gin.Context) {
 		}
 	}
 
-	// ✅ Check if NIK already exists
+	// ✅ Check if NIK already exists (termasuk yang ada di trash dan di RT lain, NIK unik secara nasional)
 	var existingWarga models.Warga
-	if err := wc.db.Where("warga_nik = ?", req.WargaNIK).First(&existingWarga).Error; err == nil {
+	if err := wc.db.Unscoped().Where("warga_nik = ?", req.WargaNIK).First(&existingWarga).Error; err == nil {
+		if existingWarga.DeletedAt.Valid {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":    "NIK belongs to a deleted resident, restore it from trash instead",
+				"warga_id": existingWarga.WargaID,
+			})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "NIK already exists",
 		})
@@ -407,7 +431,7 @@ func (wc *WargaController) CreateWarga(c *gin.Context) {
 	}
 
 	// ✅ SAFE: GORM create dengan parameterized queries
-	if err := wc.db.Create(&warga).Error; err != nil {
+	if err := scopedDB(c, wc.db).Create(&warga).Error; err != nil {
 		log.Printf("❌ Error creating resident: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create resident",
@@ -416,7 +440,7 @@ func (wc *WargaController) CreateWarga(c *gin.Context) {
 	}
 
 	// ✅ Reload warga dengan data terbaru
-	if err := wc.db.
+	if err := scopedDB(c, wc.db).
 		Preload("Keluarga").
 		Preload("Agama").
 		Preload("Pekerjaan").
@@ -476,7 +500,7 @@ func (wc *WargaController) UpdateWarga(c *gin.Context) {
 
 	var warga models.Warga
 	// ✅ SAFE: GORM First dengan parameterized query
-	if err := wc.db.First(&warga, wargaID).Error; err != nil {
+	if err := scopedDB(c, wc.db).First(&warga, wargaID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Resident not found",
@@ -492,7 +516,7 @@ func (wc *WargaController) UpdateWarga(c *gin.Context) {
 	// ✅ Update fields dengan validasi
 	if req.KeluargaID != 0 {
 		var keluarga models.Keluarga
-		if err := wc.db.First(&keluarga, req.KeluargaID).Error; err != nil {
+		if err := scopedDB(c, wc.db).First(&keluarga, req.KeluargaID).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				c.JSON(http.StatusBadRequest, gin.H{
 					"error": "Family not found",
@@ -526,7 +550,7 @@ func (wc *WargaController) UpdateWarga(c *gin.Context) {
 		}
 		// Check if NIK already exists (excluding current resident)
 		var existingWarga models.Warga
-		if err := wc.db.Where("warga_nik = ? AND warga_id != ?", req.WargaNIK, wargaID).First(&existingWarga).Error; err == nil {
+		if err := wc.db.Unscoped().Where("warga_nik = ? AND warga_id != ?", req.WargaNIK, wargaID).First(&existingWarga).Error; err == nil {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": "NIK already exists",
 			})
@@ -602,7 +626,7 @@ func (wc *WargaController) UpdateWarga(c *gin.Context) {
 
 	if req.AgamaID != 0 {
 		var agama models.Agama
-		if err := wc.db.First(&agama, req.AgamaID).Error; err != nil {
+		if err := scopedDB(c, wc.db).First(&agama, req.AgamaID).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				c.JSON(http.StatusBadRequest, gin.H{
 					"error": "Religion not found",
@@ -619,7 +643,7 @@ func (wc *WargaController) UpdateWarga(c *gin.Context) {
 
 	if req.PekerjaanID != 0 {
 		var pekerjaan models.Pekerjaan
-		if err := wc.db.First(&pekerjaan, req.PekerjaanID).Error; err != nil {
+		if err := scopedDB(c, wc.db).First(&pekerjaan, req.PekerjaanID).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				c.JSON(http.StatusBadRequest, gin.H{
 					"error": "Occupation not found",
@@ -635,7 +659,7 @@ func (wc *WargaController) UpdateWarga(c *gin.Context) {
 	}
 
 	// ✅ SAFE: GORM Save dengan parameterized queries
-	if err := wc.db.Save(&warga).Error; err != nil {
+	if err := scopedDB(c, wc.db).Save(&warga).Error; err != nil {
 		log.Printf("❌ Error updating resident: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to update resident",
@@ -644,7 +668,7 @@ func (wc *WargaController) UpdateWarga(c *gin.Context) {
 	}
 
 	// ✅ Reload dengan data terbaru
-	if err := wc.db.
+	if err := scopedDB(c, wc.db).
 		Preload("Keluarga").
 		Preload("Agama").
 		Preload("Pekerjaan").
@@ -662,7 +686,8 @@ func (wc *WargaController) UpdateWarga(c *gin.Context) {
 		"data":    warga,
 	})
 }
-// DeleteWarga deletes warga dengan security checks
+
+// DeleteWarga memindahkan warga ke trash (soft delete)
 func (wc *WargaController) DeleteWarga(c *gin.Context) {
 	wargaID := c.Param("id")
 
@@ -678,7 +703,7 @@ func (wc *WargaController) DeleteWarga(c *gin.Context) {
 
 	var warga models.Warga
 	// ✅ SAFE: GORM First dengan parameterized query
-	if err := wc.db.First(&warga, wargaID).Error; err != nil {
+	if err := scopedDB(c, wc.db).First(&warga, wargaID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Resident not found",
@@ -693,7 +718,7 @@ func (wc *WargaController) DeleteWarga(c *gin.Context) {
 
 	// ✅ Check jika warga masih memiliki rumah
 	var rumahCount int64
-	if err := wc.db.Model(&models.Rumah{}).Where("warga_id = ?", wargaID).Count(&rumahCount).Error; err != nil {
+	if err := scopedDB(c, wc.db).Model(&models.Rumah{}).Where("warga_id = ?", wargaID).Count(&rumahCount).Error; err != nil {
 		log.Printf("❌ Error checking resident's houses: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to check resident's houses",
@@ -710,7 +735,7 @@ func (wc *WargaController) DeleteWarga(c *gin.Context) {
 	}
 
 	// ✅ SAFE: GORM Delete dengan parameterized query
-	if err := wc.db.Delete(&warga).Error; err != nil {
+	if err := scopedDB(c, wc.db).Delete(&warga).Error; err != nil {
 		log.Printf("❌ Error deleting resident: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to delete resident",
@@ -738,13 +763,13 @@ func (wc *WargaController) GetWargaStats(c *gin.Context) {
 	}
 
 	// ✅ SAFE: Semua count queries menggunakan parameterized queries
-	wc.db.Model(&models.Warga{}).Count(&stats.TotalWarga)
-	wc.db.Model(&models.Warga{}).Where("warga_status_aktif = ?", "aktif").Count(&stats.WargaAktif)
-	wc.db.Model(&models.Warga{}).Where("warga_status_aktif = ?", "nonaktif").Count(&stats.WargaNonaktif)
-	wc.db.Model(&models.Warga{}).Where("warga_jenis_kelamin = ?", "L").Count(&stats.WargaLakiLaki)
-	wc.db.Model(&models.Warga{}).Where("warga_jenis_kelamin = ?", "P").Count(&stats.WargaPerempuan)
-	wc.db.Model(&models.Warga{}).Where("warga_status_hidup = ?", "hidup").Count(&stats.WargaHidup)
-	wc.db.Model(&models.Warga{}).Where("warga_status_hidup = ?", "meninggal").Count(&stats.WargaMeninggal)
+	scopedDB(c, wc.db).Model(&models.Warga{}).Count(&stats.TotalWarga)
+	scopedDB(c, wc.db).Model(&models.Warga{}).Where("warga_status_aktif = ?", "aktif").Count(&stats.WargaAktif)
+	scopedDB(c, wc.db).Model(&models.Warga{}).Where("warga_status_aktif = ?", "nonaktif").Count(&stats.WargaNonaktif)
+	scopedDB(c, wc.db).Model(&models.Warga{}).Where("warga_jenis_kelamin = ?", "L").Count(&stats.WargaLakiLaki)
+	scopedDB(c, wc.db).Model(&models.Warga{}).Where("warga_jenis_kelamin = ?", "P").Count(&stats.WargaPerempuan)
+	scopedDB(c, wc.db).Model(&models.Warga{}).Where("warga_status_hidup = ?", "hidup").Count(&stats.WargaHidup)
+	scopedDB(c, wc.db).Model(&models.Warga{}).Where("warga_status_hidup = ?", "meninggal").Count(&stats.WargaMeninggal)
 
 	log.Printf("📊 Resident stats: Total=%d, Active=%d, Male=%d, Female=%d", 
 		stats.TotalWarga, stats.WargaAktif, stats.WargaLakiLaki, stats.WargaPerempuan)
@@ -781,7 +806,7 @@ func (wc *WargaController) SearchWarga(c *gin.Context) {
     var wargas []models.Warga
     
     // ✅ SAFE: Gunakan parameterized query
-    if err := wc.db.
+    if err := scopedDB(c, wc.db).
         Preload("Keluarga").
         Preload("Agama").
         Preload("Pekerjaan").
@@ -801,4 +826,391 @@ func (wc *WargaController) SearchWarga(c *gin.Context) {
         "results": wargas,
         "count":   len(wargas),
     })
-}
\ No newline at end of file
+}
+
+// GetTrashWarga returns warga yang sudah dihapus (soft delete)
+func (wc *WargaController) GetTrashWarga(c *gin.Context) {
+	var wargas []models.Warga
+
+	// ✅ SAFE: Unscoped agar data yang sudah di-soft delete ikut terbaca
+	if err := scopedDB(c, wc.db).Unscoped().
+		Preload("Keluarga", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Find(&wargas).Error; err != nil {
+		log.Printf("❌ Error fetching deleted residents: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch deleted residents",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  wargas,
+		"count": len(wargas),
+	})
+}
+
+// RestoreWarga mengembalikan warga dari trash
+func (wc *WargaController) RestoreWarga(c *gin.Context) {
+	wargaID := c.Param("id")
+
+	// ✅ Validasi ID input
+	if !isValidWargaID(wargaID) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid resident ID format",
+		})
+		return
+	}
+
+	var warga models.Warga
+	if err := scopedDB(c, wc.db).Unscoped().Where("deleted_at IS NOT NULL").First(&warga, wargaID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Resident not found in trash",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch resident",
+			})
+		}
+		return
+	}
+
+	// ✅ NIK tidak boleh sudah dipakai warga aktif lain
+	var existingWarga models.Warga
+	if err := wc.db.Where("warga_nik = ? AND warga_id != ?", warga.WargaNIK, warga.WargaID).First(&existingWarga).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":    "NIK already used by another resident",
+			"warga_id": existingWarga.WargaID,
+		})
+		return
+	}
+
+	// ✅ Keluarga harus masih ada (tidak di trash)
+	var keluarga models.Keluarga
+	if err := scopedDB(c, wc.db).First(&keluarga, warga.KeluargaID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Family of this resident is deleted, restore the family first",
+		})
+		return
+	}
+
+	if err := scopedDB(c, wc.db).Unscoped().Model(&warga).Update("deleted_at", nil).Error; err != nil {
+		log.Printf("❌ Error restoring resident: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to restore resident",
+		})
+		return
+	}
+
+	log.Printf("♻️ Restored resident: %s (ID: %d)", warga.WargaNama, warga.WargaID)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Resident restored successfully",
+		"data":    warga,
+	})
+}
+
+// FindDuplikatWarga returns candidate pairs of residents that are likely the same person
+func (wc *WargaController) FindDuplikatWarga(c *gin.Context) {
+	minSkor := 0.6
+	if v := c.Query("min_skor"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || parsed <= 0 || parsed > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "min_skor must be a number between 0 and 1",
+			})
+			return
+		}
+		minSkor = parsed
+	}
+
+	var wargas []models.Warga
+	if err := scopedDB(c, wc.db).Preload("Keluarga").Order("warga_id ASC").Find(&wargas).Error; err != nil {
+		log.Printf("❌ Error fetching residents: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch residents",
+		})
+		return
+	}
+
+	candidates := []DuplikatWargaCandidate{}
+	for i := 0; i < len(wargas); i++ {
+		for j := i + 1; j < len(wargas); j++ {
+			skor, alasan := scoreDuplikatWarga(wargas[i], wargas[j])
+			if skor >= minSkor {
+				candidates = append(candidates, DuplikatWargaCandidate{
+					WargaA: wargas[i],
+					WargaB: wargas[j],
+					Skor:   skor,
+					Alasan: alasan,
+				})
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Skor > candidates[j].Skor
+	})
+
+	log.Printf("✅ Found %d duplicate candidates from %d residents", len(candidates), len(wargas))
+	c.JSON(http.StatusOK, gin.H{
+		"data":     candidates,
+		"count":    len(candidates),
+		"min_skor": minSkor,
+	})
+}
+
+// errMergeVotingGanda is returned when both residents already voted in the same per-resident poll
+var errMergeVotingGanda = errors.New("both residents already voted in the same poll")
+
+// MergeWarga merges a duplicate resident into the resident identified by :id
+func (wc *WargaController) MergeWarga(c *gin.Context) {
+	wargaID := c.Param("id")
+
+	if !isValidWargaID(wargaID) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid resident ID format",
+		})
+		return
+	}
+
+	var req MergeWargaRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var utama, duplikat models.Warga
+	if err := scopedDB(c, wc.db).First(&utama, wargaID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Resident not found",
+		})
+		return
+	}
+	if err := scopedDB(c, wc.db).First(&duplikat, req.DuplikatID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Duplicate resident not found",
+		})
+		return
+	}
+	if utama.WargaID == duplikat.WargaID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Cannot merge a resident with itself",
+		})
+		return
+	}
+
+	skor, alasan := scoreDuplikatWarga(utama, duplikat)
+	if req.Alasan != "" {
+		alasan = append(alasan, sanitizeString(req.Alasan))
+	}
+	snapshot, _ := json.Marshal(duplikat)
+	userID, _ := c.Get("userID")
+
+	log.Printf("🔄 Merging resident %d into %d", duplikat.WargaID, utama.WargaID)
+
+	var movedRumah int64
+	err := scopedDB(c, wc.db).Transaction(func(tx *gorm.DB) error {
+		// Lengkapi data yang kosong pada warga utama dari data duplikat
+		updates := make(map[string]interface{})
+		if utama.WargaNoTlp == "" && duplikat.WargaNoTlp != "" {
+			updates["warga_no_tlp"] = duplikat.WargaNoTlp
+		}
+		if utama.WargaTempatLahir == "" && duplikat.WargaTempatLahir != "" {
+			updates["warga_tempat_lahir"] = duplikat.WargaTempatLahir
+		}
+		if utama.WargaTanggalLahir.IsZero() && !duplikat.WargaTanggalLahir.IsZero() {
+			updates["warga_tanggal_lahir"] = duplikat.WargaTanggalLahir
+		}
+		if utama.AgamaID == 0 && duplikat.AgamaID != 0 {
+			updates["agama_id"] = duplikat.AgamaID
+		}
+		if utama.PekerjaanID == 0 && duplikat.PekerjaanID != 0 {
+			updates["pekerjaan_id"] = duplikat.PekerjaanID
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&utama).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		// Pindahkan referensi ke warga utama (termasuk rumah di trash)
+		result := tx.Unscoped().Model(&models.Rumah{}).
+			Where("warga_id = ?", duplikat.WargaID).
+			Update("warga_id", utama.WargaID)
+		if result.Error != nil {
+			return result.Error
+		}
+		movedRumah = result.RowsAffected
+
+		// Keanggotaan ronda unik per jadwal, buang milik duplikat jika warga utama sudah anggota
+		var jadwalUtama []uint
+		if err := tx.Model(&models.AnggotaRonda{}).Where("warga_id = ?", utama.WargaID).
+			Pluck("jadwal_ronda_id", &jadwalUtama).Error; err != nil {
+			return err
+		}
+		if len(jadwalUtama) > 0 {
+			if err := tx.Where("warga_id = ? AND jadwal_ronda_id IN ?", duplikat.WargaID, jadwalUtama).
+				Delete(&models.AnggotaRonda{}).Error; err != nil {
+				return err
+			}
+		}
+
+		// Hak pilih per warga tercatat dengan kunci warga:<id>; kunci duplikat diganti ke warga utama
+		// agar warga utama tidak bisa memilih lagi. Jika keduanya sudah memilih pada voting yang sama,
+		// merge dihentikan karena suara yang sudah masuk rantai hash tidak bisa dibatalkan.
+		kunciUtama := kunciPemilihVoting("warga_dewasa", utama)
+		kunciDuplikat := kunciPemilihVoting("warga_dewasa", duplikat)
+		var votingUtama []uint
+		if err := tx.Model(&models.PemilihVoting{}).Where("pemilih_kunci = ?", kunciUtama).
+			Pluck("voting_id", &votingUtama).Error; err != nil {
+			return err
+		}
+		if len(votingUtama) > 0 {
+			var bentrok int64
+			if err := tx.Model(&models.PemilihVoting{}).
+				Where("pemilih_kunci = ? AND voting_id IN ?", kunciDuplikat, votingUtama).
+				Count(&bentrok).Error; err != nil {
+				return err
+			}
+			if bentrok > 0 {
+				return errMergeVotingGanda
+			}
+		}
+		if err := tx.Model(&models.PemilihVoting{}).Where("pemilih_kunci = ?", kunciDuplikat).
+			Update("pemilih_kunci", kunciUtama).Error; err != nil {
+			return err
+		}
+
+		referensi := []struct {
+			model interface{}
+			kolom string
+		}{
+			{&models.User{}, "warga_id"},
+			{&models.KehadiranKegiatan{}, "warga_id"},
+			{&models.TindakLanjutKegiatan{}, "penanggung_jawab_id"},
+			{&models.PeminjamanFasilitas{}, "warga_id"},
+			{&models.Inventaris{}, "pemegang_id"},
+			{&models.AnggotaRonda{}, "warga_id"},
+			{&models.PetugasRonda{}, "warga_id"},
+			{&models.PemilihVoting{}, "warga_id"},
+		}
+		for _, r := range referensi {
+			if err := tx.Unscoped().Model(r.model).
+				Where(r.kolom+" = ?", duplikat.WargaID).
+				Update(r.kolom, utama.WargaID).Error; err != nil {
+				return err
+			}
+		}
+
+		// Warga duplikat dihapus permanen agar NIK-nya tidak tertahan di trash;
+		// seluruh referensi di atas sudah dipindahkan ke warga utama
+		if err := tx.Unscoped().Delete(&duplikat).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.WargaMergeLog{
+			WargaUtamaID:      utama.WargaID,
+			WargaDuplikatID:   duplikat.WargaID,
+			WargaDuplikatNama: duplikat.WargaNama,
+			WargaDuplikatNIK:  duplikat.WargaNIK,
+			MergeSkor:         skor,
+			MergeAlasan:       strings.Join(alasan, "; "),
+			MergeSnapshot:     string(snapshot),
+			DigabungOlehID:    userID.(uint),
+			RTID:              utama.RTID,
+		}).Error
+	})
+	if err == errMergeVotingGanda {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Both residents already voted in the same poll, merge cannot be completed",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error merging residents: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to merge residents",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	scopedDB(c, wc.db).Preload("Keluarga").Preload("Agama").Preload("Pekerjaan").Preload("Rumahs").First(&utama, utama.WargaID)
+
+	log.Printf("✅ Successfully merged resident %d into %d", duplikat.WargaID, utama.WargaID)
+	c.JSON(http.StatusOK, gin.H{
+		"message":           "Residents merged successfully",
+		"data":              utama,
+		"merged_warga_id":   duplikat.WargaID,
+		"moved_house_count": movedRumah,
+	})
+}
+
+// GetMergeLogWarga returns the history of resident merges
+func (wc *WargaController) GetMergeLogWarga(c *gin.Context) {
+	var logs []models.WargaMergeLog
+
+	if err := scopedDB(c, wc.db).Order("created_at DESC").Find(&logs).Error; err != nil {
+		log.Printf("❌ Error fetching merge logs: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch merge logs",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  logs,
+		"count": len(logs),
+	})
+}
+
+// ✅ Helper skor kemiripan dua warga (0..1) beserta alasannya
+func scoreDuplikatWarga(a, b models.Warga) (float64, []string) {
+	var skor float64
+	alasan := []string{}
+
+	// Nama (bobot 0.45), dihitung setelah normalisasi ejaan
+	namaA, namaB := helper.NormalizeName(a.WargaNama), helper.NormalizeName(b.WargaNama)
+	if simNama := helper.StringSimilarity(namaA, namaB); simNama >= 0.7 {
+		skor += 0.45 * simNama
+		if namaA == namaB {
+			alasan = append(alasan, "nama sama")
+		} else {
+			alasan = append(alasan, "nama mirip")
+		}
+	}
+
+	// Tanggal lahir sama (bobot 0.25)
+	if !a.WargaTanggalLahir.IsZero() && a.WargaTanggalLahir.Format("2006-01-02") == b.WargaTanggalLahir.Format("2006-01-02") {
+		skor += 0.25
+		alasan = append(alasan, "tanggal lahir sama")
+	}
+
+	// NIK beda maksimal 2 digit, kemungkinan salah ketik (bobot 0.2)
+	if len(a.WargaNIK) == len(b.WargaNIK) && a.WargaNIK != "" {
+		beda := 0
+		for i := 0; i < len(a.WargaNIK); i++ {
+			if a.WargaNIK[i] != b.WargaNIK[i] {
+				beda++
+			}
+		}
+		if beda > 0 && beda <= 2 {
+			skor += 0.2 * (1 - float64(beda-1)/2)
+			alasan = append(alasan, "NIK mirip")
+		}
+	}
+
+	// Nomor telepon sama (bobot 0.1)
+	tlpA, tlpB := helper.DigitsOnly(a.WargaNoTlp), helper.DigitsOnly(b.WargaNoTlp)
+	if tlpA != "" && tlpA == tlpB {
+		skor += 0.1
+		alasan = append(alasan, "nomor telepon sama")
+	}
+
+	return float64(int(skor*100+0.5)) / 100, alasan
+}
@@ -0,0 +1,690 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"rt-management/models"
+	"rt-management/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type WilayahController struct {
+	db *gorm.DB
+}
+
+func NewWilayahController(db *gorm.DB) *WilayahController {
+	return &WilayahController{db: db}
+}
+
+// Request structs
+type RWRequest struct {
+	RWNomor string `form:"rw_nomor"`
+	RWNama  string `form:"rw_nama"`
+}
+
+type RTRequest struct {
+	RWID    uint   `form:"rw_id"`
+	RTNomor string `form:"rt_nomor"`
+	RTNama  string `form:"rt_nama"`
+}
+
+// Rekap satu RT dalam laporan tingkat RW
+type RekapRT struct {
+	RTID             uint    `json:"rt_id"`
+	RTNomor          string  `json:"rt_nomor"`
+	RTNama           string  `json:"rt_nama"`
+	TotalKeluarga    int64   `json:"total_keluarga"`
+	TotalWarga       int64   `json:"total_warga"`
+	TotalRumah       int64   `json:"total_rumah"`
+	RumahDitempati   int64   `json:"rumah_ditempati"`
+	TotalKegiatan    int64   `json:"total_kegiatan"`
+	TotalPemasukan   float64 `json:"total_pemasukan"`
+	TotalPengeluaran float64 `json:"total_pengeluaran"`
+	Saldo            float64 `json:"saldo"`
+}
+
+// ✅ READ - Mendapatkan semua RW beserta RT-nya
+func (wc *WilayahController) GetAllRW(c *gin.Context) {
+	var rws []models.RW
+
+	query := wc.db.Preload("RTs", func(db *gorm.DB) *gorm.DB {
+		return db.Order("rt_nomor ASC")
+	})
+
+	// User RW hanya melihat RW-nya sendiri
+	if scope, _ := utils.TenantScopeFromContext(c.Request.Context()); scope.RWID != 0 {
+		query = query.Where("rw_id = ?", scope.RWID)
+	}
+
+	if err := query.Order("rw_nomor ASC").Find(&rws).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data RW",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": rws,
+	})
+}
+
+// ✅ CREATE - Membuat RW baru (admin global)
+func (wc *WilayahController) CreateRW(c *gin.Context) {
+	if !requireGlobalScope(c) {
+		return
+	}
+
+	var req RWRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	nomor, ok := normalizeRTRW(req.RWNomor)
+	if !ok || nomor == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Nomor RW harus berupa angka maksimal 3 digit",
+		})
+		return
+	}
+
+	var count int64
+	wc.db.Model(&models.RW{}).Where("rw_nomor = ?", nomor).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Nomor RW sudah terdaftar",
+		})
+		return
+	}
+
+	rw := models.RW{
+		RWNomor: nomor,
+		RWNama:  strings.TrimSpace(req.RWNama),
+	}
+	if err := wc.db.Create(&rw).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal membuat RW",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "RW berhasil dibuat",
+		"data":    rw,
+	})
+}
+
+// ✅ UPDATE - Mengupdate RW (admin global)
+func (wc *WilayahController) UpdateRW(c *gin.Context) {
+	if !requireGlobalScope(c) {
+		return
+	}
+
+	var rw models.RW
+	if err := wc.db.First(&rw, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "RW tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan RW",
+			})
+		}
+		return
+	}
+
+	var req RWRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if req.RWNomor != "" {
+		nomor, ok := normalizeRTRW(req.RWNomor)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Nomor RW harus berupa angka maksimal 3 digit",
+			})
+			return
+		}
+		var count int64
+		wc.db.Model(&models.RW{}).Where("rw_nomor = ? AND rw_id != ?", nomor, rw.RWID).Count(&count)
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Nomor RW sudah terdaftar",
+			})
+			return
+		}
+		updates["rw_nomor"] = nomor
+	}
+	if req.RWNama != "" {
+		updates["rw_nama"] = strings.TrimSpace(req.RWNama)
+	}
+
+	if err := wc.db.Model(&rw).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mengupdate RW",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "RW berhasil diupdate",
+		"data":    rw,
+	})
+}
+
+// ✅ DELETE - Menghapus RW yang sudah tidak memiliki RT (admin global)
+func (wc *WilayahController) DeleteRW(c *gin.Context) {
+	if !requireGlobalScope(c) {
+		return
+	}
+
+	var rw models.RW
+	if err := wc.db.First(&rw, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "RW tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan RW",
+			})
+		}
+		return
+	}
+
+	var rtCount, userCount int64
+	wc.db.Model(&models.RT{}).Where("rw_id = ?", rw.RWID).Count(&rtCount)
+	wc.db.Model(&models.User{}).Where("rw_id = ?", rw.RWID).Count(&userCount)
+	if rtCount > 0 || userCount > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "RW masih memiliki RT atau user, tidak dapat dihapus",
+			"rt_count":   rtCount,
+			"user_count": userCount,
+		})
+		return
+	}
+
+	if err := wc.db.Delete(&rw).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menghapus RW",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "RW berhasil dihapus",
+	})
+}
+
+// ✅ READ - Mendapatkan daftar RT sesuai wilayah user
+func (wc *WilayahController) GetAllRT(c *gin.Context) {
+	var rts []models.RT
+
+	query := wc.db.Preload("RW")
+
+	scope, _ := utils.TenantScopeFromContext(c.Request.Context())
+	if scope.RTID != 0 {
+		query = query.Where("rt_id = ?", scope.RTID)
+	}
+	if scope.RWID != 0 {
+		query = query.Where("rw_id = ?", scope.RWID)
+	}
+	if rwID := c.Query("rw_id"); rwID != "" {
+		if id, err := strconv.ParseUint(rwID, 10, 32); err == nil {
+			query = query.Where("rw_id = ?", id)
+		}
+	}
+
+	if err := query.Order("rw_id ASC, rt_nomor ASC").Find(&rts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data RT",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": rts,
+	})
+}
+
+// ✅ CREATE - Membuat RT baru dalam sebuah RW (admin global)
+func (wc *WilayahController) CreateRT(c *gin.Context) {
+	if !requireGlobalScope(c) {
+		return
+	}
+
+	var req RTRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	nomor, ok := normalizeRTRW(req.RTNomor)
+	if !ok || nomor == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Nomor RT harus berupa angka maksimal 3 digit",
+		})
+		return
+	}
+
+	var rw models.RW
+	if err := wc.db.First(&rw, req.RWID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "RW tidak ditemukan",
+		})
+		return
+	}
+
+	var count int64
+	wc.db.Model(&models.RT{}).Where("rw_id = ? AND rt_nomor = ?", rw.RWID, nomor).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Nomor RT sudah terdaftar di RW tersebut",
+		})
+		return
+	}
+
+	rt := models.RT{
+		RWID:    rw.RWID,
+		RTNomor: nomor,
+		RTNama:  strings.TrimSpace(req.RTNama),
+	}
+	if err := wc.db.Create(&rt).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal membuat RT",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "RT berhasil dibuat",
+		"data":    rt,
+	})
+}
+
+// ✅ UPDATE - Mengupdate RT (admin global)
+func (wc *WilayahController) UpdateRT(c *gin.Context) {
+	if !requireGlobalScope(c) {
+		return
+	}
+
+	var rt models.RT
+	if err := wc.db.First(&rt, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "RT tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan RT",
+			})
+		}
+		return
+	}
+
+	var req RTRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rwID, nomor := rt.RWID, rt.RTNomor
+	if req.RWID != 0 {
+		var rw models.RW
+		if err := wc.db.First(&rw, req.RWID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "RW tidak ditemukan",
+			})
+			return
+		}
+		rwID = rw.RWID
+	}
+	if req.RTNomor != "" {
+		v, ok := normalizeRTRW(req.RTNomor)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Nomor RT harus berupa angka maksimal 3 digit",
+			})
+			return
+		}
+		nomor = v
+	}
+
+	var count int64
+	wc.db.Model(&models.RT{}).Where("rw_id = ? AND rt_nomor = ? AND rt_id != ?", rwID, nomor, rt.RTID).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Nomor RT sudah terdaftar di RW tersebut",
+		})
+		return
+	}
+
+	updates := map[string]interface{}{
+		"rw_id":    rwID,
+		"rt_nomor": nomor,
+	}
+	if req.RTNama != "" {
+		updates["rt_nama"] = strings.TrimSpace(req.RTNama)
+	}
+
+	if err := wc.db.Model(&rt).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal mengupdate RT",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "RT berhasil diupdate",
+		"data":    rt,
+	})
+}
+
+// ✅ DELETE - Menghapus RT yang belum memiliki data (admin global)
+func (wc *WilayahController) DeleteRT(c *gin.Context) {
+	if !requireGlobalScope(c) {
+		return
+	}
+
+	var rt models.RT
+	if err := wc.db.First(&rt, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "RT tidak ditemukan",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Gagal menemukan RT",
+			})
+		}
+		return
+	}
+
+	// RT yang masih dipakai data inti tidak boleh dihapus
+	var keluargaCount, rumahCount, userCount int64
+	wc.db.Unscoped().Model(&models.Keluarga{}).Where("rt_id = ?", rt.RTID).Count(&keluargaCount)
+	wc.db.Unscoped().Model(&models.Rumah{}).Where("rt_id = ?", rt.RTID).Count(&rumahCount)
+	wc.db.Model(&models.User{}).Where("rt_id = ?", rt.RTID).Count(&userCount)
+	if keluargaCount > 0 || rumahCount > 0 || userCount > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":          "RT masih memiliki data, tidak dapat dihapus",
+			"keluarga_count": keluargaCount,
+			"rumah_count":    rumahCount,
+			"user_count":     userCount,
+		})
+		return
+	}
+
+	if err := wc.db.Delete(&rt).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Gagal menghapus RT",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "RT berhasil dihapus",
+	})
+}
+
+// ✅ GET - Rekap seluruh RT dalam satu RW (untuk Ketua RW)
+func (wc *WilayahController) GetRekapRW(c *gin.Context) {
+	scope, _ := utils.TenantScopeFromContext(c.Request.Context())
+
+	// Tentukan RW: dari scope user, atau query rw_id untuk admin global
+	rwID := scope.RWID
+	if rwID == 0 && scope.RTID != 0 {
+		var rt models.RT
+		if err := wc.db.First(&rt, scope.RTID).Error; err == nil {
+			rwID = rt.RWID
+		}
+	}
+	if rwID == 0 {
+		id, err := strconv.ParseUint(c.Query("rw_id"), 10, 32)
+		if err != nil || id == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Parameter rw_id wajib diisi",
+			})
+			return
+		}
+		rwID = uint(id)
+	}
+
+	var rw models.RW
+	if err := wc.db.First(&rw, rwID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "RW tidak ditemukan",
+		})
+		return
+	}
+
+	rtQuery := wc.db.Where("rw_id = ?", rw.RWID)
+	if scope.RTID != 0 {
+		rtQuery = rtQuery.Where("rt_id = ?", scope.RTID)
+	}
+	var rts []models.RT
+	if err := rtQuery.Order("rt_nomor ASC").Find(&rts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Gagal mengambil data RT",
+		})
+		return
+	}
+
+	rtIDs := make([]uint, 0, len(rts))
+	for _, rt := range rts {
+		rtIDs = append(rtIDs, rt.RTID)
+	}
+
+	db := scopedDB(c, wc.db)
+	keluarga := countPerRT(db, &models.Keluarga{}, rtIDs, "")
+	warga := countPerRT(db, &models.Warga{}, rtIDs, "")
+	rumah := countPerRT(db, &models.Rumah{}, rtIDs, "")
+	ditempati := countPerRT(db, &models.Rumah{}, rtIDs, "rumah_status = 'ditempati'")
+	kegiatan := countPerRT(db, &models.Kegiatan{}, rtIDs, "")
+	pemasukan := sumPerRT(db, &models.Pemasukan{}, rtIDs, "pemasukan_nominal")
+	pengeluaran := sumPerRT(db, &models.Pengeluaran{}, rtIDs, "pengeluaran_nominal")
+
+	var total RekapRT
+	result := make([]RekapRT, 0, len(rts))
+	for _, rt := range rts {
+		rekap := RekapRT{
+			RTID:             rt.RTID,
+			RTNomor:          rt.RTNomor,
+			RTNama:           rt.RTNama,
+			TotalKeluarga:    keluarga[rt.RTID],
+			TotalWarga:       warga[rt.RTID],
+			TotalRumah:       rumah[rt.RTID],
+			RumahDitempati:   ditempati[rt.RTID],
+			TotalKegiatan:    kegiatan[rt.RTID],
+			TotalPemasukan:   pemasukan[rt.RTID],
+			TotalPengeluaran: pengeluaran[rt.RTID],
+		}
+		rekap.Saldo = rekap.TotalPemasukan - rekap.TotalPengeluaran
+		result = append(result, rekap)
+
+		total.TotalKeluarga += rekap.TotalKeluarga
+		total.TotalWarga += rekap.TotalWarga
+		total.TotalRumah += rekap.TotalRumah
+		total.RumahDitempati += rekap.RumahDitempati
+		total.TotalKegiatan += rekap.TotalKegiatan
+		total.TotalPemasukan += rekap.TotalPemasukan
+		total.TotalPengeluaran += rekap.TotalPengeluaran
+	}
+	total.Saldo = total.TotalPemasukan - total.TotalPengeluaran
+
+	c.JSON(http.StatusOK, gin.H{
+		"rw":    rw,
+		"data":  result,
+		"total": total,
+	})
+}
+
+// ✅ Helper jumlah data per RT
+func countPerRT(db *gorm.DB, model interface{}, rtIDs []uint, cond string) map[uint]int64 {
+	type row struct {
+		RTID  uint
+		Total int64
+	}
+	var rows []row
+	query := db.Model(model).Select("rt_id, COUNT(*) AS total").Where("rt_id IN ?", rtIDs)
+	if cond != "" {
+		query = query.Where(cond)
+	}
+	query.Group("rt_id").Scan(&rows)
+
+	result := make(map[uint]int64, len(rows))
+	for _, r := range rows {
+		result[r.RTID] = r.Total
+	}
+	return result
+}
+
+// ✅ Helper total nominal per RT
+func sumPerRT(db *gorm.DB, model interface{}, rtIDs []uint, column string) map[uint]float64 {
+	type row struct {
+		RTID  uint
+		Total float64
+	}
+	var rows []row
+	db.Model(model).
+		Select(fmt.Sprintf("rt_id, COALESCE(SUM(%s), 0) AS total", column)).
+		Where("rt_id IN ?", rtIDs).
+		Group("rt_id").
+		Scan(&rows)
+
+	result := make(map[uint]float64, len(rows))
+	for _, r := range rows {
+		result[r.RTID] = r.Total
+	}
+	return result
+}
+
+// ✅ Helper parse wilayah user (RT atau RW) dari form, divalidasi terhadap scope user yang login
+func parseWilayahUser(c *gin.Context, db *gorm.DB, rtIDStr, rwIDStr string) (*uint, *uint, string) {
+	rtIDStr = strings.TrimSpace(rtIDStr)
+	rwIDStr = strings.TrimSpace(rwIDStr)
+	if rtIDStr != "" && rwIDStr != "" {
+		return nil, nil, "User hanya boleh terikat ke RT atau RW, tidak keduanya"
+	}
+
+	scope, _ := utils.TenantScopeFromContext(c.Request.Context())
+
+	// User tanpa RT dan RW adalah admin global, hanya admin global yang boleh membuatnya
+	if rtIDStr == "" && rwIDStr == "" {
+		if scope.RTID != 0 {
+			return &scope.RTID, nil, ""
+		}
+		if !scope.IsGlobal() {
+			return nil, nil, "Pilih RT user atau gunakan header X-RT-ID"
+		}
+		return nil, nil, ""
+	}
+
+	if rwIDStr != "" {
+		if !scope.IsGlobal() {
+			return nil, nil, "Hanya admin global yang dapat mengatur wilayah RW"
+		}
+		id, err := strconv.ParseUint(rwIDStr, 10, 32)
+		if err != nil {
+			return nil, nil, "RW ID tidak valid"
+		}
+		var rw models.RW
+		if err := db.First(&rw, id).Error; err != nil {
+			return nil, nil, "RW tidak ditemukan"
+		}
+		return nil, &rw.RWID, ""
+	}
+
+	id, err := strconv.ParseUint(rtIDStr, 10, 32)
+	if err != nil {
+		return nil, nil, "RT ID tidak valid"
+	}
+	var rt models.RT
+	if err := db.First(&rt, id).Error; err != nil {
+		return nil, nil, "RT tidak ditemukan"
+	}
+	if (scope.RTID != 0 && scope.RTID != rt.RTID) || (scope.RWID != 0 && scope.RWID != rt.RWID) {
+		return nil, nil, "RT berada di luar wilayah anda"
+	}
+	return &rt.RTID, nil, ""
+}
+
+// ✅ Helper scope wilayah yang disimpan di token user
+func userTenantScope(user models.User) utils.TenantScope {
+	var scope utils.TenantScope
+	if user.RTID != nil {
+		scope.RTID = *user.RTID
+	} else if user.RWID != nil {
+		scope.RWID = *user.RWID
+	}
+	return scope
+}
+
+// ✅ Helper memastikan user tidak terikat wilayah (admin global)
+func requireGlobalScope(c *gin.Context) bool {
+	if scope, _ := utils.TenantScopeFromContext(c.Request.Context()); !scope.IsGlobal() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Hanya admin global yang dapat mengelola data wilayah",
+		})
+		return false
+	}
+	return true
+}
+
+// ✅ Helper memastikan data master bersama (agama, pekerjaan) hanya dihapus atau digabung
+// oleh admin global, karena perubahannya berlaku bagi warga di seluruh RT
+func requireGlobalMasterData(c *gin.Context) bool {
+	if scope, _ := utils.TenantScopeFromContext(c.Request.Context()); !scope.IsGlobal() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Hanya admin global yang dapat menghapus atau menggabungkan data master",
+		})
+		return false
+	}
+	return true
+}
+
+// requireRTScope memastikan request berjalan di satu RT (user RT, atau user RW/global dengan
+// header X-RT-ID) untuk modul yang datanya selalu milik satu RT, misalnya jadwal ronda.
+func requireRTScope(c *gin.Context) (uint, bool) {
+	scope, _ := utils.TenantScopeFromContext(c.Request.Context())
+	if scope.RTID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Pilih RT terlebih dahulu melalui header X-RT-ID",
+		})
+		return 0, false
+	}
+	return scope.RTID, true
+}
+
+// scopedDB mengembalikan koneksi database yang membawa scope wilayah user,
+// sehingga query pada model ber-rt_id otomatis difilter per RT/RW.
+func scopedDB(c *gin.Context, db *gorm.DB) *gorm.DB {
+	return db.WithContext(c.Request.Context())
+}
@@ -0,0 +1,181 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"rt-management/models"
+	"strconv"
+	"strings"
+)
+
+// Pola alamat rumah lama, mis. "Blok A No. 12, Gg. Melati"
+var (
+	polaBlokAlamat  = regexp.MustCompile(`(?i)\bblok\s*([a-z0-9]+)`)
+	polaNomorAlamat = regexp.MustCompile(`(?i)\b(?:nomor|no)\.?\s*([0-9][a-z0-9]*)`)
+)
+
+// Blok placeholder untuk rumah lama yang alamatnya tidak bisa diurai; nomornya diisi ID rumah
+// sehingga tetap unik. Pengurus perlu memperbaiki blok/nomor rumah tersebut secara manual.
+const blokRumahBelumDiisi = "-"
+
+// siapkanAlamatRumah dijalankan sebelum AutoMigrate rumah. Pada database lama kolom rumah_blok dan
+// rumah_nomor belum ada; kolom ditambahkan tanpa index lalu diisi dari rumah_alamat agar
+// unique index (rt_id, blok, nomor) dapat dibuat oleh AutoMigrate.
+func siapkanAlamatRumah() error {
+	m := DB.Migrator()
+	if !m.HasTable(&models.Rumah{}) {
+		return nil
+	}
+	for _, kolom := range []string{"RumahBlok", "RumahNomor"} {
+		if !m.HasColumn(&models.Rumah{}, kolom) {
+			if err := m.AddColumn(&models.Rumah{}, kolom); err != nil {
+				return fmt.Errorf("add column rumah %s: %v", kolom, err)
+			}
+		}
+	}
+
+	// Rumah lama belum terikat RT (rt_id belum ada atau masih kosong), sehingga
+	// keunikan blok/nomor hasil backfill dijaga untuk seluruh tabel
+	var rumah []struct {
+		RumahID     uint
+		RumahAlamat string
+		RumahBlok   string
+		RumahNomor  string
+	}
+	if err := DB.Table("rumahs").
+		Select("rumah_id, rumah_alamat, rumah_blok, rumah_nomor").
+		Order("rumah_id ASC").
+		Scan(&rumah).Error; err != nil {
+		return err
+	}
+
+	// Rumah yang sudah memiliki blok/nomor dipertahankan lebih dulu
+	terpakai := make(map[string]bool, len(rumah))
+	for _, r := range rumah {
+		if r.RumahBlok != "" && r.RumahNomor != "" {
+			terpakai[r.RumahBlok+"|"+r.RumahNomor] = true
+		}
+	}
+
+	diisi := 0
+	for _, r := range rumah {
+		if r.RumahBlok != "" && r.RumahNomor != "" {
+			continue
+		}
+
+		blok, nomor := uraiAlamatRumah(r.RumahAlamat)
+		if blok == "" || nomor == "" || terpakai[blok+"|"+nomor] {
+			blok, nomor = blokRumahBelumDiisi, strconv.FormatUint(uint64(r.RumahID), 10)
+		}
+		terpakai[blok+"|"+nomor] = true
+
+		if err := DB.Table("rumahs").Where("rumah_id = ?", r.RumahID).
+			UpdateColumns(map[string]interface{}{"rumah_blok": blok, "rumah_nomor": nomor}).Error; err != nil {
+			return err
+		}
+		diisi++
+	}
+
+	if diisi > 0 {
+		log.Printf("✓ Backfilled blok/nomor: %d rumah", diisi)
+	}
+	return nil
+}
+
+// uraiAlamatRumah mengambil blok dan nomor dari alamat bebas, dinormalisasi seperti input rumah
+func uraiAlamatRumah(alamat string) (string, string) {
+	var blok, nomor string
+	if m := polaBlokAlamat.FindStringSubmatch(alamat); m != nil {
+		blok = strings.ToUpper(m[1])
+	}
+	if m := polaNomorAlamat.FindStringSubmatch(alamat); m != nil {
+		nomor = strings.ToUpper(m[1])
+	}
+	if len(blok) > 10 || len(nomor) > 10 {
+		return "", ""
+	}
+	return blok, nomor
+}
+
+// tabelDataRT adalah tabel data warga dan keuangan yang sudah ada sebelum pemisahan per RT.
+// rt_id pada tabel ini wajib terisi agar data tampil bagi user tingkat RT.
+var tabelDataRT = []interface{}{
+	&models.Keluarga{},
+	&models.Warga{},
+	&models.Rumah{},
+	&models.HunianRumah{},
+	&models.Kegiatan{},
+	&models.Broadcast{},
+	&models.Pengeluaran{},
+	&models.Pemasukan{},
+	&models.TagihanIuran{},
+	&models.Produk{},
+}
+
+// isiRTDataLama dijalankan sekali setelah AutoMigrate pada database yang dibuat sebelum pemisahan
+// per RT, ditandai dengan tabel RT yang masih kosong padahal data warga sudah ada. RW 001 dan RT 001
+// bawaan dibuat, lalu seluruh data lama (rt_id kosong) dimasukkan ke RT tersebut. User Ketua RT dan
+// warga diikat ke RT bawaan, Ketua RW ke RW bawaan, sedangkan admin/sekretaris/bendahara tetap global.
+// Nama dan nomor wilayah bawaan dapat diubah setelahnya melalui menu wilayah.
+func isiRTDataLama() error {
+	var jumlahRT int64
+	if err := DB.Model(&models.RT{}).Count(&jumlahRT).Error; err != nil {
+		return err
+	}
+	if jumlahRT > 0 {
+		return nil
+	}
+
+	adaData := false
+	for _, t := range tabelDataRT {
+		var jumlah int64
+		if err := DB.Unscoped().Model(t).Count(&jumlah).Error; err != nil {
+			return err
+		}
+		if jumlah > 0 {
+			adaData = true
+			break
+		}
+	}
+	// Database baru: wilayah dibuat oleh seeder atau admin
+	if !adaData {
+		return nil
+	}
+
+	rw := models.RW{RWNomor: "001", RWNama: "RW 001"}
+	if err := DB.Create(&rw).Error; err != nil {
+		return fmt.Errorf("create default RW: %v", err)
+	}
+	rt := models.RT{RWID: rw.RWID, RTNomor: "001", RTNama: "RT 001"}
+	if err := DB.Create(&rt).Error; err != nil {
+		return fmt.Errorf("create default RT: %v", err)
+	}
+
+	if err := isiRTKosong(rt.RTID); err != nil {
+		return err
+	}
+	if err := DB.Model(&models.User{}).
+		Where("rt_id IS NULL AND rw_id IS NULL AND level_id IN ?", []uint{4, 6}).
+		Update("rt_id", rt.RTID).Error; err != nil {
+		return err
+	}
+	if err := DB.Model(&models.User{}).
+		Where("rt_id IS NULL AND rw_id IS NULL AND level_id = ?", 5).
+		Update("rw_id", rw.RWID).Error; err != nil {
+		return err
+	}
+
+	log.Printf("✓ Backfilled legacy data into default RT %d (RW %d)", rt.RTID, rw.RWID)
+	return nil
+}
+
+// isiRTKosong memasukkan baris tabelDataRT yang belum memiliki RT ke rtID, termasuk yang ada di trash
+func isiRTKosong(rtID uint) error {
+	for _, t := range tabelDataRT {
+		if err := DB.Unscoped().Model(t).Where("rt_id IS NULL").Update("rt_id", rtID).Error; err != nil {
+			return fmt.Errorf("backfill rt_id %T: %v", t, err)
+		}
+	}
+	return nil
+}
@@ -28,6 +28,10 @@ func InitDB(config DatabaseConfig) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to connect database: %v", err)
 	}
 
+	if err := RegisterTenantScope(db); err != nil {
+		return nil, fmt.Errorf("failed to register tenant scope: %v", err)
+	}
+
 	DB = db
 	log.Println("✅ Connected to database")
 	return db, nil
@@ -41,6 +45,8 @@ func Migrate() error {
 	DB.Exec("SET FOREIGN_KEY_CHECKS = 0")
 
 	tables := []interface{}{
+		&models.RW{},
+		&models.RT{},
 		&models.Level{},
 		&models.Agama{},
 		&models.Pekerjaan{},
@@ -52,15 +58,58 @@ func Migrate() error {
 		&models.KategoriProduk{},
 		&models.User{},
 		&models.Warga{},
+		&models.WargaMergeLog{},
 		&models.Rumah{},
+		&models.HunianRumah{},
+		&models.LaporanTamu{},
 		&models.Kegiatan{},
+		&models.KehadiranKegiatan{},
+		&models.FotoKegiatan{},
+		&models.NotulenKegiatan{},
+		&models.TindakLanjutKegiatan{},
+		&models.Fasilitas{},
+		&models.PeminjamanFasilitas{},
+		&models.JadwalRonda{},
+		&models.AnggotaRonda{},
+		&models.PetugasRonda{},
+		&models.TukarRonda{},
 		&models.Broadcast{},
+		&models.BroadcastTarget{},
+		&models.BroadcastPenerimaan{},
+		&models.BroadcastLampiran{},
+		&models.BroadcastKomentar{},
+		&models.BroadcastKomentarMention{},
+		&models.GrupPenerima{},
+		&models.GrupPenerimaAnggota{},
+		&models.NotifikasiOutbox{},
+		&models.PreferensiNotifikasi{},
+		&models.LanggananPush{},
+		&models.Voting{},
+		&models.VotingPilihan{},
+		&models.PemilihVoting{},
+		&models.SuaraVoting{},
+		&models.KategoriPengaduan{},
+		&models.Pengaduan{},
+		&models.FotoPengaduan{},
+		&models.KomentarPengaduan{},
+		&models.RiwayatStatusPengaduan{},
+		&models.PeringatanDarurat{},
+		&models.TanggapanDarurat{},
+		&models.LogDarurat{},
 		&models.MutasiKeluarga{},
 		&models.Pengeluaran{},
+		&models.Inventaris{},
+		&models.RiwayatKondisiInventaris{},
+		&models.FotoInventaris{},
 		&models.Pemasukan{},
 		&models.Produk{},
 	}
 
+	// Data lama disiapkan lebih dulu agar index baru dapat dibuat
+	if err := siapkanAlamatRumah(); err != nil {
+		return fmt.Errorf("prepare rumah alamat failed: %v", err)
+	}
+
 	for _, t := range tables {
 		if err := DB.AutoMigrate(t); err != nil {
 			return fmt.Errorf("migrate failed %T: %v", t, err)
@@ -70,6 +119,11 @@ func Migrate() error {
 
 	DB.Exec("SET FOREIGN_KEY_CHECKS = 1")
 
+	// Data dari sebelum pemisahan per RT dimasukkan ke RT bawaan (sekali saja)
+	if err := isiRTDataLama(); err != nil {
+		return fmt.Errorf("backfill legacy rt_id failed: %v", err)
+	}
+
 	log.Println("✅ Migration complete")
 	return nil
 }
@@ -84,11 +138,49 @@ func DropTables() error {
 	tables := []interface{}{
 		&models.Produk{},
 		&models.Pemasukan{},
+		&models.FotoInventaris{},
+		&models.RiwayatKondisiInventaris{},
+		&models.Inventaris{},
 		&models.Pengeluaran{},
 		&models.MutasiKeluarga{},
+		&models.LogDarurat{},
+		&models.TanggapanDarurat{},
+		&models.PeringatanDarurat{},
+		&models.RiwayatStatusPengaduan{},
+		&models.KomentarPengaduan{},
+		&models.FotoPengaduan{},
+		&models.Pengaduan{},
+		&models.KategoriPengaduan{},
+		&models.SuaraVoting{},
+		&models.PemilihVoting{},
+		&models.VotingPilihan{},
+		&models.Voting{},
+		&models.LanggananPush{},
+		&models.PreferensiNotifikasi{},
+		&models.NotifikasiOutbox{},
+		&models.GrupPenerimaAnggota{},
+		&models.GrupPenerima{},
+		&models.BroadcastKomentarMention{},
+		&models.BroadcastKomentar{},
+		&models.BroadcastLampiran{},
+		&models.BroadcastPenerimaan{},
+		&models.BroadcastTarget{},
 		&models.Broadcast{},
+		&models.TukarRonda{},
+		&models.PetugasRonda{},
+		&models.AnggotaRonda{},
+		&models.JadwalRonda{},
+		&models.PeminjamanFasilitas{},
+		&models.Fasilitas{},
+		&models.TindakLanjutKegiatan{},
+		&models.NotulenKegiatan{},
+		&models.FotoKegiatan{},
+		&models.KehadiranKegiatan{},
 		&models.Kegiatan{},
+		&models.LaporanTamu{},
+		&models.HunianRumah{},
 		&models.Rumah{},
+		&models.WargaMergeLog{},
 		&models.Warga{},
 		&models.User{},
 		&models.KategoriProduk{},
@@ -100,6 +192,8 @@ func DropTables() error {
 		&models.Agama{},
 		&models.Keluarga{},
 		&models.Level{},
+		&models.RT{},
+		&models.RW{},
 	}
 
 	for _, t := range tables {
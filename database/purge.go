@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"rt-management/helper"
+	"rt-management/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PurgeTrash menghapus permanen data yang sudah berada di trash lebih lama
+// dari retensi yang ditentukan, beserta file yang tersimpan di storage.
+func PurgeTrash(retentionDays int) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	if retentionDays < 0 {
+		return fmt.Errorf("retention days must not be negative")
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -retentionDays)
+	log.Printf("🧹 Purging trash older than %s (%d days)", cutoff.Format("2006-01-02 15:04"), retentionDays)
+
+	// Urutan penting: anak dihapus lebih dulu sebelum parent (FK RESTRICT).
+	// Baris yang masih direferensikan (mis. keluarga pada mutasi) dilewati.
+	targets := []trashTarget{
+		trash[models.Rumah]("rumah", nil),
+		trash[models.Warga]("warga", nil),
+		trash[models.Keluarga]("keluarga", nil),
+		trash[models.Kegiatan]("kegiatan", nil),
+		trash("broadcast", func(d *models.Broadcast) {
+			helper.DeleteOldPhoto(d.BroadcastFoto, "broadcast_foto")
+			helper.DeleteOldDocument(d.BroadcastDokumen, "broadcast_dokumen")
+		}),
+		trash("pengeluaran", func(d *models.Pengeluaran) {
+			helper.DeleteOldPhoto(d.PengeluaranBukti, "pengeluaran_bukti")
+		}),
+		trash("pemasukan", func(d *models.Pemasukan) {
+			helper.DeleteOldPhoto(d.PemasukanBukti, "pemasukan_bukti")
+		}),
+		trash("produk", func(d *models.Produk) {
+			helper.DeleteOldPhoto(d.ProdukFoto, "produk_foto")
+		}),
+	}
+
+	for _, t := range targets {
+		if err := t.purge(cutoff); err != nil {
+			return err
+		}
+	}
+
+	log.Println("✅ Trash purge complete")
+	return nil
+}
+
+// trashTarget adalah satu entitas soft delete yang dibersihkan oleh PurgeTrash
+type trashTarget struct {
+	purge func(cutoff time.Time) error
+}
+
+// trash mendaftarkan model T sebagai target purge. setelahHapus (boleh nil) dipanggil untuk
+// setiap baris yang berhasil dihapus permanen, mis. untuk menghapus file di storage.
+func trash[T any](nama string, setelahHapus func(*T)) trashTarget {
+	return trashTarget{
+		purge: func(cutoff time.Time) error {
+			return purgeModel(nama, cutoff, setelahHapus)
+		},
+	}
+}
+
+// purgeModel menghapus permanen baris model T yang berada di trash sebelum cutoff
+func purgeModel[T any](nama string, cutoff time.Time, setelahHapus func(*T)) error {
+	stmt := &gorm.Statement{DB: DB}
+	if err := stmt.Parse(new(T)); err != nil {
+		return fmt.Errorf("purge %s failed: %v", nama, err)
+	}
+	primary := stmt.Schema.PrioritizedPrimaryField
+
+	var data []T
+	if err := DB.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Find(&data).Error; err != nil {
+		return fmt.Errorf("purge %s failed: %v", nama, err)
+	}
+	for i := range data {
+		d := &data[i]
+		if err := DB.Unscoped().Delete(d).Error; err != nil {
+			id, _ := primary.ValueOf(context.Background(), reflect.ValueOf(d).Elem())
+			log.Printf("⚠️ Skip %s %v: %v", nama, id, err)
+			continue
+		}
+		if setelahHapus != nil {
+			setelahHapus(d)
+		}
+	}
+	log.Printf("✓ Purged: %d %s", len(data), nama)
+	return nil
+}
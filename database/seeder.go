@@ -19,6 +19,7 @@ func SeedData() error {
 	log.Println("Starting to seed data...")
 
 	seeders := []func() error{
+		seedWilayah,
 		seedLevels,
 		seedUsers,
 		seedAgama,
@@ -26,6 +27,7 @@ func SeedData() error {
 		seedKeluarga,
 		seedWarga,
 		seedRumah,
+		seedHunianRumah,
 		seedKategoriKegiatan,
 		seedKegiatan,
 		seedKategoriPengeluaran,
@@ -36,6 +38,7 @@ func SeedData() error {
 		seedKategoriProduk,
 		seedProduk,
 		seedBroadcast,
+		assignSeedRT,
 	}
 
 	for _, fn := range seeders {
@@ -63,18 +66,39 @@ func seedLevels() error {
 	return DB.Create(&data).Error
 }
 
+/* --------------------- WILAYAH ---------------------- */
+
+func seedWilayah() error {
+	rw := models.RW{RWNomor: "001", RWNama: "RW 001"}
+	if err := DB.Create(&rw).Error; err != nil {
+		return err
+	}
+
+	data := []models.RT{
+		{RWID: rw.RWID, RTNomor: "001", RTNama: "RT 001"},
+		{RWID: rw.RWID, RTNomor: "002", RTNama: "RT 002"},
+	}
+	return DB.Create(&data).Error
+}
+
+// assignSeedRT memasukkan seluruh data hasil seeder ke RT pertama
+func assignSeedRT() error {
+	return isiRTKosong(1)
+}
+
 /* --------------------- USERS ---------------------- */
 
 func seedUsers() error {
 	pass, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	rtID, rwID := uint(1), uint(1)
 
 	data := []models.User{
 		{Username: "admin", Password: string(pass), LevelID: 1},
 		{Username: "sekretaris", Password: string(pass), LevelID: 2},
 		{Username: "bendahara", Password: string(pass), LevelID: 3},
-		{Username: "pengurus_rt", Password: string(pass), LevelID: 4},
-		{Username: "pengurus_rw", Password: string(pass), LevelID: 5},
-		{Username: "warga001", Password: string(pass), LevelID: 6},
+		{Username: "pengurus_rt", Password: string(pass), LevelID: 4, RTID: &rtID},
+		{Username: "pengurus_rw", Password: string(pass), LevelID: 5, RWID: &rwID},
+		{Username: "warga001", Password: string(pass), LevelID: 6, RTID: &rtID},
 	}
 
 	return DB.Create(&data).Error
@@ -171,8 +195,15 @@ func seedRumah() error {
 	rumahs := []models.Rumah{}
 
 	for i := 1; i <= 5; i++ {
+		blok := string(rune('A' + (i-1)/3))
+		nomor := fmt.Sprintf("%d", (i-1)%3+1)
 		rumahs = append(rumahs, models.Rumah{
-			RumahAlamat: fmt.Sprintf("Jl. Contoh No.%d", i),
+			RumahAlamat: fmt.Sprintf("Blok %s No. %s, Gg. Contoh, RT 001/RW 001", blok, nomor),
+			RumahBlok:   blok,
+			RumahNomor:  nomor,
+			RumahGang:   "Gg. Contoh",
+			RumahRT:     "001",
+			RumahRW:     "001",
 			RumahStatus: "ditempati",
 			WargaID:     uint(i),
 		})
@@ -181,6 +212,37 @@ func seedRumah() error {
 	return DB.Create(&rumahs).Error
 }
 
+func seedHunianRumah() error {
+	var rumahs []models.Rumah
+	DB.Find(&rumahs)
+
+	var data []models.HunianRumah
+	for i, r := range rumahs {
+		var warga models.Warga
+		if err := DB.First(&warga, r.WargaID).Error; err != nil {
+			continue
+		}
+
+		hunian := models.HunianRumah{
+			RumahID:            r.RumahID,
+			KeluargaID:         warga.KeluargaID,
+			HunianJenis:        "pemilik",
+			HunianTanggalMulai: time.Now().AddDate(-1, 0, 0),
+		}
+		if i%2 == 1 {
+			kontrak := time.Now().AddDate(0, 0, 14+i*10)
+			hunian.HunianJenis = "penyewa"
+			hunian.HunianKontrakBerakhir = &kontrak
+		}
+		data = append(data, hunian)
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+	return DB.Create(&data).Error
+}
+
 
 
 
@@ -203,13 +265,15 @@ func seedKegiatan() error {
 
 	var data []models.Kegiatan
 	for i := 0; i < 30; i++ {
+		k := kategori[rand.Intn(len(kategori))]
 		data = append(data, models.Kegiatan{
 			KegiatanNama:       faker.Sentence(),
-			KategoriKegiatanID: kategori[rand.Intn(len(kategori))].KategoriKegiatanID,
+			KategoriKegiatanID: k.KategoriKegiatanID,
 			KegiatanTanggal:    time.Now().AddDate(0, 0, rand.Intn(30)),
 			KegiatanLokasi:     places[rand.Intn(len(places))],
 			KegiatanPJ:         faker.Name(),
 			KegiatanDeskripsi:  faker.Paragraph(),
+			KegiatanWajib:      k.KategoriKegiatanNama == "Gotong Royong",
 		})
 	}
 	return DB.Create(&data).Error
@@ -0,0 +1,171 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+	"rt-management/models"
+	"rt-management/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrOutOfScope dikembalikan saat data dibuat di luar wilayah user yang sedang login
+var ErrOutOfScope = errors.New("data berada di luar wilayah RT/RW anda")
+
+// ErrRTRequired dikembalikan saat user tingkat RW membuat data tanpa memilih RT
+var ErrRTRequired = errors.New("pilih RT terlebih dahulu melalui header X-RT-ID")
+
+// RegisterTenantScope memasang callback GORM yang otomatis memfilter setiap model
+// yang memiliki kolom rt_id sesuai scope wilayah pada context request, dan mengisi
+// rt_id saat data baru dibuat. Query tanpa scope (CLI, seeder) tidak difilter.
+func RegisterTenantScope(db *gorm.DB) error {
+	cb := db.Callback()
+
+	if err := cb.Query().Before("gorm:query").Register("tenant:query", applyTenantFilter); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("tenant:row", applyTenantFilter); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("tenant:update", applyTenantWriteFilter); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("tenant:delete", applyTenantWriteFilter); err != nil {
+		return err
+	}
+	return cb.Create().Before("gorm:create").Register("tenant:create", assignTenant)
+}
+
+// tenantField mengembalikan field rt_id milik model, kecuali jika rt_id adalah primary key (tabel RT sendiri)
+func tenantField(db *gorm.DB) *schema.Field {
+	if db.Statement.Schema == nil {
+		return nil
+	}
+	field := db.Statement.Schema.LookUpField("rt_id")
+	if field == nil || field.PrimaryKey {
+		return nil
+	}
+	return field
+}
+
+func applyTenantFilter(db *gorm.DB) {
+	if db.Error != nil || db.Statement.SQL.Len() > 0 {
+		return
+	}
+	scope, ok := utils.TenantScopeFromContext(db.Statement.Context)
+	if !ok || scope.IsGlobal() {
+		return
+	}
+	field := tenantField(db)
+	if field == nil {
+		return
+	}
+
+	column := clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+	var exprs []clause.Expression
+	if scope.RTID != 0 {
+		exprs = append(exprs, clause.Eq{Column: column, Value: scope.RTID})
+	}
+	if scope.RWID != 0 {
+		expr := clause.Expr{
+			SQL:  "? IN (SELECT rt_id FROM rts WHERE rw_id = ?)",
+			Vars: []interface{}{column, scope.RWID},
+		}
+		// Model yang juga menyimpan rw_id (mis. user tingkat RW) terikat langsung ke RW tanpa rt_id
+		if rwField := rwTenantField(db); rwField != nil {
+			rwColumn := clause.Column{Table: clause.CurrentTable, Name: rwField.DBName}
+			expr = clause.Expr{
+				SQL:  "(? IN (SELECT rt_id FROM rts WHERE rw_id = ?) OR (? IS NULL AND ? = ?))",
+				Vars: []interface{}{column, scope.RWID, column, rwColumn, scope.RWID},
+			}
+		}
+		exprs = append(exprs, expr)
+	}
+	db.Statement.AddClause(clause.Where{Exprs: exprs})
+}
+
+// rwTenantField mengembalikan field rw_id milik model yang bisa terikat langsung ke RW
+func rwTenantField(db *gorm.DB) *schema.Field {
+	field := db.Statement.Schema.LookUpField("rw_id")
+	if field == nil || field.PrimaryKey {
+		return nil
+	}
+	return field
+}
+
+// applyTenantWriteFilter sama dengan applyTenantFilter, tetapi tidak menambahkan kondisi
+// pada update/delete tanpa WHERE agar proteksi ErrMissingWhereClause GORM tetap berlaku.
+func applyTenantWriteFilter(db *gorm.DB) {
+	if _, ok := db.Statement.Clauses["WHERE"]; !ok && !db.AllowGlobalUpdate && !hasPrimaryKey(db) {
+		return
+	}
+	applyTenantFilter(db)
+}
+
+func hasPrimaryKey(db *gorm.DB) bool {
+	stmt := db.Statement
+	if stmt.Schema == nil || stmt.Schema.PrioritizedPrimaryField == nil {
+		return false
+	}
+	switch rv := reflect.Indirect(stmt.ReflectValue); rv.Kind() {
+	case reflect.Struct:
+		_, isZero := stmt.Schema.PrioritizedPrimaryField.ValueOf(stmt.Context, rv)
+		return !isZero
+	case reflect.Slice, reflect.Array:
+		return rv.Len() > 0
+	}
+	return false
+}
+
+func assignTenant(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	scope, ok := utils.TenantScopeFromContext(db.Statement.Context)
+	if !ok || scope.IsGlobal() {
+		return
+	}
+	field := tenantField(db)
+	if field == nil {
+		return
+	}
+
+	// User RW wajib memilih RT yang berada di wilayahnya
+	if scope.RTID == 0 {
+		db.AddError(ErrRTRequired)
+		return
+	}
+	if scope.RWID != 0 {
+		var count int64
+		db.Session(&gorm.Session{NewDB: true}).Model(&models.RT{}).
+			Where("rt_id = ? AND rw_id = ?", scope.RTID, scope.RWID).Count(&count)
+		if count == 0 {
+			db.AddError(ErrOutOfScope)
+			return
+		}
+	}
+
+	ctx := db.Statement.Context
+	assign := func(rv reflect.Value) {
+		value, isZero := field.ValueOf(ctx, rv)
+		if isZero {
+			rtID := scope.RTID
+			db.AddError(field.Set(ctx, rv, &rtID))
+			return
+		}
+		if id, ok := value.(*uint); ok && id != nil && *id != scope.RTID {
+			db.AddError(ErrOutOfScope)
+		}
+	}
+
+	switch rv := db.Statement.ReflectValue; rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			assign(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		assign(rv)
+	}
+}
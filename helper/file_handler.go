@@ -3,6 +3,7 @@ package helper
 import (
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -38,6 +39,12 @@ func DeleteOldPhoto(filename string, fieldName string) error {
 			fullPath = filepath.Join("storage", "images", "broadcast", filename)
 		case "foto_profile":
 			fullPath = filepath.Join("storage", "images", "profile", filename)
+		case "kegiatan_foto":
+			fullPath = filepath.Join("storage", "images", "kegiatan", filename)
+		case "inventaris_foto":
+			fullPath = filepath.Join("storage", "images", "inventaris", filename)
+		case "pengaduan_foto":
+			fullPath = filepath.Join("storage", "images", "pengaduan", filename)
 		default:
 		}
 
@@ -64,6 +71,62 @@ func HandleFileImageUpload(c *gin.Context, fieldName string, oldPhotoPath string
 	}
 	defer file.Close()
 
+	filename, err := saveImageFile(file, header, fieldName)
+	if err != nil {
+		return "", err
+	}
+
+	// Hapus foto lama jika ada file baru diupload dan foto lama ada
+	if oldPhotoPath != "" {
+		DeleteOldPhoto(oldPhotoPath, fieldName)
+	}
+
+	return filename, nil
+}
+
+// Helper function untuk handle upload banyak foto sekaligus pada satu field (multipart)
+func HandleMultipleFileImageUpload(c *gin.Context, fieldName string, maxFiles int) ([]string, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+
+	headers := form.File[fieldName]
+	if len(headers) == 0 {
+		return nil, http.ErrMissingFile
+	}
+	if maxFiles > 0 && len(headers) > maxFiles {
+		return nil, fmt.Errorf("maksimal %d foto per upload", maxFiles)
+	}
+
+	filenames := make([]string, 0, len(headers))
+	for _, header := range headers {
+		filename, err := saveImageFileHeader(header, fieldName)
+		if err != nil {
+			// Hapus foto yang sudah tersimpan agar upload bersifat semua-atau-tidak-sama-sekali
+			for _, saved := range filenames {
+				DeleteOldPhoto(saved, fieldName)
+			}
+			return nil, fmt.Errorf("%s: %v", header.Filename, err)
+		}
+		filenames = append(filenames, filename)
+	}
+
+	return filenames, nil
+}
+
+func saveImageFileHeader(header *multipart.FileHeader, fieldName string) (string, error) {
+	file, err := header.Open()
+	if err != nil {
+		return "", fmt.Errorf("gagal membuka file: %v", err)
+	}
+	defer file.Close()
+
+	return saveImageFile(file, header, fieldName)
+}
+
+// Helper function untuk validasi tipe dan menyimpan satu file foto
+func saveImageFile(file multipart.File, header *multipart.FileHeader, fieldName string) (string, error) {
 	// Validasi tipe file
 	allowedTypes := map[string]bool{
 		"image/jpeg": true,
@@ -74,7 +137,7 @@ func HandleFileImageUpload(c *gin.Context, fieldName string, oldPhotoPath string
 	}
 
 	buffer := make([]byte, 512)
-	_, err = file.Read(buffer)
+	_, err := file.Read(buffer)
 	if err != nil {
 		return "", fmt.Errorf("gagal membaca file: %v", err)
 	}
@@ -109,6 +172,15 @@ func HandleFileImageUpload(c *gin.Context, fieldName string, oldPhotoPath string
 	case "foto_profile":
 		storageDir = "storage/images/profile"
 		filePrefix = "profile"
+	case "kegiatan_foto":
+		storageDir = "storage/images/kegiatan"
+		filePrefix = "kegiatan"
+	case "inventaris_foto":
+		storageDir = "storage/images/inventaris"
+		filePrefix = "inventaris"
+	case "pengaduan_foto":
+		storageDir = "storage/images/pengaduan"
+		filePrefix = "pengaduan"
 	default:
 		storageDir = "storage/images/default"
 		filePrefix = "default"
@@ -139,11 +211,6 @@ func HandleFileImageUpload(c *gin.Context, fieldName string, oldPhotoPath string
 		return "", fmt.Errorf("gagal menyimpan file: %v", err)
 	}
 
-	// Hapus foto lama jika ada file baru diupload dan foto lama ada
-	if oldPhotoPath != "" {
-		DeleteOldPhoto(oldPhotoPath, fieldName)
-	}
-
 	return filename, nil
 }
 
@@ -155,6 +222,10 @@ func DeleteOldDocument(filename string, fieldName string) error {
 		switch fieldName {
 		case "broadcast_dokumen":
 			fullPath = filepath.Join("storage", "dokumen", "broadcast", filename)
+		case "notulen_dokumen":
+			fullPath = filepath.Join("storage", "dokumen", "notulen", filename)
+		case "broadcast_lampiran", "broadcast_lampiran_pratinjau":
+			fullPath = filepath.Join(lampiranStorageDir(fieldName), filename)
 		default:
 			fullPath = filepath.Join("storage", "dokumen", filename)
 		}
@@ -220,6 +291,9 @@ func HandleFileDokumenUpload(c *gin.Context, fieldName string, oldDocumentPath s
 	case "broadcast_dokumen":
 		storageDir = "storage/dokumen/broadcast"
 		filePrefix = "broadcast"
+	case "notulen_dokumen":
+		storageDir = "storage/dokumen/notulen"
+		filePrefix = "notulen"
 	default:
 		storageDir = "storage/dokumen/umum"
 		filePrefix = "dokumen"
@@ -353,6 +427,16 @@ func GetFileByFileName(fieldName string, fileName string) (*os.File, error) {
 		storageDir = "storage/dokumen/pemasukan"
 	case "foto_profile":
 		storageDir = "storage/images/profile"
+	case "kegiatan_foto":
+		storageDir = "storage/images/kegiatan"
+	case "inventaris_foto":
+		storageDir = "storage/images/inventaris"
+	case "pengaduan_foto":
+		storageDir = "storage/images/pengaduan"
+	case "notulen_dokumen":
+		storageDir = "storage/dokumen/notulen"
+	case "broadcast_lampiran", "broadcast_lampiran_pratinjau":
+		storageDir = lampiranStorageDir(fieldName)
 	default:
 		storageDir = "storage/images/default"
 	}
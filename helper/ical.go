@@ -0,0 +1,147 @@
+package helper
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format waktu UTC iCalendar
+const icalTimeLayout = "20060102T150405Z"
+
+// Jadwal kegiatan ditulis dalam waktu lokal WIB dengan TZID, karena BYDAY/BYMONTHDAY
+// pada RRULE dihitung dari hari lokal. Waktu UTC bisa menggeser hari kegiatan pagi.
+const (
+	icalTZID        = "Asia/Jakarta"
+	icalLocalLayout = "20060102T150405"
+)
+
+var icalZona = loadICalZona()
+
+func loadICalZona() *time.Location {
+	if loc, err := time.LoadLocation(icalTZID); err == nil {
+		return loc
+	}
+	return time.FixedZone("WIB", 7*60*60)
+}
+
+// ICalEvent adalah satu VEVENT pada feed iCalendar (RFC 5545)
+type ICalEvent struct {
+	UID          string
+	Sequence     int
+	Start        time.Time
+	End          time.Time
+	Summary      string
+	Description  string
+	Location     string
+	Categories   []string
+	RRule        string     // diisi untuk event berulang
+	RecurrenceID *time.Time // diisi untuk satu jadwal dari event berulang yang diubah/dibatalkan
+	Cancelled    bool
+	Created      time.Time
+	LastModified time.Time
+}
+
+// BuildICalendar menyusun dokumen VCALENDAR lengkap dengan baris CRLF dan pelipatan 75 oktet
+func BuildICalendar(nama string, events []ICalEvent) string {
+	var b strings.Builder
+	write := func(line string) {
+		b.WriteString(foldICalLine(line))
+		b.WriteString("\r\n")
+	}
+
+	write("BEGIN:VCALENDAR")
+	write("VERSION:2.0")
+	write("PRODID:-//RT Management//Kegiatan//ID")
+	write("CALSCALE:GREGORIAN")
+	write("METHOD:PUBLISH")
+	write("X-WR-CALNAME:" + escapeICalText(nama))
+	write("X-WR-TIMEZONE:" + icalTZID)
+
+	// WIB tidak mengenal daylight saving, cukup satu komponen STANDARD
+	write("BEGIN:VTIMEZONE")
+	write("TZID:" + icalTZID)
+	write("BEGIN:STANDARD")
+	write("DTSTART:19700101T000000")
+	write("TZOFFSETFROM:+0700")
+	write("TZOFFSETTO:+0700")
+	write("TZNAME:WIB")
+	write("END:STANDARD")
+	write("END:VTIMEZONE")
+
+	now := time.Now().UTC().Format(icalTimeLayout)
+	for _, e := range events {
+		write("BEGIN:VEVENT")
+		write("UID:" + e.UID)
+		write("DTSTAMP:" + now)
+		write("SEQUENCE:" + strconv.Itoa(e.Sequence))
+		if e.RecurrenceID != nil {
+			write("RECURRENCE-ID;" + icalWaktuLokal(*e.RecurrenceID))
+		}
+		write("DTSTART;" + icalWaktuLokal(e.Start))
+		write("DTEND;" + icalWaktuLokal(e.End))
+		if e.RRule != "" {
+			write("RRULE:" + e.RRule)
+		}
+		write("SUMMARY:" + escapeICalText(e.Summary))
+		if e.Location != "" {
+			write("LOCATION:" + escapeICalText(e.Location))
+		}
+		if e.Description != "" {
+			write("DESCRIPTION:" + escapeICalText(e.Description))
+		}
+		if len(e.Categories) > 0 {
+			categories := make([]string, 0, len(e.Categories))
+			for _, c := range e.Categories {
+				categories = append(categories, escapeICalText(c))
+			}
+			write("CATEGORIES:" + strings.Join(categories, ","))
+		}
+		if e.Cancelled {
+			write("STATUS:CANCELLED")
+		} else {
+			write("STATUS:CONFIRMED")
+		}
+		if !e.Created.IsZero() {
+			write("CREATED:" + e.Created.UTC().Format(icalTimeLayout))
+		}
+		if !e.LastModified.IsZero() {
+			write("LAST-MODIFIED:" + e.LastModified.UTC().Format(icalTimeLayout))
+		}
+		write("END:VEVENT")
+	}
+
+	write("END:VCALENDAR")
+	return b.String()
+}
+
+// icalWaktuLokal menghasilkan nilai "TZID=Asia/Jakarta:20060102T150405" untuk properti tanggal
+func icalWaktuLokal(t time.Time) string {
+	return "TZID=" + icalTZID + ":" + t.In(icalZona).Format(icalLocalLayout)
+}
+
+func escapeICalText(value string) string {
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	return replacer.Replace(value)
+}
+
+// foldICalLine memecah baris lebih dari 75 oktet tanpa memotong karakter UTF-8
+func foldICalLine(line string) string {
+	if len(line) <= 75 {
+		return line
+	}
+
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
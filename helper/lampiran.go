@@ -0,0 +1,363 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "image/gif" // decoder GIF untuk thumbnail
+	_ "image/png" // decoder PNG untuk thumbnail
+
+	"github.com/gin-gonic/gin"
+)
+
+// Ukuran sisi terpanjang gambar pratinjau (thumbnail foto dan halaman pertama PDF)
+const UkuranPratinjau = 480
+
+// Gambar yang lebih besar dari ini tidak dibuatkan thumbnail agar decode tidak menghabiskan memori
+const maksPikselPratinjau = 40_000_000
+
+// ErrPratinjauTidakDidukung dikembalikan jika file tidak dapat dibuatkan pratinjau,
+// misalnya WebP atau PDF saat pdftoppm tidak terpasang di server
+var ErrPratinjauTidakDidukung = errors.New("pratinjau tidak didukung untuk file ini")
+
+// LampiranUpload adalah satu file lampiran yang sudah tersimpan di storage
+type LampiranUpload struct {
+	Filename    string
+	NamaAsli    string
+	Ukuran      int64
+	ContentType string
+	Jenis       string // foto atau dokumen
+}
+
+var tipeLampiranFoto = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+var tipeLampiranDokumen = map[string]bool{
+	"application/pdf":    true,
+	"application/msword": true,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": true,
+	"application/vnd.ms-excel": true,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         true,
+	"application/vnd.ms-powerpoint":                                             true,
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": true,
+	"text/plain":                   true,
+	"text/csv":                     true,
+	"application/zip":              true,
+	"application/x-rar-compressed": true,
+}
+
+// Signature Compound File Binary (OLE2) yang dipakai format Office lama (DOC, XLS, PPT)
+var signatureOLE = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// tipeLampiranEkstensi memperjelas hasil DetectContentType yang terlalu umum berdasarkan ekstensi:
+// Office lama terbaca octet-stream, Office Open XML terbaca zip, dan CSV terbaca text/plain
+var tipeLampiranEkstensi = map[string]map[string]string{
+	"application/octet-stream": {
+		".doc": "application/msword",
+		".xls": "application/vnd.ms-excel",
+		".ppt": "application/vnd.ms-powerpoint",
+	},
+	"application/zip": {
+		".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	},
+	"text/plain": {
+		".csv": "text/csv",
+	},
+}
+
+// tipeLampiran menentukan content type lampiran dari isi file, diperjelas dengan ekstensi
+// hanya jika isi file cocok dengan format ekstensi tersebut
+func tipeLampiran(buffer []byte, filename string) string {
+	// DetectContentType mengembalikan parameter charset untuk teks, cukup tipe utamanya
+	contentType := strings.Split(http.DetectContentType(buffer), ";")[0]
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	tipe, ok := tipeLampiranEkstensi[contentType][ext]
+	if !ok {
+		return contentType
+	}
+	if contentType == "application/octet-stream" && !bytes.HasPrefix(buffer, signatureOLE) {
+		return contentType
+	}
+	return tipe
+}
+
+// Helper function untuk handle upload banyak lampiran (foto maupun dokumen) pada satu field
+func HandleMultipleLampiranUpload(c *gin.Context, fieldName string, maxFiles int) ([]LampiranUpload, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+
+	headers := form.File[fieldName]
+	if len(headers) == 0 {
+		return nil, http.ErrMissingFile
+	}
+	if maxFiles > 0 && len(headers) > maxFiles {
+		return nil, fmt.Errorf("maksimal %d lampiran per upload", maxFiles)
+	}
+
+	uploads := make([]LampiranUpload, 0, len(headers))
+	for _, header := range headers {
+		upload, err := saveLampiranFile(header, fieldName)
+		if err != nil {
+			// Hapus lampiran yang sudah tersimpan agar upload bersifat semua-atau-tidak-sama-sekali
+			for _, saved := range uploads {
+				DeleteOldDocument(saved.Filename, fieldName)
+			}
+			return nil, fmt.Errorf("%s: %v", header.Filename, err)
+		}
+		uploads = append(uploads, upload)
+	}
+
+	return uploads, nil
+}
+
+// Helper function untuk validasi tipe dan menyimpan satu file lampiran
+func saveLampiranFile(header *multipart.FileHeader, fieldName string) (LampiranUpload, error) {
+	var upload LampiranUpload
+
+	file, err := header.Open()
+	if err != nil {
+		return upload, fmt.Errorf("gagal membuka file: %v", err)
+	}
+	defer file.Close()
+
+	buffer := make([]byte, 512)
+	n, err := file.Read(buffer)
+	if err != nil && err != io.EOF {
+		return upload, fmt.Errorf("gagal membaca file: %v", err)
+	}
+
+	contentType := tipeLampiran(buffer[:n], header.Filename)
+	switch {
+	case tipeLampiranFoto[contentType]:
+		upload.Jenis = "foto"
+	case tipeLampiranDokumen[contentType]:
+		upload.Jenis = "dokumen"
+	default:
+		return upload, fmt.Errorf("tipe file tidak diizinkan. Hanya foto (JPEG, PNG, GIF, WebP) dan dokumen (PDF, DOC, DOCX, XLS, XLSX, PPT, PPTX, TXT, CSV, ZIP, RAR)")
+	}
+
+	if _, err := file.Seek(0, 0); err != nil {
+		return upload, fmt.Errorf("gagal reset file pointer: %v", err)
+	}
+
+	storageDir := lampiranStorageDir(fieldName)
+	if err := EnsureDirectory(storageDir); err != nil {
+		return upload, fmt.Errorf("gagal membuat direktori: %v", err)
+	}
+
+	// Buat nama file unik dengan ekstensi asli
+	ext := strings.ToLower(filepath.Ext(header.Filename))
+	timestamp := time.Now().Format("20060102150405")
+	randomStr := strconv.FormatInt(time.Now().UnixNano(), 10)
+	filename := fmt.Sprintf("lampiran_%s_%s%s", timestamp, randomStr[len(randomStr)-6:], ext)
+
+	out, err := os.Create(filepath.Join(storageDir, filename))
+	if err != nil {
+		return upload, fmt.Errorf("gagal membuat file: %v", err)
+	}
+	defer out.Close()
+
+	ukuran, err := io.Copy(out, file)
+	if err != nil {
+		return upload, fmt.Errorf("gagal menyimpan file: %v", err)
+	}
+
+	upload.Filename = filename
+	upload.NamaAsli = filepath.Base(header.Filename)
+	upload.Ukuran = ukuran
+	upload.ContentType = contentType
+	return upload, nil
+}
+
+// BuatPratinjauLampiran membuat gambar pratinjau JPEG untuk lampiran: thumbnail untuk foto dan
+// halaman pertama untuk PDF. Nama file pratinjau dikembalikan, disimpan di direktori pratinjau.
+func BuatPratinjauLampiran(fieldName string, upload LampiranUpload) (string, error) {
+	src := filepath.Join(lampiranStorageDir(fieldName), upload.Filename)
+
+	pratinjauDir := lampiranStorageDir(fieldName + "_pratinjau")
+	if err := EnsureDirectory(pratinjauDir); err != nil {
+		return "", fmt.Errorf("gagal membuat direktori: %v", err)
+	}
+	nama := strings.TrimSuffix(upload.Filename, filepath.Ext(upload.Filename)) + "_pratinjau.jpg"
+	dst := filepath.Join(pratinjauDir, nama)
+
+	var err error
+	switch {
+	case upload.Jenis == "foto":
+		err = buatThumbnail(src, dst, UkuranPratinjau)
+	case upload.ContentType == "application/pdf":
+		err = buatPratinjauPDF(src, dst, UkuranPratinjau)
+	default:
+		err = ErrPratinjauTidakDidukung
+	}
+	if err != nil {
+		os.Remove(dst)
+		return "", err
+	}
+	return nama, nil
+}
+
+// buatThumbnail memperkecil foto (JPEG, PNG, GIF) dengan rata-rata area sehingga sisi terpanjang
+// paling besar maksSisi piksel, lalu menyimpannya sebagai JPEG
+func buatThumbnail(src, dst string, maksSisi int) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return ErrPratinjauTidakDidukung
+	}
+	if config.Width*config.Height > maksPikselPratinjau {
+		return fmt.Errorf("gambar terlalu besar untuk dibuatkan pratinjau")
+	}
+	if _, err := file.Seek(0, 0); err != nil {
+		return err
+	}
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return ErrPratinjauTidakDidukung
+	}
+
+	return simpanJPEG(dst, perkecilGambar(img, maksSisi))
+}
+
+// perkecilGambar mengubah ukuran gambar dengan box filter; gambar yang sudah kecil hanya diratakan
+// ke latar putih (JPEG tidak mendukung transparansi)
+func perkecilGambar(img image.Image, maksSisi int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= 0 || h <= 0 {
+		return img
+	}
+
+	tw, th := w, h
+	if w > maksSisi || h > maksSisi {
+		if w >= h {
+			tw, th = maksSisi, h*maksSisi/w
+		} else {
+			tw, th = w*maksSisi/h, maksSisi
+		}
+		if tw < 1 {
+			tw = 1
+		}
+		if th < 1 {
+			th = 1
+		}
+	}
+
+	hasil := image.NewRGBA(image.Rect(0, 0, tw, th))
+	draw.Draw(hasil, hasil.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	for y := 0; y < th; y++ {
+		y0 := b.Min.Y + y*h/th
+		y1 := b.Min.Y + (y+1)*h/th
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < tw; x++ {
+			x0 := b.Min.X + x*w/tw
+			x1 := b.Min.X + (x+1)*w/tw
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r += uint64(pr)
+					g += uint64(pg)
+					bl += uint64(pb)
+					a += uint64(pa)
+					n++
+				}
+			}
+
+			// Warna premultiplied alpha dipadukan dengan latar putih
+			alpha := a / n
+			hasil.Set(x, y, color.RGBA64{
+				R: uint16(r/n + 0xffff - alpha),
+				G: uint16(g/n + 0xffff - alpha),
+				B: uint16(bl/n + 0xffff - alpha),
+				A: 0xffff,
+			})
+		}
+	}
+	return hasil
+}
+
+// buatPratinjauPDF merender halaman pertama PDF memakai pdftoppm (poppler-utils) jika tersedia
+func buatPratinjauPDF(src, dst string, maksSisi int) error {
+	bin, err := exec.LookPath("pdftoppm")
+	if err != nil {
+		return ErrPratinjauTidakDidukung
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	// -singlefile menulis tepat <prefix>.jpg tanpa nomor halaman
+	prefix := strings.TrimSuffix(dst, filepath.Ext(dst))
+	cmd := exec.CommandContext(ctx, bin,
+		"-jpeg", "-f", "1", "-l", "1", "-singlefile",
+		"-scale-to", strconv.Itoa(maksSisi),
+		src, prefix,
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("gagal merender PDF: %v %s", err, strings.TrimSpace(string(output)))
+	}
+	if prefix+".jpg" != dst {
+		return os.Rename(prefix+".jpg", dst)
+	}
+	return nil
+}
+
+func simpanJPEG(dst string, img image.Image) error {
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return jpeg.Encode(out, img, &jpeg.Options{Quality: 80})
+}
+
+// Direktori penyimpanan lampiran dan pratinjaunya berdasarkan fieldName upload
+func lampiranStorageDir(fieldName string) string {
+	switch fieldName {
+	case "broadcast_lampiran":
+		return "storage/lampiran/broadcast"
+	case "broadcast_lampiran_pratinjau":
+		return "storage/lampiran/broadcast/pratinjau"
+	default:
+		return "storage/lampiran/umum"
+	}
+}
@@ -0,0 +1,117 @@
+package helper
+
+import (
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Tag yang boleh dipakai pada isi rich-text (notulen, dsb.) beserta atribut yang diizinkan
+var richTextTags = map[string][]string{
+	"p": nil, "br": nil, "hr": nil, "div": nil, "span": nil,
+	"b": nil, "strong": nil, "i": nil, "em": nil, "u": nil, "s": nil, "sub": nil, "sup": nil,
+	"h1": nil, "h2": nil, "h3": nil, "h4": nil,
+	"ul": nil, "ol": nil, "li": nil, "blockquote": nil, "pre": nil, "code": nil,
+	"table": nil, "thead": nil, "tbody": nil, "tr": nil, "th": {"colspan", "rowspan"}, "td": {"colspan", "rowspan"},
+	"a": {"href", "title"},
+}
+
+// Isi tag ini dibuang seluruhnya, bukan hanya tag pembungkusnya
+var richTextDropContent = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true, "noscript": true, "template": true,
+}
+
+// SanitizeRichText membersihkan HTML dari editor rich-text: hanya tag format dasar yang
+// dipertahankan, atribut di luar daftar dibuang, dan link hanya boleh http(s)/mailto.
+func SanitizeRichText(value string) string {
+	var sb strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(value))
+	drop := 0
+
+	for {
+		tt := tokenizer.Next()
+		if tt == html.ErrorToken {
+			if tokenizer.Err() == io.EOF {
+				return strings.TrimSpace(sb.String())
+			}
+			return ""
+		}
+
+		token := tokenizer.Token()
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if richTextDropContent[token.Data] {
+				if tt == html.StartTagToken {
+					drop++
+				}
+				continue
+			}
+			allowed, ok := richTextTags[token.Data]
+			if !ok || drop > 0 {
+				continue
+			}
+			token.Attr = filterRichTextAttr(token.Attr, allowed)
+			sb.WriteString(token.String())
+		case html.EndTagToken:
+			if richTextDropContent[token.Data] {
+				if drop > 0 {
+					drop--
+				}
+				continue
+			}
+			if _, ok := richTextTags[token.Data]; ok && drop == 0 {
+				sb.WriteString(token.String())
+			}
+		case html.TextToken:
+			if drop == 0 {
+				sb.WriteString(html.EscapeString(token.Data))
+			}
+		}
+	}
+}
+
+// RichTextToPlain mengambil teks polos dari isi rich-text, misalnya untuk pencarian atau notifikasi
+func RichTextToPlain(value string) string {
+	var sb strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(value))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return strings.Join(strings.Fields(sb.String()), " ")
+		case html.TextToken:
+			sb.Write(tokenizer.Text())
+			sb.WriteString(" ")
+		}
+	}
+}
+
+func filterRichTextAttr(attrs []html.Attribute, allowed []string) []html.Attribute {
+	result := attrs[:0]
+	for _, attr := range attrs {
+		if !containsString(allowed, attr.Key) {
+			continue
+		}
+		if attr.Key == "href" && !isSafeRichTextURL(attr.Val) {
+			continue
+		}
+		result = append(result, attr)
+	}
+	return result
+}
+
+func isSafeRichTextURL(value string) bool {
+	value = strings.ToLower(strings.TrimSpace(value))
+	return strings.HasPrefix(value, "http://") ||
+		strings.HasPrefix(value, "https://") ||
+		strings.HasPrefix(value, "mailto:")
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
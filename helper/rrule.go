@@ -0,0 +1,284 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Batas iterasi ekspansi agar aturan yang keliru tidak membuat loop panjang
+const maxRRulePeriode = 5000
+
+var hariRRule = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// RRule adalah subset aturan pengulangan iCalendar (RFC 5545) yang dipakai kegiatan rutin:
+//
+//	FREQ=WEEKLY;INTERVAL=1;BYDAY=MO,TH        setiap Senin dan Kamis
+//	FREQ=MONTHLY;BYDAY=2FR                    Jumat kedua setiap bulan
+//	FREQ=MONTHLY;BYDAY=-1SU;UNTIL=20261231    Minggu terakhir setiap bulan sampai akhir 2026
+//	FREQ=MONTHLY;BYMONTHDAY=15;COUNT=12       tanggal 15, 12 kali
+type RRule struct {
+	Freq       string // WEEKLY atau MONTHLY
+	Interval   int
+	ByDay      []time.Weekday // WEEKLY: hari dalam minggu
+	Nth        int            // MONTHLY: urutan hari ke-n (negatif dihitung dari akhir bulan)
+	NthWeekday time.Weekday
+	ByMonthDay int // MONTHLY: tanggal dalam bulan
+	Until      *time.Time
+	Count      int
+}
+
+// ParseRRule mengurai string RRULE, misalnya "FREQ=WEEKLY;BYDAY=FR;COUNT=10"
+func ParseRRule(value string) (*RRule, error) {
+	value = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(value)), "RRULE:")
+	if value == "" {
+		return nil, errors.New("aturan pengulangan kosong")
+	}
+
+	rule := &RRule{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return nil, fmt.Errorf("bagian aturan tidak valid: %q", part)
+		}
+		key, val := kv[0], kv[1]
+
+		switch key {
+		case "FREQ":
+			if val != "WEEKLY" && val != "MONTHLY" {
+				return nil, errors.New("FREQ hanya mendukung WEEKLY atau MONTHLY")
+			}
+			rule.Freq = val
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 || n > 12 {
+				return nil, errors.New("INTERVAL harus angka 1-12")
+			}
+			rule.Interval = n
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				if len(day) < 2 {
+					return nil, fmt.Errorf("BYDAY tidak valid: %q", day)
+				}
+				weekday, ok := hariRRule[day[len(day)-2:]]
+				if !ok {
+					return nil, fmt.Errorf("BYDAY tidak valid: %q", day)
+				}
+				if prefix := day[:len(day)-2]; prefix != "" {
+					n, err := strconv.Atoi(prefix)
+					if err != nil || n == 0 || n < -5 || n > 5 {
+						return nil, fmt.Errorf("urutan BYDAY tidak valid: %q", day)
+					}
+					if rule.Nth != 0 {
+						return nil, errors.New("BYDAY bulanan hanya boleh satu hari, misalnya 2FR")
+					}
+					rule.Nth = n
+					rule.NthWeekday = weekday
+					continue
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		case "BYMONTHDAY":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 || n > 31 {
+				return nil, errors.New("BYMONTHDAY harus angka 1-31")
+			}
+			rule.ByMonthDay = n
+		case "UNTIL":
+			t, err := parseUntil(val)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &t
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 || n > 1000 {
+				return nil, errors.New("COUNT harus angka 1-1000")
+			}
+			rule.Count = n
+		default:
+			return nil, fmt.Errorf("bagian aturan %s tidak didukung", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, errors.New("FREQ wajib diisi")
+	}
+	if rule.Until != nil && rule.Count > 0 {
+		return nil, errors.New("UNTIL dan COUNT tidak boleh dipakai bersamaan")
+	}
+	if rule.Freq == "WEEKLY" && (rule.Nth != 0 || rule.ByMonthDay != 0) {
+		return nil, errors.New("aturan mingguan hanya mendukung BYDAY tanpa urutan, misalnya BYDAY=MO,TH")
+	}
+	if rule.Freq == "MONTHLY" {
+		if len(rule.ByDay) > 0 {
+			return nil, errors.New("aturan bulanan memakai BYDAY dengan urutan, misalnya BYDAY=2FR atau BYDAY=-1SU")
+		}
+		if rule.Nth != 0 && rule.ByMonthDay != 0 {
+			return nil, errors.New("BYDAY dan BYMONTHDAY tidak boleh dipakai bersamaan")
+		}
+	}
+
+	sort.Slice(rule.ByDay, func(i, j int) bool {
+		return mondayIndex(rule.ByDay[i]) < mondayIndex(rule.ByDay[j])
+	})
+
+	return rule, nil
+}
+
+// String mengembalikan bentuk kanonik aturan untuk disimpan
+func (r *RRule) String() string {
+	return r.format("20060102")
+}
+
+// ICalString mengembalikan aturan untuk feed iCalendar. UNTIL ditulis sebagai waktu UTC
+// karena DTSTART pada feed memakai TZID (RFC 5545 3.3.10).
+func (r *RRule) ICalString() string {
+	return r.format("")
+}
+
+func (r *RRule) format(untilLayout string) string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, d := range r.ByDay {
+			days = append(days, kodeHari(d))
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Nth != 0 {
+		parts = append(parts, "BYDAY="+strconv.Itoa(r.Nth)+kodeHari(r.NthWeekday))
+	}
+	if r.ByMonthDay != 0 {
+		parts = append(parts, "BYMONTHDAY="+strconv.Itoa(r.ByMonthDay))
+	}
+	if r.Until != nil {
+		if untilLayout != "" {
+			parts = append(parts, "UNTIL="+r.Until.Format(untilLayout))
+		} else {
+			parts = append(parts, "UNTIL="+endOfDay(*r.Until).UTC().Format("20060102T150405Z"))
+		}
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Occurrences mengembalikan seluruh jadwal pengulangan yang dimulai dari start dan jatuh
+// dalam rentang [from, to]. Jam kegiatan mengikuti jam pada start.
+func (r *RRule) Occurrences(start, from, to time.Time) []time.Time {
+	var result []time.Time
+	count := 0
+
+	for periode := 0; periode < maxRRulePeriode; periode++ {
+		for _, t := range r.candidates(start, periode) {
+			if t.Before(start) {
+				continue
+			}
+			if t.After(to) || (r.Until != nil && t.After(endOfDay(*r.Until))) {
+				return result
+			}
+			count++
+			if r.Count > 0 && count > r.Count {
+				return result
+			}
+			if !t.Before(from) {
+				result = append(result, t)
+			}
+		}
+	}
+	return result
+}
+
+// candidates menghitung jadwal pada periode (minggu/bulan) ke-n sejak start
+func (r *RRule) candidates(start time.Time, periode int) []time.Time {
+	jam, menit, detik := start.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, jam, menit, detik, 0, start.Location())
+	}
+
+	if r.Freq == "WEEKLY" {
+		awalMinggu := start.AddDate(0, 0, -mondayIndex(start.Weekday())+periode*r.Interval*7)
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []time.Weekday{start.Weekday()}
+		}
+		result := make([]time.Time, 0, len(days))
+		for _, d := range days {
+			t := awalMinggu.AddDate(0, 0, mondayIndex(d))
+			result = append(result, at(t.Year(), t.Month(), t.Day()))
+		}
+		return result
+	}
+
+	awalBulan := time.Date(start.Year(), start.Month()+time.Month(periode*r.Interval), 1, 0, 0, 0, 0, start.Location())
+	y, m := awalBulan.Year(), awalBulan.Month()
+	jumlahHari := awalBulan.AddDate(0, 1, -1).Day()
+
+	if r.Nth != 0 {
+		var day int
+		if r.Nth > 0 {
+			offset := (int(r.NthWeekday) - int(awalBulan.Weekday()) + 7) % 7
+			day = 1 + offset + (r.Nth-1)*7
+		} else {
+			akhir := time.Date(y, m, jumlahHari, 0, 0, 0, 0, start.Location())
+			offset := (int(akhir.Weekday()) - int(r.NthWeekday) + 7) % 7
+			day = jumlahHari - offset + (r.Nth+1)*7
+		}
+		if day < 1 || day > jumlahHari {
+			return nil
+		}
+		return []time.Time{at(y, m, day)}
+	}
+
+	day := r.ByMonthDay
+	if day == 0 {
+		day = start.Day()
+	}
+	// Bulan yang tidak memiliki tanggal tersebut dilewati (sesuai RFC 5545)
+	if day > jumlahHari {
+		return nil
+	}
+	return []time.Time{at(y, m, day)}
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102", "20060102T150405Z", "20060102T150405", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("UNTIL harus berformat YYYYMMDD")
+}
+
+func mondayIndex(d time.Weekday) int {
+	return (int(d) + 6) % 7
+}
+
+func kodeHari(d time.Weekday) string {
+	for kode, weekday := range hariRRule {
+		if weekday == d {
+			return kode
+		}
+	}
+	return ""
+}
+
+func endOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, t.Location())
+}
@@ -0,0 +1,79 @@
+package helper
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Levenshtein menghitung jumlah minimum operasi edit (sisip, hapus, ganti) antara dua string
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 {
+		return len(rb)
+	}
+	if len(rb) == 0 {
+		return len(ra)
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// StringSimilarity mengembalikan kemiripan 0..1 berdasarkan jarak Levenshtein
+func StringSimilarity(a, b string) float64 {
+	maxLen := len([]rune(a))
+	if l := len([]rune(b)); l > maxLen {
+		maxLen = l
+	}
+	if maxLen == 0 {
+		return 0
+	}
+	return 1 - float64(Levenshtein(a, b))/float64(maxLen)
+}
+
+// NormalizeName menyeragamkan nama untuk perbandingan: huruf kecil, tanpa tanda baca, spasi tunggal
+func NormalizeName(name string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsSpace(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+	return strings.Join(strings.Fields(cleaned), " ")
+}
+
+// DigitsOnly membuang semua karakter selain angka, mis. untuk nomor telepon
+func DigitsOnly(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, value)
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
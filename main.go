@@ -7,8 +7,11 @@ import (
 	"rt-management/controllers"
 	"rt-management/database"
 	"rt-management/middleware"
+	"rt-management/notification"
+	"rt-management/realtime"
 	"rt-management/routes"
 	"rt-management/utils"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,8 +20,11 @@ func main() {
 
 	// FLAGS
 	migrate := flag.Bool("migrate", false, "Run database migration only")
+	upgrade := flag.Bool("upgrade", false, "Migrate an existing database without dropping tables (backfills legacy data)")
 	seed := flag.Bool("seed", false, "Run database seed only")
 	migrateSeed := flag.Bool("migrate-seed", false, "Run migration and then seed")
+	purgeTrash := flag.Bool("purge-trash", false, "Permanently delete trashed data older than -retention-days")
+	retentionDays := flag.Int("retention-days", 30, "Trash retention period in days used by -purge-trash")
 	flag.Parse()
 
 	// CONNECT DB
@@ -44,6 +50,15 @@ func main() {
 		return
 	}
 
+	// UPGRADE EXISTING DATABASE
+	if *upgrade {
+		log.Println("🔄 Upgrading database...")
+		if err := database.Migrate(); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// RUN SEED ONLY
 	if *seed {
 		log.Println("🌱 Running seeder...")
@@ -65,6 +80,15 @@ func main() {
 		return
 	}
 
+	// PURGE TRASH
+	if *purgeTrash {
+		log.Println("🧹 Purging trash...")
+		if err := database.PurgeTrash(*retentionDays); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// =============== NORMAL MODE (START API SERVER) ===============
 
 	jwtSecret := os.Getenv("JWT_SECRET")
@@ -74,6 +98,14 @@ func main() {
 
 	jwtUtils := utils.NewJWTUtils(jwtSecret)
 
+	// NOTIFIKASI (kanal dikonfigurasi lewat environment variable)
+	notifikasiService := notification.NewService(db, notification.KanalDariEnv()...)
+	notifikasiService.BaseURL = notification.BaseURLDariEnv()
+	log.Printf("🔔 Kanal notifikasi aktif: %v", notifikasiService.KanalAktif())
+
+	// REALTIME (pub/sub in-process untuk SSE)
+	realtimeHub := realtime.NewHub()
+
 	// CONTROLLERS
 	authController := controllers.NewAuthController(db, jwtUtils)
 	userController := controllers.NewUserController(db)
@@ -81,16 +113,35 @@ func main() {
 	keluargaController := controllers.NewKeluargaController(db)
 	wargaController := controllers.NewWargaController(db)
 	rumahController := controllers.NewRumahController(db)
-	kegiatanController := controllers.NewKegiatanController(db)
+	hunianRumahController := controllers.NewHunianRumahController(db)
+	laporanTamuController := controllers.NewLaporanTamuController(db)
+	wilayahController := controllers.NewWilayahController(db)
+	agamaController := controllers.NewAgamaController(db)
+	pekerjaanController := controllers.NewPekerjaanController(db)
+	kategoriKegiatanController := controllers.NewKategoriKegiatanController(db)
+	kegiatanController := controllers.NewKegiatanController(db, realtimeHub)
+	kehadiranKegiatanController := controllers.NewKehadiranKegiatanController(db)
+	dokumentasiKegiatanController := controllers.NewDokumentasiKegiatanController(db)
+	fasilitasController := controllers.NewFasilitasController(db, realtimeHub)
+	rondaController := controllers.NewRondaController(db, realtimeHub)
+	kalenderController := controllers.NewKalenderController(db)
 	mutasiKeluargaController := controllers.NewMutasiKeluargaController(db)
-	broadcastController := controllers.NewBroadcastController(db)
+	broadcastController := controllers.NewBroadcastController(db, notifikasiService, realtimeHub)
+	grupPenerimaController := controllers.NewGrupPenerimaController(db)
+	notifikasiController := controllers.NewNotifikasiController(db, notifikasiService)
+	realtimeController := controllers.NewRealtimeController(db, realtimeHub)
+	votingController := controllers.NewVotingController(db, realtimeHub)
+	kategoriPengaduanController := controllers.NewKategoriPengaduanController(db)
+	pengaduanController := controllers.NewPengaduanController(db, notifikasiService, realtimeHub)
+	daruratController := controllers.NewDaruratController(db, notifikasiService, realtimeHub)
 	kategoriPengeluaranController := controllers.NewKategoriPengeluaranController(db)
 	pengeluaranController := controllers.NewPengeluaranController(db)
+	inventarisController := controllers.NewInventarisController(db)
 	kategoriPemasukanController := controllers.NewKategoriPemasukanController(db)
-	pemasukanController := controllers.NewPemasukanController(db)
+	pemasukanController := controllers.NewPemasukanController(db, realtimeHub)
 	tagihanIuranController := controllers.NewTagihanIuranController(db)
 	kategoriProdukController := controllers.NewKategoriProdukController(db)
-	produkController := controllers.NewProdukController(db)
+	produkController := controllers.NewProdukController(db, realtimeHub)
 	profileController := controllers.NewProfileController(db)
 
 	// MIDDLEWARE
@@ -103,7 +154,7 @@ func main() {
 	r.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-RT-ID") // X-RT-ID: RT tujuan untuk user RW/global
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
 			return
@@ -118,11 +169,30 @@ func main() {
 		KeluargaController:            keluargaController,
 		WargaController:               wargaController,
 		RumahController:               rumahController,
+		HunianRumahController:         hunianRumahController,
+		LaporanTamuController:         laporanTamuController,
+		WilayahController:             wilayahController,
+		AgamaController:               agamaController,
+		PekerjaanController:           pekerjaanController,
+		KategoriKegiatanController:    kategoriKegiatanController,
 		KegiatanController:            kegiatanController,
+		KehadiranKegiatanController:   kehadiranKegiatanController,
+		DokumentasiKegiatanController: dokumentasiKegiatanController,
+		FasilitasController:           fasilitasController,
+		RondaController:               rondaController,
+		KalenderController:            kalenderController,
 		BroadcastController:           broadcastController,
+		GrupPenerimaController:        grupPenerimaController,
+		NotifikasiController:          notifikasiController,
+		RealtimeController:            realtimeController,
+		VotingController:              votingController,
+		KategoriPengaduanController:   kategoriPengaduanController,
+		PengaduanController:           pengaduanController,
+		DaruratController:             daruratController,
 		MutasiKeluargaController:      mutasiKeluargaController,
 		KategoriPengeluaranController: kategoriPengeluaranController,
 		PengeluaranController:         pengeluaranController,
+		InventarisController:          inventarisController,
 		KategoriPemasukanController:   kategoriPemasukanController,
 		PemasukanController:           pemasukanController,
 		TagihanIuranController:        tagihanIuranController,
@@ -134,6 +204,11 @@ func main() {
 
 	routes.SetupRoutes(r, routeConfig)
 
+	// BACKGROUND JOBS
+	go broadcastController.JalankanPenjadwalBroadcast(time.Minute)
+	go notifikasiService.Jalankan(30 * time.Second)
+	go controllers.NewPenjadwalPengingat(db, notifikasiService).Jalankan(15 * time.Minute)
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
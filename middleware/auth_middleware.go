@@ -3,6 +3,7 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
 	"rt-management/utils"
 	"log"
@@ -28,6 +29,13 @@ func (m *AuthMiddleware) Auth() gin.HandlerFunc {
 		}
 
 		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			// EventSource (SSE) tidak dapat mengirim header, token dikirim lewat query ?token=
+			// hanya untuk endpoint stream realtime agar token di URL tidak berlaku di endpoint lain
+			if token := c.Query("token"); token != "" && m.isStreamEndpoint(c) {
+				authHeader = "Bearer " + token
+			}
+		}
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
 			c.Abort()
@@ -58,6 +66,17 @@ func (m *AuthMiddleware) Auth() gin.HandlerFunc {
 		c.Set("userID", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("levelID", claims.LevelID)
+		c.Set("rtID", claims.RTID)
+		c.Set("rwID", claims.RWID)
+
+		// Set scope wilayah agar query database otomatis difilter per RT
+		scope, ok := tenantScopeFromClaims(c, claims)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid X-RT-ID header"})
+			c.Abort()
+			return
+		}
+		c.Request = c.Request.WithContext(utils.WithTenantScope(c.Request.Context(), scope))
 		
 		log.Printf("✅ User authenticated: %s (ID: %d, Level: %d)", 
 			claims.Username, claims.UserID, claims.LevelID)
@@ -116,12 +135,42 @@ func (m *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 			c.Set("userID", claims.UserID)
 			c.Set("username", claims.Username)
 			c.Set("levelID", claims.LevelID)
+			c.Set("rtID", claims.RTID)
+			c.Set("rwID", claims.RWID)
+			if scope, ok := tenantScopeFromClaims(c, claims); ok {
+				c.Request = c.Request.WithContext(utils.WithTenantScope(c.Request.Context(), scope))
+			}
 		}
 
 		c.Next()
 	}
 }
 
+// tenantScopeFromClaims menentukan scope wilayah user. User tingkat RW atau global
+// boleh mempersempit scope ke satu RT lewat header X-RT-ID.
+func tenantScopeFromClaims(c *gin.Context, claims *utils.JWTClaims) (utils.TenantScope, bool) {
+	scope := utils.TenantScope{RTID: claims.RTID, RWID: claims.RWID}
+
+	header := strings.TrimSpace(c.GetHeader("X-RT-ID"))
+	if header == "" || claims.RTID != 0 {
+		return scope, true
+	}
+
+	rtID, err := strconv.ParseUint(header, 10, 32)
+	if err != nil || rtID == 0 {
+		return scope, false
+	}
+	scope.RTID = uint(rtID)
+	return scope, true
+}
+
+// isStreamEndpoint check apakah request adalah koneksi EventSource ke stream realtime
+func (m *AuthMiddleware) isStreamEndpoint(c *gin.Context) bool {
+	return c.Request.Method == http.MethodGet &&
+		c.Request.URL.Path == "/api/realtime/stream" &&
+		strings.Contains(c.GetHeader("Accept"), "text/event-stream")
+}
+
 // isPublicEndpoint check apakah endpoint tidak memerlukan auth
 func (m *AuthMiddleware) isPublicEndpoint(path string) bool {
 	publicEndpoints := []string{
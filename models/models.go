@@ -2,8 +2,35 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
+/* ============================
+   WILAYAH (RW & RT)
+============================ */
+
+type RW struct {
+	RWID      uint      `gorm:"primaryKey;autoIncrement" json:"rw_id"`
+	RWNomor   string    `gorm:"unique;not null;size:3" json:"rw_nomor"`
+	RWNama    string    `gorm:"size:100" json:"rw_nama"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	RTs []RT `gorm:"foreignKey:RWID" json:"rts,omitempty"`
+}
+
+type RT struct {
+	RTID      uint      `gorm:"primaryKey;autoIncrement" json:"rt_id"`
+	RWID      uint      `gorm:"not null;uniqueIndex:idx_rt_rw_nomor" json:"rw_id"`
+	RTNomor   string    `gorm:"not null;size:3;uniqueIndex:idx_rt_rw_nomor" json:"rt_nomor"`
+	RTNama    string    `gorm:"size:100" json:"rt_nama"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	RW *RW `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"rw,omitempty"`
+}
+
 /* ============================
    LEVEL & USER
 ============================ */
@@ -25,6 +52,20 @@ type User struct {
 	LevelID     uint      `gorm:"not null" json:"level_id"`
 	Level       Level     `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"level"`
 	FotoProfile string    `gorm:"size:255" json:"foto_profile"`
+
+	// Data warga pemilik akun, dipakai untuk audiens broadcast per keluarga/blok
+	WargaID *uint  `gorm:"index" json:"warga_id"`
+	Warga   *Warga `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"warga,omitempty"`
+
+	// Token rahasia untuk URL feed kalender (.ics), karena aplikasi kalender tidak bisa mengirim JWT
+	KalenderToken *string `gorm:"size:64;uniqueIndex" json:"-"`
+
+	// Wilayah user: RT untuk pengurus/warga RT, RW untuk Ketua RW, kosong untuk admin global
+	RTID *uint `gorm:"index" json:"rt_id"`
+	RWID *uint `gorm:"index" json:"rw_id"`
+	RT   *RT   `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"rt,omitempty"`
+	RW   *RW   `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"rw,omitempty"`
+
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
@@ -37,8 +78,10 @@ type Keluarga struct {
 	KeluargaID     uint      `gorm:"primaryKey;autoIncrement" json:"keluarga_id"`
 	KeluargaNama   string    `gorm:"not null;size:100" json:"keluarga_nama"`
 	KeluargaStatus string    `gorm:"type:enum('aktif','nonaktif');default:'aktif'" json:"keluarga_status"`
+	RTID *uint `gorm:"index" json:"rt_id"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 
 	Wargas          []Warga          `gorm:"foreignKey:KeluargaID"`
 	MutasiKeluargas []MutasiKeluarga `gorm:"foreignKey:KeluargaID"`
@@ -77,6 +120,7 @@ type Warga struct {
 	WargaJenisKelamin string    `gorm:"type:enum('L','P');default:'L'" json:"warga_jenis_kelamin"`
 	WargaStatusAktif  string    `gorm:"type:enum('aktif','nonaktif');default:'aktif'" json:"warga_status_aktif"`
 	WargaStatusHidup  string    `gorm:"type:enum('hidup','meninggal');default:'hidup'" json:"warga_status_hidup"`
+	RTID *uint `gorm:"index" json:"rt_id"`
 
 	AgamaID     uint     `json:"agama_id"`
 	PekerjaanID uint     `json:"pekerjaan_id"`
@@ -87,22 +131,104 @@ type Warga struct {
 
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 
 	Rumahs []Rumah `gorm:"foreignKey:WargaID"`
 }
 
+// Catatan penggabungan data warga ganda
+type WargaMergeLog struct {
+	WargaMergeLogID   uint      `gorm:"primaryKey;autoIncrement" json:"warga_merge_log_id"`
+	WargaUtamaID      uint      `gorm:"not null;index" json:"warga_utama_id"`
+	WargaDuplikatID   uint      `gorm:"not null" json:"warga_duplikat_id"`
+	WargaDuplikatNama string    `gorm:"size:100" json:"warga_duplikat_nama"`
+	WargaDuplikatNIK  string    `gorm:"size:16" json:"warga_duplikat_nik"`
+	MergeSkor         float64   `gorm:"type:decimal(5,2)" json:"merge_skor"`
+	MergeAlasan       string    `gorm:"type:text" json:"merge_alasan"`
+	MergeSnapshot     string    `gorm:"type:text" json:"merge_snapshot"` // data warga duplikat sebelum dihapus (JSON)
+	DigabungOlehID    uint      `gorm:"not null" json:"digabung_oleh_id"`
+	RTID              *uint     `gorm:"index" json:"rt_id"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
 /* ============================
    RUMAH
 ============================ */
 
 type Rumah struct {
 	RumahID     uint      `gorm:"primaryKey;autoIncrement" json:"rumah_id"`
-	RumahAlamat string    `gorm:"not null" json:"rumah_alamat"`
-	RumahStatus string    `gorm:"type:enum('tersedia','ditempati');default:'tersedia'" json:"rumah_status"`
+	RumahAlamat string    `gorm:"not null" json:"rumah_alamat"` // alamat terformat untuk tampilan
+	RumahStatus string    `gorm:"type:enum('tersedia','ditempati');default:'tersedia'" json:"rumah_status"` // diturunkan dari hunian aktif
+	RTID        *uint     `gorm:"uniqueIndex:idx_rumah_rt_blok_nomor" json:"rt_id"`
+
+	// Alamat terstruktur, blok + nomor unik dalam satu RT
+	RumahBlok      string   `gorm:"size:10;not null;uniqueIndex:idx_rumah_rt_blok_nomor" json:"rumah_blok"`
+	RumahNomor     string   `gorm:"size:10;not null;uniqueIndex:idx_rumah_rt_blok_nomor" json:"rumah_nomor"`
+	RumahGang      string   `gorm:"size:100" json:"rumah_gang"`
+	RumahRT        string   `gorm:"size:3" json:"rumah_rt"`
+	RumahRW        string   `gorm:"size:3" json:"rumah_rw"`
+	RumahLatitude  *float64 `gorm:"type:decimal(10,7)" json:"rumah_latitude"`
+	RumahLongitude *float64 `gorm:"type:decimal(10,7)" json:"rumah_longitude"`
 
 	WargaID uint  `json:"warga_id"`
 	Warga   *Warga `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"warga"`
 
+	// Penghuni saat ini diambil dari hunian yang belum berakhir
+	HunianAktif *HunianRumah `gorm:"foreignKey:RumahID" json:"hunian_aktif"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+/* ============================
+   HUNIAN RUMAH (RIWAYAT PENGHUNI)
+============================ */
+
+type HunianRumah struct {
+	HunianRumahID         uint       `gorm:"primaryKey;autoIncrement" json:"hunian_rumah_id"`
+	RumahID               uint       `gorm:"not null;index" json:"rumah_id"`
+	KeluargaID            uint       `gorm:"not null;index" json:"keluarga_id"`
+	HunianJenis           string     `gorm:"type:enum('pemilik','penyewa');default:'pemilik'" json:"hunian_jenis"`
+	HunianTanggalMulai    time.Time  `gorm:"not null" json:"hunian_tanggal_mulai"`
+	HunianTanggalSelesai  *time.Time `json:"hunian_tanggal_selesai"`
+	HunianKontrakBerakhir *time.Time `json:"hunian_kontrak_berakhir"`
+	HunianKeterangan      string     `gorm:"type:text" json:"hunian_keterangan"`
+	RTID *uint `gorm:"index" json:"rt_id"`
+
+	Rumah    *Rumah    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"rumah,omitempty"`
+	Keluarga *Keluarga `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"keluarga,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+/* ============================
+   LAPORAN TAMU (WAJIB LAPOR 1x24 JAM)
+============================ */
+
+type LaporanTamu struct {
+	LaporanTamuID      uint       `gorm:"primaryKey;autoIncrement" json:"laporan_tamu_id"`
+	RumahID            uint       `gorm:"not null;index" json:"rumah_id"`
+	KeluargaID         uint       `gorm:"not null;index" json:"keluarga_id"`
+	TamuNama           string     `gorm:"not null;size:100" json:"tamu_nama"`
+	TamuNIK            string     `gorm:"not null;size:16;index" json:"tamu_nik"`
+	TamuJenisKelamin   string     `gorm:"type:enum('L','P');default:'L'" json:"tamu_jenis_kelamin"`
+	TamuNoTlp          string     `gorm:"size:15" json:"tamu_no_tlp"`
+	TamuAsal           string     `gorm:"not null;size:255" json:"tamu_asal"`
+	TamuHubungan       string     `gorm:"size:50" json:"tamu_hubungan"`
+	TamuTanggalDatang  time.Time  `gorm:"not null" json:"tamu_tanggal_datang"`
+	TamuRencanaPulang  time.Time  `gorm:"not null" json:"tamu_rencana_pulang"`
+	TamuTanggalPulang  *time.Time `json:"tamu_tanggal_pulang"`
+	TamuKeterangan     string     `gorm:"type:text" json:"tamu_keterangan"`
+	LaporanTerlambat   bool       `gorm:"default:false" json:"laporan_terlambat"` // dilaporkan lewat dari 1x24 jam
+	DilaporkanOlehID   uint       `gorm:"not null;index" json:"dilaporkan_oleh_id"`
+	RTID *uint `gorm:"index" json:"rt_id"`
+
+	Rumah          *Rumah    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"rumah,omitempty"`
+	Keluarga       *Keluarga `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"keluarga,omitempty"`
+	DilaporkanOleh *User     `gorm:"foreignKey:DilaporkanOlehID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"dilaporkan_oleh,omitempty"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -133,14 +259,298 @@ type Kegiatan struct {
     KegiatanLokasi     string    `json:"kegiatan_lokasi"`
     KegiatanPJ         string    `gorm:"size:100" json:"kegiatan_pj"`
     KegiatanDeskripsi  string    `gorm:"type:text" json:"kegiatan_deskripsi"`
+    KegiatanWajib      bool      `gorm:"default:false" json:"kegiatan_wajib"` // contoh: kerja bakti wajib tiap keluarga
+    KegiatanQRToken    string    `gorm:"size:64;index" json:"-"`              // token absensi via scan QR
+
+    // Kegiatan rutin: seri menyimpan aturan RRULE, perubahan/pembatalan satu jadwal
+    // disimpan sebagai kegiatan turunan yang menunjuk ke seri dan tanggal aslinya
+    KegiatanRRule       string     `gorm:"size:255" json:"kegiatan_rrule"`
+    KegiatanIndukID     *uint      `gorm:"index" json:"kegiatan_induk_id"`
+    KegiatanTanggalAsli *time.Time `json:"kegiatan_tanggal_asli"`
+    KegiatanDibatalkan  bool       `gorm:"default:false" json:"kegiatan_dibatalkan"`
+    KegiatanSequence    int        `gorm:"default:0" json:"kegiatan_sequence"` // naik setiap perubahan, dipakai SEQUENCE iCalendar
+    RTID *uint `gorm:"index" json:"rt_id"`
 
     // Relasi ke parent kategori
     KategoriKegiatan   KategoriKegiatan `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"kategori_kegiatan"`
 
     CreatedAt time.Time `json:"created_at"`
     UpdatedAt time.Time `json:"updated_at"`
+    DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+
+/* ============================
+   KEHADIRAN KEGIATAN (RSVP & ABSENSI)
+============================ */
+
+// KehadiranKegiatan mencatat RSVP dan absensi satu keluarga pada satu kegiatan
+type KehadiranKegiatan struct {
+	KehadiranKegiatanID uint       `gorm:"primaryKey;autoIncrement" json:"kehadiran_kegiatan_id"`
+	KegiatanID          uint       `gorm:"not null;uniqueIndex:idx_kehadiran_kegiatan_keluarga" json:"kegiatan_id"`
+	KeluargaID          uint       `gorm:"not null;uniqueIndex:idx_kehadiran_kegiatan_keluarga;index" json:"keluarga_id"`
+	WargaID             *uint      `gorm:"index" json:"warga_id"` // warga yang mewakili keluarga
+	RSVPStatus          string     `gorm:"size:10" json:"rsvp_status"` // hadir, tidak, mungkin (kosong = belum RSVP)
+	RSVPWaktu           *time.Time `json:"rsvp_waktu"`
+	Hadir               bool       `gorm:"default:false" json:"hadir"`
+	JumlahHadir         int        `gorm:"default:0" json:"jumlah_hadir"`
+	WaktuHadir          *time.Time `json:"waktu_hadir"`
+	MetodeAbsen         string     `gorm:"size:10" json:"metode_absen"` // manual, qr
+	Keterangan          string     `gorm:"size:255" json:"keterangan"`
+	DicatatOlehID       *uint      `gorm:"index" json:"dicatat_oleh_id"`
+	RTID *uint `gorm:"index" json:"rt_id"`
+
+	Kegiatan *Kegiatan `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"kegiatan,omitempty"`
+	Keluarga *Keluarga `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"keluarga,omitempty"`
+	Warga    *Warga    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"warga,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+/* ============================
+   DOKUMENTASI KEGIATAN (GALERI & NOTULEN)
+============================ */
+
+// FotoKegiatan adalah satu foto dokumentasi pada galeri kegiatan
+type FotoKegiatan struct {
+	FotoKegiatanID uint   `gorm:"primaryKey;autoIncrement" json:"foto_kegiatan_id"`
+	KegiatanID     uint   `gorm:"not null;index" json:"kegiatan_id"`
+	FotoFile       string `gorm:"not null;size:255" json:"foto_file"`
+	Keterangan     string `gorm:"size:255" json:"keterangan"`
+	DiunggahOlehID *uint  `json:"diunggah_oleh_id"`
+	RTID *uint `gorm:"index" json:"rt_id"`
+
+	Kegiatan *Kegiatan `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"kegiatan,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NotulenKegiatan adalah notulen rapat/kegiatan, satu notulen untuk setiap kegiatan
+type NotulenKegiatan struct {
+	NotulenKegiatanID uint   `gorm:"primaryKey;autoIncrement" json:"notulen_kegiatan_id"`
+	KegiatanID        uint   `gorm:"not null;uniqueIndex" json:"kegiatan_id"`
+	NotulenIsi        string `gorm:"type:longtext" json:"notulen_isi"`      // HTML rich-text yang sudah disanitasi
+	NotulenKeputusan  string `gorm:"type:text" json:"notulen_keputusan"`    // HTML rich-text yang sudah disanitasi
+	NotulenPeserta    string `gorm:"type:text" json:"notulen_peserta"`      // daftar hadir singkat, bebas
+	NotulenDokumen    string `gorm:"size:255" json:"notulen_dokumen"`       // file notulen (PDF/DOC) opsional
+	DitulisOlehID     *uint  `json:"ditulis_oleh_id"`
+	RTID *uint `gorm:"index" json:"rt_id"`
+
+	Kegiatan     *Kegiatan              `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"kegiatan,omitempty"`
+	TindakLanjut []TindakLanjutKegiatan `gorm:"foreignKey:NotulenKegiatanID" json:"tindak_lanjut,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TindakLanjutKegiatan adalah action item hasil notulen dengan penanggung jawab dan tenggat
+type TindakLanjutKegiatan struct {
+	TindakLanjutKegiatanID uint       `gorm:"primaryKey;autoIncrement" json:"tindak_lanjut_kegiatan_id"`
+	NotulenKegiatanID      uint       `gorm:"not null;index" json:"notulen_kegiatan_id"`
+	KegiatanID             uint       `gorm:"not null;index" json:"kegiatan_id"`
+	Deskripsi              string     `gorm:"not null;size:255" json:"deskripsi"`
+	PenanggungJawabID      *uint      `gorm:"index" json:"penanggung_jawab_id"` // warga yang ditugaskan
+	Tenggat                *time.Time `gorm:"type:date;index" json:"tenggat"`
+	Status                 string     `gorm:"type:enum('terbuka','proses','selesai','batal');default:'terbuka';index" json:"status"`
+	Catatan                string     `gorm:"size:255" json:"catatan"`
+	SelesaiPada            *time.Time `json:"selesai_pada"`
+	RTID *uint `gorm:"index" json:"rt_id"`
+
+	NotulenKegiatan *NotulenKegiatan `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Kegiatan        *Kegiatan        `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"kegiatan,omitempty"`
+	PenanggungJawab *Warga           `gorm:"foreignKey:PenanggungJawabID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"penanggung_jawab,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+/* ============================
+   FASILITAS & PEMINJAMAN
+============================ */
+
+// Fasilitas adalah sarana milik RT yang dapat dipinjam warga (balai warga, tenda, kursi, sound system)
+type Fasilitas struct {
+	FasilitasID          uint    `gorm:"primaryKey;autoIncrement" json:"fasilitas_id"`
+	FasilitasNama        string  `gorm:"not null;size:100" json:"fasilitas_nama"`
+	FasilitasJenis       string  `gorm:"type:enum('ruangan','peralatan');default:'peralatan'" json:"fasilitas_jenis"`
+	FasilitasLokasi      string  `gorm:"size:100" json:"fasilitas_lokasi"`          // dicocokkan dengan lokasi kegiatan untuk deteksi bentrok
+	FasilitasJumlah      int     `gorm:"not null;default:1" json:"fasilitas_jumlah"` // unit yang dapat dipinjam bersamaan
+	FasilitasTarif       float64 `gorm:"type:decimal(15,2);default:0" json:"fasilitas_tarif"` // per unit per satuan tarif, 0 = gratis
+	FasilitasSatuanTarif string  `gorm:"type:enum('peminjaman','hari','jam');default:'peminjaman'" json:"fasilitas_satuan_tarif"`
+	FasilitasDeskripsi   string  `gorm:"type:text" json:"fasilitas_deskripsi"`
+	FasilitasAktif       bool    `gorm:"default:true" json:"fasilitas_aktif"`
+	RTID *uint `gorm:"index" json:"rt_id"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 }
 
+// PeminjamanFasilitas adalah pengajuan pemakaian fasilitas oleh warga pada rentang waktu tertentu
+type PeminjamanFasilitas struct {
+	PeminjamanFasilitasID uint       `gorm:"primaryKey;autoIncrement" json:"peminjaman_fasilitas_id"`
+	FasilitasID           uint       `gorm:"not null;index" json:"fasilitas_id"`
+	WargaID               uint       `gorm:"not null;index" json:"warga_id"` // warga peminjam
+	Keperluan             string     `gorm:"not null;size:255" json:"keperluan"`
+	WaktuMulai            time.Time  `gorm:"not null;index" json:"waktu_mulai"`
+	WaktuSelesai          time.Time  `gorm:"not null;index" json:"waktu_selesai"`
+	Jumlah                int        `gorm:"not null;default:1" json:"jumlah"`
+	Status                string     `gorm:"type:enum('menunggu','disetujui','ditolak','dibatalkan');default:'menunggu';index" json:"status"`
+	Biaya                 float64    `gorm:"type:decimal(15,2);default:0" json:"biaya"`
+	BiayaLunas            bool       `gorm:"default:false" json:"biaya_lunas"`
+	PemasukanID           *uint      `gorm:"index" json:"pemasukan_id"` // pemasukan kas saat biaya sewa dibayar
+	DiajukanOlehID        uint       `gorm:"not null;index" json:"diajukan_oleh_id"`
+	DiprosesOlehID        *uint      `json:"diproses_oleh_id"`
+	DiprosesPada          *time.Time `json:"diproses_pada"`
+	CatatanPengurus       string     `gorm:"size:255" json:"catatan_pengurus"`
+	RTID *uint `gorm:"index" json:"rt_id"`
+
+	Fasilitas *Fasilitas `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"fasilitas,omitempty"`
+	Warga     *Warga     `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"warga,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+/* ============================
+   INVENTARIS (ASET RT)
+============================ */
+
+// Inventaris adalah aset milik RT beserta data perolehan untuk perhitungan penyusutan garis lurus
+type Inventaris struct {
+	InventarisID       uint      `gorm:"primaryKey;autoIncrement" json:"inventaris_id"`
+	InventarisKode     string    `gorm:"not null;size:30;uniqueIndex:idx_inventaris_kode_rt" json:"inventaris_kode"`
+	InventarisNama     string    `gorm:"not null;size:100" json:"inventaris_nama"`
+	InventarisKategori string    `gorm:"size:50;index" json:"inventaris_kategori"` // contoh: elektronik, kebersihan, keamanan
+	TanggalPerolehan   time.Time `gorm:"type:date;not null" json:"tanggal_perolehan"`
+	NilaiPerolehan     float64   `gorm:"type:decimal(15,2);not null" json:"nilai_perolehan"`
+	NilaiResidu        float64   `gorm:"type:decimal(15,2);default:0" json:"nilai_residu"`
+	UmurEkonomis       int       `gorm:"default:0" json:"umur_ekonomis"` // dalam tahun, 0 = tidak disusutkan
+	PengeluaranID      *uint     `gorm:"index" json:"pengeluaran_id"`   // pengeluaran kas saat aset dibeli
+	Lokasi             string    `gorm:"size:100" json:"lokasi"`
+	PemegangID         *uint     `gorm:"index" json:"pemegang_id"` // warga yang menyimpan/bertanggung jawab
+	Kondisi            string    `gorm:"type:enum('baik','rusak_ringan','rusak_berat','hilang');default:'baik'" json:"kondisi"`
+	Keterangan         string    `gorm:"type:text" json:"keterangan"`
+	RTID *uint `gorm:"index;uniqueIndex:idx_inventaris_kode_rt" json:"rt_id"`
+
+	Pengeluaran    *Pengeluaran               `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"pengeluaran,omitempty"`
+	Pemegang       *Warga                     `gorm:"foreignKey:PemegangID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"pemegang,omitempty"`
+	Foto           []FotoInventaris           `gorm:"foreignKey:InventarisID" json:"foto,omitempty"`
+	RiwayatKondisi []RiwayatKondisiInventaris `gorm:"foreignKey:InventarisID" json:"riwayat_kondisi,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// RiwayatKondisiInventaris mencatat setiap perubahan kondisi aset
+type RiwayatKondisiInventaris struct {
+	RiwayatKondisiInventarisID uint      `gorm:"primaryKey;autoIncrement" json:"riwayat_kondisi_inventaris_id"`
+	InventarisID               uint      `gorm:"not null;index" json:"inventaris_id"`
+	Tanggal                    time.Time `gorm:"type:date;not null" json:"tanggal"`
+	KondisiSebelumnya          string    `gorm:"size:20" json:"kondisi_sebelumnya"`
+	Kondisi                    string    `gorm:"size:20;not null" json:"kondisi"`
+	Catatan                    string    `gorm:"size:255" json:"catatan"`
+	DicatatOlehID              *uint     `json:"dicatat_oleh_id"`
+	RTID *uint `gorm:"index" json:"rt_id"`
+
+	Inventaris *Inventaris `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FotoInventaris adalah foto aset (kondisi fisik, nota, label kode)
+type FotoInventaris struct {
+	FotoInventarisID uint   `gorm:"primaryKey;autoIncrement" json:"foto_inventaris_id"`
+	InventarisID     uint   `gorm:"not null;index" json:"inventaris_id"`
+	FotoFile         string `gorm:"not null;size:255" json:"foto_file"`
+	Keterangan       string `gorm:"size:255" json:"keterangan"`
+	RTID *uint `gorm:"index" json:"rt_id"`
+
+	Inventaris *Inventaris `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+/* ============================
+   RONDA (SISKAMLING)
+============================ */
+
+// JadwalRonda adalah satu periode roster ronda yang dibagi ke beberapa kelompok bergilir
+type JadwalRonda struct {
+	JadwalRondaID   uint      `gorm:"primaryKey;autoIncrement" json:"jadwal_ronda_id"`
+	JadwalRondaNama string    `gorm:"not null;size:100" json:"jadwal_ronda_nama"`
+	TanggalMulai    time.Time `gorm:"type:date;not null" json:"tanggal_mulai"`
+	TanggalSelesai  time.Time `gorm:"type:date;not null" json:"tanggal_selesai"`
+	JumlahKelompok  int       `gorm:"not null" json:"jumlah_kelompok"`
+	DendaPerAbsen   float64   `gorm:"type:decimal(15,2);default:0" json:"denda_per_absen"`
+	RTID *uint `gorm:"index" json:"rt_id"`
+
+	Anggota []AnggotaRonda `gorm:"foreignKey:JadwalRondaID" json:"anggota,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// AnggotaRonda adalah warga yang masuk ke salah satu kelompok pada sebuah jadwal ronda
+type AnggotaRonda struct {
+	AnggotaRondaID uint `gorm:"primaryKey;autoIncrement" json:"anggota_ronda_id"`
+	JadwalRondaID  uint `gorm:"not null;uniqueIndex:idx_anggota_ronda_warga" json:"jadwal_ronda_id"`
+	WargaID        uint `gorm:"not null;uniqueIndex:idx_anggota_ronda_warga;index" json:"warga_id"`
+	Kelompok       int  `gorm:"not null" json:"kelompok"`
+	RTID *uint `gorm:"index" json:"rt_id"`
+
+	Warga *Warga `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"warga,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PetugasRonda adalah satu shift ronda seorang warga pada satu malam
+type PetugasRonda struct {
+	PetugasRondaID uint       `gorm:"primaryKey;autoIncrement" json:"petugas_ronda_id"`
+	JadwalRondaID  uint       `gorm:"not null;index" json:"jadwal_ronda_id"`
+	WargaID        uint       `gorm:"not null;index" json:"warga_id"`
+	Tanggal        time.Time  `gorm:"type:date;not null;index" json:"tanggal"`
+	Kelompok       int        `gorm:"not null" json:"kelompok"`
+	Status         string     `gorm:"type:enum('terjadwal','hadir','absen','izin');default:'terjadwal'" json:"status"`
+	Denda          float64    `gorm:"type:decimal(15,2);default:0" json:"denda"`
+	DendaLunas     bool       `gorm:"default:false" json:"denda_lunas"`
+	PemasukanID    *uint      `gorm:"index" json:"pemasukan_id"` // pemasukan kas saat denda dibayar
+	Keterangan     string     `gorm:"size:255" json:"keterangan"`
+	DicatatOlehID  *uint      `json:"dicatat_oleh_id"`
+	DicatatPada    *time.Time `json:"dicatat_pada"`
+	RTID *uint `gorm:"index" json:"rt_id"`
+
+	Warga       *Warga       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"warga,omitempty"`
+	JadwalRonda *JadwalRonda `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"jadwal_ronda,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TukarRonda adalah pengajuan tukar shift antara dua warga yang harus disetujui pengurus
+type TukarRonda struct {
+	TukarRondaID         uint       `gorm:"primaryKey;autoIncrement" json:"tukar_ronda_id"`
+	PetugasRondaID       uint       `gorm:"not null;index" json:"petugas_ronda_id"`        // shift pemohon
+	PetugasRondaTujuanID uint       `gorm:"not null;index" json:"petugas_ronda_tujuan_id"` // shift warga pengganti
+	Alasan               string     `gorm:"size:255" json:"alasan"`
+	Status               string     `gorm:"type:enum('menunggu','disetujui','ditolak');default:'menunggu'" json:"status"`
+	DiajukanOlehID       uint       `gorm:"not null;index" json:"diajukan_oleh_id"`
+	DiprosesOlehID       *uint      `json:"diproses_oleh_id"`
+	DiprosesPada         *time.Time `json:"diproses_pada"`
+	CatatanPengurus      string     `gorm:"size:255" json:"catatan_pengurus"`
+	RTID *uint `gorm:"index" json:"rt_id"`
+
+	PetugasRonda       *PetugasRonda `gorm:"foreignKey:PetugasRondaID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"petugas_ronda,omitempty"`
+	PetugasRondaTujuan *PetugasRonda `gorm:"foreignKey:PetugasRondaTujuanID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"petugas_ronda_tujuan,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
 
 /* ============================
    BROADCAST
@@ -152,8 +562,445 @@ type Broadcast struct {
 	BroadcastDeskripsi string    `gorm:"type:text" json:"broadcast_deskripsi"`
 	BroadcastFoto      string    `gorm:"size:255" json:"broadcast_foto"`
 	BroadcastDokumen   string    `gorm:"size:255" json:"broadcast_dokumen"`
+	RTID *uint `gorm:"index" json:"rt_id"`
+
+	// Audiens: semua warga, atau terbatas pada target di BroadcastTarget
+	BroadcastAudiens string            `gorm:"type:enum('semua','terbatas');default:'semua'" json:"broadcast_audiens"`
+	Target           []BroadcastTarget `gorm:"foreignKey:BroadcastID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"target,omitempty"`
+
+	// Pengumuman penting yang harus dikonfirmasi (acknowledge) oleh setiap penerima
+	BroadcastWajibKonfirmasi bool `gorm:"default:false" json:"broadcast_wajib_konfirmasi"`
+
+	// Penjadwalan: draft, terjadwal (menunggu terbit_pada), terbit, arsip (lewat kedaluwarsa_pada)
+	BroadcastStatus          string     `gorm:"type:enum('draft','terjadwal','terbit','arsip');default:'terbit';index" json:"broadcast_status"`
+	BroadcastTerbitPada      *time.Time `gorm:"index" json:"broadcast_terbit_pada"`
+	BroadcastKedaluwarsaPada *time.Time `gorm:"index" json:"broadcast_kedaluwarsa_pada"`
+	BroadcastDiterbitkanPada *time.Time `json:"broadcast_diterbitkan_pada"`
+	BroadcastNotifikasiPada  *time.Time `json:"broadcast_notifikasi_pada"` // notifikasi terbit sudah dipicu
+	BroadcastDisematkan      bool       `gorm:"default:false" json:"broadcast_disematkan"`
+	BroadcastPrioritas       string     `gorm:"type:enum('normal','penting','darurat');default:'normal'" json:"broadcast_prioritas"`
+
+	// Diskusi: pembuat broadcast menerima penanda komentar baru sejak terakhir ia membuka komentar
+	BroadcastDibuatOlehID        *uint      `gorm:"index" json:"broadcast_dibuat_oleh_id"`
+	BroadcastKomentarDikunci     bool       `gorm:"default:false" json:"broadcast_komentar_dikunci"`
+	BroadcastKomentarDilihatPada *time.Time `json:"-"`
+
+	// Lampiran foto/dokumen (boleh banyak). BroadcastFoto dan BroadcastDokumen tetap dipakai broadcast lama.
+	Lampiran []BroadcastLampiran `gorm:"foreignKey:BroadcastID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"lampiran,omitempty"`
+
 	CreatedAt          time.Time `json:"created_at"`
 	UpdatedAt          time.Time `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// Satu target audiens broadcast; user menerima broadcast jika cocok dengan salah satu target
+type BroadcastTarget struct {
+	BroadcastTargetID uint   `gorm:"primaryKey;autoIncrement" json:"broadcast_target_id"`
+	BroadcastID       uint   `gorm:"not null;index" json:"broadcast_id"`
+	TargetJenis       string `gorm:"type:enum('keluarga','blok','level','grup');not null" json:"target_jenis"`
+	TargetID          *uint  `gorm:"index" json:"target_id"` // keluarga_id, level_id atau grup_penerima_id
+	TargetBlok        string `gorm:"size:10" json:"target_blok"`
+	RTID              *uint  `gorm:"index" json:"rt_id"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Status penerimaan broadcast per user: diterima saat muncul di daftar, dibaca saat detail dibuka
+type BroadcastPenerimaan struct {
+	BroadcastPenerimaanID uint       `gorm:"primaryKey;autoIncrement" json:"broadcast_penerimaan_id"`
+	BroadcastID           uint       `gorm:"not null;uniqueIndex:idx_broadcast_penerimaan_user" json:"broadcast_id"`
+	UserID                uint       `gorm:"not null;uniqueIndex:idx_broadcast_penerimaan_user;index" json:"user_id"`
+	DiterimaPada          *time.Time `json:"diterima_pada"`
+	DibacaPada            *time.Time `json:"dibaca_pada"`
+	DikonfirmasiPada      *time.Time `json:"dikonfirmasi_pada"`
+	RTID                  *uint      `gorm:"index" json:"rt_id"`
+
+	Broadcast *Broadcast `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"broadcast,omitempty"`
+	User      *User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Lampiran broadcast berurutan dengan keterangan; pratinjau berisi thumbnail foto atau halaman pertama PDF
+type BroadcastLampiran struct {
+	BroadcastLampiranID uint   `gorm:"primaryKey;autoIncrement" json:"broadcast_lampiran_id"`
+	BroadcastID         uint   `gorm:"not null;index" json:"broadcast_id"`
+	LampiranJenis       string `gorm:"type:enum('foto','dokumen');not null" json:"lampiran_jenis"`
+	LampiranFile        string `gorm:"not null;size:255" json:"lampiran_file"`
+	LampiranNamaAsli    string `gorm:"size:255" json:"lampiran_nama_asli"`
+	LampiranTipe        string `gorm:"size:100" json:"lampiran_tipe"`
+	LampiranUkuran      int64  `gorm:"not null" json:"lampiran_ukuran"`
+	LampiranKeterangan  string `gorm:"size:255" json:"lampiran_keterangan"`
+	LampiranUrutan      int    `gorm:"not null" json:"lampiran_urutan"`
+	LampiranPratinjau   string `gorm:"size:255" json:"lampiran_pratinjau"` // kosong jika pratinjau tidak tersedia
+	LampiranJumlahUnduh int64  `gorm:"not null" json:"lampiran_jumlah_unduh"`
+	DiunggahOlehID      *uint  `json:"diunggah_oleh_id"`
+	RTID                *uint  `gorm:"index" json:"rt_id"`
+
+	Broadcast *Broadcast `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"broadcast,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Komentar pada broadcast. Balasan hanya satu tingkat: IndukID selalu menunjuk komentar utama.
+type BroadcastKomentar struct {
+	BroadcastKomentarID uint       `gorm:"primaryKey;autoIncrement" json:"broadcast_komentar_id"`
+	BroadcastID         uint       `gorm:"not null;index" json:"broadcast_id"`
+	IndukID             *uint      `gorm:"index" json:"induk_id"`
+	UserID              uint       `gorm:"not null;index" json:"user_id"`
+	KomentarIsi         string     `gorm:"type:text;not null" json:"komentar_isi"`
+	KomentarDiubahPada  *time.Time `json:"komentar_diubah_pada"`
+
+	// Moderasi pengurus: komentar disembunyikan dari warga, utas dikunci sehingga tidak bisa dibalas
+	KomentarDisembunyikan  bool   `gorm:"default:false" json:"komentar_disembunyikan"`
+	KomentarAlasanSembunyi string `gorm:"size:255" json:"komentar_alasan_sembunyi,omitempty"`
+	DisembunyikanOlehID    *uint  `json:"disembunyikan_oleh_id,omitempty"`
+	KomentarDikunci        bool   `gorm:"default:false" json:"komentar_dikunci"`
+	RTID                   *uint  `gorm:"index" json:"rt_id"`
+
+	Broadcast *Broadcast                 `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"broadcast,omitempty"`
+	Induk     *BroadcastKomentar         `gorm:"foreignKey:IndukID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	User      *User                      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Mention   []BroadcastKomentarMention `gorm:"foreignKey:BroadcastKomentarID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"mention,omitempty"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// User yang di-mention (@username) dalam komentar broadcast
+type BroadcastKomentarMention struct {
+	BroadcastKomentarMentionID uint   `gorm:"primaryKey;autoIncrement" json:"broadcast_komentar_mention_id"`
+	BroadcastKomentarID        uint   `gorm:"not null;uniqueIndex:idx_komentar_mention_user" json:"broadcast_komentar_id"`
+	UserID                     uint   `gorm:"not null;uniqueIndex:idx_komentar_mention_user;index" json:"user_id"`
+	Username                   string `gorm:"size:50" json:"username"`
+	RTID                       *uint  `gorm:"index" json:"rt_id"`
+
+	User *User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Grup penerima tersimpan untuk audiens broadcast yang sering dipakai (misalnya pengurus masjid)
+type GrupPenerima struct {
+	GrupPenerimaID uint   `gorm:"primaryKey;autoIncrement" json:"grup_penerima_id"`
+	GrupNama       string `gorm:"not null;size:100" json:"grup_nama"`
+	GrupDeskripsi  string `gorm:"type:text" json:"grup_deskripsi"`
+	RTID           *uint  `gorm:"index" json:"rt_id"`
+
+	Anggota []GrupPenerimaAnggota `gorm:"foreignKey:GrupPenerimaID" json:"anggota,omitempty"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+type GrupPenerimaAnggota struct {
+	GrupPenerimaAnggotaID uint  `gorm:"primaryKey;autoIncrement" json:"grup_penerima_anggota_id"`
+	GrupPenerimaID        uint  `gorm:"not null;uniqueIndex:idx_grup_penerima_user" json:"grup_penerima_id"`
+	UserID                uint  `gorm:"not null;uniqueIndex:idx_grup_penerima_user" json:"user_id"`
+	RTID                  *uint `gorm:"index" json:"rt_id"`
+
+	User *User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+/* ============================
+   NOTIFIKASI (OUTBOX)
+============================ */
+
+// NotifikasiOutbox adalah antrian notifikasi per user per kanal yang dikirim worker,
+// sehingga notifikasi tetap terkirim (dengan retry) walaupun kanal sedang gangguan
+type NotifikasiOutbox struct {
+	NotifikasiOutboxID uint       `gorm:"primaryKey;autoIncrement" json:"notifikasi_outbox_id"`
+	UserID             *uint      `gorm:"index" json:"user_id"`
+	Kanal              string     `gorm:"size:20;not null;index" json:"kanal"` // email, whatsapp, push, log
+	Tujuan             string     `gorm:"type:text" json:"tujuan"`             // alamat email, nomor WA, atau langganan push (JSON)
+	Judul              string     `gorm:"size:200" json:"judul"`
+	Pesan              string     `gorm:"type:text" json:"pesan"`
+	URL                string     `gorm:"size:255" json:"url"`
+	Sumber             string     `gorm:"size:30;index" json:"sumber"` // broadcast, tagihan, kegiatan, ronda, tes
+	SumberID           *uint      `json:"sumber_id"`
+	KunciUnik          *string    `gorm:"size:191;uniqueIndex" json:"-"` // mencegah notifikasi ganda untuk kejadian yang sama
+	Status             string     `gorm:"type:enum('menunggu','diproses','terkirim','gagal');default:'menunggu';index" json:"status"`
+	Percobaan          int        `gorm:"default:0" json:"percobaan"`
+	MaksPercobaan      int        `gorm:"default:5" json:"maks_percobaan"`
+	JadwalKirim        time.Time  `gorm:"index" json:"jadwal_kirim"`
+	Prioritas          int        `gorm:"default:0" json:"prioritas"` // lebih tinggi diproses lebih dulu, mis. peringatan darurat
+	TerkirimPada       *time.Time `json:"terkirim_pada"`
+	ErrorTerakhir      string     `gorm:"type:text" json:"error_terakhir"`
+	RTID               *uint      `gorm:"index" json:"rt_id"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PreferensiNotifikasi menyimpan kanal dan jenis notifikasi yang diinginkan user.
+// Nomor WhatsApp diambil dari WargaNoTlp data warga yang ditautkan ke akun.
+type PreferensiNotifikasi struct {
+	PreferensiNotifikasiID uint   `gorm:"primaryKey;autoIncrement" json:"preferensi_notifikasi_id"`
+	UserID                 uint   `gorm:"not null;uniqueIndex" json:"user_id"`
+	Email                  string `gorm:"size:100" json:"email"`
+	EmailAktif             bool   `gorm:"not null" json:"email_aktif"`
+	WhatsAppAktif          bool   `gorm:"not null" json:"whatsapp_aktif"`
+	PushAktif              bool   `gorm:"not null" json:"push_aktif"`
+	BroadcastAktif         bool   `gorm:"not null" json:"broadcast_aktif"`
+	TagihanAktif           bool   `gorm:"not null" json:"tagihan_aktif"`
+	KegiatanAktif          bool   `gorm:"not null" json:"kegiatan_aktif"`
+	RTID                   *uint  `gorm:"index" json:"rt_id"`
+
+	User *User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LanggananPush adalah langganan Web Push dari satu browser/perangkat user
+type LanggananPush struct {
+	LanggananPushID uint   `gorm:"primaryKey;autoIncrement" json:"langganan_push_id"`
+	UserID          uint   `gorm:"not null;index" json:"user_id"`
+	Endpoint        string `gorm:"size:500;not null;uniqueIndex" json:"endpoint"`
+	P256dh          string `gorm:"size:200;not null" json:"-"`
+	Auth            string `gorm:"size:100;not null" json:"-"`
+	UserAgent       string `gorm:"size:255" json:"user_agent"`
+	RTID            *uint  `gorm:"index" json:"rt_id"`
+
+	User *User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+/* ============================
+   VOTING (MUSYAWARAH)
+============================ */
+
+// Voting untuk keputusan warga (mis. kenaikan iuran, pemilihan Ketua RT). Setiap suara dirantai
+// dengan hash suara sebelumnya sehingga perubahan atau penghapusan suara terdeteksi saat verifikasi.
+type Voting struct {
+	VotingID          uint      `gorm:"primaryKey;autoIncrement" json:"voting_id"`
+	VotingJudul       string    `gorm:"not null;size:200" json:"voting_judul"`
+	VotingDeskripsi   string    `gorm:"type:text" json:"voting_deskripsi"`
+	VotingHakPilih    string    `gorm:"type:enum('keluarga','warga_dewasa');default:'keluarga'" json:"voting_hak_pilih"` // satu suara per keluarga atau per warga 17+
+	VotingAnonim      bool      `gorm:"not null" json:"voting_anonim"`
+	VotingTampilHasil string    `gorm:"type:enum('langsung','setelah_tutup');default:'setelah_tutup'" json:"voting_tampil_hasil"`
+	VotingDibukaPada  time.Time `gorm:"not null;index" json:"voting_dibuka_pada"`
+	VotingDitutupPada time.Time `gorm:"not null;index" json:"voting_ditutup_pada"`
+	VotingJumlahSuara int       `gorm:"not null;default:0" json:"voting_jumlah_suara"`
+	VotingHashAkhir   string    `gorm:"size:64" json:"voting_hash_akhir"` // hash suara terakhir (kepala rantai)
+	BroadcastID       *uint     `gorm:"index" json:"broadcast_id"`
+	KegiatanID        *uint     `gorm:"index" json:"kegiatan_id"`
+	DibuatOlehID      uint      `gorm:"not null" json:"dibuat_oleh_id"`
+	RTID              *uint     `gorm:"index" json:"rt_id"`
+
+	Pilihan   []VotingPilihan `gorm:"foreignKey:VotingID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"pilihan,omitempty"`
+	Broadcast *Broadcast      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"broadcast,omitempty"`
+	Kegiatan  *Kegiatan       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"kegiatan,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type VotingPilihan struct {
+	VotingPilihanID uint   `gorm:"primaryKey;autoIncrement" json:"voting_pilihan_id"`
+	VotingID        uint   `gorm:"not null;index" json:"voting_id"`
+	PilihanTeks     string `gorm:"not null;size:200" json:"pilihan_teks"`
+	PilihanUrutan   int    `gorm:"not null" json:"pilihan_urutan"`
+	RTID            *uint  `gorm:"index" json:"rt_id"`
+}
+
+// PemilihVoting mencatat siapa yang sudah memilih (daftar hadir), terpisah dari isi suara
+// agar pada voting anonim pilihan seseorang tidak tersimpan bersama identitasnya
+type PemilihVoting struct {
+	// ID acak (bukan auto increment) agar urutan baris pemilih tidak bisa dicocokkan dengan urutan suara
+	PemilihVotingID string `gorm:"primaryKey;size:32" json:"pemilih_voting_id"`
+	VotingID        uint   `gorm:"not null;uniqueIndex:idx_pemilih_voting" json:"voting_id"`
+	PemilihKunci    string `gorm:"not null;size:50;uniqueIndex:idx_pemilih_voting" json:"pemilih_kunci"` // keluarga:<id> atau warga:<id>
+	UserID          uint   `gorm:"not null;index" json:"user_id"`
+	WargaID         uint   `gorm:"not null" json:"warga_id"`
+	KeluargaID      uint   `gorm:"not null" json:"keluarga_id"`
+	RTID            *uint  `gorm:"index" json:"rt_id"`
+
+	Voting *Voting `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	CreatedAt time.Time `json:"created_at"` // pada voting anonim hanya tanggal, tanpa jam
+}
+
+// SuaraVoting adalah satu surat suara dalam rantai hash:
+// Hash = SHA-256(HashSebelum|voting|urutan|pilihan|pemilih|nonce|waktu)
+type SuaraVoting struct {
+	SuaraVotingID   uint      `gorm:"primaryKey;autoIncrement" json:"suara_voting_id"`
+	VotingID        uint      `gorm:"not null;uniqueIndex:idx_suara_voting_urutan" json:"voting_id"`
+	Urutan          int       `gorm:"not null;uniqueIndex:idx_suara_voting_urutan" json:"urutan"`
+	VotingPilihanID uint      `gorm:"not null;index" json:"voting_pilihan_id"`
+	PemilihKunci    string    `gorm:"size:50" json:"pemilih_kunci"` // kosong pada voting anonim
+	Nonce           string    `gorm:"not null;size:32" json:"nonce"`
+	DicatatPada     time.Time `gorm:"not null" json:"dicatat_pada"`
+	HashSebelum     string    `gorm:"not null;size:64" json:"hash_sebelum"`
+	Hash            string    `gorm:"not null;size:64;uniqueIndex" json:"hash"`
+	RTID            *uint     `gorm:"index" json:"rt_id"`
+
+	Voting  *Voting        `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Pilihan *VotingPilihan `gorm:"foreignKey:VotingPilihanID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"pilihan,omitempty"`
+}
+
+/* ============================
+   PENGADUAN WARGA
+============================ */
+
+// KategoriPengaduan menentukan target waktu (SLA) respon pertama dan penyelesaian pengaduan
+type KategoriPengaduan struct {
+	KategoriPengaduanID   uint   `gorm:"primaryKey;autoIncrement" json:"kategori_pengaduan_id"`
+	KategoriPengaduanNama string `gorm:"not null;size:100" json:"kategori_pengaduan_nama"`
+	KategoriDeskripsi     string `gorm:"size:255" json:"kategori_deskripsi"`
+	SLAResponJam          int    `gorm:"not null" json:"sla_respon_jam"`
+	SLASelesaiJam         int    `gorm:"not null" json:"sla_selesai_jam"`
+	RTID                  *uint  `gorm:"index" json:"rt_id"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// Pengaduan adalah tiket laporan/aspirasi warga: terbuka -> diproses -> selesai -> ditutup
+type Pengaduan struct {
+	PengaduanID         uint   `gorm:"primaryKey;autoIncrement" json:"pengaduan_id"`
+	PengaduanKode       string `gorm:"size:20;uniqueIndex" json:"pengaduan_kode"`
+	KategoriPengaduanID uint   `gorm:"not null;index" json:"kategori_pengaduan_id"`
+	PengaduanJudul      string `gorm:"not null;size:150" json:"pengaduan_judul"`
+	PengaduanDeskripsi  string `gorm:"type:text" json:"pengaduan_deskripsi"`
+
+	// Lokasi: rumah terdaftar atau keterangan bebas (mis. "lampu jalan depan pos ronda")
+	RumahID         *uint  `gorm:"index" json:"rumah_id"`
+	PengaduanLokasi string `gorm:"size:255" json:"pengaduan_lokasi"`
+
+	// Publik terlihat oleh semua warga RT, privat hanya oleh pelapor, petugas dan pengurus
+	PengaduanVisibilitas  string `gorm:"type:enum('publik','privat');default:'publik'" json:"pengaduan_visibilitas"`
+	PengaduanStatus       string `gorm:"type:enum('terbuka','diproses','selesai','ditutup');default:'terbuka';index" json:"pengaduan_status"`
+	PengaduanPenyelesaian string `gorm:"type:text" json:"pengaduan_penyelesaian"`
+
+	DilaporkanOlehID uint  `gorm:"not null;index" json:"dilaporkan_oleh_id"`
+	PetugasID        *uint `gorm:"index" json:"petugas_id"` // user yang ditugaskan menangani
+
+	// SLA dihitung dari kategori saat pengaduan dibuat
+	TenggatRespon    time.Time  `gorm:"not null;index" json:"tenggat_respon"`
+	TenggatSelesai   time.Time  `gorm:"not null;index" json:"tenggat_selesai"`
+	DiresponPada     *time.Time `json:"direspon_pada"`
+	DiselesaikanPada *time.Time `json:"diselesaikan_pada"`
+	DitutupPada      *time.Time `json:"ditutup_pada"`
+	RTID             *uint      `gorm:"index" json:"rt_id"`
+
+	Kategori *KategoriPengaduan `gorm:"foreignKey:KategoriPengaduanID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"kategori,omitempty"`
+	Rumah    *Rumah             `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"rumah,omitempty"`
+	Foto     []FotoPengaduan    `gorm:"foreignKey:PengaduanID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"foto,omitempty"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// FotoPengaduan adalah foto kondisi saat dilaporkan atau bukti penyelesaian
+type FotoPengaduan struct {
+	FotoPengaduanID uint   `gorm:"primaryKey;autoIncrement" json:"foto_pengaduan_id"`
+	PengaduanID     uint   `gorm:"not null;index" json:"pengaduan_id"`
+	FotoFile        string `gorm:"not null;size:255" json:"foto_file"`
+	FotoTahap       string `gorm:"type:enum('laporan','penyelesaian');default:'laporan'" json:"foto_tahap"`
+	DiunggahOlehID  *uint  `json:"diunggah_oleh_id"`
+	RTID            *uint  `gorm:"index" json:"rt_id"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// KomentarPengaduan adalah percakapan pada tiket; komentar internal hanya terlihat oleh pengurus dan petugas
+type KomentarPengaduan struct {
+	KomentarPengaduanID uint   `gorm:"primaryKey;autoIncrement" json:"komentar_pengaduan_id"`
+	PengaduanID         uint   `gorm:"not null;index" json:"pengaduan_id"`
+	UserID              uint   `gorm:"not null;index" json:"user_id"`
+	KomentarIsi         string `gorm:"type:text;not null" json:"komentar_isi"`
+	KomentarInternal    bool   `gorm:"default:false" json:"komentar_internal"`
+	RTID                *uint  `gorm:"index" json:"rt_id"`
+
+	Pengaduan *Pengaduan `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RiwayatStatusPengaduan mencatat setiap perubahan status, penugasan dan siapa pelakunya
+type RiwayatStatusPengaduan struct {
+	RiwayatStatusPengaduanID uint   `gorm:"primaryKey;autoIncrement" json:"riwayat_status_pengaduan_id"`
+	PengaduanID              uint   `gorm:"not null;index" json:"pengaduan_id"`
+	StatusDari               string `gorm:"size:20" json:"status_dari"`
+	StatusKe                 string `gorm:"size:20" json:"status_ke"`
+	Catatan                  string `gorm:"size:500" json:"catatan"`
+	DiubahOlehID             uint   `gorm:"not null" json:"diubah_oleh_id"`
+	RTID                     *uint  `gorm:"index" json:"rt_id"`
+
+	Pengaduan *Pengaduan `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+/* ============================
+   PERINGATAN DARURAT
+============================ */
+
+// PeringatanDarurat adalah sinyal darurat (panic button) dari warga. Data tidak dapat dihapus
+// karena menjadi catatan kejadian untuk evaluasi: aktif -> ditangani -> selesai/dibatalkan
+type PeringatanDarurat struct {
+	PeringatanDaruratID uint   `gorm:"primaryKey;autoIncrement" json:"peringatan_darurat_id"`
+	DaruratJenis        string `gorm:"type:enum('kebakaran','pencurian','medis','lainnya');not null" json:"darurat_jenis"`
+	DaruratKeterangan   string `gorm:"size:500" json:"darurat_keterangan"`
+
+	// Lokasi disalin saat kejadian agar log tetap benar walaupun data rumah berubah
+	RumahID       *uint  `gorm:"index" json:"rumah_id"`
+	RumahBlok     string `gorm:"size:10;index" json:"rumah_blok"`
+	DaruratLokasi string `gorm:"size:255" json:"darurat_lokasi"`
+
+	DaruratStatus    string `gorm:"type:enum('aktif','ditangani','selesai','dibatalkan');default:'aktif';index" json:"darurat_status"`
+	DilaporkanOlehID uint   `gorm:"not null;index" json:"dilaporkan_oleh_id"`
+	JumlahPenerima   int    `gorm:"default:0" json:"jumlah_penerima"` // user yang diberi peringatan
+
+	DitanganiPada      *time.Time `json:"ditangani_pada"` // tanggapan pertama
+	SelesaiPada        *time.Time `json:"selesai_pada"`
+	DiselesaikanOlehID *uint      `json:"diselesaikan_oleh_id"`
+	CatatanPenutupan   string     `gorm:"size:500" json:"catatan_penutupan"`
+	RTID               *uint      `gorm:"index" json:"rt_id"`
+
+	Rumah     *Rumah             `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"rumah,omitempty"`
+	Tanggapan []TanggapanDarurat `gorm:"foreignKey:PeringatanDaruratID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"tanggapan,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TanggapanDarurat adalah konfirmasi dari responden (tetangga/pengurus), satu per user per peringatan
+type TanggapanDarurat struct {
+	TanggapanDaruratID  uint   `gorm:"primaryKey;autoIncrement" json:"tanggapan_darurat_id"`
+	PeringatanDaruratID uint   `gorm:"not null;uniqueIndex:idx_tanggapan_darurat_user" json:"peringatan_darurat_id"`
+	UserID              uint   `gorm:"not null;uniqueIndex:idx_tanggapan_darurat_user" json:"user_id"`
+	TanggapanStatus     string `gorm:"type:enum('menuju','di_lokasi','tidak_bisa');default:'menuju'" json:"tanggapan_status"`
+	TanggapanPesan      string `gorm:"size:255" json:"tanggapan_pesan"`
+	RTID                *uint  `gorm:"index" json:"rt_id"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LogDarurat mencatat kronologi kejadian: peringatan dibuat, tanggapan, dan perubahan status
+type LogDarurat struct {
+	LogDaruratID        uint   `gorm:"primaryKey;autoIncrement" json:"log_darurat_id"`
+	PeringatanDaruratID uint   `gorm:"not null;index" json:"peringatan_darurat_id"`
+	LogAksi             string `gorm:"size:30;not null" json:"log_aksi"` // dibuat, ditanggapi, selesai, dibatalkan
+	LogKeterangan       string `gorm:"size:500" json:"log_keterangan"`
+	UserID              uint   `gorm:"not null" json:"user_id"`
+	RTID                *uint  `gorm:"index" json:"rt_id"`
+
+	PeringatanDarurat *PeringatanDarurat `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	CreatedAt time.Time `json:"created_at"`
 }
 
 /* ============================
@@ -167,6 +1014,7 @@ type MutasiKeluarga struct {
 	MutasiKeluargaJenis   string    `gorm:"not null;size:50" json:"mutasi_keluarga_jenis"`
 	MutasiKeluargaAlasan  string    `gorm:"type:text" json:"mutasi_keluarga_alasan"`
 	MutasiKeluargaTanggal time.Time `json:"mutasi_keluarga_tanggal"`
+	RTID *uint `gorm:"index" json:"rt_id"`
 
 	Keluarga Keluarga `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"keluarga"`
 
@@ -197,11 +1045,13 @@ type Pengeluaran struct {
     PengeluaranTanggal    time.Time `json:"pengeluaran_tanggal"`
     PengeluaranNominal    float64   `gorm:"not null;type:decimal(15,2)" json:"pengeluaran_nominal"`
     PengeluaranBukti      string    `gorm:"size:255" json:"pengeluaran_bukti"`
+    RTID *uint `gorm:"index" json:"rt_id"`
 
     KategoriPengeluaran   KategoriPengeluaran `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"kategori_pengeluaran"`
 
     CreatedAt time.Time `json:"created_at"`
     UpdatedAt time.Time `json:"updated_at"`
+    DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 }
 
 
@@ -227,12 +1077,14 @@ type Pemasukan struct {
     PemasukanTanggal    time.Time `json:"pemasukan_tanggal"`
     PemasukanNominal    float64   `gorm:"not null;type:decimal(15,2)" json:"pemasukan_nominal"`
     PemasukanBukti      string    `gorm:"size:255" json:"pemasukan_bukti"`
+    RTID *uint `gorm:"index" json:"rt_id"`
 
     // relasi many-to-one ke kategori
     KategoriPemasukan   KategoriPemasukan `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"kategori_pemasukan"`
 
     CreatedAt time.Time `json:"created_at"`
     UpdatedAt time.Time `json:"updated_at"`
+    DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 }
 
 
@@ -245,6 +1097,7 @@ type Pemasukan struct {
 type TagihanIuran struct {
 	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
 	TagihanIuran string    `gorm:"not null;size:100" json:"tagihan_iuran"`
+	RTID *uint `gorm:"index" json:"rt_id"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
@@ -272,11 +1125,13 @@ type Produk struct {
     ProdukHarga      float64   `gorm:"not null;type:decimal(15,2)" json:"produk_harga"`
     ProdukFoto       string    `gorm:"not null;size:255" json:"produk_foto"`
     KategoriProdukID uint      `gorm:"not null" json:"kategori_produk_id"`
+    RTID *uint `gorm:"index" json:"rt_id"`
 
     // relasi many-to-one (produk → kategori)
     KategoriProduk KategoriProduk `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"kategori_produk"`
 
     CreatedAt time.Time `json:"created_at"`
     UpdatedAt time.Time `json:"updated_at"`
+    DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 }
 
@@ -0,0 +1,62 @@
+// Package notification mengirim notifikasi ke warga lewat outbox yang tahan gangguan.
+// Setiap kanal (email, WhatsApp, web push, log) mengimplementasikan interface Channel.
+package notification
+
+import (
+	"context"
+	"errors"
+	"log"
+)
+
+// Nama kanal yang dikenal outbox
+const (
+	KanalEmail    = "email"
+	KanalWhatsApp = "whatsapp"
+	KanalPush     = "push"
+	KanalLog      = "log"
+)
+
+// Pesan adalah satu notifikasi yang siap dikirim ke satu tujuan
+type Pesan struct {
+	Tujuan string // alamat email, nomor WhatsApp, atau langganan push (JSON)
+	Judul  string
+	Isi    string
+	URL    string
+}
+
+// Channel mengirim pesan ke satu kanal. Error yang dibungkus Permanen tidak akan dicoba ulang.
+type Channel interface {
+	Nama() string
+	Kirim(ctx context.Context, pesan Pesan) error
+}
+
+type errPermanen struct {
+	err error
+}
+
+func (e errPermanen) Error() string { return e.err.Error() }
+func (e errPermanen) Unwrap() error { return e.err }
+
+// Permanen menandai error yang tidak akan berhasil walaupun dicoba ulang (tujuan tidak valid, ditolak gateway)
+func Permanen(err error) error {
+	if err == nil {
+		return nil
+	}
+	return errPermanen{err: err}
+}
+
+// IsPermanen mengecek apakah error ditandai Permanen
+func IsPermanen(err error) bool {
+	var p errPermanen
+	return errors.As(err, &p)
+}
+
+// LogChannel hanya menulis pesan ke log server, dipakai untuk pengujian tanpa gateway sungguhan
+type LogChannel struct{}
+
+func (LogChannel) Nama() string { return KanalLog }
+
+func (LogChannel) Kirim(ctx context.Context, pesan Pesan) error {
+	log.Printf("🔔 [notifikasi] ke %s: %s - %s", pesan.Tujuan, pesan.Judul, pesan.Isi)
+	return nil
+}
@@ -0,0 +1,77 @@
+package notification
+
+import (
+	"log"
+	"os"
+	"strings"
+)
+
+// KanalDariEnv membuat daftar kanal dari environment variable. Kanal yang variabelnya
+// tidak diisi dilewati sehingga outbox tetap berjalan tanpa gateway apa pun.
+//
+//	SMTP_HOST, SMTP_PORT (default 587), SMTP_USER, SMTP_PASSWORD, SMTP_FROM
+//	WHATSAPP_GATEWAY_URL, WHATSAPP_GATEWAY_TOKEN, WHATSAPP_FIELD_NOMOR, WHATSAPP_FIELD_PESAN
+//	VAPID_PUBLIC_KEY, VAPID_PRIVATE_KEY, VAPID_SUBJECT
+//	NOTIFIKASI_LOG=true untuk menulis setiap notifikasi ke log server
+func KanalDariEnv() []Channel {
+	var kanal []Channel
+
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		from := os.Getenv("SMTP_FROM")
+		if from == "" {
+			from = os.Getenv("SMTP_USER")
+		}
+		kanal = append(kanal, SMTPChannel{
+			Host:     host,
+			Port:     port,
+			Username: os.Getenv("SMTP_USER"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		})
+	}
+
+	if url := os.Getenv("WHATSAPP_GATEWAY_URL"); url != "" {
+		kanal = append(kanal, WhatsAppChannel{
+			URL:        url,
+			Token:      os.Getenv("WHATSAPP_GATEWAY_TOKEN"),
+			FieldNomor: os.Getenv("WHATSAPP_FIELD_NOMOR"),
+			FieldPesan: os.Getenv("WHATSAPP_FIELD_PESAN"),
+		})
+	}
+
+	publicKey, privateKey := os.Getenv("VAPID_PUBLIC_KEY"), os.Getenv("VAPID_PRIVATE_KEY")
+	if publicKey != "" && privateKey != "" {
+		if _, err := vapidPrivateKey(privateKey); err != nil {
+			log.Printf("⚠️ Web push dinonaktifkan: %v", err)
+		} else {
+			kanal = append(kanal, WebPushChannel{
+				VAPIDPublicKey:  publicKey,
+				VAPIDPrivateKey: privateKey,
+				Subject:         os.Getenv("VAPID_SUBJECT"),
+			})
+		}
+	}
+
+	if strings.EqualFold(os.Getenv("NOTIFIKASI_LOG"), "true") {
+		kanal = append(kanal, LogChannel{})
+	}
+
+	return kanal
+}
+
+// BaseURLDariEnv mengembalikan APP_BASE_URL tanpa garis miring di akhir, dipakai untuk tautan di notifikasi
+func BaseURLDariEnv() string {
+	return strings.TrimRight(os.Getenv("APP_BASE_URL"), "/")
+}
+
+// VAPIDPublicKey mengembalikan kunci publik VAPID kanal push, kosong jika push tidak aktif
+func (s *Service) VAPIDPublicKey() string {
+	if push, ok := s.kanal[KanalPush].(WebPushChannel); ok {
+		return push.VAPIDPublicKey
+	}
+	return ""
+}
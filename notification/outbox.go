@@ -0,0 +1,321 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"rt-management/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Jenis notifikasi yang dapat dimatikan user lewat preferensi
+const (
+	JenisBroadcast = "broadcast"
+	JenisTagihan   = "tagihan"
+	JenisKegiatan  = "kegiatan"
+)
+
+// Jenis notifikasi yang tidak dapat dimatikan: perkembangan tiket pengaduan milik user dan peringatan darurat
+const (
+	JenisPengaduan = "pengaduan"
+	JenisDarurat   = "darurat"
+)
+
+// Notifikasi adalah kejadian yang perlu diberitahukan ke sekumpulan user
+type Notifikasi struct {
+	Jenis    string // broadcast, tagihan, kegiatan, pengaduan, darurat; menentukan preferensi yang dicek
+	Judul    string
+	Pesan    string
+	URL      string // path di aplikasi, diawali BaseURL jika diatur
+	Sumber   string // nama tabel/fitur sumber, mis. broadcast, peminjaman_fasilitas
+	SumberID *uint
+	Kunci    string // kunci kejadian untuk mencegah notifikasi ganda, kosong berarti tanpa dedup
+	RTID     *uint  // jika kosong dipakai RT milik user penerima
+}
+
+// Service mengantrikan notifikasi ke tabel outbox dan mengirimkannya lewat kanal yang aktif
+type Service struct {
+	db      *gorm.DB
+	kanal   map[string]Channel
+	BaseURL string
+}
+
+func NewService(db *gorm.DB, kanal ...Channel) *Service {
+	s := &Service{db: db, kanal: make(map[string]Channel, len(kanal))}
+	for _, k := range kanal {
+		s.kanal[k.Nama()] = k
+	}
+	return s
+}
+
+// KanalAktif mengembalikan nama kanal yang terkonfigurasi
+func (s *Service) KanalAktif() []string {
+	var nama []string
+	for _, k := range []string{KanalEmail, KanalWhatsApp, KanalPush, KanalLog} {
+		if s.kanal[k] != nil {
+			nama = append(nama, k)
+		}
+	}
+	return nama
+}
+
+// Aktif mengecek apakah kanal tertentu terkonfigurasi
+func (s *Service) Aktif(kanal string) bool {
+	return s.kanal[kanal] != nil
+}
+
+// PreferensiDefault adalah preferensi user yang belum pernah mengaturnya:
+// WhatsApp dan push aktif, email nonaktif karena alamatnya belum diisi.
+func PreferensiDefault(userID uint) models.PreferensiNotifikasi {
+	return models.PreferensiNotifikasi{
+		UserID:         userID,
+		WhatsAppAktif:  true,
+		PushAktif:      true,
+		BroadcastAktif: true,
+		TagihanAktif:   true,
+		KegiatanAktif:  true,
+	}
+}
+
+func menerimaJenis(pref models.PreferensiNotifikasi, jenis string) bool {
+	switch jenis {
+	case JenisBroadcast:
+		return pref.BroadcastAktif
+	case JenisTagihan:
+		return pref.TagihanAktif
+	case JenisKegiatan:
+		return pref.KegiatanAktif
+	}
+	return true
+}
+
+// KirimKeUser mengantrikan notifikasi untuk setiap user ke semua kanal yang diaktifkan user
+// dan terkonfigurasi di server. Mengembalikan jumlah pesan baru yang masuk antrian.
+func (s *Service) KirimKeUser(userIDs []uint, n Notifikasi) (int, error) {
+	if len(userIDs) == 0 {
+		return 0, nil
+	}
+
+	var users []models.User
+	if err := s.db.Preload("Warga").Where("user_id IN ?", userIDs).Find(&users).Error; err != nil {
+		return 0, err
+	}
+
+	var preferensi []models.PreferensiNotifikasi
+	if err := s.db.Where("user_id IN ?", userIDs).Find(&preferensi).Error; err != nil {
+		return 0, err
+	}
+	prefUser := make(map[uint]models.PreferensiNotifikasi, len(preferensi))
+	for _, p := range preferensi {
+		prefUser[p.UserID] = p
+	}
+
+	langgananUser := make(map[uint][]models.LanggananPush)
+	if s.Aktif(KanalPush) {
+		var langganan []models.LanggananPush
+		if err := s.db.Where("user_id IN ?", userIDs).Find(&langganan).Error; err != nil {
+			return 0, err
+		}
+		for _, l := range langganan {
+			langgananUser[l.UserID] = append(langgananUser[l.UserID], l)
+		}
+	}
+
+	url := n.URL
+	if url != "" && s.BaseURL != "" && strings.HasPrefix(url, "/") {
+		url = s.BaseURL + url
+	}
+
+	// Peringatan darurat didahulukan dari antrian lain agar tidak tertahan di belakang backlog
+	prioritas := 0
+	if n.Jenis == JenisDarurat {
+		prioritas = 1
+	}
+
+	now := time.Now()
+	var antrian []models.NotifikasiOutbox
+	for _, u := range users {
+		pref, ok := prefUser[u.UserID]
+		if !ok {
+			pref = PreferensiDefault(u.UserID)
+		}
+		if !menerimaJenis(pref, n.Jenis) {
+			continue
+		}
+
+		rtID := n.RTID
+		if rtID == nil {
+			rtID = u.RTID
+		}
+		userID := u.UserID
+		tambah := func(kanal, tujuan, akhiran string) {
+			item := models.NotifikasiOutbox{
+				UserID:      &userID,
+				Kanal:       kanal,
+				Tujuan:      tujuan,
+				Judul:       n.Judul,
+				Pesan:       n.Pesan,
+				URL:         url,
+				Sumber:      n.Sumber,
+				SumberID:    n.SumberID,
+				Status:      "menunggu",
+				JadwalKirim: now,
+				Prioritas:   prioritas,
+				RTID:        rtID,
+			}
+			if n.Kunci != "" {
+				kunci := fmt.Sprintf("%s:%d:%s%s", n.Kunci, userID, kanal, akhiran)
+				item.KunciUnik = &kunci
+			}
+			antrian = append(antrian, item)
+		}
+
+		if s.Aktif(KanalEmail) && pref.EmailAktif && pref.Email != "" {
+			tambah(KanalEmail, pref.Email, "")
+		}
+		if s.Aktif(KanalWhatsApp) && pref.WhatsAppAktif && u.Warga != nil {
+			if nomor := NormalisasiNomorWA(u.Warga.WargaNoTlp); nomor != "" {
+				tambah(KanalWhatsApp, nomor, "")
+			}
+		}
+		if pref.PushAktif {
+			for _, l := range langgananUser[u.UserID] {
+				tujuan, err := json.Marshal(langgananKeWebPush(l))
+				if err != nil {
+					continue
+				}
+				tambah(KanalPush, string(tujuan), fmt.Sprintf(":%d", l.LanggananPushID))
+			}
+		}
+		if s.Aktif(KanalLog) {
+			tambah(KanalLog, u.Username, "")
+		}
+	}
+
+	if len(antrian) == 0 {
+		return 0, nil
+	}
+
+	// Kunci unik yang sudah ada dilewati sehingga kejadian yang sama tidak dikirim dua kali
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&antrian, 100)
+	return int(result.RowsAffected), result.Error
+}
+
+func langgananKeWebPush(l models.LanggananPush) LanggananWebPush {
+	var w LanggananWebPush
+	w.Endpoint = l.Endpoint
+	w.Keys.P256dh = l.P256dh
+	w.Keys.Auth = l.Auth
+	return w
+}
+
+// Jalankan memproses antrian outbox secara berkala. Dijalankan sebagai goroutine dari main.
+func (s *Service) Jalankan(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ProsesAntrian(time.Now()); err != nil {
+			log.Printf("⚠️ Outbox notifikasi: %v", err)
+		}
+		<-ticker.C
+	}
+}
+
+// ProsesAntrian mengirim notifikasi yang sudah jatuh tempo, prioritas tertinggi lebih dulu. Setiap pesan
+// diklaim dengan update bersyarat agar aman walaupun ada lebih dari satu worker. Mengembalikan jumlah pesan terkirim.
+func (s *Service) ProsesAntrian(now time.Time) (int, error) {
+	// Pesan yang tertahan "diproses" (worker mati di tengah pengiriman) dikembalikan ke antrian
+	if err := s.db.Model(&models.NotifikasiOutbox{}).
+		Where("status = ? AND updated_at < ?", "diproses", now.Add(-10*time.Minute)).
+		Update("status", "menunggu").Error; err != nil {
+		return 0, err
+	}
+
+	var antrian []models.NotifikasiOutbox
+	if err := s.db.
+		Where("status = ? AND jadwal_kirim <= ?", "menunggu", now).
+		Order("prioritas DESC, jadwal_kirim ASC").
+		Limit(100).
+		Find(&antrian).Error; err != nil {
+		return 0, err
+	}
+
+	terkirim := 0
+	for _, item := range antrian {
+		klaim := s.db.Model(&models.NotifikasiOutbox{}).
+			Where("notifikasi_outbox_id = ? AND status = ?", item.NotifikasiOutboxID, "menunggu").
+			Update("status", "diproses")
+		if klaim.Error != nil || klaim.RowsAffected == 0 {
+			continue
+		}
+		if s.kirim(item) {
+			terkirim++
+		}
+	}
+	return terkirim, nil
+}
+
+// kirim mengirim satu pesan outbox dan mencatat hasilnya
+func (s *Service) kirim(item models.NotifikasiOutbox) bool {
+	var err error
+	kanal := s.kanal[item.Kanal]
+	if kanal == nil {
+		err = Permanen(fmt.Errorf("kanal %s tidak terkonfigurasi", item.Kanal))
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		err = kanal.Kirim(ctx, Pesan{Tujuan: item.Tujuan, Judul: item.Judul, Isi: item.Pesan, URL: item.URL})
+		cancel()
+	}
+
+	percobaan := item.Percobaan + 1
+	selesai := time.Now()
+	update := map[string]interface{}{"percobaan": percobaan}
+
+	switch {
+	case err == nil:
+		update["status"] = "terkirim"
+		update["terkirim_pada"] = selesai
+		update["error_terakhir"] = ""
+	case IsPermanen(err) || percobaan >= item.MaksPercobaan:
+		update["status"] = "gagal"
+		update["error_terakhir"] = err.Error()
+	default:
+		update["status"] = "menunggu"
+		update["jadwal_kirim"] = selesai.Add(Backoff(percobaan))
+		update["error_terakhir"] = err.Error()
+	}
+
+	if errors.Is(err, ErrLanggananKedaluwarsa) {
+		var langganan LanggananWebPush
+		if json.Unmarshal([]byte(item.Tujuan), &langganan) == nil && langganan.Endpoint != "" {
+			s.db.Where("endpoint = ?", langganan.Endpoint).Delete(&models.LanggananPush{})
+		}
+	}
+
+	if dbErr := s.db.Model(&models.NotifikasiOutbox{}).
+		Where("notifikasi_outbox_id = ?", item.NotifikasiOutboxID).
+		Updates(update).Error; dbErr != nil {
+		log.Printf("⚠️ Outbox notifikasi: gagal mencatat hasil pesan %d: %v", item.NotifikasiOutboxID, dbErr)
+	}
+	return err == nil
+}
+
+// Backoff adalah jeda sebelum percobaan berikutnya: 1 menit, 2, 4, ... maksimal 6 jam
+func Backoff(percobaan int) time.Duration {
+	jeda := time.Minute
+	for i := 1; i < percobaan; i++ {
+		jeda *= 2
+		if jeda >= 6*time.Hour {
+			return 6 * time.Hour
+		}
+	}
+	return jeda
+}
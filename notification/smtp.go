@@ -0,0 +1,115 @@
+package notification
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// SMTPChannel mengirim notifikasi sebagai email teks biasa
+type SMTPChannel struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (s SMTPChannel) Nama() string { return KanalEmail }
+
+func (s SMTPChannel) Kirim(ctx context.Context, pesan Pesan) error {
+	to, err := mail.ParseAddress(pesan.Tujuan)
+	if err != nil {
+		return Permanen(fmt.Errorf("alamat email tidak valid: %v", err))
+	}
+	from, err := mail.ParseAddress(s.From)
+	if err != nil {
+		return Permanen(fmt.Errorf("alamat pengirim email tidak valid: %v", err))
+	}
+
+	isi := pesan.Isi
+	if pesan.URL != "" {
+		isi += "\r\n\r\n" + pesan.URL
+	}
+
+	var msg strings.Builder
+	msg.WriteString("From: " + from.String() + "\r\n")
+	msg.WriteString("To: " + to.String() + "\r\n")
+	msg.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", pesan.Judul) + "\r\n")
+	msg.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(isi, "\n", "\r\n"))
+
+	return s.kirimSMTP(ctx, from.Address, to.Address, []byte(msg.String()))
+}
+
+func (s SMTPChannel) kirimSMTP(ctx context.Context, from, to string, msg []byte) error {
+	addr := net.JoinHostPort(s.Host, s.Port)
+	dialer := &net.Dialer{Timeout: 15 * time.Second}
+
+	// Port 465 memakai TLS langsung, port lain memakai STARTTLS jika server mendukung
+	var conn net.Conn
+	var err error
+	if s.Port == "465" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: s.Host})
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if s.Port != "465" {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: s.Host}); err != nil {
+				return err
+			}
+		}
+	}
+	if s.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		// Kode 5xx berarti alamat ditolak permanen
+		if tpErr, ok := err.(*textproto.Error); ok && tpErr.Code >= 500 {
+			return Permanen(err)
+		}
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,280 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/crypto/hkdf"
+)
+
+// ErrLanggananKedaluwarsa dikembalikan saat push service menyatakan langganan sudah tidak berlaku (404/410)
+var ErrLanggananKedaluwarsa = errors.New("langganan push sudah tidak berlaku")
+
+// LanggananWebPush adalah format PushSubscription dari browser (hasil JSON.stringify)
+type LanggananWebPush struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// hostPushDiizinkan adalah push service browser yang dikenal. Endpoint langganan hanya boleh
+// mengarah ke host ini agar server tidak bisa dipakai mengirim request ke alamat sembarang (SSRF).
+var hostPushDiizinkan = []string{
+	"fcm.googleapis.com",                // Chrome, Edge (Chromium), Opera
+	"updates.push.services.mozilla.com", // Firefox
+	"web.push.apple.com",                // Safari
+}
+
+// sufiksHostPushDiizinkan untuk push service yang memakai subdomain per region
+var sufiksHostPushDiizinkan = []string{
+	".notify.windows.com", // Edge lama / WNS
+	".push.apple.com",
+}
+
+// EndpointPushDiizinkan memeriksa endpoint langganan berupa URL https ke push service yang dikenal
+func EndpointPushDiizinkan(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "https" || u.User != nil {
+		return false
+	}
+	if port := u.Port(); port != "" && port != "443" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, h := range hostPushDiizinkan {
+		if host == h {
+			return true
+		}
+	}
+	for _, sufiks := range sufiksHostPushDiizinkan {
+		if strings.HasSuffix(host, sufiks) {
+			return true
+		}
+	}
+	return false
+}
+
+// WebPushChannel mengirim notifikasi Web Push (RFC 8030) dengan enkripsi aes128gcm (RFC 8291)
+// dan autentikasi VAPID (RFC 8292). Kunci VAPID dalam base64url tanpa padding.
+type WebPushChannel struct {
+	VAPIDPublicKey  string
+	VAPIDPrivateKey string
+	Subject         string // mailto: atau https: kontak pengelola
+	TTL             int    // detik pesan disimpan push service, default 1 hari
+	Client          *http.Client
+}
+
+func (w WebPushChannel) Nama() string { return KanalPush }
+
+func (w WebPushChannel) Kirim(ctx context.Context, pesan Pesan) error {
+	var langganan LanggananWebPush
+	if err := json.Unmarshal([]byte(pesan.Tujuan), &langganan); err != nil || langganan.Endpoint == "" {
+		return Permanen(fmt.Errorf("langganan push tidak valid"))
+	}
+	// Langganan lama yang tersimpan sebelum allowlist tetap diperiksa saat pengiriman
+	if !EndpointPushDiizinkan(langganan.Endpoint) {
+		return Permanen(fmt.Errorf("endpoint push tidak dikenal"))
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"title": pesan.Judul,
+		"body":  pesan.Isi,
+		"url":   pesan.URL,
+	})
+	if err != nil {
+		return Permanen(err)
+	}
+
+	body, err := enkripsiWebPush(payload, langganan)
+	if err != nil {
+		return Permanen(err)
+	}
+
+	authorization, err := w.vapidAuthorization(langganan.Endpoint)
+	if err != nil {
+		return Permanen(err)
+	}
+
+	ttl := w.TTL
+	if ttl <= 0 {
+		ttl = 86400
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, langganan.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return Permanen(err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", fmt.Sprint(ttl))
+	req.Header.Set("Urgency", "normal")
+	req.Header.Set("Authorization", authorization)
+
+	client := w.Client
+	if client == nil {
+		client = &http.Client{
+			Timeout: 30 * time.Second,
+			// Push service tidak melakukan redirect; jangan ikuti agar tidak keluar dari allowlist
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return Permanen(ErrLanggananKedaluwarsa)
+	}
+	return statusGateway("push service", resp)
+}
+
+// vapidAuthorization membuat header Authorization VAPID untuk origin endpoint push
+func (w WebPushChannel) vapidAuthorization(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	privateKey, err := vapidPrivateKey(w.VAPIDPrivateKey)
+	if err != nil {
+		return "", err
+	}
+
+	subject := w.Subject
+	if subject == "" {
+		subject = "mailto:admin@localhost"
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": subject,
+	})
+	signed, err := token.SignedString(privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	return "vapid t=" + signed + ", k=" + w.VAPIDPublicKey, nil
+}
+
+func vapidPrivateKey(value string) (*ecdsa.PrivateKey, error) {
+	d, err := decodeBase64URL(value)
+	if err != nil || len(d) != 32 {
+		return nil, fmt.Errorf("VAPID private key tidak valid")
+	}
+
+	curve := elliptic.P256()
+	key := &ecdsa.PrivateKey{D: new(big.Int).SetBytes(d)}
+	key.PublicKey.Curve = curve
+	key.PublicKey.X, key.PublicKey.Y = curve.ScalarBaseMult(d)
+	return key, nil
+}
+
+// enkripsiWebPush mengenkripsi payload untuk satu langganan sesuai RFC 8291 (satu record aes128gcm)
+func enkripsiWebPush(payload []byte, langganan LanggananWebPush) ([]byte, error) {
+	uaPublic, err := decodeBase64URL(langganan.Keys.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("kunci p256dh tidak valid")
+	}
+	authSecret, err := decodeBase64URL(langganan.Keys.Auth)
+	if err != nil || len(authSecret) == 0 {
+		return nil, fmt.Errorf("kunci auth tidak valid")
+	}
+
+	curve := elliptic.P256()
+	uaX, uaY := elliptic.Unmarshal(curve, uaPublic)
+	if uaX == nil {
+		return nil, fmt.Errorf("kunci p256dh bukan titik P-256")
+	}
+
+	// Kunci sementara milik server untuk pesan ini
+	asPrivate, asX, asY, err := elliptic.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := elliptic.Marshal(curve, asX, asY)
+
+	sharedX, _ := curve.ScalarMult(uaX, uaY, asPrivate)
+	ecdhSecret := make([]byte, 32)
+	sharedX.FillBytes(ecdhSecret)
+
+	keyInfo := append([]byte("WebPush: info\x00"), uaPublic...)
+	keyInfo = append(keyInfo, asPublic...)
+	ikm, err := hkdfBytes(ecdhSecret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	cek, err := hkdfBytes(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdfBytes(ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Delimiter 0x02 menandai record terakhir
+	plaintext := append(append([]byte{}, payload...), 0x02)
+	ciphertext := gcm.Seal(nil, nonce, plaintext, nil)
+
+	const recordSize = 4096
+	header := make([]byte, 0, 16+4+1+len(asPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+
+	return append(header, ciphertext...), nil
+}
+
+func hkdfBytes(secret, salt, info []byte, length int) ([]byte, error) {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func decodeBase64URL(value string) ([]byte, error) {
+	value = strings.TrimRight(strings.TrimSpace(value), "=")
+	value = strings.NewReplacer("+", "-", "/", "_").Replace(value)
+	return base64.RawURLEncoding.DecodeString(value)
+}
@@ -0,0 +1,114 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WhatsAppChannel mengirim pesan lewat gateway WhatsApp berbasis HTTP. Gateway menerima
+// POST JSON {FieldNomor: "628...", FieldPesan: "..."} dengan token di header Authorization,
+// format yang dipakai mayoritas gateway WhatsApp lokal.
+type WhatsAppChannel struct {
+	URL        string
+	Token      string
+	FieldNomor string // default "phone"
+	FieldPesan string // default "message"
+	Client     *http.Client
+}
+
+func (w WhatsAppChannel) Nama() string { return KanalWhatsApp }
+
+func (w WhatsAppChannel) Kirim(ctx context.Context, pesan Pesan) error {
+	nomor := NormalisasiNomorWA(pesan.Tujuan)
+	if nomor == "" {
+		return Permanen(fmt.Errorf("nomor WhatsApp tidak valid: %q", pesan.Tujuan))
+	}
+
+	isi := pesan.Isi
+	if pesan.Judul != "" {
+		isi = "*" + pesan.Judul + "*\n\n" + isi
+	}
+	if pesan.URL != "" {
+		isi += "\n\n" + pesan.URL
+	}
+
+	fieldNomor, fieldPesan := w.FieldNomor, w.FieldPesan
+	if fieldNomor == "" {
+		fieldNomor = "phone"
+	}
+	if fieldPesan == "" {
+		fieldPesan = "message"
+	}
+	body, err := json.Marshal(map[string]string{fieldNomor: nomor, fieldPesan: isi})
+	if err != nil {
+		return Permanen(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return Permanen(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.Token != "" {
+		req.Header.Set("Authorization", w.Token)
+	}
+
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return statusGateway("gateway WhatsApp", resp)
+}
+
+// NormalisasiNomorWA mengubah nomor telepon lokal (08xx, +62xx, 62xx) menjadi format 62xx.
+// Mengembalikan string kosong jika nomor tidak valid.
+func NormalisasiNomorWA(nomor string) string {
+	var digit strings.Builder
+	for _, r := range nomor {
+		if r >= '0' && r <= '9' {
+			digit.WriteRune(r)
+		}
+	}
+
+	hasil := digit.String()
+	switch {
+	case strings.HasPrefix(hasil, "62"):
+	case strings.HasPrefix(hasil, "0"):
+		hasil = "62" + hasil[1:]
+	case strings.HasPrefix(hasil, "8"):
+		hasil = "62" + hasil
+	default:
+		return ""
+	}
+
+	if len(hasil) < 10 || len(hasil) > 15 {
+		return ""
+	}
+	return hasil
+}
+
+// statusGateway menerjemahkan status HTTP gateway: 2xx sukses, 429/5xx dicoba ulang, 4xx lain permanen
+func statusGateway(nama string, resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	cuplikan, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err := fmt.Errorf("%s merespons %d: %s", nama, resp.StatusCode, strings.TrimSpace(string(cuplikan)))
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return err
+	}
+	return Permanen(err)
+}
@@ -0,0 +1,169 @@
+// Package realtime adalah pub/sub in-process untuk mendorong event perubahan data ke client
+// (lewat Server-Sent Events). Controller mempublikasikan event setelah penulisan berhasil,
+// setiap koneksi SSE berlangganan topik tertentu dan hanya menerima event untuk dirinya.
+package realtime
+
+import (
+	"sync"
+	"time"
+)
+
+// Topik event yang dikenal
+const (
+	TopikBroadcast = "broadcast"
+	TopikTagihan   = "tagihan"
+	TopikKegiatan  = "kegiatan"
+	TopikProduk    = "produk"
+	TopikVoting    = "voting"
+	TopikPengaduan = "pengaduan"
+	TopikDarurat   = "darurat"
+)
+
+// DaftarTopik adalah semua topik yang dapat dilanggan client
+var DaftarTopik = []string{TopikBroadcast, TopikTagihan, TopikKegiatan, TopikProduk, TopikVoting, TopikPengaduan, TopikDarurat}
+
+// ukuranRiwayat adalah jumlah event terakhir yang disimpan untuk dikirim ulang saat client
+// tersambung kembali dengan header Last-Event-ID
+const ukuranRiwayat = 200
+
+// Event adalah satu perubahan data yang dikirim ke client
+type Event struct {
+	ID    uint64      `json:"id"`
+	Topik string      `json:"topik"`
+	Jenis string      `json:"jenis"` // mis. broadcast.terbit, tagihan.dibayar
+	Data  interface{} `json:"data"`
+	Waktu time.Time   `json:"waktu"`
+
+	// Penyaring penerima, tidak dikirim ke client.
+	// UserIDs selalu menerima event; LevelIDs membatasi penerima lain di wilayah RTID;
+	// jika keduanya kosong semua user di wilayah RTID menerima. RTID kosong berarti semua wilayah.
+	RTID     *uint  `json:"-"`
+	UserIDs  []uint `json:"-"`
+	LevelIDs []uint `json:"-"`
+}
+
+// Pelanggan adalah satu koneksi client yang menerima event
+type Pelanggan struct {
+	UserID  uint
+	LevelID uint
+	Global  bool            // user tanpa batas wilayah (admin global)
+	RTIDs   map[uint]bool   // RT yang boleh diakses user
+	Topik   map[string]bool // kosong berarti semua topik
+
+	C chan Event
+}
+
+// Menerima mengecek apakah event boleh dikirim ke pelanggan ini
+func (p *Pelanggan) Menerima(e Event) bool {
+	if len(p.Topik) > 0 && !p.Topik[e.Topik] {
+		return false
+	}
+
+	for _, id := range e.UserIDs {
+		if id == p.UserID {
+			return true
+		}
+	}
+	if len(e.UserIDs) > 0 && len(e.LevelIDs) == 0 {
+		return false
+	}
+
+	if len(e.LevelIDs) > 0 {
+		cocok := false
+		for _, level := range e.LevelIDs {
+			if level == p.LevelID {
+				cocok = true
+				break
+			}
+		}
+		if !cocok {
+			return false
+		}
+	}
+
+	return e.RTID == nil || p.Global || p.RTIDs[*e.RTID]
+}
+
+// Hub menyalurkan event ke semua pelanggan yang berhak. Hub nil aman dipakai dan tidak melakukan apa pun.
+type Hub struct {
+	mu        sync.RWMutex
+	pelanggan map[*Pelanggan]struct{}
+	nextID    uint64
+	riwayat   []Event
+}
+
+func NewHub() *Hub {
+	return &Hub{pelanggan: make(map[*Pelanggan]struct{})}
+}
+
+// Langgan mendaftarkan pelanggan. Jika lastEventID diisi, event setelahnya yang masih tersimpan
+// di riwayat dikembalikan untuk dikirim lebih dulu sehingga tidak ada event yang terlewat.
+func (h *Hub) Langgan(p *Pelanggan, lastEventID uint64) []Event {
+	if p.C == nil {
+		p.C = make(chan Event, 32)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.pelanggan[p] = struct{}{}
+
+	var susulan []Event
+	if lastEventID > 0 {
+		for _, e := range h.riwayat {
+			if e.ID > lastEventID && p.Menerima(e) {
+				susulan = append(susulan, e)
+			}
+		}
+	}
+	return susulan
+}
+
+// BerhentiLangganan melepas pelanggan saat koneksi ditutup
+func (h *Hub) BerhentiLangganan(p *Pelanggan) {
+	h.mu.Lock()
+	delete(h.pelanggan, p)
+	h.mu.Unlock()
+}
+
+// Publikasikan mengirim event ke pelanggan yang berhak. Pengiriman tidak pernah memblokir:
+// pelanggan yang antriannya penuh (client lambat) melewatkan event dan dapat memuat ulang data.
+func (h *Hub) Publikasikan(e Event) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	e.ID = h.nextID
+	if e.Waktu.IsZero() {
+		e.Waktu = time.Now()
+	}
+
+	h.riwayat = append(h.riwayat, e)
+	if len(h.riwayat) > ukuranRiwayat {
+		h.riwayat = h.riwayat[len(h.riwayat)-ukuranRiwayat:]
+	}
+
+	for p := range h.pelanggan {
+		if !p.Menerima(e) {
+			continue
+		}
+		select {
+		case p.C <- e:
+		default:
+		}
+	}
+}
+
+// JumlahPelanggan mengembalikan jumlah koneksi yang sedang aktif
+func (h *Hub) JumlahPelanggan() int {
+	if h == nil {
+		return 0
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.pelanggan)
+}
@@ -0,0 +1,29 @@
+// routes/agama_routes.go
+package routes
+
+import (
+	"rt-management/controllers"
+	"rt-management/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupAgamaRoutes(api *gin.RouterGroup, agamaController *controllers.AgamaController, authMiddleware *middleware.AuthMiddleware) {
+	agama := api.Group("/agama")
+	{
+		// Public routes (butuh auth)
+		agama.GET("", authMiddleware.RequireLevel(1, 2), agamaController.GetAllAgama)
+		agama.GET("/dropdown", authMiddleware.RequireLevel(1, 2), agamaController.GetAgamaDropdown)
+		agama.GET("/:id", authMiddleware.RequireLevel(1, 2), agamaController.GetAgamaByID)
+
+		// Admin only routes
+		adminAgama := agama.Group("")
+		adminAgama.Use(authMiddleware.RequireLevel(1))
+		{
+			adminAgama.POST("", agamaController.CreateAgama)
+			adminAgama.PUT("/:id", agamaController.UpdateAgama)
+			adminAgama.DELETE("/:id", agamaController.DeleteAgama)
+			adminAgama.POST("/:id/merge", agamaController.MergeAgama)
+		}
+	}
+}
@@ -11,14 +11,30 @@ import (
 func SetupBroadcastRoutes(api *gin.RouterGroup, broadcastController *controllers.BroadcastController, authMiddleware *middleware.AuthMiddleware) {
 	broadcast := api.Group("/broadcast")
 	{
-		// Public routes (butuh auth)
-		broadcast.GET("", authMiddleware.RequireLevel(1, 2), broadcastController.GetAllBroadcast)
-		broadcast.GET("/terbaru", authMiddleware.RequireLevel(1, 2), broadcastController.GetBroadcastTerbaru)
+		// Semua level dapat membaca broadcast, warga hanya melihat broadcast yang ditujukan kepadanya
+		broadcast.GET("", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), broadcastController.GetAllBroadcast)
+		broadcast.GET("/terbaru", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), broadcastController.GetBroadcastTerbaru)
 		broadcast.GET("/statistik", authMiddleware.RequireLevel(1, 2), broadcastController.GetStatistikBroadcast)
-		broadcast.GET("/search", authMiddleware.RequireLevel(1, 2), broadcastController.SearchBroadcast)
-		broadcast.GET("/:id", authMiddleware.RequireLevel(1, 2), broadcastController.GetBroadcastByID)
-		broadcast.GET("/dokumen/:filename", authMiddleware.RequireLevel(1, 2), broadcastController.GetBroadcastDokumen)
-		broadcast.GET("/image/:filename", authMiddleware.RequireLevel(1, 2), broadcastController.GetBroadcastFoto)
+		broadcast.GET("/search", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), broadcastController.SearchBroadcast)
+		broadcast.GET("/:id", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), broadcastController.GetBroadcastByID)
+		broadcast.GET("/:id/penerima", authMiddleware.RequireLevel(1, 2), broadcastController.GetPenerimaBroadcast)
+		broadcast.GET("/:id/laporan-baca", authMiddleware.RequireLevel(1, 2), broadcastController.GetLaporanPenerimaanBroadcast)
+		broadcast.POST("/:id/konfirmasi", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), broadcastController.KonfirmasiBroadcast)
+
+		// Komentar dan diskusi broadcast; edit/hapus hanya oleh penulis, moderasi oleh admin dan sekretaris
+		broadcast.GET("/:id/komentar", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), broadcastController.GetKomentarBroadcast)
+		broadcast.POST("/:id/komentar", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), broadcastController.CreateKomentarBroadcast)
+		broadcast.PUT("/:id/komentar/kunci", authMiddleware.RequireLevel(1, 2), broadcastController.KunciKomentarBroadcast)
+		broadcast.PUT("/komentar/:komentar_id", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), broadcastController.UpdateKomentarBroadcast)
+		broadcast.DELETE("/komentar/:komentar_id", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), broadcastController.DeleteKomentarBroadcast)
+		broadcast.PUT("/komentar/:komentar_id/sembunyikan", authMiddleware.RequireLevel(1, 2), broadcastController.SembunyikanKomentarBroadcast)
+		broadcast.PUT("/komentar/:komentar_id/kunci", authMiddleware.RequireLevel(1, 2), broadcastController.KunciUtasKomentarBroadcast)
+
+		broadcast.GET("/dokumen/:filename", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), broadcastController.GetBroadcastDokumen)
+		broadcast.GET("/image/:filename", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), broadcastController.GetBroadcastFoto)
+		broadcast.GET("/:id/lampiran", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), broadcastController.GetLampiranBroadcast)
+		broadcast.GET("/lampiran/:lampiran_id/unduh", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), broadcastController.UnduhLampiranBroadcast)
+		broadcast.GET("/lampiran/:lampiran_id/pratinjau", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), broadcastController.GetPratinjauLampiranBroadcast)
 		
 		// Admin only routes
 		adminBroadcast := broadcast.Group("")
@@ -27,6 +43,16 @@ func SetupBroadcastRoutes(api *gin.RouterGroup, broadcastController *controllers
 			adminBroadcast.POST("", broadcastController.CreateBroadcast)
 			adminBroadcast.PUT("/:id", broadcastController.UpdateBroadcast)
 			adminBroadcast.DELETE("/:id", broadcastController.DeleteBroadcast)
+			adminBroadcast.GET("/trash", broadcastController.GetTrashBroadcast)
+			adminBroadcast.PUT("/:id/restore", broadcastController.RestoreBroadcast)
+			adminBroadcast.PUT("/:id/terbitkan", broadcastController.TerbitkanBroadcast)
+			adminBroadcast.PUT("/:id/arsipkan", broadcastController.ArsipkanBroadcast)
+
+			// Lampiran foto/dokumen broadcast
+			adminBroadcast.POST("/:id/lampiran", broadcastController.UploadLampiranBroadcast)
+			adminBroadcast.PUT("/:id/lampiran/urutan", broadcastController.UrutkanLampiranBroadcast)
+			adminBroadcast.PUT("/:id/lampiran/:lampiran_id", broadcastController.UpdateLampiranBroadcast)
+			adminBroadcast.DELETE("/:id/lampiran/:lampiran_id", broadcastController.DeleteLampiranBroadcast)
 		}
 	}
 }
\ No newline at end of file
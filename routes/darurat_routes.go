@@ -0,0 +1,22 @@
+// routes/darurat_routes.go
+package routes
+
+import (
+	"rt-management/controllers"
+	"rt-management/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupDaruratRoutes(api *gin.RouterGroup, daruratController *controllers.DaruratController, authMiddleware *middleware.AuthMiddleware) {
+	darurat := api.Group("/darurat")
+	{
+		// Semua user dapat menekan tombol darurat dan menanggapi peringatan yang terlihat olehnya;
+		// menutup peringatan dicek di controller (pelapor atau pengurus)
+		darurat.GET("", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), daruratController.GetAllDarurat)
+		darurat.GET("/:id", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), daruratController.GetDaruratByID)
+		darurat.POST("", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), daruratController.KirimDarurat)
+		darurat.POST("/:id/tanggapan", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), daruratController.TanggapiDarurat)
+		darurat.PUT("/:id/status", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), daruratController.UbahStatusDarurat)
+	}
+}
@@ -0,0 +1,38 @@
+// routes/dokumentasi_kegiatan_routes.go
+package routes
+
+import (
+	"rt-management/controllers"
+	"rt-management/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupDokumentasiKegiatanRoutes(api *gin.RouterGroup, dokumentasiController *controllers.DokumentasiKegiatanController, authMiddleware *middleware.AuthMiddleware) {
+	kegiatan := api.Group("/kegiatan")
+	{
+		// Galeri dan notulen dapat dilihat warga
+		kegiatan.GET("/:id/foto", authMiddleware.RequireLevel(1, 2, 6), dokumentasiController.GetFotoKegiatan)
+		kegiatan.GET("/foto/image/:filename", authMiddleware.RequireLevel(1, 2, 6), dokumentasiController.GetFotoKegiatanImage)
+		kegiatan.GET("/:id/notulen", authMiddleware.RequireLevel(1, 2, 6), dokumentasiController.GetNotulenKegiatan)
+		kegiatan.GET("/notulen/dokumen/:filename", authMiddleware.RequireLevel(1, 2, 6), dokumentasiController.GetNotulenDokumen)
+
+		// Admin & sekretaris mengelola dokumentasi
+		pengurus := kegiatan.Group("")
+		pengurus.Use(authMiddleware.RequireLevel(1, 2))
+		{
+			pengurus.POST("/:id/foto", dokumentasiController.UploadFotoKegiatan)
+			pengurus.PUT("/:id/foto/:foto_id", dokumentasiController.UpdateFotoKegiatan)
+			pengurus.DELETE("/:id/foto/:foto_id", dokumentasiController.DeleteFotoKegiatan)
+
+			pengurus.PUT("/:id/notulen", dokumentasiController.SimpanNotulenKegiatan)
+			pengurus.DELETE("/:id/notulen", dokumentasiController.DeleteNotulenKegiatan)
+
+			// Tindak lanjut (action item) notulen
+			pengurus.GET("/tindak-lanjut", dokumentasiController.GetTindakLanjutTerbuka)
+			pengurus.POST("/:id/tindak-lanjut", dokumentasiController.CreateTindakLanjut)
+			pengurus.PUT("/tindak-lanjut/:tindak_lanjut_id", dokumentasiController.UpdateTindakLanjut)
+			pengurus.DELETE("/tindak-lanjut/:tindak_lanjut_id", dokumentasiController.DeleteTindakLanjut)
+		}
+	}
+}
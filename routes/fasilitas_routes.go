@@ -0,0 +1,38 @@
+// routes/fasilitas_routes.go
+package routes
+
+import (
+	"rt-management/controllers"
+	"rt-management/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupFasilitasRoutes(api *gin.RouterGroup, fasilitasController *controllers.FasilitasController, authMiddleware *middleware.AuthMiddleware) {
+	fasilitas := api.Group("/fasilitas")
+	{
+		// Daftar, ketersediaan dan kalender fasilitas dapat dilihat warga
+		fasilitas.GET("", authMiddleware.RequireLevel(1, 2, 3, 6), fasilitasController.GetAllFasilitas)
+		fasilitas.GET("/kalender", authMiddleware.RequireLevel(1, 2, 3, 6), fasilitasController.GetKalenderFasilitas)
+		fasilitas.GET("/:id", authMiddleware.RequireLevel(1, 2, 3, 6), fasilitasController.GetFasilitasByID)
+		fasilitas.GET("/:id/ketersediaan", authMiddleware.RequireLevel(1, 2, 3, 6), fasilitasController.GetKetersediaanFasilitas)
+
+		// Peminjaman: warga mengajukan, admin menyetujui, bendahara mencatat pembayaran
+		fasilitas.GET("/peminjaman", authMiddleware.RequireLevel(1, 2, 3, 6), fasilitasController.GetAllPeminjaman)
+		fasilitas.GET("/peminjaman/:id", authMiddleware.RequireLevel(1, 2, 3, 6), fasilitasController.GetPeminjamanByID)
+		fasilitas.POST("/peminjaman", authMiddleware.RequireLevel(1, 2, 6), fasilitasController.AjukanPeminjaman)
+		fasilitas.PUT("/peminjaman/:id/batal", authMiddleware.RequireLevel(1, 2, 6), fasilitasController.BatalkanPeminjaman)
+		fasilitas.PUT("/peminjaman/:id/bayar", authMiddleware.RequireLevel(1, 3), fasilitasController.BayarPeminjaman)
+
+		// Admin only routes
+		adminFasilitas := fasilitas.Group("")
+		adminFasilitas.Use(authMiddleware.RequireLevel(1))
+		{
+			adminFasilitas.POST("", fasilitasController.CreateFasilitas)
+			adminFasilitas.PUT("/:id", fasilitasController.UpdateFasilitas)
+			adminFasilitas.DELETE("/:id", fasilitasController.DeleteFasilitas)
+			adminFasilitas.PUT("/peminjaman/:id/setujui", fasilitasController.SetujuiPeminjaman)
+			adminFasilitas.PUT("/peminjaman/:id/tolak", fasilitasController.TolakPeminjaman)
+		}
+	}
+}
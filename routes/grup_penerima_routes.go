@@ -0,0 +1,24 @@
+// routes/grup_penerima_routes.go
+package routes
+
+import (
+	"rt-management/controllers"
+	"rt-management/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupGrupPenerimaRoutes(api *gin.RouterGroup, grupPenerimaController *controllers.GrupPenerimaController, authMiddleware *middleware.AuthMiddleware) {
+	// Grup penerima broadcast dikelola admin dan sekretaris
+	grup := api.Group("/grup-penerima")
+	grup.Use(authMiddleware.RequireLevel(1, 2))
+	{
+		grup.GET("", grupPenerimaController.GetAllGrupPenerima)
+		grup.GET("/:id", grupPenerimaController.GetGrupPenerimaByID)
+		grup.POST("", grupPenerimaController.CreateGrupPenerima)
+		grup.PUT("/:id", grupPenerimaController.UpdateGrupPenerima)
+		grup.DELETE("/:id", grupPenerimaController.DeleteGrupPenerima)
+		grup.POST("/:id/anggota", grupPenerimaController.TambahAnggotaGrupPenerima)
+		grup.DELETE("/:id/anggota/:user_id", grupPenerimaController.HapusAnggotaGrupPenerima)
+	}
+}
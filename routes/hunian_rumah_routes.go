@@ -0,0 +1,28 @@
+// routes/hunian_rumah_routes.go
+package routes
+
+import (
+	"rt-management/controllers"
+	"rt-management/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupHunianRumahRoutes(api *gin.RouterGroup, hunianRumahController *controllers.HunianRumahController, authMiddleware *middleware.AuthMiddleware) {
+	hunian := api.Group("/hunian-rumah")
+	{
+		// Public routes (butuh auth)
+		hunian.GET("", authMiddleware.RequireLevel(1, 2), hunianRumahController.GetAllHunianRumah)
+		hunian.GET("/kontrak-berakhir", authMiddleware.RequireLevel(1, 2), hunianRumahController.GetKontrakAkanBerakhir)
+		hunian.GET("/rumah/:rumah_id", authMiddleware.RequireLevel(1, 2), hunianRumahController.GetRiwayatHunianByRumah)
+
+		// Admin only routes
+		adminHunian := hunian.Group("")
+		adminHunian.Use(authMiddleware.RequireLevel(1))
+		{
+			adminHunian.POST("", hunianRumahController.CreateHunianRumah)
+			adminHunian.PUT("/:id", hunianRumahController.UpdateHunianRumah)
+			adminHunian.PUT("/:id/selesai", hunianRumahController.SelesaiHunianRumah)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+// routes/inventaris_routes.go
+package routes
+
+import (
+	"rt-management/controllers"
+	"rt-management/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupInventarisRoutes(api *gin.RouterGroup, inventarisController *controllers.InventarisController, authMiddleware *middleware.AuthMiddleware) {
+	inventaris := api.Group("/inventaris")
+	{
+		// Data aset dapat dilihat pengurus
+		inventaris.GET("", authMiddleware.RequireLevel(1, 2, 3), inventarisController.GetAllInventaris)
+		inventaris.GET("/laporan", authMiddleware.RequireLevel(1, 2, 3), inventarisController.GetLaporanInventarisTahunan)
+		inventaris.GET("/foto/image/:filename", authMiddleware.RequireLevel(1, 2, 3), inventarisController.GetFotoInventarisImage)
+		inventaris.GET("/:id", authMiddleware.RequireLevel(1, 2, 3), inventarisController.GetInventarisByID)
+
+		// Pencatatan aset oleh admin dan bendahara
+		inventaris.POST("", authMiddleware.RequireLevel(1, 3), inventarisController.CreateInventaris)
+		inventaris.PUT("/:id", authMiddleware.RequireLevel(1, 3), inventarisController.UpdateInventaris)
+		inventaris.POST("/:id/kondisi", authMiddleware.RequireLevel(1, 3), inventarisController.CatatKondisiInventaris)
+		inventaris.POST("/:id/foto", authMiddleware.RequireLevel(1, 3), inventarisController.UploadFotoInventaris)
+		inventaris.DELETE("/:id/foto/:foto_id", authMiddleware.RequireLevel(1, 3), inventarisController.DeleteFotoInventaris)
+
+		// Admin only routes
+		inventaris.DELETE("/:id", authMiddleware.RequireLevel(1), inventarisController.DeleteInventaris)
+	}
+}
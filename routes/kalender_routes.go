@@ -0,0 +1,25 @@
+// routes/kalender_routes.go
+package routes
+
+import (
+	"rt-management/controllers"
+	"rt-management/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupKalenderRoutes(router *gin.Engine, api *gin.RouterGroup, kalenderController *controllers.KalenderController, authMiddleware *middleware.AuthMiddleware) {
+	// Feed .ics memakai token rahasia di URL karena aplikasi kalender tidak bisa mengirim JWT
+	feed := router.Group("/kalender/:token")
+	{
+		feed.GET("/kegiatan.ics", kalenderController.GetFeedKegiatan)
+		feed.GET("/kegiatan/:id", kalenderController.GetICSKegiatan)
+	}
+
+	// Pengelolaan token kalender untuk semua user yang login
+	kalender := api.Group("/kalender")
+	{
+		kalender.GET("/token", kalenderController.GetKalenderToken)
+		kalender.POST("/token/reset", kalenderController.ResetKalenderToken)
+	}
+}
@@ -1,6 +1,6 @@
 
 
-// routes/warga_routes.go
+// routes/kategori_kegiatan_routes.go
 package routes
 
 import (
@@ -14,9 +14,11 @@ func SetupKategoriKegiatanRoutes(api *gin.RouterGroup, kategoriKegiatanControlle
 	kategori_kegiatan := api.Group("/kategori-kegiatan")
 	{
 		// Public routes (butuh auth)
-		kategori_kegiatan.GET("", authMiddleware.RequireLevel(1, 2), kategoriKegiatanController.CreateKategoriKegiatan)
-		kategori_kegiatan.GET("/:id", authMiddleware.RequireLevel(1, 2), kategoriKegiatanController.GetKategoriKegiatanByID)
+		kategori_kegiatan.GET("", authMiddleware.RequireLevel(1, 2), kategoriKegiatanController.GetAllKategoriKegiatan)
 		kategori_kegiatan.GET("/dropdown", authMiddleware.RequireLevel(1, 2), kategoriKegiatanController.GetKategoriKegiatanDropdown)
+		kategori_kegiatan.GET("/search", authMiddleware.RequireLevel(1, 2), kategoriKegiatanController.SearchKategoriKegiatan)
+		kategori_kegiatan.GET("/statistik", authMiddleware.RequireLevel(1, 2), kategoriKegiatanController.GetKategoriKegiatanWithStats)
+		kategori_kegiatan.GET("/:id", authMiddleware.RequireLevel(1, 2), kategoriKegiatanController.GetKategoriKegiatanByID)
 		
 		// Admin only routes
 		adminKategoriKegiatan := kategori_kegiatan.Group("")
@@ -0,0 +1,24 @@
+// routes/kategori_pengaduan_routes.go
+package routes
+
+import (
+	"rt-management/controllers"
+	"rt-management/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupKategoriPengaduanRoutes(api *gin.RouterGroup, kategoriPengaduanController *controllers.KategoriPengaduanController, authMiddleware *middleware.AuthMiddleware) {
+	kategori := api.Group("/kategori-pengaduan")
+	{
+		// Semua user perlu memilih kategori saat membuat pengaduan
+		kategori.GET("", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), kategoriPengaduanController.GetAllKategoriPengaduan)
+		kategori.GET("/dropdown", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), kategoriPengaduanController.GetKategoriPengaduanDropdown)
+		kategori.GET("/:id", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), kategoriPengaduanController.GetKategoriPengaduanByID)
+
+		// Kategori dan SLA dikelola admin dan sekretaris
+		kategori.POST("", authMiddleware.RequireLevel(1, 2), kategoriPengaduanController.CreateKategoriPengaduan)
+		kategori.PUT("/:id", authMiddleware.RequireLevel(1, 2), kategoriPengaduanController.UpdateKategoriPengaduan)
+		kategori.DELETE("/:id", authMiddleware.RequireLevel(1), kategoriPengaduanController.DeleteKategoriPengaduan)
+	}
+}
@@ -15,6 +15,8 @@ func SetupKegiatanRoutes(api *gin.RouterGroup, kegiatanController *controllers.K
 		kegiatan.GET("/mendatang", authMiddleware.RequireLevel(1, 2), kegiatanController.GetKegiatanMendatang)
 		kegiatan.GET("/statistik", authMiddleware.RequireLevel(1, 2), kegiatanController.GetStatistikKegiatan)
 		kegiatan.GET("/search", authMiddleware.RequireLevel(1, 2), kegiatanController.SearchKegiatan)
+		kegiatan.GET("/kalender/:tahun/:bulan", authMiddleware.RequireLevel(1, 2), kegiatanController.GetKegiatanByBulanTahun)
+		kegiatan.GET("/:id/jadwal", authMiddleware.RequireLevel(1, 2), kegiatanController.GetJadwalKegiatan)
 		kegiatan.GET("/:id", authMiddleware.RequireLevel(1, 2), kegiatanController.GetKegiatanByID)
 		
 		// Admin only routes
@@ -24,7 +26,12 @@ func SetupKegiatanRoutes(api *gin.RouterGroup, kegiatanController *controllers.K
 			adminKategoriKegiatan.POST("", kegiatanController.CreateKegiatan)
 			adminKategoriKegiatan.PUT("/:id", kegiatanController.UpdateKegiatan)
 			adminKategoriKegiatan.DELETE("/:id", kegiatanController.DeleteKegiatan)
-			// adminKategoriKegiatan.GET("/:tahun/:bulan", kegiatanController.GetKegiatanByBulanTahun)
+			adminKategoriKegiatan.GET("/trash", kegiatanController.GetTrashKegiatan)
+			adminKategoriKegiatan.PUT("/:id/restore", kegiatanController.RestoreKegiatan)
+
+			// Ubah / batalkan satu jadwal kegiatan rutin
+			adminKategoriKegiatan.PUT("/:id/jadwal", kegiatanController.UpdateJadwalKegiatan)
+			adminKategoriKegiatan.DELETE("/:id/jadwal", kegiatanController.BatalkanJadwalKegiatan)
 		}
 	}
 }
\ No newline at end of file
@@ -0,0 +1,36 @@
+// routes/kehadiran_kegiatan_routes.go
+package routes
+
+import (
+	"rt-management/controllers"
+	"rt-management/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupKehadiranKegiatanRoutes(api *gin.RouterGroup, kehadiranController *controllers.KehadiranKegiatanController, authMiddleware *middleware.AuthMiddleware) {
+	kegiatan := api.Group("/kegiatan")
+	{
+		// Kegiatan rutin memilih jadwal lewat query tanggal_asli pada endpoint /:id
+		// Warga (level 6) dapat RSVP dan absen via scan QR
+		kegiatan.POST("/:id/rsvp", authMiddleware.RequireLevel(1, 2, 6), kehadiranController.RSVPKegiatan)
+		kegiatan.POST("/absen-qr", authMiddleware.RequireLevel(1, 2, 6), kehadiranController.AbsenQR)
+
+		// PJ / pengurus mencatat kehadiran dan menampilkan QR
+		kegiatan.GET("/:id/kehadiran", authMiddleware.RequireLevel(1, 2), kehadiranController.GetKehadiranKegiatan)
+		kegiatan.POST("/:id/kehadiran", authMiddleware.RequireLevel(1, 2), kehadiranController.CatatKehadiran)
+		kegiatan.GET("/:id/qr", authMiddleware.RequireLevel(1, 2), kehadiranController.GetQRKegiatan)
+
+		// Laporan kehadiran
+		kegiatan.GET("/laporan/kehadiran", authMiddleware.RequireLevel(1, 2), kehadiranController.GetLaporanKehadiranKegiatan)
+		kegiatan.GET("/laporan/kehadiran-keluarga", authMiddleware.RequireLevel(1, 2), kehadiranController.GetLaporanKehadiranKeluarga)
+		kegiatan.GET("/laporan/absen-wajib", authMiddleware.RequireLevel(1, 2), kehadiranController.GetKeluargaAbsenWajib)
+
+		// Admin only routes
+		adminKehadiran := kegiatan.Group("")
+		adminKehadiran.Use(authMiddleware.RequireLevel(1))
+		{
+			adminKehadiran.PUT("/:id/qr", kehadiranController.ResetQRKegiatan)
+		}
+	}
+}
@@ -28,6 +28,8 @@ func SetupKeluargaRoutes(api *gin.RouterGroup, keluargaController *controllers.K
 			adminKeluarga.POST("", keluargaController.CreateKeluarga)
 			adminKeluarga.PUT("/:id", keluargaController.UpdateKeluarga)
 			adminKeluarga.DELETE("/:id", keluargaController.DeleteKeluarga)
+			adminKeluarga.GET("/trash", keluargaController.GetTrashKeluarga)
+			adminKeluarga.PUT("/:id/restore", keluargaController.RestoreKeluarga)
 		}
 	}
 }
\ No newline at end of file
@@ -0,0 +1,31 @@
+// routes/laporan_tamu_routes.go
+package routes
+
+import (
+	"rt-management/controllers"
+	"rt-management/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupLaporanTamuRoutes(api *gin.RouterGroup, laporanTamuController *controllers.LaporanTamuController, authMiddleware *middleware.AuthMiddleware) {
+	tamu := api.Group("/laporan-tamu")
+	{
+		// Warga (level 6) dapat melaporkan tamunya sendiri
+		tamu.GET("", authMiddleware.RequireLevel(1, 2, 6), laporanTamuController.GetAllLaporanTamu)
+		tamu.GET("/menginap", authMiddleware.RequireLevel(1, 2), laporanTamuController.GetTamuMenginap)
+		tamu.GET("/terlambat-pulang", authMiddleware.RequireLevel(1, 2), laporanTamuController.GetTamuTerlambatPulang)
+		tamu.GET("/rumah/:rumah_id", authMiddleware.RequireLevel(1, 2), laporanTamuController.GetRiwayatTamuByRumah)
+		tamu.GET("/:id", authMiddleware.RequireLevel(1, 2), laporanTamuController.GetLaporanTamuByID)
+		tamu.POST("", authMiddleware.RequireLevel(1, 2, 6), laporanTamuController.CreateLaporanTamu)
+		tamu.PUT("/:id", authMiddleware.RequireLevel(1, 2, 6), laporanTamuController.UpdateLaporanTamu)
+		tamu.PUT("/:id/pulang", authMiddleware.RequireLevel(1, 2, 6), laporanTamuController.PulangLaporanTamu)
+
+		// Admin only routes
+		adminTamu := tamu.Group("")
+		adminTamu.Use(authMiddleware.RequireLevel(1))
+		{
+			adminTamu.DELETE("/:id", laporanTamuController.DeleteLaporanTamu)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// routes/notifikasi_routes.go
+package routes
+
+import (
+	"rt-management/controllers"
+	"rt-management/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupNotifikasiRoutes(api *gin.RouterGroup, notifikasiController *controllers.NotifikasiController, authMiddleware *middleware.AuthMiddleware) {
+	notifikasi := api.Group("/notifikasi")
+	{
+		// Preferensi dan langganan push milik user yang login
+		notifikasi.GET("/preferensi", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), notifikasiController.GetPreferensiNotifikasi)
+		notifikasi.PUT("/preferensi", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), notifikasiController.UpdatePreferensiNotifikasi)
+		notifikasi.GET("/push/vapid-public-key", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), notifikasiController.GetVAPIDPublicKey)
+		notifikasi.POST("/push/langganan", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), notifikasiController.SimpanLanggananPush)
+		notifikasi.DELETE("/push/langganan", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), notifikasiController.HapusLanggananPush)
+
+		// Pemantauan outbox hanya untuk admin
+		adminNotifikasi := notifikasi.Group("")
+		adminNotifikasi.Use(authMiddleware.RequireLevel(1))
+		{
+			adminNotifikasi.GET("/kanal", notifikasiController.GetStatusKanal)
+			adminNotifikasi.GET("/outbox", notifikasiController.GetAllOutbox)
+			adminNotifikasi.POST("/outbox/:id/ulangi", notifikasiController.UlangiNotifikasi)
+			adminNotifikasi.POST("/tes", notifikasiController.KirimNotifikasiTes)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+// routes/pekerjaan_routes.go
+package routes
+
+import (
+	"rt-management/controllers"
+	"rt-management/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupPekerjaanRoutes(api *gin.RouterGroup, pekerjaanController *controllers.PekerjaanController, authMiddleware *middleware.AuthMiddleware) {
+	pekerjaan := api.Group("/pekerjaan")
+	{
+		// Public routes (butuh auth)
+		pekerjaan.GET("", authMiddleware.RequireLevel(1, 2), pekerjaanController.GetAllPekerjaan)
+		pekerjaan.GET("/dropdown", authMiddleware.RequireLevel(1, 2), pekerjaanController.GetPekerjaanDropdown)
+		pekerjaan.GET("/:id", authMiddleware.RequireLevel(1, 2), pekerjaanController.GetPekerjaanByID)
+
+		// Admin only routes
+		adminPekerjaan := pekerjaan.Group("")
+		adminPekerjaan.Use(authMiddleware.RequireLevel(1))
+		{
+			adminPekerjaan.POST("", pekerjaanController.CreatePekerjaan)
+			adminPekerjaan.PUT("/:id", pekerjaanController.UpdatePekerjaan)
+			adminPekerjaan.DELETE("/:id", pekerjaanController.DeletePekerjaan)
+			adminPekerjaan.POST("/:id/merge", pekerjaanController.MergePekerjaan)
+		}
+	}
+}
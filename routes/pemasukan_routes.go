@@ -27,6 +27,8 @@ func SetupPemasukanRoutes(api *gin.RouterGroup, pemasukanController *controllers
 			adminPemasukan.POST("", pemasukanController.CreatePemasukan)
 			adminPemasukan.PUT("/:id", pemasukanController.UpdatePemasukan)
 			adminPemasukan.DELETE("/:id", pemasukanController.DeletePemasukan)
+			adminPemasukan.GET("/trash", pemasukanController.GetTrashPemasukan)
+			adminPemasukan.PUT("/:id/restore", pemasukanController.RestorePemasukan)
 		}
 	}
 }
\ No newline at end of file
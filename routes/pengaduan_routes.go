@@ -0,0 +1,29 @@
+// routes/pengaduan_routes.go
+package routes
+
+import (
+	"rt-management/controllers"
+	"rt-management/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupPengaduanRoutes(api *gin.RouterGroup, pengaduanController *controllers.PengaduanController, authMiddleware *middleware.AuthMiddleware) {
+	pengaduan := api.Group("/pengaduan")
+	{
+		// Semua user dapat melapor dan mengikuti pengaduan; hak per aksi dicek di controller
+		// (pelapor, petugas yang ditugaskan, atau pengurus)
+		pengaduan.GET("", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), pengaduanController.GetAllPengaduan)
+		pengaduan.GET("/statistik", authMiddleware.RequireLevel(1, 2), pengaduanController.GetStatistikPengaduan)
+		pengaduan.GET("/foto/image/:filename", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), pengaduanController.GetFotoPengaduanImage)
+		pengaduan.GET("/:id", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), pengaduanController.GetPengaduanByID)
+		pengaduan.POST("", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), pengaduanController.CreatePengaduan)
+		pengaduan.PUT("/:id/status", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), pengaduanController.UbahStatusPengaduan)
+		pengaduan.POST("/:id/komentar", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), pengaduanController.CreateKomentarPengaduan)
+		pengaduan.POST("/:id/foto", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), pengaduanController.UploadFotoPengaduan)
+
+		// Penugasan petugas oleh admin dan sekretaris
+		pengaduan.PUT("/:id/petugas", authMiddleware.RequireLevel(1, 2), pengaduanController.TugaskanPetugas)
+		pengaduan.DELETE("/:id", authMiddleware.RequireLevel(1), pengaduanController.DeletePengaduan)
+	}
+}
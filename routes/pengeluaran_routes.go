@@ -26,6 +26,8 @@ func SetupPengeluaranRoutes(api *gin.RouterGroup, pengeluaranController *control
 			adminPengeluaran.POST("", pengeluaranController.CreatePengeluaran)
 			adminPengeluaran.PUT("/:id", pengeluaranController.UpdatePengeluaran)
 			adminPengeluaran.DELETE("/:id", pengeluaranController.DeletePengeluaran)
+			adminPengeluaran.GET("/trash", pengeluaranController.GetTrashPengeluaran)
+			adminPengeluaran.PUT("/:id/restore", pengeluaranController.RestorePengeluaran)
 		}
 	}
 }
\ No newline at end of file
@@ -27,6 +27,8 @@ func SetupProdukRoutes(api *gin.RouterGroup, produkController *controllers.Produ
 			adminProduk.PUT("/:id", produkController.UpdateProduk)
 			adminProduk.PATCH("/:id/stok", produkController.UpdateStokProduk)
 			adminProduk.DELETE("/:id", produkController.DeleteProduk)
+			adminProduk.GET("/trash", produkController.GetTrashProduk)
+			adminProduk.PUT("/:id/restore", produkController.RestoreProduk)
 		}
 	}
 }
\ No newline at end of file
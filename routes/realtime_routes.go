@@ -0,0 +1,19 @@
+// routes/realtime_routes.go
+package routes
+
+import (
+	"rt-management/controllers"
+	"rt-management/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupRealtimeRoutes(api *gin.RouterGroup, realtimeController *controllers.RealtimeController, authMiddleware *middleware.AuthMiddleware) {
+	realtime := api.Group("/realtime")
+	{
+		// Stream SSE untuk semua user yang login, event disaring per user dan wilayah.
+		// Hanya endpoint ini yang menerima token lewat query ?token= (EventSource tidak bisa kirim header)
+		realtime.GET("/stream", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), realtimeController.StreamEvent)
+		realtime.GET("/topik", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), realtimeController.GetTopikRealtime)
+	}
+}
@@ -0,0 +1,43 @@
+// routes/ronda_routes.go
+package routes
+
+import (
+	"rt-management/controllers"
+	"rt-management/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupRondaRoutes(api *gin.RouterGroup, rondaController *controllers.RondaController, authMiddleware *middleware.AuthMiddleware) {
+	ronda := api.Group("/ronda")
+	{
+		// Jadwal ronda dapat dilihat warga
+		ronda.GET("", authMiddleware.RequireLevel(1, 2, 6), rondaController.GetAllJadwalRonda)
+		ronda.GET("/malam-ini", authMiddleware.RequireLevel(1, 2, 6), rondaController.GetPetugasRondaMalamIni)
+		ronda.GET("/:id", authMiddleware.RequireLevel(1, 2, 6), rondaController.GetJadwalRondaByID)
+		ronda.GET("/:id/petugas", authMiddleware.RequireLevel(1, 2, 6), rondaController.GetPetugasRonda)
+		ronda.GET("/:id/rekap", authMiddleware.RequireLevel(1, 2), rondaController.GetRekapRonda)
+
+		// Tukar shift: warga mengajukan, pengurus menyetujui
+		ronda.GET("/tukar", authMiddleware.RequireLevel(1, 2, 6), rondaController.GetAllTukarRonda)
+		ronda.POST("/tukar", authMiddleware.RequireLevel(1, 2, 6), rondaController.AjukanTukarRonda)
+		ronda.PUT("/tukar/:id/setujui", authMiddleware.RequireLevel(1, 2), rondaController.SetujuiTukarRonda)
+		ronda.PUT("/tukar/:id/tolak", authMiddleware.RequireLevel(1, 2), rondaController.TolakTukarRonda)
+
+		// Penyusunan jadwal dan absensi oleh pengurus
+		ronda.GET("/kandidat", authMiddleware.RequireLevel(1, 2), rondaController.GetKandidatRonda)
+		ronda.POST("/generate", authMiddleware.RequireLevel(1, 2), rondaController.GenerateJadwalRonda)
+		ronda.PUT("/petugas/:id/kehadiran", authMiddleware.RequireLevel(1, 2), rondaController.CatatKehadiranRonda)
+
+		// Denda ronda dikelola bersama bendahara
+		ronda.GET("/denda", authMiddleware.RequireLevel(1, 2, 3), rondaController.GetDendaRonda)
+		ronda.PUT("/petugas/:id/bayar-denda", authMiddleware.RequireLevel(1, 3), rondaController.BayarDendaRonda)
+
+		// Admin only routes
+		adminRonda := ronda.Group("")
+		adminRonda.Use(authMiddleware.RequireLevel(1))
+		{
+			adminRonda.DELETE("/:id", rondaController.DeleteJadwalRonda)
+		}
+	}
+}
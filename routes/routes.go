@@ -15,11 +15,30 @@ type RouteConfig struct {
 	KeluargaController            *controllers.KeluargaController
 	WargaController               *controllers.WargaController
 	RumahController               *controllers.RumahController
+	HunianRumahController         *controllers.HunianRumahController
+	LaporanTamuController         *controllers.LaporanTamuController
+	WilayahController             *controllers.WilayahController
+	AgamaController               *controllers.AgamaController
+	PekerjaanController           *controllers.PekerjaanController
+	KategoriKegiatanController    *controllers.KategoriKegiatanController
 	KegiatanController            *controllers.KegiatanController
+	KehadiranKegiatanController   *controllers.KehadiranKegiatanController
+	DokumentasiKegiatanController *controllers.DokumentasiKegiatanController
+	FasilitasController           *controllers.FasilitasController
+	RondaController               *controllers.RondaController
+	KalenderController            *controllers.KalenderController
 	BroadcastController           *controllers.BroadcastController
+	GrupPenerimaController        *controllers.GrupPenerimaController
+	NotifikasiController          *controllers.NotifikasiController
+	RealtimeController            *controllers.RealtimeController
+	VotingController              *controllers.VotingController
+	KategoriPengaduanController   *controllers.KategoriPengaduanController
+	PengaduanController           *controllers.PengaduanController
+	DaruratController             *controllers.DaruratController
 	MutasiKeluargaController      *controllers.MutasiKeluargaController
 	KategoriPengeluaranController *controllers.KategoriPengeluaranController
 	PengeluaranController         *controllers.PengeluaranController
+	InventarisController          *controllers.InventarisController
 	KategoriPemasukanController   *controllers.KategoriPemasukanController
 	PemasukanController           *controllers.PemasukanController
 	TagihanIuranController        *controllers.TagihanIuranController
@@ -76,12 +95,64 @@ func SetupRoutes(router *gin.Engine, config *RouteConfig) {
 		// Setup rumah routes
 		SetupRumahRoutes(api, config.RumahController, config.AuthMiddleware)
 
+		// Setup hunian rumah routes
+		SetupHunianRumahRoutes(api, config.HunianRumahController, config.AuthMiddleware)
+
+		// Setup laporan tamu routes
+		SetupLaporanTamuRoutes(api, config.LaporanTamuController, config.AuthMiddleware)
+
+		// Setup wilayah (RT/RW) routes
+		SetupWilayahRoutes(api, config.WilayahController, config.AuthMiddleware)
+
+		// Setup master data agama & pekerjaan routes
+		SetupAgamaRoutes(api, config.AgamaController, config.AuthMiddleware)
+		SetupPekerjaanRoutes(api, config.PekerjaanController, config.AuthMiddleware)
+
+		// Setup kategori kegiatan routes
+		SetupKategoriKegiatanRoutes(api, config.KategoriKegiatanController, config.AuthMiddleware)
+
 		// Setup kegiatan routes
 		SetupKegiatanRoutes(api, config.KegiatanController, config.AuthMiddleware)
 
+		// Setup kehadiran & RSVP kegiatan routes
+		SetupKehadiranKegiatanRoutes(api, config.KehadiranKegiatanController, config.AuthMiddleware)
+
+		// Setup galeri & notulen kegiatan routes
+		SetupDokumentasiKegiatanRoutes(api, config.DokumentasiKegiatanController, config.AuthMiddleware)
+
+		// Setup fasilitas & peminjaman routes
+		SetupFasilitasRoutes(api, config.FasilitasController, config.AuthMiddleware)
+
+		// Setup ronda (siskamling) routes
+		SetupRondaRoutes(api, config.RondaController, config.AuthMiddleware)
+
+		// Setup kalender (.ics feed) routes
+		SetupKalenderRoutes(router, api, config.KalenderController, config.AuthMiddleware)
+
 		// Setup broadcast routes
 		SetupBroadcastRoutes(api, config.BroadcastController, config.AuthMiddleware)
 
+		// Setup grup penerima broadcast routes
+		SetupGrupPenerimaRoutes(api, config.GrupPenerimaController, config.AuthMiddleware)
+
+		// Setup notifikasi (preferensi, web push, outbox) routes
+		SetupNotifikasiRoutes(api, config.NotifikasiController, config.AuthMiddleware)
+
+		// Setup realtime (SSE) routes
+		SetupRealtimeRoutes(api, config.RealtimeController, config.AuthMiddleware)
+
+		// Setup voting (musyawarah) routes
+		SetupVotingRoutes(api, config.VotingController, config.AuthMiddleware)
+
+		// Setup kategori pengaduan routes
+		SetupKategoriPengaduanRoutes(api, config.KategoriPengaduanController, config.AuthMiddleware)
+
+		// Setup pengaduan warga routes
+		SetupPengaduanRoutes(api, config.PengaduanController, config.AuthMiddleware)
+
+		// Setup peringatan darurat (panic button) routes
+		SetupDaruratRoutes(api, config.DaruratController, config.AuthMiddleware)
+
 		// Setup mutasi keluarga routes
 		SetupMutasiKeluargaRoutes(api, config.MutasiKeluargaController, config.AuthMiddleware)
 
@@ -91,6 +162,9 @@ func SetupRoutes(router *gin.Engine, config *RouteConfig) {
 		// Setup pengeluaran routes
 		SetupPengeluaranRoutes(api, config.PengeluaranController, config.AuthMiddleware)
 
+		// Setup inventaris (aset RT) routes
+		SetupInventarisRoutes(api, config.InventarisController, config.AuthMiddleware)
+
 		// Setup kategori pemasukan routes
 		SetupKategoriPemasukanRoutes(api, config.KategoriPemasukanController, config.AuthMiddleware)
 
@@ -37,6 +37,7 @@ func SetupRumahRoutes(api *gin.RouterGroup, rumahController *controllers.RumahCo
 	{
 		// Public routes (butuh auth)
 		warga.GET("", authMiddleware.RequireLevel(1, 2), rumahController.GetAllRumah)
+		warga.GET("/blok", authMiddleware.RequireLevel(1, 2), rumahController.GetRumahByBlok)
 		warga.GET("/:id", authMiddleware.RequireLevel(1, 2), rumahController.GetRumahByID)
 		
 		// Admin only routes
@@ -46,6 +47,8 @@ func SetupRumahRoutes(api *gin.RouterGroup, rumahController *controllers.RumahCo
 			adminWarga.POST("", rumahController.CreateRumah)
 			adminWarga.PUT("/:id", rumahController.UpdateRumah)
 			adminWarga.DELETE("/:id", rumahController.DeleteRumah)
+			adminWarga.GET("/trash", rumahController.GetTrashRumah)
+			adminWarga.PUT("/:id/restore", rumahController.RestoreRumah)
 		}
 	}
 }
\ No newline at end of file
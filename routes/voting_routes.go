@@ -0,0 +1,28 @@
+// routes/voting_routes.go
+package routes
+
+import (
+	"rt-management/controllers"
+	"rt-management/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupVotingRoutes(api *gin.RouterGroup, votingController *controllers.VotingController, authMiddleware *middleware.AuthMiddleware) {
+	voting := api.Group("/voting")
+	{
+		// Semua user dapat melihat, memilih, dan mengaudit voting di wilayahnya
+		voting.GET("", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), votingController.GetAllVoting)
+		voting.GET("/:id", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), votingController.GetVotingByID)
+		voting.POST("/:id/pilih", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), votingController.PilihVoting)
+		voting.GET("/:id/hasil", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), votingController.GetHasilVoting)
+		voting.GET("/:id/suara", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), votingController.GetSuaraVoting)
+		voting.GET("/:id/verifikasi", authMiddleware.RequireLevel(1, 2, 3, 4, 5, 6), votingController.VerifikasiVoting)
+
+		// Voting diselenggarakan admin dan sekretaris
+		voting.POST("", authMiddleware.RequireLevel(1, 2), votingController.CreateVoting)
+		voting.PUT("/:id", authMiddleware.RequireLevel(1, 2), votingController.UpdateVoting)
+		voting.PUT("/:id/tutup", authMiddleware.RequireLevel(1, 2), votingController.TutupVoting)
+		voting.DELETE("/:id", authMiddleware.RequireLevel(1), votingController.DeleteVoting)
+	}
+}
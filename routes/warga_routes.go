@@ -26,6 +26,11 @@ func SetupWargaRoutes(api *gin.RouterGroup, wargaController *controllers.WargaCo
 			adminWarga.POST("", wargaController.CreateWarga)
 			adminWarga.PUT("/:id", wargaController.UpdateWarga)
 			adminWarga.DELETE("/:id", wargaController.DeleteWarga)
+			adminWarga.GET("/trash", wargaController.GetTrashWarga)
+			adminWarga.PUT("/:id/restore", wargaController.RestoreWarga)
+			adminWarga.GET("/duplikat", wargaController.FindDuplikatWarga)
+			adminWarga.GET("/merge-log", wargaController.GetMergeLogWarga)
+			adminWarga.POST("/:id/merge", wargaController.MergeWarga)
 		}
 	}
 }
\ No newline at end of file
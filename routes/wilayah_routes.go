@@ -0,0 +1,31 @@
+// routes/wilayah_routes.go
+package routes
+
+import (
+	"rt-management/controllers"
+	"rt-management/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupWilayahRoutes(api *gin.RouterGroup, wilayahController *controllers.WilayahController, authMiddleware *middleware.AuthMiddleware) {
+	wilayah := api.Group("/wilayah")
+	{
+		// Admin dan Ketua RW (level 5)
+		wilayah.GET("/rw", authMiddleware.RequireLevel(1, 5), wilayahController.GetAllRW)
+		wilayah.GET("/rt", authMiddleware.RequireLevel(1, 5), wilayahController.GetAllRT)
+		wilayah.GET("/rekap", authMiddleware.RequireLevel(1, 5), wilayahController.GetRekapRW)
+
+		// Admin only routes
+		adminWilayah := wilayah.Group("")
+		adminWilayah.Use(authMiddleware.RequireLevel(1))
+		{
+			adminWilayah.POST("/rw", wilayahController.CreateRW)
+			adminWilayah.PUT("/rw/:id", wilayahController.UpdateRW)
+			adminWilayah.DELETE("/rw/:id", wilayahController.DeleteRW)
+			adminWilayah.POST("/rt", wilayahController.CreateRT)
+			adminWilayah.PUT("/rt/:id", wilayahController.UpdateRT)
+			adminWilayah.DELETE("/rt/:id", wilayahController.DeleteRT)
+		}
+	}
+}
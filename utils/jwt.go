@@ -13,6 +13,8 @@ type JWTClaims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
 	LevelID  uint   `json:"level_id"`
+	RTID     uint   `json:"rt_id,omitempty"`
+	RWID     uint   `json:"rw_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -29,11 +31,13 @@ func NewJWTUtils(secretKey string) *JWTUtils {
 	}
 }
 
-func (j *JWTUtils) GenerateToken(userID uint, username string, levelID uint) (string, error) {
+func (j *JWTUtils) GenerateToken(userID uint, username string, levelID uint, scope TenantScope) (string, error) {
 	claims := JWTClaims{
 		UserID:   userID,
 		Username: username,
 		LevelID:  levelID,
+		RTID:     scope.RTID,
+		RWID:     scope.RWID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
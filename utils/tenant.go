@@ -0,0 +1,33 @@
+// utils/tenant.go
+package utils
+
+import "context"
+
+// TenantScope menyimpan wilayah (RT/RW) yang boleh diakses user yang sedang login.
+// RTID terisi untuk user tingkat RT, RWID terisi untuk user tingkat RW (mis. Ketua RW).
+// Keduanya kosong berarti user global yang dapat mengakses seluruh data.
+type TenantScope struct {
+	RTID uint
+	RWID uint
+}
+
+type tenantScopeKey struct{}
+
+// IsGlobal mengembalikan true jika scope tidak membatasi wilayah apa pun
+func (s TenantScope) IsGlobal() bool {
+	return s.RTID == 0 && s.RWID == 0
+}
+
+// WithTenantScope menyimpan scope wilayah ke dalam context request
+func WithTenantScope(ctx context.Context, scope TenantScope) context.Context {
+	return context.WithValue(ctx, tenantScopeKey{}, scope)
+}
+
+// TenantScopeFromContext mengambil scope wilayah dari context request
+func TenantScopeFromContext(ctx context.Context) (TenantScope, bool) {
+	if ctx == nil {
+		return TenantScope{}, false
+	}
+	scope, ok := ctx.Value(tenantScopeKey{}).(TenantScope)
+	return scope, ok
+}